@@ -0,0 +1,212 @@
+// Package cardvalidation checks card numbers and CVVs and detects the card scheme from the number,
+// so the provider stored with a card comes from its number rather than from the client.
+// Error messages never contain the checked card number or CVV.
+package cardvalidation
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// Names of the validated fields, as in the add card request.
+const (
+	FieldCardNumber = "cardNumber"
+	FieldCVV        = "cvv"
+	FieldProvider   = "provider"
+)
+
+// Scheme is a card network, Provider is the card provider stored for its cards.
+type Scheme struct {
+	Provider  string
+	Lengths   []int
+	CVVLength int
+
+	// Luhn is false for schemes whose numbers don't all carry a Luhn check digit.
+	Luhn bool
+}
+
+var (
+	visa       = &Scheme{Provider: domain.CardProviderVisa, Lengths: []int{13, 16, 19}, CVVLength: 3, Luhn: true}
+	mastercard = &Scheme{Provider: domain.CardProviderMastercard, Lengths: []int{16}, CVVLength: 3, Luhn: true}
+	amex       = &Scheme{Provider: domain.CardProviderAmex, Lengths: []int{15}, CVVLength: 4, Luhn: true}
+	discover   = &Scheme{Provider: domain.CardProviderDiscover, Lengths: []int{16, 17, 18, 19}, CVVLength: 3, Luhn: true}
+	jcb        = &Scheme{Provider: domain.CardProviderJCB, Lengths: []int{16, 17, 18, 19}, CVVLength: 3, Luhn: true}
+	unionPay   = &Scheme{Provider: domain.CardProviderUnionPay, Lengths: []int{16, 17, 18, 19}, CVVLength: 3, Luhn: false}
+)
+
+// binRange is a range of issuer identification number (IIN/BIN) prefixes of one scheme, low and high have the same length.
+type binRange struct {
+	low, high string
+	scheme    *Scheme
+}
+
+// schemes are the supported schemes by provider.
+var schemes = map[string]*Scheme{
+	visa.Provider:       visa,
+	mastercard.Provider: mastercard,
+	amex.Provider:       amex,
+	discover.Provider:   discover,
+	jcb.Provider:        jcb,
+	unionPay.Provider:   unionPay,
+}
+
+// binRanges are the IIN ranges of the supported schemes. Ranges may nest, the longest matching prefix wins.
+var binRanges = []binRange{
+	{"4", "4", visa},
+	{"51", "55", mastercard},
+	{"2221", "2720", mastercard},
+	{"34", "34", amex},
+	{"37", "37", amex},
+	{"6011", "6011", discover},
+	{"644", "649", discover},
+	{"65", "65", discover},
+	{"3528", "3589", jcb},
+	{"62", "62", unionPay},
+}
+
+// FieldError is a validation error of one field of the request.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Errors are the field errors of a card, in field order.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].Message
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// DetectScheme returns the scheme of the card number's IIN range, or nil if no supported scheme issues it.
+func DetectScheme(number string) *Scheme {
+	var detected *Scheme
+	matched := 0
+
+	for _, r := range binRanges {
+		if len(r.low) <= matched || len(number) < len(r.low) {
+			continue
+		}
+
+		if prefix := number[:len(r.low)]; prefix >= r.low && prefix <= r.high {
+			detected, matched = r.scheme, len(r.low)
+		}
+	}
+
+	return detected
+}
+
+// ValidateNumber checks that number is a card number of a supported scheme, with the scheme's length
+// and check digit, and returns the scheme.
+func ValidateNumber(number string) (*Scheme, error) {
+	if number == "" {
+		return nil, errors.New("card number is required")
+	}
+
+	if !IsDigits(number) {
+		return nil, errors.New("card number must contain only digits")
+	}
+
+	scheme := DetectScheme(number)
+	if scheme == nil {
+		return nil, errors.New("card number is not from a supported card network")
+	}
+
+	if !slices.Contains(scheme.Lengths, len(number)) {
+		return nil, fmt.Errorf("card number must be %s digits long for %s cards", joinLengths(scheme.Lengths), scheme.Provider)
+	}
+
+	if scheme.Luhn && !luhnValid(number) {
+		return nil, errors.New("card number is invalid, check digit mismatch")
+	}
+
+	return scheme, nil
+}
+
+// Validate checks a card number and returns the scheme detected from it.
+// provider is the one the client declared, it's optional and must be the detected one when given.
+// A failed check returns the Errors of every invalid field, they are nil otherwise.
+func Validate(number, provider string) (*Scheme, Errors) {
+	scheme, err := ValidateNumber(number)
+	if err != nil {
+		return nil, Errors{{Field: FieldCardNumber, Message: err.Error()}}
+	}
+
+	if provider != "" && provider != scheme.Provider {
+		return nil, Errors{{Field: FieldProvider, Message: fmt.Sprintf("provider %s doesn't match the card number, which is a %s card", provider, scheme.Provider)}}
+	}
+
+	return scheme, nil
+}
+
+// ValidateCVV checks that cvv has the length of the CVVs of provider's scheme, it returns nil if it has.
+func ValidateCVV(provider, cvv string) Errors {
+	scheme, ok := schemes[provider]
+	switch {
+	case !IsDigits(cvv):
+		return Errors{{Field: FieldCVV, Message: "cvv must contain only digits"}}
+	case ok && len(cvv) != scheme.CVVLength:
+		return Errors{{Field: FieldCVV, Message: fmt.Sprintf("cvv must be %d digits for %s cards", scheme.CVVLength, scheme.Provider)}}
+	case !ok && (len(cvv) < 3 || len(cvv) > 4):
+		return Errors{{Field: FieldCVV, Message: "cvv must be 3 or 4 digits"}}
+	}
+
+	return nil
+}
+
+// luhnValid reports whether the last digit of number is its Luhn check digit.
+func luhnValid(number string) bool {
+	return LuhnSum(number)%10 == 0
+}
+
+// LuhnSum returns the Luhn checksum of a string of digits, every second digit from the right doubled.
+// The last digit is a valid check digit when the sum is a multiple of 10.
+func LuhnSum(digits string) int {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+	}
+
+	return sum
+}
+
+// IsDigits reports whether s is a non-empty string of ASCII digits.
+func IsDigits(s string) bool {
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// joinLengths formats lengths for an error message, e.g. "13, 16 or 19".
+func joinLengths(lengths []int) string {
+	parts := make([]string, len(lengths))
+	for i, l := range lengths {
+		parts[i] = fmt.Sprint(l)
+	}
+
+	if len(parts) == 1 {
+		return parts[0]
+	}
+
+	return strings.Join(parts[:len(parts)-1], ", ") + " or " + parts[len(parts)-1]
+}
@@ -0,0 +1,110 @@
+package cardvalidation
+
+import (
+	"testing"
+
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateNumber(t *testing.T) {
+	tests := []struct {
+		name         string
+		number       string
+		wantProvider string
+		wantErr      string
+	}{
+		{"Visa", "4111111111111111", domain.CardProviderVisa, ""},
+		{"Visa 13 Digits", "4222222222222", domain.CardProviderVisa, ""},
+		{"Mastercard", "5555555555554444", domain.CardProviderMastercard, ""},
+		{"Mastercard 2-Series", "2223003122003222", domain.CardProviderMastercard, ""},
+		{"Amex", "378282246310005", domain.CardProviderAmex, ""},
+		{"Discover 6011", "6011111111111117", domain.CardProviderDiscover, ""},
+		{"Discover 644", "6445644564456445", domain.CardProviderDiscover, ""},
+		{"Discover 65", "6500000000000002", domain.CardProviderDiscover, ""},
+		{"JCB", "3566002020360505", domain.CardProviderJCB, ""},
+		{"UnionPay", "6200000000000005", domain.CardProviderUnionPay, ""},
+		{"UnionPay Without Check Digit", "6200000000000001", domain.CardProviderUnionPay, ""},
+		{"Empty", "", "", "card number is required"},
+		{"Not Digits", "4111 1111 1111 1111", "", "card number must contain only digits"},
+		{"Unsupported Network", "36227206271667", "", "card number is not from a supported card network"},
+		{"Outside Mastercard 2-Series", "2721000000000004", "", "card number is not from a supported card network"},
+		{"Wrong Length", "3782822463100050", "", "card number must be 15 digits long for amex cards"},
+		{"Wrong Visa Length", "41111111111111", "", "card number must be 13, 16 or 19 digits long for visa cards"},
+		{"Luhn Mismatch", "4111111111111112", "", "card number is invalid, check digit mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, err := ValidateNumber(tt.number)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantProvider, scheme.Provider)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		number       string
+		provider     string
+		wantProvider string
+		wantField    string
+	}{
+		{"Provider Inferred", "378282246310005", "", domain.CardProviderAmex, ""},
+		{"Provider Matches", "4111111111111111", domain.CardProviderVisa, domain.CardProviderVisa, ""},
+		{"Provider Contradicts Number", "4111111111111111", domain.CardProviderAmex, "", FieldProvider},
+		{"Invalid Number", "4111111111111112", domain.CardProviderVisa, "", FieldCardNumber},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, errs := Validate(tt.number, tt.provider)
+			if tt.wantField != "" {
+				require.Len(t, errs, 1)
+				assert.Equal(t, tt.wantField, errs[0].Field)
+				assert.NotContains(t, errs.Error(), tt.number, "errors never carry the card number")
+				return
+			}
+
+			require.Nil(t, errs)
+			assert.Equal(t, tt.wantProvider, scheme.Provider)
+		})
+	}
+}
+
+func TestValidateCVV(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		cvv      string
+		wantErr  string
+	}{
+		{"Amex", domain.CardProviderAmex, "1234", ""},
+		{"Visa", domain.CardProviderVisa, "123", ""},
+		{"Amex Too Short", domain.CardProviderAmex, "123", "cvv must be 4 digits for amex cards"},
+		{"Visa Too Long", domain.CardProviderVisa, "1234", "cvv must be 3 digits for visa cards"},
+		{"Not Digits", domain.CardProviderVisa, "12a", "cvv must contain only digits"},
+		{"Empty", domain.CardProviderVisa, "", "cvv must contain only digits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateCVV(tt.provider, tt.cvv)
+			if tt.wantErr == "" {
+				assert.Nil(t, errs)
+				return
+			}
+
+			require.Len(t, errs, 1)
+			assert.Equal(t, FieldCVV, errs[0].Field)
+			assert.Equal(t, tt.wantErr, errs[0].Message)
+		})
+	}
+}
@@ -147,3 +147,21 @@ func NewRateLimitError(message string) AppError {
 func NewForbiddenError(message string) AppError {
 	return newAppError(http.StatusForbidden, message)
 }
+
+// NewUnprocessableEntityError creates a new AppError for well-formed requests that violate a business rule.
+//
+// Example:
+//
+//	err := NewUnprocessableEntityError("insufficient funds")
+func NewUnprocessableEntityError(message string) AppError {
+	return newAppError(http.StatusUnprocessableEntity, message)
+}
+
+// NewLockedError creates a new AppError for resources that are temporarily locked.
+//
+// Example:
+//
+//	err := NewLockedError("too many failed login attempts")
+func NewLockedError(message string) AppError {
+	return newAppError(http.StatusLocked, message)
+}
@@ -2,7 +2,9 @@ package common
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/spf13/viper"
@@ -10,10 +12,15 @@ import (
 
 // AppConfig is the structured configuration used throughout the application.
 type AppConfig struct {
-	App  AppSettings `mapstructure:"app"`
-	DB   DBConfig    `mapstructure:"db"`
-	JWT  JWTConfig   `mapstructure:"jwt"`
-	Card CardConfig  `mapstructure:"card"`
+	App     AppSettings   `mapstructure:"app"`
+	DB      DBConfig      `mapstructure:"db"`
+	JWT     JWTConfig     `mapstructure:"jwt"`
+	Card    CardConfig    `mapstructure:"card"`
+	MFA     MFAConfig     `mapstructure:"mfa"`
+	Webhook WebhookConfig `mapstructure:"webhook"`
+	Mailer  MailerConfig  `mapstructure:"mailer"`
+	FX      FXConfig      `mapstructure:"fx"`
+	Events  EventsConfig  `mapstructure:"events"`
 }
 
 type AppSettings struct {
@@ -30,17 +37,72 @@ type DBConfig struct {
 	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
 }
 
+// JWTConfig holds the token signing key set. Tokens are signed with the key named by ActiveKeyID,
+// the other keys are retired and only verify tokens signed before a rotation, they don't need a private key.
 type JWTConfig struct {
-	PrivateKey        string `mapstructure:"private_key"`
-	PublicKey         string `mapstructure:"public_key"`
+	ActiveKeyID       string         `mapstructure:"active_key_id"`
+	Keys              []JWTKeyConfig `mapstructure:"keys"`
 	AccessExpiration  time.Duration
 	RefreshExpiration time.Duration
 }
 
+type JWTKeyConfig struct {
+	ID         string `mapstructure:"id" json:"id"`
+	PrivateKey string `mapstructure:"private_key" json:"privateKey"`
+	PublicKey  string `mapstructure:"public_key" json:"publicKey"`
+}
+
+// CardConfig holds the keys of stored card numbers. KeyringFile is the keyring of the key-encryption keys
+// wrapping the per-card data keys. AESKey decrypts card numbers stored before envelope encryption,
+// and wraps the data keys itself when no keyring file is set.
 type CardConfig struct {
+	AESKey      string `mapstructure:"aes_key"`
+	KeyringFile string `mapstructure:"keyring_file"`
+}
+
+type MFAConfig struct {
+	AESKey string `mapstructure:"aes_key"`
+}
+
+// WebhookConfig holds the key webhook endpoint signing secrets are encrypted with at rest.
+type WebhookConfig struct {
 	AESKey string `mapstructure:"aes_key"`
 }
 
+// MailerConfig selects how emails are sent. The log driver writes them to FilePath, or to the log
+// when FilePath is empty, and is meant for local development only.
+type MailerConfig struct {
+	Driver   string     `mapstructure:"driver"`
+	From     string     `mapstructure:"from"`
+	FilePath string     `mapstructure:"file_path"`
+	SMTP     SMTPConfig `mapstructure:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// FXConfig points to the JSON file with the exchange rates FX quotes are priced at,
+// the rates embedded in the binary are used when RatesFile is empty and are meant for local development only.
+type FXConfig struct {
+	RatesFile string `mapstructure:"rates_file"`
+}
+
+// EventsConfig selects where the domain events of the outbox are published. The log driver writes them
+// to stdout and is meant for local development only.
+type EventsConfig struct {
+	Driver string      `mapstructure:"driver"`
+	Kafka  KafkaConfig `mapstructure:"kafka"`
+}
+
+type KafkaConfig struct {
+	Brokers []string `mapstructure:"brokers"`
+	Topic   string   `mapstructure:"topic"`
+}
+
 // LoadConfig reads the config file and returns a structured AppConfig.
 func LoadConfig() (*AppConfig, error) {
 	v := viper.New()
@@ -62,6 +124,10 @@ func LoadConfig() (*AppConfig, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := overrideJWTKeys(&config); err != nil {
+		return nil, err
+	}
+
 	// Set default values for JWT expiration times
 	config.JWT.AccessExpiration = 30 * time.Minute
 	config.JWT.RefreshExpiration = 24 * time.Hour
@@ -88,19 +154,33 @@ func decodeKeys(config *AppConfig) error {
 	}
 
 	var err error
-	config.JWT.PrivateKey, err = decodeBase64(config.JWT.PrivateKey)
+	for i := range config.JWT.Keys {
+		key := &config.JWT.Keys[i]
+
+		key.PrivateKey, err = decodeBase64(key.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("failed to decode JWT private key %q: %w", key.ID, err)
+		}
+
+		key.PublicKey, err = decodeBase64(key.PublicKey)
+		if err != nil {
+			return fmt.Errorf("failed to decode JWT public key %q: %w", key.ID, err)
+		}
+	}
+
+	config.Card.AESKey, err = decodeBase64(config.Card.AESKey)
 	if err != nil {
-		return fmt.Errorf("failed to decode JWT private key: %w", err)
+		return fmt.Errorf("failed to decode Card AES key: %w", err)
 	}
 
-	config.JWT.PublicKey, err = decodeBase64(config.JWT.PublicKey)
+	config.MFA.AESKey, err = decodeBase64(config.MFA.AESKey)
 	if err != nil {
-		return fmt.Errorf("failed to decode JWT public key: %w", err)
+		return fmt.Errorf("failed to decode MFA AES key: %w", err)
 	}
 
-	config.Card.AESKey, err = decodeBase64(config.Card.AESKey)
+	config.Webhook.AESKey, err = decodeBase64(config.Webhook.AESKey)
 	if err != nil {
-		return fmt.Errorf("failed to decode Card AES key: %w", err)
+		return fmt.Errorf("failed to decode Webhook AES key: %w", err)
 	}
 
 	return nil
@@ -120,9 +200,18 @@ func validateConfig(config *AppConfig) error {
 		{"db.max_idle_conns", config.DB.MaxIdleConns > 0},
 		{"db.conn_max_lifetime", config.DB.ConnMaxLifetime > 0},
 		{"db.conn_max_idle_time", config.DB.ConnMaxIdleTime > 0},
-		{"jwt.private_key", config.JWT.PrivateKey != ""},
-		{"jwt.public_key", config.JWT.PublicKey != ""},
-		{"card.aes_key", config.Card.AESKey != ""},
+		{"jwt.active_key_id", config.JWT.ActiveKeyID != ""},
+		{"jwt.keys", validJWTKeys(config.JWT)},
+		{"card.aes_key", config.Card.AESKey != "" || config.Card.KeyringFile != ""},
+		{"mfa.aes_key", config.MFA.AESKey != ""},
+		{"webhook.aes_key", config.Webhook.AESKey != ""},
+		{"mailer.driver", config.Mailer.Driver == MailerDriverLog || config.Mailer.Driver == MailerDriverSMTP},
+		{"mailer.from", config.Mailer.From != ""},
+		{"mailer.smtp.host", config.Mailer.Driver != MailerDriverSMTP || config.Mailer.SMTP.Host != ""},
+		{"mailer.smtp.port", config.Mailer.Driver != MailerDriverSMTP || config.Mailer.SMTP.Port > 0},
+		{"events.driver", config.Events.Driver == EventsDriverLog || config.Events.Driver == EventsDriverKafka},
+		{"events.kafka.brokers", config.Events.Driver != EventsDriverKafka || len(config.Events.Kafka.Brokers) > 0},
+		{"events.kafka.topic", config.Events.Driver != EventsDriverKafka || config.Events.Kafka.Topic != ""},
 	}
 
 	var missingConfigs []string
@@ -139,6 +228,45 @@ func validateConfig(config *AppConfig) error {
 	return nil
 }
 
+// validJWTKeys reports whether every key has an id and a public key, and the active key has a private key.
+func validJWTKeys(jwtConfig JWTConfig) bool {
+	hasActiveKey := false
+
+	for _, key := range jwtConfig.Keys {
+		if key.ID == "" || key.PublicKey == "" {
+			return false
+		}
+
+		if key.ID == jwtConfig.ActiveKeyID {
+			hasActiveKey = key.PrivateKey != ""
+		}
+	}
+
+	return hasActiveKey
+}
+
+// overrideJWTKeys replaces the configured jwt key set with the JSON array in the JWT_KEYS environment variable,
+// if it is set. Viper can't bind a list of objects to a single environment variable, so it's decoded here.
+//
+// Example:
+//
+//	JWT_KEYS='[{"id":"2025-01","privateKey":"LS0t...","publicKey":"LS0t..."},{"id":"2024-07","publicKey":"LS0t..."}]'
+func overrideJWTKeys(config *AppConfig) error {
+	keysJSON, ok := os.LookupEnv("JWT_KEYS")
+	if !ok {
+		return nil
+	}
+
+	var keys []JWTKeyConfig
+	if err := json.Unmarshal([]byte(keysJSON), &keys); err != nil {
+		return fmt.Errorf("failed to parse JWT_KEYS: %w", err)
+	}
+
+	config.JWT.Keys = keys
+
+	return nil
+}
+
 // bindEnvVariables maps environment variables to configuration keys.
 // This allows overriding config values using Docker environment variables.
 //
@@ -155,9 +283,22 @@ func bindEnvVariables(v *viper.Viper) error {
 		"db.max_idle_conns":     "DB_MAX_IDLE_CONNS",
 		"db.conn_max_lifetime":  "DB_CONN_MAX_LIFETIME",
 		"db.conn_max_idle_time": "DB_CONN_MAX_IDLE_TIME",
-		"jwt.private_key":       "JWT_PRIVATE_KEY",
-		"jwt.public_key":        "JWT_PUBLIC_KEY",
+		"jwt.active_key_id":     "JWT_ACTIVE_KEY_ID",
 		"card.aes_key":          "CARD_AES_KEY",
+		"card.keyring_file":     "CARD_KEYRING_FILE",
+		"mfa.aes_key":           "MFA_AES_KEY",
+		"webhook.aes_key":       "WEBHOOK_AES_KEY",
+		"mailer.driver":         "MAILER_DRIVER",
+		"mailer.from":           "MAILER_FROM",
+		"mailer.file_path":      "MAILER_FILE_PATH",
+		"mailer.smtp.host":      "SMTP_HOST",
+		"mailer.smtp.port":      "SMTP_PORT",
+		"mailer.smtp.username":  "SMTP_USERNAME",
+		"mailer.smtp.password":  "SMTP_PASSWORD",
+		"fx.rates_file":         "FX_RATES_FILE",
+		"events.driver":         "EVENTS_DRIVER",
+		"events.kafka.brokers":  "KAFKA_BROKERS",
+		"events.kafka.topic":    "KAFKA_TOPIC",
 	}
 
 	for configKey, envVar := range envMappings {
@@ -1,22 +1,101 @@
 package common
 
+import "time"
+
 const (
 	AppEnvDev        = "dev"
 	AppEnvProduction = "production"
 	AppEnvStaging    = "staging"
 
-	RequestIDHeader = "X-Request-ID"
-	RequestIDKey    = "requestID"
+	// APIBasePath prefixes every API route, the rbac policy must cover each route under it
+	APIBasePath = "/api/v1"
+
+	MailerDriverLog  = "log"
+	MailerDriverSMTP = "smtp"
+
+	EventsDriverLog   = "log"
+	EventsDriverKafka = "kafka"
+
+	RequestIDHeader  = "X-Request-ID"
+	RequestIDKey     = "requestID"
+	RetryAfterHeader = "Retry-After"
+
+	// RequestIDMaxLength is the longest request ID accepted from the X-Request-ID header, a new one is generated otherwise
+	RequestIDMaxLength = 128
+
+	CacheControlHeader       = "Cache-Control"
+	ContentDispositionHeader = "Content-Disposition"
+	LocationHeader           = "Location"
+	// JWKSCacheControl lets verifiers cache the signing keys briefly, a new key must be published this long before it's activated
+	JWKSCacheControl = "public, max-age=300"
+
+	// UserAgentMaxLength is the longest User-Agent stored with a login attempt, longer ones are truncated
+	UserAgentMaxLength = 512
+
+	// Webhook requests carry the event type, the delivery UUID, the unix time they were signed at
+	// and the HMAC-SHA256 signature of "<timestamp>.<body>", see secure.SignWebhookPayload
+	WebhookEventHeader     = "X-Xpay-Event"
+	WebhookDeliveryHeader  = "X-Xpay-Delivery"
+	WebhookTimestampHeader = "X-Xpay-Timestamp"
+	WebhookSignatureHeader = "X-Xpay-Signature"
+	WebhookUserAgent       = "xPay-Webhooks/1.0"
+
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	IdempotencyKeyMaxLength  = 255
 
 	AuthorizationHeaderKey = "Authorization"
 	TokenTypeBearer        = "bearer"
 
+	AccessTokenCookieName  = "accessToken"
+	RefreshTokenCookieName = "refreshToken"
+
+	// DenylistSyncInterval is how often revoked access tokens are pulled from the database into the in-memory denylist.
+	// Tokens revoked on another instance are rejected here at most this long after the revocation.
+	DenylistSyncInterval = 30 * time.Second
+
+	// PolicySyncInterval is how often the rbac grants are reloaded when an admin changed them on another instance.
+	PolicySyncInterval = 30 * time.Second
+
+	// StatementExportInterval is how often pending statement exports are picked up by the background worker.
+	StatementExportInterval = 5 * time.Second
+
+	// PaymentIntentExpiryInterval is how often expired payment intents are released by the background worker.
+	PaymentIntentExpiryInterval = time.Minute
+
+	// DepositReconciliationInterval is how often deposits with an unknown charge outcome are settled by the background worker.
+	DepositReconciliationInterval = time.Minute
+
+	// IdempotencyPurgeInterval is how often idempotency keys past their TTL are deleted by the background worker.
+	IdempotencyPurgeInterval = 10 * time.Minute
+
+	// WebhookDispatchInterval is how often due webhook deliveries are picked up by the background worker.
+	WebhookDispatchInterval = 2 * time.Second
+
+	// CardReencryptionInterval is how often card numbers not encrypted under the active key-encryption key
+	// are looked for by the background re-encryption worker.
+	CardReencryptionInterval = 5 * time.Minute
+
+	// CardDefaultKeyID is the ID of card.aes_key as key-encryption key, when no card.keyring_file is set.
+	// Keep it in the keyring under this ID when moving to a keyring file, until the cards were re-encrypted.
+	CardDefaultKeyID = "card-aes-key"
+
+	// OutboxRelayInterval is how often pending outbox events are published by the background worker.
+	OutboxRelayInterval = time.Second
+
+	// RefreshTokenCookiePath limits the refresh token cookie to the token endpoints, so it isn't sent with every request
+	RefreshTokenCookiePath = "/api/v1/token"
+
+	// TOTPIssuer is the account issuer shown by authenticator apps
+	TOTPIssuer = "xpay"
+
 	DBTSLayout       = "time.RFC3339"
 	CardExpiryLayout = "01/06" // MM/YY
 
-	DBColumnID       = "id"
-	DBColumnUUID     = "uuid"
-	DBColumnUserID   = "user_id"
-	DBColumnEmail    = "email"
-	DBColumnWalletID = "wallet_id"
+	DBColumnID        = "id"
+	DBColumnUUID      = "uuid"
+	DBColumnUserID    = "user_id"
+	DBColumnEmail     = "email"
+	DBColumnWalletID  = "wallet_id"
+	DBColumnCardToken = "card_token"
 )
@@ -1,6 +1,24 @@
 package common
 
+import "context"
+
 const (
 	ContextKeyAuthorizedUser = "authorizedUser"
+	ContextKeyResourceOwner  = "resourceOwner"
 	ContextKeyRequestID      = "requestID"
+	ContextKeyTokenClaims    = "tokenClaims"
 )
+
+// requestIDKey is the key of the request ID in a context.Context, gin's context keys don't reach the repositories.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID, e.g. to record it with the events a request causes.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID set by WithRequestID, or an empty string outside a request.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
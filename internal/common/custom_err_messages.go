@@ -9,4 +9,45 @@ const (
 	ErrTXRollback         = "failed to rollback transaction"
 	ErrTxCommit           = "failed to commit transaction"
 	ErrIncorrectPassword  = "incorrect password"
+	ErrInsufficientFunds  = "insufficient funds"
+	ErrConcurrentUpdate   = "the resource was modified concurrently, please retry"
+
+	ErrInvalidRefreshToken = "invalid or expired refresh token"
+	ErrRefreshTokenReused  = "refresh token was already used, please log in again"
+	ErrTokenRevoked        = "token has been revoked, please log in again"
+
+	ErrIdempotencyKeyInFlight     = "a request with this idempotency key is already in progress"
+	ErrIdempotencyKeyReused       = "idempotency key was already used with a different request"
+	ErrIdempotentResponseWithheld = "the response to this request contained secrets and can't be replayed, retry with a new idempotency key"
+
+	ErrAccountLocked       = "too many failed login attempts, please try again later"
+	ErrInvalidAccountToken = "invalid or expired token"
+	ErrEmailNotVerified    = "please verify your email address first"
+
+	ErrMFAAlreadyEnabled   = "two-factor authentication is already enabled"
+	ErrMFANotSetUp         = "two-factor authentication is not set up"
+	ErrInvalidMFACode      = "invalid verification code"
+	ErrInvalidMFAChallenge = "invalid or expired mfa token, please log in again"
+
+	ErrResourceAccessDenied       = "You can only access your own resources"
+	ErrPolicyRoleOrActionNotFound = "role or action not found"
+	ErrPolicyGrantProtected       = "admins can't lose access to policy management"
+
+	ErrFXQuoteNotFound     = "fx quote not found"
+	ErrFXQuoteUsed         = "fx quote was already used"
+	ErrFXQuoteExpired      = "fx quote expired, please request a new quote"
+	ErrFXTargetWalletEmpty = "you need an active wallet in the target currency to convert into it"
+
+	ErrStatementExportNotFound = "statement export not found"
+	ErrStatementExportNotReady = "statement export is not ready yet"
+
+	ErrPaymentIntentNotFound  = "payment intent not found"
+	ErrPaymentIntentExpired   = "payment intent expired"
+	ErrPaymentIntentOwnWallet = "a payment intent can't be paid from the merchant's wallet"
+
+	ErrWebhookEndpointNotFound = "webhook endpoint not found"
+	ErrWebhookDeliveryNotFound = "webhook delivery not found"
+	ErrWebhookEndpointDisabled = "webhook endpoint is disabled, enable it first"
+	ErrWebhookURLNotPublic     = "webhook URL must resolve to public IP addresses"
+	ErrWebhookURLUnresolvable  = "webhook URL host can't be resolved"
 )
@@ -8,6 +8,7 @@ import (
 
 // GetJSONHandlerOptions returns customized slog.HandlerOptions for JSON logging.
 // Purpose is to provide a consistent, structured JSON log format with simplified source information.
+// Card numbers, CVVs, passwords, tokens and secrets are redacted from every attribute and the message.
 // Example usage:
 //
 //	logLevel := new(slog.LevelVar)
@@ -56,14 +57,17 @@ func GetJSONHandlerOptions(logLevel *slog.LevelVar) *slog.HandlerOptions {
 	}
 
 	return &slog.HandlerOptions{
-		AddSource:   true,
-		Level:       logLevel,
-		ReplaceAttr: customizeSourceAttr,
+		AddSource: true,
+		Level:     logLevel,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			return redactAttr(groups, customizeSourceAttr(groups, a))
+		},
 	}
 }
 
 // GetTextHandlerOptions returns customized slog.HandlerOptions for text-based logging.
 // Purpose is to provide a concise text log format with simplified source file information.
+// Card numbers, CVVs, passwords, tokens and secrets are redacted from every attribute and the message.
 // Example usage:
 //
 //	logLevel := new(slog.LevelVar)
@@ -85,7 +89,7 @@ func GetTextHandlerOptions(logLevel *slog.LevelVar) *slog.HandlerOptions {
 			sourceVal.File = filepath.Base(sourceVal.File)
 		}
 
-		return a
+		return redactAttr(groups, a)
 	}
 
 	return &slog.HandlerOptions{
@@ -0,0 +1,117 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLoggers returns a JSON and a text logger configured like the app's, both writing to out.
+func newTestLoggers(out *bytes.Buffer) map[string]*slog.Logger {
+	level := new(slog.LevelVar)
+
+	return map[string]*slog.Logger{
+		"json": slog.New(slog.NewJSONHandler(out, GetJSONHandlerOptions(level))),
+		"text": slog.New(slog.NewTextHandler(out, GetTextHandlerOptions(level))),
+	}
+}
+
+type testCard struct {
+	Number string
+	Expiry string
+}
+
+// logCardNumber logs pan every way a handler could: in the message, as a string, int, error, struct,
+// group member and logger attribute, and in echoed query strings and JSON bodies.
+func logCardNumber(logger *slog.Logger, pan string) {
+	logger.Error("failed to charge card "+pan, "card", pan, "error", errors.New("invalid card number "+pan))
+	logger.Info("card", "value", testCard{Number: pan, Expiry: "12/30"}, slog.Group("request", slog.String("body", `{"cardNumber":"`+pan+`"}`)))
+	logger.With("cardNumber", pan, "pan", pan).Warn("request", "path", "/cards?number="+pan+"&cvv=123")
+
+	if number, err := strconv.ParseInt(strings.NewReplacer(" ", "", "-", "").Replace(pan), 10, 64); err == nil {
+		logger.Info("card", "number", number)
+	}
+}
+
+func TestLogRedactionHidesCardNumbers(t *testing.T) {
+	pans := []string{
+		"4111111111111111", "378282246310005", "5555555555554444", "4222222222222", "6200000000000001",
+		"6011111111111117000", "4111 1111 1111 1111", "4111-1111-1111-1111", "3782 822463 10005",
+	}
+
+	for range 50 {
+		digits := make([]byte, 13+rand.IntN(7))
+		for i := range digits {
+			digits[i] = byte('0' + rand.IntN(10))
+		}
+
+		pans = append(pans, string(digits))
+	}
+
+	for _, pan := range pans {
+		var out bytes.Buffer
+		for name, logger := range newTestLoggers(&out) {
+			out.Reset()
+			logCardNumber(logger, pan)
+
+			digits := strings.NewReplacer(" ", "", "-", "").Replace(pan)
+			logged := out.String()
+
+			assert.NotContains(t, logged, pan, "%s log", name)
+			assert.NotContains(t, strings.NewReplacer(" ", "", "-", "").Replace(logged), digits, "%s log", name)
+			assert.Contains(t, logged, "****"+digits[len(digits)-4:], "%s log keeps the last four", name)
+		}
+	}
+}
+
+func TestLogRedactionHidesSecrets(t *testing.T) {
+	var out bytes.Buffer
+	for name, logger := range newTestLoggers(&out) {
+		out.Reset()
+		logger.Info("login",
+			"password", "hunter2-secret",
+			"newPassword", "hunter3-secret",
+			"refresh_token", "rt-secret",
+			"cvv", "123",
+			"webhookSecret", "whsec-secret",
+			"Authorization", "Bearer jwt-secret",
+			"body", `{"email":"a@b.c","password":"hunter4-secret","cvv":"456"}`,
+			"query", "token=tk-secret&page=2",
+		)
+
+		logged := out.String()
+		assert.NotContains(t, logged, "-secret", "%s log", name)
+		assert.NotContains(t, logged, "123", "%s log", name)
+		assert.NotContains(t, logged, "456", "%s log", name)
+		assert.Contains(t, logged, RedactedValue, "%s log", name)
+		assert.Contains(t, logged, "a@b.c", "%s log", name)
+	}
+}
+
+func TestLogRedactionKeepsOtherValues(t *testing.T) {
+	var out bytes.Buffer
+	for name, logger := range newTestLoggers(&out) {
+		out.Reset()
+		logger.Info("invalid refresh token: expired",
+			"requestID", "12345678-1234-1234-1234-123456789012",
+			"keyID", "2026-01",
+			"tokenType", "access",
+			"amountInCents", 125000,
+			"lastFour", "1111",
+		)
+
+		logged := out.String()
+		assert.Contains(t, logged, "invalid refresh token: expired", "%s log", name)
+		assert.Contains(t, logged, "12345678-1234-1234-1234-123456789012", "%s log keeps UUIDs", name)
+		assert.Contains(t, logged, "2026-01", "%s log", name)
+		assert.Contains(t, logged, "access", "%s log", name)
+		assert.Contains(t, logged, "125000", "%s log", name)
+		assert.NotContains(t, logged, RedactedValue, "%s log", name)
+	}
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces the values of sensitive log attributes.
+const RedactedValue = "[REDACTED]"
+
+var (
+	// panPattern matches card number like runs of 13 or more digits, also grouped with spaces or dashes, e.g. 4111 1111 1111 1111.
+	panPattern = regexp.MustCompile(`\d(?:[ -]?\d){12,}`)
+
+	// secretPairPattern matches the values of sensitive keys in free text, e.g. cvv=123 in a query string
+	// or "password":"..." in an echoed JSON body.
+	secretPairPattern = regexp.MustCompile(`(?i)((?:cvv2?|cvc|password|secret|token)(?:=|"\s*:\s*"?))([^"\s,;&}]+)`)
+
+	// uuidPattern matches UUIDs, they are left as they are even where they contain a long run of digits.
+	uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+	sensitiveKeyNormalizer = strings.NewReplacer("_", "", "-", "")
+)
+
+// redactAttr masks what must never reach the logs in an attribute of any kind, the message included:
+// the values of sensitive keys are replaced, card number like digit runs keep only their last four digits,
+// and sensitive key/value pairs in text are replaced. Values that need no masking are left as they are.
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	switch a.Key {
+	case slog.TimeKey, slog.LevelKey, slog.SourceKey:
+		return a
+	}
+
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, RedactedValue)
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		return a
+	}
+
+	text := a.Value.String()
+	if redacted := redactText(text); redacted != text {
+		return slog.String(a.Key, redacted)
+	}
+
+	return a
+}
+
+// isSensitiveKey reports whether the values of key are secrets, e.g. password, newPassword, cvv, refresh_token or cardNumber.
+func isSensitiveKey(key string) bool {
+	k := strings.ToLower(sensitiveKeyNormalizer.Replace(key))
+
+	return strings.Contains(k, "password") || strings.Contains(k, "secret") || strings.Contains(k, "cvv") ||
+		strings.Contains(k, "cvc") || strings.HasSuffix(k, "token") || k == "authorization" || k == "cardnumber" || k == "pan"
+}
+
+// redactText masks card numbers and sensitive key/value pairs in s, outside of UUIDs.
+func redactText(s string) string {
+	var b strings.Builder
+	last := 0
+
+	for _, m := range uuidPattern.FindAllStringIndex(s, -1) {
+		b.WriteString(redactSegment(s[last:m[0]]))
+		b.WriteString(s[m[0]:m[1]])
+		last = m[1]
+	}
+
+	b.WriteString(redactSegment(s[last:]))
+
+	return b.String()
+}
+
+func redactSegment(s string) string {
+	s = secretPairPattern.ReplaceAllString(s, "${1}"+RedactedValue)
+
+	return panPattern.ReplaceAllStringFunc(s, func(run string) string {
+		digits := strings.NewReplacer(" ", "", "-", "").Replace(run)
+		return "****" + digits[len(digits)-4:]
+	})
+}
@@ -11,12 +11,26 @@ type ServiceTimeouts struct {
 
 // Timeouts contains timeout configurations for different services
 var Timeouts = struct {
-	Auth    ServiceTimeouts
-	User    ServiceTimeouts
-	Wallet  ServiceTimeouts
-	Card    ServiceTimeouts
-	Server  ServiceTimeouts
-	Default ServiceTimeouts
+	Auth          ServiceTimeouts
+	User          ServiceTimeouts
+	Wallet        ServiceTimeouts
+	Card          ServiceTimeouts
+	Ledger        ServiceTimeouts
+	Transfer      ServiceTimeouts
+	Deposit       ServiceTimeouts
+	Gateway       ServiceTimeouts
+	Idempotency   ServiceTimeouts
+	MFA           ServiceTimeouts
+	Account       ServiceTimeouts
+	Mailer        ServiceTimeouts
+	RBAC          ServiceTimeouts
+	FX            ServiceTimeouts
+	Statement     ServiceTimeouts
+	PaymentIntent ServiceTimeouts
+	Webhook       ServiceTimeouts
+	Outbox        ServiceTimeouts
+	Server        ServiceTimeouts
+	Default       ServiceTimeouts
 }{
 	Auth: ServiceTimeouts{
 		Read:  300 * time.Millisecond,
@@ -34,6 +48,61 @@ var Timeouts = struct {
 		Read:  300 * time.Millisecond,
 		Write: 500 * time.Millisecond,
 	},
+	Ledger: ServiceTimeouts{
+		Read:  5 * time.Second,
+		Write: 500 * time.Millisecond,
+	},
+	Transfer: ServiceTimeouts{
+		Read:  300 * time.Millisecond,
+		Write: 1 * time.Second,
+	},
+	Deposit: ServiceTimeouts{
+		Read:  300 * time.Millisecond,
+		Write: 5 * time.Second,
+	},
+	Gateway: ServiceTimeouts{
+		Write: 3 * time.Second,
+	},
+	Idempotency: ServiceTimeouts{
+		Read:  300 * time.Millisecond,
+		Write: 300 * time.Millisecond,
+	},
+	MFA: ServiceTimeouts{
+		Read:  300 * time.Millisecond,
+		Write: 2 * time.Second,
+	},
+	Account: ServiceTimeouts{
+		Read:  300 * time.Millisecond,
+		Write: 500 * time.Millisecond,
+	},
+	Mailer: ServiceTimeouts{
+		Write: 5 * time.Second,
+	},
+	RBAC: ServiceTimeouts{
+		Read:    300 * time.Millisecond,
+		Write:   500 * time.Millisecond,
+		Startup: 5 * time.Second,
+	},
+	FX: ServiceTimeouts{
+		Read:  300 * time.Millisecond,
+		Write: 1 * time.Second,
+	},
+	Statement: ServiceTimeouts{
+		Read:  5 * time.Second,
+		Write: 500 * time.Millisecond,
+	},
+	PaymentIntent: ServiceTimeouts{
+		Read:  300 * time.Millisecond,
+		Write: 500 * time.Millisecond,
+	},
+	Webhook: ServiceTimeouts{
+		Read:  300 * time.Millisecond,
+		Write: 500 * time.Millisecond,
+	},
+	Outbox: ServiceTimeouts{
+		Read:  300 * time.Millisecond,
+		Write: 500 * time.Millisecond,
+	},
 	Server: ServiceTimeouts{
 		Read:    5 * time.Second,
 		Write:   10 * time.Second,
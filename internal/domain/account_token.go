@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+const (
+	AccountTokenPurposePasswordReset     string = "password_reset"
+	AccountTokenPurposeEmailVerification string = "email_verification"
+
+	PasswordResetTokenTTL     = 30 * time.Minute
+	EmailVerificationTokenTTL = 24 * time.Hour
+)
+
+// AccountToken is the stored, hashed form of a single use token emailed to a user,
+// to reset a forgotten password or to verify the email address.
+type AccountToken struct {
+	ID        int64
+	UserID    int64
+	Purpose   string
+	TokenHash []byte
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// AccountTokenRepository defines the interface for password reset and email verification tokens.
+type AccountTokenRepository interface {
+	Create(ctx context.Context, token *AccountToken) common.AppError
+	ResetPassword(ctx context.Context, tokenHash []byte, passwordHash string) common.AppError
+	VerifyEmail(ctx context.Context, tokenHash []byte) common.AppError
+}
+
+type accountTokenRepository struct {
+	db *sql.DB
+}
+
+// NewAccountTokenRepository creates a new instance of AccountTokenRepository.
+func NewAccountTokenRepository(db *sql.DB) AccountTokenRepository {
+	return &accountTokenRepository{db: db}
+}
+
+// Create stores a new token and deletes the user's unused tokens of the same purpose,
+// so only the most recently emailed token works.
+func (r *accountTokenRepository) Create(ctx context.Context, t *AccountToken) common.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Create Account Token")
+
+	deleteQuery := `DELETE FROM account_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+	if _, err = tx.ExecContext(ctx, deleteQuery, t.UserID, t.Purpose); err != nil {
+		slog.Error("failed to delete previous account tokens", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	insertQuery := `INSERT INTO account_tokens (user_id, purpose, token_hash, expires_at)
+                    VALUES ($1, $2, $3, $4)
+                    RETURNING id, created_at`
+
+	if err = tx.QueryRowContext(ctx, insertQuery, t.UserID, t.Purpose, t.TokenHash, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt); err != nil {
+		slog.Error("failed to create account token", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// ResetPassword consumes a password reset token and sets the new password hash. In the same transaction
+// it revokes all sessions of the user like RevokeAllSessions, and clears a login lockout. The token was
+// delivered by email, so using it verifies the email address as well.
+func (r *accountTokenRepository) ResetPassword(ctx context.Context, tokenHash []byte, passwordHash string) common.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Reset Password")
+
+	userID, appErr := consumeAccountToken(ctx, tx, tokenHash, AccountTokenPurposePasswordReset)
+	if appErr != nil {
+		return appErr
+	}
+
+	updateQuery := `UPDATE users
+                    SET password_hash = $2, sessions_revoked_at = CURRENT_TIMESTAMP,
+                        failed_login_attempts = 0, login_locked_until = NULL,
+                        email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP)
+                    WHERE id = $1`
+
+	if _, err = tx.ExecContext(ctx, updateQuery, userID, passwordHash); err != nil {
+		slog.Error("failed to reset password", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	revokeQuery := `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err = tx.ExecContext(ctx, revokeQuery, userID); err != nil {
+		slog.Error("failed to revoke user refresh tokens", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// VerifyEmail consumes an email verification token and marks the user's email as verified.
+func (r *accountTokenRepository) VerifyEmail(ctx context.Context, tokenHash []byte) common.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Verify Email")
+
+	userID, appErr := consumeAccountToken(ctx, tx, tokenHash, AccountTokenPurposeEmailVerification)
+	if appErr != nil {
+		return appErr
+	}
+
+	updateQuery := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP) WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, updateQuery, userID); err != nil {
+		slog.Error("failed to verify email", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// consumeAccountToken marks an unused, unexpired token of the purpose as used and returns its user ID.
+// Unknown, used, expired and wrong purpose tokens get the same error, so they can't be told apart.
+func consumeAccountToken(ctx context.Context, tx *sql.Tx, tokenHash []byte, purpose string) (int64, common.AppError) {
+	query := `UPDATE account_tokens SET used_at = CURRENT_TIMESTAMP
+              WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+              RETURNING user_id`
+
+	var userID int64
+	if err := tx.QueryRowContext(ctx, query, tokenHash, purpose).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, common.NewBadRequestError(common.ErrInvalidAccountToken)
+		}
+
+		slog.Error("failed to consume account token", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return userID, nil
+}
@@ -11,6 +11,9 @@ const (
 	CardProviderVisa       = "visa"
 	CardProviderMastercard = "mastercard"
 	CardProviderAmex       = "amex"
+	CardProviderDiscover   = "discover"
+	CardProviderJCB        = "jcb"
+	CardProviderUnionPay   = "unionpay"
 
 	CardTypeCredit = "credit"
 	CardTypeDebit  = "debit"
@@ -18,6 +21,12 @@ const (
 	CardStatusActive   = "active"
 	CardStatusInactive = "inactive"
 	CardStatusDeleted  = "deleted"
+
+	// CardDetokenizationPurposeCharge is the purpose audited when a card number is detokenized to charge the card.
+	CardDetokenizationPurposeCharge = "charge"
+
+	// CardReencryptionBatchSize is the most card numbers the re-encryption worker moves to a new key at once.
+	CardReencryptionBatchSize = 100
 )
 
 type Card struct {
@@ -26,6 +35,9 @@ type Card struct {
 	UserID              int64     `json:"-"`
 	WalletID            int64     `json:"-"`
 	EncryptedCardNumber []byte    `json:"-"`
+	EncryptedDataKey    []byte    `json:"-"`
+	EncryptionKeyID     string    `json:"-"`
+	Token               string    `json:"-"`
 	Provider            string    `json:"provider"`
 	Type                string    `json:"type"`
 	LastFour            string    `json:"lastFour"`
@@ -35,9 +47,21 @@ type Card struct {
 	UpdatedAt           time.Time `json:"updatedAt"`
 }
 
+// CardDetokenization is the audit record of a card token exchanged for the card number,
+// e.g. by the payment gateway to charge the card for the deposit in Reference.
+type CardDetokenization struct {
+	ID        int64
+	CardID    int64
+	Purpose   string
+	Reference string
+	RequestID string
+	CreatedAt time.Time
+}
+
 // IsValidCardProvider utility method to validate queryParams, request body is validated with validator/v10
 func IsValidCardProvider(provider string) bool {
-	if provider == CardProviderAmex || provider == CardProviderMastercard || provider == CardProviderVisa {
+	switch provider {
+	case CardProviderVisa, CardProviderMastercard, CardProviderAmex, CardProviderDiscover, CardProviderJCB, CardProviderUnionPay:
 		return true
 	}
 
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
 )
 
 // CardFilters defines the filters for querying cards, Used in List()
@@ -24,6 +26,10 @@ type CardRepository interface {
 	Update(ctx context.Context, card *Card) common.AppError
 	Delete(ctx context.Context, cardID string) common.AppError
 	List(ctx context.Context, filters CardFilters) ([]*Card, common.AppError)
+	ListForReencryption(ctx context.Context, activeKeyID string, afterID int64, limit int) ([]*Card, common.AppError)
+	UpdateEncryption(ctx context.Context, card *Card, previousKeyID string) (bool, common.AppError)
+	SetToken(ctx context.Context, cardID int64, token string) (string, common.AppError)
+	RecordDetokenization(ctx context.Context, d *CardDetokenization) common.AppError
 }
 
 type cardRepository struct {
@@ -38,7 +44,8 @@ func NewCardRepository(db *sql.DB) CardRepository {
 
 // AddCardToWallet adds a new card to a wallet, using serializable isolation to prevent
 // concurrent addition of duplicate cards for the same user and wallet.
-// It checks for existing cards before insertion and handles potential conflicts.
+// It checks for existing cards before insertion and handles potential conflicts,
+// and records a card.added event in the same transaction.
 func (r *cardRepository) AddCardToWallet(ctx context.Context, card *Card) (*Card, common.AppError) {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
@@ -52,20 +59,30 @@ func (r *cardRepository) AddCardToWallet(ctx context.Context, card *Card) (*Card
 		return nil, appErr
 	}
 
-	query := `INSERT INTO cards (uuid, user_id, wallet_id, encrypted_card_number, provider, type, last_four, expiry_date, status)
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	query := `INSERT INTO cards (uuid, user_id, wallet_id, encrypted_card_number, encrypted_data_key, encryption_key_id,
+			                   card_token, provider, type, last_four, expiry_date, status)
+			  VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $12)
 			  RETURNING id, created_at, updated_at`
 
 	err = tx.QueryRowContext(ctx, query,
-		card.UUID, card.UserID, card.WalletID, card.EncryptedCardNumber, card.Provider, card.Type,
-		card.LastFour, card.ExpiryDate, card.Status).
+		card.UUID, card.UserID, card.WalletID, card.EncryptedCardNumber, card.EncryptedDataKey, card.EncryptionKeyID, card.Token,
+		card.Provider, card.Type, card.LastFour, card.ExpiryDate, card.Status).
 		Scan(&card.ID, &card.CreatedAt, &card.UpdatedAt)
 
 	if err != nil {
+		if isSerializationFailure(err) || isUniqueViolation(err) {
+			// a token collision too, the retry gets a new token
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
 		slog.Error("failed to add card to wallet", "err", err)
 		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
+	if appErr := r.recordCardAdded(ctx, tx, card); appErr != nil {
+		return nil, appErr
+	}
+
 	if err = tx.Commit(); err != nil {
 		slog.Error(common.ErrTxCommit, "err", err)
 		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
@@ -74,7 +91,7 @@ func (r *cardRepository) AddCardToWallet(ctx context.Context, card *Card) (*Card
 	return card, nil
 }
 
-// FindBy retrieves a card based on a specific database column (id, uuid, wallet_id, user_id),
+// FindBy retrieves a card based on a specific database column (id, uuid, wallet_id, user_id, card_token),
 // using read committed isolation to ensure consistent reads across different lookup methods.
 func (r *cardRepository) FindBy(ctx context.Context, dbColumnName string, value any) (*Card, common.AppError) {
 	query, err := r.generateFindByQuery(dbColumnName)
@@ -92,8 +109,8 @@ func (r *cardRepository) FindBy(ctx context.Context, dbColumnName string, value
 
 	var card Card
 	err = tx.QueryRowContext(ctx, query, value).Scan(
-		&card.ID, &card.UUID, &card.UserID, &card.WalletID, &card.EncryptedCardNumber, &card.Provider, &card.Type,
-		&card.LastFour, &card.ExpiryDate, &card.Status, &card.CreatedAt, &card.UpdatedAt)
+		&card.ID, &card.UUID, &card.UserID, &card.WalletID, &card.EncryptedCardNumber, &card.EncryptedDataKey, &card.EncryptionKeyID, &card.Token,
+		&card.Provider, &card.Type, &card.LastFour, &card.ExpiryDate, &card.Status, &card.CreatedAt, &card.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -206,8 +223,8 @@ func (r *cardRepository) List(ctx context.Context, filters CardFilters) ([]*Card
 	for rows.Next() {
 		var card Card
 		err := rows.Scan(
-			&card.ID, &card.UUID, &card.UserID, &card.WalletID, &card.EncryptedCardNumber, &card.Provider, &card.Type,
-			&card.LastFour, &card.ExpiryDate, &card.Status, &card.CreatedAt, &card.UpdatedAt)
+			&card.ID, &card.UUID, &card.UserID, &card.WalletID, &card.EncryptedCardNumber, &card.EncryptedDataKey, &card.EncryptionKeyID, &card.Token,
+			&card.Provider, &card.Type, &card.LastFour, &card.ExpiryDate, &card.Status, &card.CreatedAt, &card.UpdatedAt)
 
 		if err != nil {
 			slog.Error("failed to scan card", "err", err)
@@ -230,6 +247,122 @@ func (r *cardRepository) List(ctx context.Context, filters CardFilters) ([]*Card
 	return cards, nil
 }
 
+// ListForReencryption returns up to limit cards after afterID, in id order, whose card number isn't encrypted
+// under the active key-encryption key, deleted cards included. Only the id, uuid and encryption columns are set.
+func (r *cardRepository) ListForReencryption(ctx context.Context, activeKeyID string, afterID int64, limit int) ([]*Card, common.AppError) {
+	query := `SELECT id, uuid, encrypted_card_number, encrypted_data_key, COALESCE(encryption_key_id, '')
+              FROM cards
+              WHERE id > $1 AND encryption_key_id IS DISTINCT FROM $2
+              ORDER BY id
+              LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, afterID, activeKeyID, limit)
+	if err != nil {
+		slog.Error("failed to list cards for re-encryption", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	cards := make([]*Card, 0, limit)
+	for rows.Next() {
+		var card Card
+		if err := rows.Scan(&card.ID, &card.UUID, &card.EncryptedCardNumber, &card.EncryptedDataKey, &card.EncryptionKeyID); err != nil {
+			slog.Error("failed to scan card", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		cards = append(cards, &card)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return cards, nil
+}
+
+// UpdateEncryption stores the re-encrypted card number of a card, unless it was re-encrypted concurrently,
+// i.e. its key-encryption key isn't previousKeyID anymore. It reports whether the card was updated.
+func (r *cardRepository) UpdateEncryption(ctx context.Context, card *Card, previousKeyID string) (bool, common.AppError) {
+	query := `UPDATE cards SET encrypted_card_number = $1, encrypted_data_key = $2, encryption_key_id = $3
+              WHERE id = $4 AND encryption_key_id IS NOT DISTINCT FROM NULLIF($5, '')`
+
+	result, err := r.db.ExecContext(ctx, query, card.EncryptedCardNumber, card.EncryptedDataKey, card.EncryptionKeyID, card.ID, previousKeyID)
+	if err != nil {
+		slog.Error("failed to update card encryption", "err", err)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// SetToken stores the token of a card added before tokenization, unless it got one concurrently,
+// and returns the card's token either way. A token already taken by another card is a conflict.
+func (r *cardRepository) SetToken(ctx context.Context, cardID int64, token string) (string, common.AppError) {
+	query := `UPDATE cards SET card_token = COALESCE(card_token, $1) WHERE id = $2 RETURNING card_token`
+
+	var stored string
+	if err := r.db.QueryRowContext(ctx, query, token, cardID).Scan(&stored); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", common.NewNotFoundError("card not found")
+		}
+
+		if isUniqueViolation(err) {
+			return "", common.NewConflictError("card token is already taken")
+		}
+
+		slog.Error("failed to set card token", "err", err)
+		return "", common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return stored, nil
+}
+
+// RecordDetokenization writes the audit record of a detokenized card number.
+func (r *cardRepository) RecordDetokenization(ctx context.Context, d *CardDetokenization) common.AppError {
+	query := `INSERT INTO card_detokenizations (card_id, purpose, reference, request_id)
+              VALUES ($1, $2, $3, NULLIF($4, ''))
+              RETURNING id, created_at`
+
+	if err := r.db.QueryRowContext(ctx, query, d.CardID, d.Purpose, d.Reference, d.RequestID).Scan(&d.ID, &d.CreatedAt); err != nil {
+		slog.Error("failed to record card detokenization", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// recordCardAdded records the card.added event of a new card, it never carries the card number.
+func (r *cardRepository) recordCardAdded(ctx context.Context, tx *sql.Tx, card *Card) common.AppError {
+	var walletUUID uuid.UUID
+	if err := tx.QueryRowContext(ctx, `SELECT uuid FROM wallets WHERE id = $1`, card.WalletID).Scan(&walletUUID); err != nil {
+		slog.Error("failed to find wallet of card", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	event, err := NewEvent(ctx, EventCardAdded, AggregateTypeCard, card.UUID, CardAddedData{
+		CardUUID:   card.UUID,
+		WalletUUID: walletUUID,
+		Provider:   card.Provider,
+		Type:       card.Type,
+		LastFour:   card.LastFour,
+	}, time.Now().UTC())
+	if err != nil {
+		slog.Error("failed to create card added event", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedEvent, err)
+	}
+
+	return recordEvent(ctx, tx, event, card.UserID)
+}
+
 // checkExistingCard verifies if a card with the same type and provider already exists for the user,
 // preventing duplicate entries and handling reactivation of previously deleted cards.
 func (r *cardRepository) checkExistingCard(ctx context.Context, tx *sql.Tx, card *Card) common.AppError {
@@ -255,7 +388,7 @@ func (r *cardRepository) checkExistingCard(ctx context.Context, tx *sql.Tx, card
 // generateFindByQuery creates the appropriate SQL query based on the specified field name,
 // supporting flexible querying for the FindBy method while preventing SQL injection.
 func (r *cardRepository) generateFindByQuery(fieldName string) (string, error) {
-	baseQuery := `SELECT id, uuid, user_id, wallet_id, encrypted_card_number, provider, type, last_four, expiry_date, status, created_at, updated_at
+	baseQuery := `SELECT id, uuid, user_id, wallet_id, encrypted_card_number, encrypted_data_key, COALESCE(encryption_key_id, ''), COALESCE(card_token, ''), provider, type, last_four, expiry_date, status, created_at, updated_at
 				  FROM cards WHERE status != 'deleted' AND `
 
 	switch fieldName {
@@ -267,6 +400,8 @@ func (r *cardRepository) generateFindByQuery(fieldName string) (string, error) {
 		return baseQuery + "user_id = $1", nil
 	case common.DBColumnWalletID:
 		return baseQuery + "wallet_id = $1", nil
+	case common.DBColumnCardToken:
+		return baseQuery + "card_token = $1", nil
 	default:
 		return "", errors.New("invalid db field name for card lookup")
 	}
@@ -274,7 +409,7 @@ func (r *cardRepository) generateFindByQuery(fieldName string) (string, error) {
 
 // buildListQuery constructs the SQL query and arguments for listing cards based on the provided CardFilters.
 func (r *cardRepository) buildListQuery(filters CardFilters) (string, []any) {
-	query := `SELECT id, uuid, user_id, wallet_id, encrypted_card_number, provider, type, last_four, expiry_date, status, created_at, updated_at
+	query := `SELECT id, uuid, user_id, wallet_id, encrypted_card_number, encrypted_data_key, COALESCE(encryption_key_id, ''), COALESCE(card_token, ''), provider, type, last_four, expiry_date, status, created_at, updated_at
               FROM cards
               WHERE 1=1`
 	var args []any
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DepositStatusPending   = "pending"
+	DepositStatusSucceeded = "succeeded"
+	DepositStatusDeclined  = "declined"
+	DepositStatusFailed    = "failed"
+	DepositStatusTimedOut  = "timed_out"
+
+	JournalEntryTypeDeposit = "deposit"
+
+	// SystemAccountCardSettlement holds funds charged from cards until the gateway settles them with xPay.
+	SystemAccountCardSettlement = "card_settlement"
+
+	// DepositSucceedMaxAttempts is how often crediting a captured charge is tried when it races with other
+	// activity on the wallet, before it's left to the reconciler.
+	DepositSucceedMaxAttempts = 3
+
+	// DepositSucceedRetryBackoff is the pause before the second attempt to credit a captured charge, it grows linearly.
+	DepositSucceedRetryBackoff = 20 * time.Millisecond
+
+	// DepositReconciliationDelay is how long a deposit stays pending or timed out before the reconciler asks
+	// the gateway about its charge. It must be longer than a deposit request, so requests in flight are left alone.
+	DepositReconciliationDelay = 5 * time.Minute
+
+	// DepositReconciliationWindow is how long the reconciler keeps asking about the charge of a timed out deposit.
+	DepositReconciliationWindow = 24 * time.Hour
+
+	// DepositReconciliationBatchSize is the most deposits the reconciler lists at once.
+	DepositReconciliationBatchSize = 100
+)
+
+// Deposit tops up a wallet by charging one of its saved cards through the payment gateway.
+// Every attempt is persisted, the wallet is credited only once the charge succeeds.
+type Deposit struct {
+	ID              int64     `json:"-"`
+	UUID            uuid.UUID `json:"uuid"`
+	UserID          int64     `json:"-"`
+	WalletID        int64     `json:"-"`
+	WalletUUID      uuid.UUID `json:"walletUuid"`
+	CardID          int64     `json:"-"`
+	CardUUID        uuid.UUID `json:"cardUuid"`
+	AmountInCents   int64     `json:"amountInCents"`
+	Currency        string    `json:"currency"`
+	Status          string    `json:"status"`
+	GatewayChargeID string    `json:"gatewayChargeId,omitempty"`
+	FailureReason   string    `json:"failureReason,omitempty"`
+	JournalEntryID  *int64    `json:"-"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
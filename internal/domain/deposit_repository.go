@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// DepositRepository defines the interface for card to wallet deposit operations.
+type DepositRepository interface {
+	Create(ctx context.Context, deposit *Deposit) (*Deposit, common.AppError)
+	MarkSucceeded(ctx context.Context, deposit *Deposit, gatewayChargeID string) (*Deposit, common.AppError)
+	MarkFailed(ctx context.Context, deposit *Deposit, status, gatewayChargeID, reason string) (*Deposit, common.AppError)
+	ListUnsettled(ctx context.Context, afterID int64, limit int) ([]*Deposit, common.AppError)
+}
+
+type depositRepository struct {
+	db *sql.DB
+}
+
+// NewDepositRepository creates a new instance of DepositRepository.
+func NewDepositRepository(db *sql.DB) DepositRepository {
+	return &depositRepository{db: db}
+}
+
+// Create persists a pending deposit before the card is charged, so every gateway call has a record.
+func (r *depositRepository) Create(ctx context.Context, d *Deposit) (*Deposit, common.AppError) {
+	query := `INSERT INTO deposits (uuid, user_id, wallet_id, card_id, amount, currency, status)
+              VALUES ($1, $2, $3, $4, $5, $6, $7)
+              RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		d.UUID, d.UserID, d.WalletID, d.CardID, d.AmountInCents, d.Currency, DepositStatusPending).
+		Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+
+	if err != nil {
+		slog.Error("failed to create deposit", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	d.Status = DepositStatusPending
+
+	return d, nil
+}
+
+// MarkSucceeded credits the wallet from the card settlement account and completes the deposit.
+// The ledger entry and the status change share a serializable transaction, and only a pending or
+// timed out deposit can succeed, so a deposit never credits its wallet twice. A timed out deposit
+// succeeds when the reconciler finds its charge captured after all.
+func (r *depositRepository) MarkSucceeded(ctx context.Context, d *Deposit, gatewayChargeID string) (*Deposit, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Mark Deposit Succeeded")
+
+	if appErr := lockUnsettledDeposit(ctx, tx, d.ID); appErr != nil {
+		return nil, appErr
+	}
+
+	entry := NewJournalEntry(JournalEntryTypeDeposit, fmt.Sprintf("Deposit %s", d.UUID),
+		NewSystemPosting(SystemAccountCardSettlement, PostingDirectionDebit, d.AmountInCents, d.Currency),
+		NewWalletPosting(d.WalletID, PostingDirectionCredit, d.AmountInCents, d.Currency),
+	)
+
+	if appErr := postJournalEntry(ctx, tx, entry); appErr != nil {
+		return nil, appErr
+	}
+
+	query := `UPDATE deposits SET status = $1, gateway_charge_id = $2, journal_entry_id = $3, failure_reason = ''
+              WHERE id = $4
+              RETURNING updated_at`
+
+	if err = tx.QueryRowContext(ctx, query, DepositStatusSucceeded, gatewayChargeID, entry.ID, d.ID).Scan(&d.UpdatedAt); err != nil {
+		slog.Error("failed to mark deposit as succeeded", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	d.Status = DepositStatusSucceeded
+	d.GatewayChargeID = gatewayChargeID
+	d.FailureReason = ""
+	d.JournalEntryID = &entry.ID
+
+	return d, nil
+}
+
+// MarkFailed records why a pending deposit didn't go through, and a failed transaction in the wallet's history.
+// The wallet balance is not touched. gatewayChargeID is empty when the gateway never returned a charge, e.g. on timeouts.
+func (r *depositRepository) MarkFailed(ctx context.Context, d *Deposit, status, gatewayChargeID, reason string) (*Deposit, common.AppError) {
+	if status != DepositStatusDeclined && status != DepositStatusFailed && status != DepositStatusTimedOut {
+		return nil, common.NewInternalServerError(fmt.Sprintf("invalid failed deposit status %q", status), nil)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Mark Deposit Failed")
+
+	query := `UPDATE deposits SET status = $1, gateway_charge_id = NULLIF($2, ''), failure_reason = $3
+              WHERE id = $4 AND status = $5
+              RETURNING updated_at`
+
+	err = tx.QueryRowContext(ctx, query, status, gatewayChargeID, reason, d.ID, DepositStatusPending).Scan(&d.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewConflictError("deposit is no longer pending")
+		}
+
+		slog.Error("failed to mark deposit as failed", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	attempt := &Transaction{
+		WalletID:      d.WalletID,
+		Type:          JournalEntryTypeDeposit,
+		Direction:     PostingDirectionCredit,
+		AmountInCents: d.AmountInCents,
+		Currency:      d.Currency,
+		Description:   fmt.Sprintf("Deposit %s %s", d.UUID, strings.ReplaceAll(status, "_", " ")),
+	}
+
+	if appErr := recordFailedTransaction(ctx, tx, attempt); appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	d.Status = status
+	d.GatewayChargeID = gatewayChargeID
+	d.FailureReason = reason
+
+	return d, nil
+}
+
+// ListUnsettled returns up to limit deposits after afterID, in id order, whose charge outcome is unknown:
+// pending for longer than DepositReconciliationDelay, or timed out within DepositReconciliationWindow.
+func (r *depositRepository) ListUnsettled(ctx context.Context, afterID int64, limit int) ([]*Deposit, common.AppError) {
+	query := `SELECT d.id, d.uuid, d.user_id, d.wallet_id, w.uuid, d.card_id, c.uuid, d.amount, d.currency, d.status,
+                  COALESCE(d.gateway_charge_id, ''), d.failure_reason, d.created_at, d.updated_at
+              FROM deposits d
+              JOIN wallets w ON w.id = d.wallet_id
+              JOIN cards c ON c.id = d.card_id
+              WHERE d.id > $1 AND d.status IN ($2, $3) AND d.updated_at < $4 AND d.created_at > $5
+              ORDER BY d.id
+              LIMIT $6`
+
+	now := time.Now()
+
+	rows, err := r.db.QueryContext(ctx, query, afterID, DepositStatusPending, DepositStatusTimedOut,
+		now.Add(-DepositReconciliationDelay), now.Add(-DepositReconciliationWindow), limit)
+	if err != nil {
+		slog.Error("failed to list unsettled deposits", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	deposits := make([]*Deposit, 0, limit)
+	for rows.Next() {
+		var d Deposit
+		if err := rows.Scan(&d.ID, &d.UUID, &d.UserID, &d.WalletID, &d.WalletUUID, &d.CardID, &d.CardUUID, &d.AmountInCents,
+			&d.Currency, &d.Status, &d.GatewayChargeID, &d.FailureReason, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			slog.Error("failed to scan deposit", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		deposits = append(deposits, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return deposits, nil
+}
+
+// lockUnsettledDeposit locks the deposit row and verifies it is still pending or timed out.
+func lockUnsettledDeposit(ctx context.Context, tx *sql.Tx, depositID int64) common.AppError {
+	var status string
+
+	err := tx.QueryRowContext(ctx, `SELECT status FROM deposits WHERE id = $1 FOR UPDATE`, depositID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NewNotFoundError("deposit not found")
+		}
+
+		if isSerializationFailure(err) {
+			return common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error("failed to lock deposit", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if status != DepositStatusPending && status != DepositStatusTimedOut {
+		return common.NewConflictError(fmt.Sprintf("deposit is already %s", status))
+	}
+
+	return nil
+}
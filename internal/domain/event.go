@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+)
+
+const (
+	// EventVersion is the version of the event envelope and of the data of every event type. A breaking change
+	// to either gets a new version, so consumers can tell the shapes apart.
+	EventVersion = 1
+
+	EventWalletStatusChanged = "wallet.status_changed"
+	EventCardAdded           = "card.added"
+	EventTransferCompleted   = "transfer.completed"
+
+	AggregateTypeWallet   = "wallet"
+	AggregateTypeCard     = "card"
+	AggregateTypeTransfer = "transfer"
+
+	// OutboxRetryBaseDelay is the delay before an event whose publishing failed is published again,
+	// it doubles with every failed attempt up to OutboxRetryMaxDelay. Events are retried until they are published.
+	OutboxRetryBaseDelay = time.Second
+	OutboxRetryMaxDelay  = 5 * time.Minute
+
+	// OutboxLockTTL is how long claimed events are hidden from other relays. Events whose relay crashed
+	// before marking them published are published again afterwards.
+	OutboxLockTTL = time.Minute
+
+	// OutboxPublishTimeout is how long the broker has to acknowledge a batch, it must be shorter than OutboxLockTTL.
+	OutboxPublishTimeout = 10 * time.Second
+
+	// OutboxBatchSize is the most events a relay claims and publishes at once.
+	OutboxBatchSize = 100
+
+	// OutboxRetention is how long published events are kept in the outbox, for debugging and replays.
+	OutboxRetention = 7 * 24 * time.Hour
+)
+
+// Event is the versioned envelope of a domain event, recorded in the outbox in the transaction of the state
+// change it is about and published at least once afterwards. Consumers drop duplicates by DedupKey.
+// AggregateID is the UUID of the wallet, card or transfer that changed, events of one aggregate share a partition.
+type Event struct {
+	ID            uuid.UUID       `json:"id"`
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateId"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	RequestID     string          `json:"requestId,omitempty"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// NewEvent creates an event about the aggregate with data, it carries the request ID of ctx, if any.
+func NewEvent(ctx context.Context, eventType, aggregateType string, aggregateID uuid.UUID, data any, now time.Time) (*Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event data: %w", eventType, err)
+	}
+
+	return &Event{
+		ID:            uuid.New(),
+		Type:          eventType,
+		Version:       EventVersion,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID.String(),
+		OccurredAt:    now,
+		RequestID:     common.RequestIDFromContext(ctx),
+		Data:          encoded,
+	}, nil
+}
+
+// DedupKey identifies the event across redeliveries, consumers store the keys they processed to drop duplicates.
+// It is the event ID, so a webhook delivery of the event carries the same ID.
+func (e *Event) DedupKey() string {
+	return e.ID.String()
+}
+
+// OutboxEvent is an event in the outbox waiting to be published, Attempts counts the publishing attempts.
+type OutboxEvent struct {
+	ID       int64
+	Event    Event
+	Attempts int
+}
+
+// OutboxRetryDelay returns the delay before publishing an event again after the given number of failed attempts.
+func OutboxRetryDelay(attempts int) time.Duration {
+	delay := OutboxRetryBaseDelay
+	for i := 1; i < attempts && delay < OutboxRetryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, OutboxRetryMaxDelay)
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEvent(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	walletUUID := uuid.New()
+	ctx := common.WithRequestID(context.Background(), "req-1")
+
+	e, err := NewEvent(ctx, EventWalletStatusChanged, AggregateTypeWallet, walletUUID, WalletStatusChangedData{
+		WalletUUID:     walletUUID,
+		PreviousStatus: WalletStatusActive,
+		Status:         WalletStatusInactive,
+	}, now)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, uuid.Nil, e.ID)
+	assert.Equal(t, e.ID.String(), e.DedupKey())
+	assert.Equal(t, EventVersion, e.Version)
+	assert.Equal(t, walletUUID.String(), e.AggregateID)
+	assert.Equal(t, "req-1", e.RequestID)
+	assert.Equal(t, now, e.OccurredAt)
+
+	var envelope map[string]any
+	require.NoError(t, json.Unmarshal(mustMarshal(t, e), &envelope))
+	assert.Equal(t, EventWalletStatusChanged, envelope["type"])
+	assert.Equal(t, AggregateTypeWallet, envelope["aggregateType"])
+	assert.Equal(t, WalletStatusInactive, envelope["data"].(map[string]any)["status"])
+
+	other, err := NewEvent(context.Background(), EventWalletStatusChanged, AggregateTypeWallet, walletUUID, nil, now)
+	require.NoError(t, err)
+	assert.NotEqual(t, e.DedupKey(), other.DedupKey(), "every event has its own dedup key")
+	assert.Empty(t, other.RequestID, "events outside requests carry no request ID")
+	assert.NotContains(t, string(mustMarshal(t, other)), "requestId")
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	assert.Equal(t, OutboxRetryBaseDelay, OutboxRetryDelay(1))
+	assert.Equal(t, 2*OutboxRetryBaseDelay, OutboxRetryDelay(2))
+	assert.Equal(t, 8*OutboxRetryBaseDelay, OutboxRetryDelay(4))
+	assert.Equal(t, OutboxRetryMaxDelay, OutboxRetryDelay(100))
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return b
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	JournalEntryTypeFXConversion = "fx_conversion"
+
+	// SystemAccountFXClearing receives the source currency of conversions and pays out the target currency.
+	SystemAccountFXClearing = "fx_clearing"
+
+	// FXQuoteTTL is how long a quoted rate is locked, the conversion must be made before the quote expires.
+	FXQuoteTTL = 30 * time.Second
+
+	// FXRateDecimals is the precision quoted rates are rounded to, the quoted amount is exact at the rounded rate.
+	FXRateDecimals = 10
+)
+
+var ErrSameCurrency = errors.New("source and target currencies must differ")
+
+// FXQuote locks an exchange rate for converting an amount between two currencies until it expires.
+// A quote can be used for one conversion.
+type FXQuote struct {
+	ID                  int64      `json:"-"`
+	UUID                uuid.UUID  `json:"uuid"`
+	UserID              int64      `json:"-"`
+	FromCurrency        string     `json:"fromCurrency"`
+	ToCurrency          string     `json:"toCurrency"`
+	Rate                string     `json:"rate"`
+	SourceAmountInCents int64      `json:"sourceAmountInCents"`
+	TargetAmountInCents int64      `json:"targetAmountInCents"`
+	ExpiresAt           time.Time  `json:"expiresAt"`
+	UsedAt              *time.Time `json:"-"`
+	CreatedAt           time.Time  `json:"createdAt"`
+}
+
+// FXConversion moves funds between two wallets of a user in different currencies at a quoted rate.
+// Each conversion is backed by exactly one journal entry in the ledger.
+type FXConversion struct {
+	ID                  int64     `json:"-"`
+	UUID                uuid.UUID `json:"uuid"`
+	UserID              int64     `json:"-"`
+	QuoteID             int64     `json:"-"`
+	QuoteUUID           uuid.UUID `json:"quoteUuid"`
+	SourceWalletID      int64     `json:"-"`
+	SourceWalletUUID    uuid.UUID `json:"sourceWalletUuid"`
+	TargetWalletID      int64     `json:"-"`
+	TargetWalletUUID    uuid.UUID `json:"targetWalletUuid"`
+	JournalEntryID      int64     `json:"-"`
+	SourceAmountInCents int64     `json:"sourceAmountInCents"`
+	SourceCurrency      string    `json:"sourceCurrency"`
+	TargetAmountInCents int64     `json:"targetAmountInCents"`
+	TargetCurrency      string    `json:"targetCurrency"`
+	Rate                string    `json:"rate"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
+
+// NewFXQuote prices the conversion of source into the currency at rate, rounded to FXRateDecimals,
+// and locks it for FXQuoteTTL from now.
+func NewFXQuote(userID int64, source Money, currency string, rate *big.Rat, now time.Time) (*FXQuote, error) {
+	if source.Currency == currency {
+		return nil, ErrSameCurrency
+	}
+
+	if rate == nil || rate.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+
+	quotedRate, err := ParseRate(rate.FloatString(FXRateDecimals))
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := source.Convert(quotedRate, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FXQuote{
+		UUID:                uuid.New(),
+		UserID:              userID,
+		FromCurrency:        source.Currency,
+		ToCurrency:          currency,
+		Rate:                FormatRate(quotedRate),
+		SourceAmountInCents: source.Amount,
+		TargetAmountInCents: target.Amount,
+		ExpiresAt:           now.Add(FXQuoteTTL),
+		CreatedAt:           now,
+	}, nil
+}
+
+// IsExpired reports whether the quote can't be used anymore at the given time.
+func (q *FXQuote) IsExpired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
+
+// FormatRate formats a rate with at most FXRateDecimals decimals and without trailing zeros, e.g. "0.9215".
+func FormatRate(rate *big.Rat) string {
+	formatted := rate.FloatString(FXRateDecimals)
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	}
+
+	return formatted
+}
@@ -0,0 +1,220 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+)
+
+// FXRepository defines the interface for fx quotes and currency conversions between a user's wallets.
+type FXRepository interface {
+	CreateQuote(ctx context.Context, quote *FXQuote) common.AppError
+	Convert(ctx context.Context, userID int64, quoteUUID string, sourceWalletID int64) (*FXConversion, common.AppError)
+}
+
+type fxRepository struct {
+	db *sql.DB
+}
+
+// NewFXRepository creates a new instance of FXRepository.
+func NewFXRepository(db *sql.DB) FXRepository {
+	return &fxRepository{db: db}
+}
+
+// CreateQuote stores a quote, so the conversion can be made at its rate until it expires.
+func (r *fxRepository) CreateQuote(ctx context.Context, q *FXQuote) common.AppError {
+	query := `INSERT INTO fx_quotes (uuid, user_id, from_currency, to_currency, rate, source_amount, target_amount, expires_at, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+              RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		q.UUID, q.UserID, q.FromCurrency, q.ToCurrency, q.Rate, q.SourceAmountInCents, q.TargetAmountInCents, q.ExpiresAt, q.CreatedAt).
+		Scan(&q.ID)
+
+	if err != nil {
+		slog.Error("failed to create fx quote", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// Convert uses the user's quote to move its source amount out of the source wallet and its target amount into
+// the user's wallet in the target currency, through the fx clearing account. It uses a serializable transaction
+// and locks the quote and both wallets, so a quote is used at most once and the ledger entry, the balance updates,
+// the conversion record and the used quote are atomic.
+func (r *fxRepository) Convert(ctx context.Context, userID int64, quoteUUID string, sourceWalletID int64) (*FXConversion, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Convert Funds")
+
+	quote, appErr := lockUnusedFXQuote(ctx, tx, userID, quoteUUID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	c := &FXConversion{
+		UUID:                uuid.New(),
+		UserID:              userID,
+		QuoteID:             quote.ID,
+		QuoteUUID:           quote.UUID,
+		SourceWalletID:      sourceWalletID,
+		SourceAmountInCents: quote.SourceAmountInCents,
+		SourceCurrency:      quote.FromCurrency,
+		TargetAmountInCents: quote.TargetAmountInCents,
+		TargetCurrency:      quote.ToCurrency,
+		Rate:                quote.Rate,
+	}
+
+	if appErr := lockConversionWallets(ctx, tx, c); appErr != nil {
+		return nil, appErr
+	}
+
+	entry := NewJournalEntry(JournalEntryTypeFXConversion, fmt.Sprintf("FX conversion %s", c.UUID),
+		NewWalletPosting(c.SourceWalletID, PostingDirectionDebit, c.SourceAmountInCents, c.SourceCurrency),
+		NewSystemPosting(SystemAccountFXClearing, PostingDirectionCredit, c.SourceAmountInCents, c.SourceCurrency),
+		NewSystemPosting(SystemAccountFXClearing, PostingDirectionDebit, c.TargetAmountInCents, c.TargetCurrency),
+		NewWalletPosting(c.TargetWalletID, PostingDirectionCredit, c.TargetAmountInCents, c.TargetCurrency),
+	)
+
+	if appErr := postJournalEntry(ctx, tx, entry); appErr != nil {
+		return nil, appErr
+	}
+
+	c.JournalEntryID = entry.ID
+
+	query := `INSERT INTO fx_conversions (uuid, user_id, quote_id, source_wallet_id, target_wallet_id, journal_entry_id,
+                  source_amount, source_currency, target_amount, target_currency, rate)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+              RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, query,
+		c.UUID, c.UserID, c.QuoteID, c.SourceWalletID, c.TargetWalletID, c.JournalEntryID,
+		c.SourceAmountInCents, c.SourceCurrency, c.TargetAmountInCents, c.TargetCurrency, c.Rate).
+		Scan(&c.ID, &c.CreatedAt)
+
+	if err != nil {
+		slog.Error("failed to create fx conversion", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE fx_quotes SET used_at = CURRENT_TIMESTAMP WHERE id = $1`, quote.ID); err != nil {
+		slog.Error("failed to mark fx quote as used", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return c, nil
+}
+
+// lockUnusedFXQuote locks the user's quote and verifies it is neither used nor expired.
+// Quotes of other users are reported as not found.
+func lockUnusedFXQuote(ctx context.Context, tx *sql.Tx, userID int64, quoteUUID string) (*FXQuote, common.AppError) {
+	query := `SELECT id, uuid, user_id, from_currency, to_currency, rate, source_amount, target_amount, expires_at, used_at, created_at
+              FROM fx_quotes WHERE uuid = $1 AND user_id = $2 FOR UPDATE`
+
+	var q FXQuote
+	var usedAt sql.NullTime
+
+	err := tx.QueryRowContext(ctx, query, quoteUUID, userID).Scan(
+		&q.ID, &q.UUID, &q.UserID, &q.FromCurrency, &q.ToCurrency, &q.Rate,
+		&q.SourceAmountInCents, &q.TargetAmountInCents, &q.ExpiresAt, &usedAt, &q.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewNotFoundError(common.ErrFXQuoteNotFound)
+		}
+
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error("failed to lock fx quote", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if usedAt.Valid {
+		return nil, common.NewConflictError(common.ErrFXQuoteUsed)
+	}
+
+	if q.IsExpired(time.Now()) {
+		return nil, common.NewUnprocessableEntityError(common.ErrFXQuoteExpired)
+	}
+
+	// NUMERIC scans with trailing zeros, e.g. "0.9215000000"
+	rate, err := ParseRate(q.Rate)
+	if err != nil {
+		slog.Error("invalid stored fx quote rate", "quoteUUID", q.UUID, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedServer, err)
+	}
+
+	q.Rate = FormatRate(rate)
+
+	return &q, nil
+}
+
+// lockConversionWallets finds the user's wallet in the target currency, locks it with the source wallet
+// in id order and verifies that both are active and hold the conversion currencies.
+func lockConversionWallets(ctx context.Context, tx *sql.Tx, c *FXConversion) common.AppError {
+	query := `SELECT id FROM wallets WHERE user_id = $1 AND currency = $2`
+
+	if err := tx.QueryRowContext(ctx, query, c.UserID, c.TargetCurrency).Scan(&c.TargetWalletID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NewUnprocessableEntityError(common.ErrFXTargetWalletEmpty)
+		}
+
+		slog.Error("failed to find fx target wallet", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	wallets, appErr := lockWalletPair(ctx, tx, c.SourceWalletID, c.TargetWalletID)
+	if appErr != nil {
+		return appErr
+	}
+
+	source, ok := wallets[c.SourceWalletID]
+	if !ok {
+		return common.NewNotFoundError("source wallet not found")
+	}
+
+	target, ok := wallets[c.TargetWalletID]
+	if !ok {
+		return common.NewUnprocessableEntityError(common.ErrFXTargetWalletEmpty)
+	}
+
+	if source.Currency != c.SourceCurrency {
+		return common.NewUnprocessableEntityError(fmt.Sprintf("the quote converts %s, but the wallet holds %s", c.SourceCurrency, source.Currency))
+	}
+
+	if source.Status != WalletStatusActive {
+		return common.NewUnprocessableEntityError(fmt.Sprintf("source wallet is %s", source.Status))
+	}
+
+	if target.Status != WalletStatusActive {
+		return common.NewUnprocessableEntityError(common.ErrFXTargetWalletEmpty)
+	}
+
+	c.SourceWalletUUID = source.UUID
+	c.TargetWalletUUID = target.UUID
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFXQuote(t *testing.T) {
+	now := time.Now()
+	source := Money{Amount: 10000, Currency: WalletCurrencyEUR}
+
+	// 1 / 0.9215 has no finite decimal expansion, the quote is priced at the rate rounded to FXRateDecimals
+	rate := new(big.Rat).Quo(big.NewRat(1, 1), big.NewRat(9215, 10000))
+
+	quote, err := NewFXQuote(7, source, WalletCurrencyUSD, rate, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(7), quote.UserID)
+	assert.Equal(t, WalletCurrencyEUR, quote.FromCurrency)
+	assert.Equal(t, WalletCurrencyUSD, quote.ToCurrency)
+	assert.Equal(t, "1.0851871948", quote.Rate)
+	assert.Equal(t, int64(10000), quote.SourceAmountInCents)
+	assert.Equal(t, int64(10851), quote.TargetAmountInCents)
+	assert.Equal(t, now.Add(FXQuoteTTL), quote.ExpiresAt)
+
+	assert.False(t, quote.IsExpired(now.Add(FXQuoteTTL-time.Second)))
+	assert.True(t, quote.IsExpired(now.Add(FXQuoteTTL)))
+}
+
+func TestNewFXQuoteRejectsInvalidInput(t *testing.T) {
+	now := time.Now()
+	usd := Money{Amount: 100, Currency: WalletCurrencyUSD}
+
+	_, err := NewFXQuote(1, usd, WalletCurrencyUSD, big.NewRat(1, 1), now)
+	assert.ErrorIs(t, err, ErrSameCurrency)
+
+	_, err = NewFXQuote(1, usd, WalletCurrencyEUR, big.NewRat(-1, 1), now)
+	assert.ErrorIs(t, err, ErrInvalidRate)
+
+	_, err = NewFXQuote(1, Money{Amount: 1, Currency: WalletCurrencyJPY}, WalletCurrencyUSD, big.NewRat(66, 10000), now)
+	assert.ErrorIs(t, err, ErrAmountTooSmall)
+}
+
+func TestFormatRate(t *testing.T) {
+	assert.Equal(t, "151.37", FormatRate(big.NewRat(15137, 100)))
+	assert.Equal(t, "2", FormatRate(big.NewRat(2, 1)))
+	assert.Equal(t, "0.3333333333", FormatRate(big.NewRat(1, 3)))
+}
@@ -1,10 +1,24 @@
 package domain
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log/slog"
 
 	"github.com/ashtishad/xpay/internal/common"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// pgCheckViolation is the postgres SQLSTATE raised when a CHECK constraint fails.
+	pgCheckViolation = "23514"
+
+	// pgUniqueViolation is the postgres SQLSTATE raised when a UNIQUE constraint fails.
+	pgUniqueViolation = "23505"
+
+	// pgSerializationFailure is the postgres SQLSTATE raised when a serializable transaction conflicts with another.
+	pgSerializationFailure = "40001"
 )
 
 func rollBackOnError(tx *sql.Tx, methodName string) {
@@ -12,3 +26,42 @@ func rollBackOnError(tx *sql.Tx, methodName string) {
 		slog.Error(common.ErrTXRollback, "err", rbErr, "method", methodName)
 	}
 }
+
+func closeRows(ctx context.Context, rows *sql.Rows) {
+	if clsErr := rows.Close(); clsErr != nil {
+		slog.WarnContext(ctx, "failed to close rows", "err", clsErr)
+	}
+}
+
+// isCheckViolation reports whether err was caused by a CHECK constraint, e.g. wallets.balance >= 0.
+func isCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgCheckViolation
+}
+
+// isUniqueViolation reports whether err was caused by a UNIQUE constraint, e.g. cards.card_token.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+// isSerializationFailure reports whether a serializable transaction lost a race and can be retried by the client.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgSerializationFailure
+}
+
+// requireAffectedRow returns notAffected if the statement didn't change any row.
+func requireAffectedRow(result sql.Result, notAffected common.AppError) common.AppError {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return notAffected
+	}
+
+	return nil
+}
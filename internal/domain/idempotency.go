@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"bytes"
+	"time"
+)
+
+const (
+	// IdempotencyKeyTTL is how long a completed response is replayed, afterwards the key can be reused.
+	IdempotencyKeyTTL = 24 * time.Hour
+
+	// IdempotencyLockTTL is how long an in-flight request holds its key. A request that crashed
+	// without completing releases the key after this, so a retry can run instead of getting 409 forever.
+	IdempotencyLockTTL = 1 * time.Minute
+
+	// IdempotencyPurgeBatchSize is how many expired keys are deleted in one batch of the purger.
+	IdempotencyPurgeBatchSize = 1000
+
+	// IdempotencyPurgeTimeout is how long the purger may take to delete one batch.
+	IdempotencyPurgeTimeout = 30 * time.Second
+)
+
+// IdempotencyKey is a client supplied key for a mutating request, scoped per user.
+// It stores the stored response once the first request completes, so retries get the same response.
+// Responses that must not be stored, e.g. because they carry secrets, are withheld: only their status code is kept.
+type IdempotencyKey struct {
+	ID                  int64
+	UserID              int64
+	Key                 string
+	Method              string
+	Path                string
+	RequestHash         []byte
+	StatusCode          *int
+	ResponseContentType string
+	ResponseBody        []byte
+	ResponseWithheld    bool
+	LockedAt            time.Time
+	CreatedAt           time.Time
+	CompletedAt         *time.Time
+}
+
+// IsCompleted reports whether the first request finished and its response can be replayed.
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.StatusCode != nil
+}
+
+// Matches reports whether a retry is the same request as the one that first used the key.
+func (k *IdempotencyKey) Matches(method, path string, requestHash []byte) bool {
+	return k.Method == method && k.Path == path && bytes.Equal(k.RequestHash, requestHash)
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// IdempotencyKeyPurger deletes idempotency keys past IdempotencyKeyTTL, with the responses stored for their replays.
+// Expired keys are never replayed, so a late run only keeps them in the database longer.
+type IdempotencyKeyPurger struct {
+	repo IdempotencyRepository
+}
+
+// NewIdempotencyKeyPurger creates an IdempotencyKeyPurger for the keys of repo.
+func NewIdempotencyKeyPurger(repo IdempotencyRepository) *IdempotencyKeyPurger {
+	return &IdempotencyKeyPurger{repo: repo}
+}
+
+// Run deletes the expired keys every interval until ctx is done.
+func (p *IdempotencyKeyPurger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.purge(ctx)
+		}
+	}
+}
+
+// purge deletes expired keys batch by batch, until a batch comes back short.
+func (p *IdempotencyKeyPurger) purge(ctx context.Context) {
+	for ctx.Err() == nil {
+		purgeCtx, cancel := context.WithTimeout(ctx, IdempotencyPurgeTimeout)
+		deleted, appErr := p.repo.DeleteExpired(purgeCtx)
+		cancel()
+
+		if appErr != nil {
+			slog.Error("failed to delete expired idempotency keys", "err", appErr.Error())
+			return
+		}
+
+		if deleted > 0 {
+			slog.Info("deleted expired idempotency keys", "count", deleted)
+		}
+
+		if deleted < IdempotencyPurgeBatchSize {
+			return
+		}
+	}
+}
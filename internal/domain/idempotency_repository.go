@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// IdempotencyRepository defines the interface for storing idempotency keys and their responses.
+type IdempotencyRepository interface {
+	Acquire(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, bool, common.AppError)
+	Complete(ctx context.Context, key *IdempotencyKey) common.AppError
+	Release(ctx context.Context, key *IdempotencyKey) common.AppError
+	DeleteExpired(ctx context.Context) (int64, common.AppError)
+}
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository.
+func NewIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Acquire claims the key for the current request in a single atomic upsert, so of two concurrent
+// duplicates only one can win. It returns the claimed key and true, or the existing key and false.
+// An existing key is taken over when it expired, or when it is in flight for the same request
+// for longer than IdempotencyLockTTL, e.g. because the server crashed before completing it.
+func (r *idempotencyRepository) Acquire(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, bool, common.AppError) {
+	now := time.Now()
+
+	query := `INSERT INTO idempotency_keys (user_id, idempotency_key, method, path, request_hash, locked_at, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $6)
+              ON CONFLICT (user_id, idempotency_key) DO UPDATE
+              SET method = EXCLUDED.method, path = EXCLUDED.path, request_hash = EXCLUDED.request_hash,
+                  status_code = NULL, response_content_type = NULL, response_body = NULL, response_withheld = FALSE,
+                  locked_at = EXCLUDED.locked_at, created_at = EXCLUDED.created_at, completed_at = NULL
+              WHERE idempotency_keys.created_at < $7
+                 OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_at < $8
+                     AND idempotency_keys.method = EXCLUDED.method AND idempotency_keys.path = EXCLUDED.path
+                     AND idempotency_keys.request_hash = EXCLUDED.request_hash)
+              RETURNING id, locked_at, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		key.UserID, key.Key, key.Method, key.Path, key.RequestHash, now,
+		now.Add(-IdempotencyKeyTTL), now.Add(-IdempotencyLockTTL)).
+		Scan(&key.ID, &key.LockedAt, &key.CreatedAt)
+
+	if err == nil {
+		return key, true, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to acquire idempotency key", "err", err)
+		return nil, false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	existing, appErr := r.findByUserAndKey(ctx, key.UserID, key.Key)
+	if appErr != nil {
+		return nil, false, appErr
+	}
+
+	return existing, false, nil
+}
+
+// Complete stores the response of the request holding the key, so retries can replay it.
+func (r *idempotencyRepository) Complete(ctx context.Context, key *IdempotencyKey) common.AppError {
+	query := `UPDATE idempotency_keys
+              SET status_code = $1, response_content_type = $2, response_body = $3, response_withheld = $4, completed_at = CURRENT_TIMESTAMP
+              WHERE id = $5 AND status_code IS NULL AND locked_at = $6`
+
+	result, err := r.db.ExecContext(ctx, query, key.StatusCode, key.ResponseContentType, key.ResponseBody, key.ResponseWithheld, key.ID, key.LockedAt)
+	if err != nil {
+		slog.Error("failed to complete idempotency key", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return common.NewConflictError("idempotency key was taken over by another request")
+	}
+
+	return nil
+}
+
+// Release deletes an in-flight key without storing a response, so the client can retry the request.
+func (r *idempotencyRepository) Release(ctx context.Context, key *IdempotencyKey) common.AppError {
+	query := `DELETE FROM idempotency_keys WHERE id = $1 AND status_code IS NULL AND locked_at = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, key.ID, key.LockedAt); err != nil {
+		slog.Error("failed to release idempotency key", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes up to IdempotencyPurgeBatchSize keys older than IdempotencyKeyTTL with their stored responses,
+// returns the number of deleted rows. Acquire takes over expired keys anyway, so a late purge never changes a response.
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context) (int64, common.AppError) {
+	query := `DELETE FROM idempotency_keys
+              WHERE id IN (SELECT id FROM idempotency_keys WHERE created_at < $1 ORDER BY created_at LIMIT $2)`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now().Add(-IdempotencyKeyTTL), IdempotencyPurgeBatchSize)
+	if err != nil {
+		slog.Error("failed to delete expired idempotency keys", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return rowsAffected, nil
+}
+
+func (r *idempotencyRepository) findByUserAndKey(ctx context.Context, userID int64, key string) (*IdempotencyKey, common.AppError) {
+	query := `SELECT id, user_id, idempotency_key, method, path, request_hash, status_code,
+              COALESCE(response_content_type, ''), response_body, response_withheld, locked_at, created_at, completed_at
+              FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`
+
+	var k IdempotencyKey
+	var statusCode sql.NullInt32
+	var completedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, userID, key).Scan(
+		&k.ID, &k.UserID, &k.Key, &k.Method, &k.Path, &k.RequestHash, &statusCode,
+		&k.ResponseContentType, &k.ResponseBody, &k.ResponseWithheld, &k.LockedAt, &k.CreatedAt, &completedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The holder released the key between our upsert and this read, the client can retry right away.
+			return nil, common.NewConflictError(common.ErrIdempotencyKeyInFlight)
+		}
+
+		slog.Error("failed to find idempotency key", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if statusCode.Valid {
+		code := int(statusCode.Int32)
+		k.StatusCode = &code
+	}
+
+	if completedAt.Valid {
+		k.CompletedAt = &completedAt.Time
+	}
+
+	return &k, nil
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// batchIdempotencyRepo deletes up to IdempotencyPurgeBatchSize of its remaining expired keys per call.
+type batchIdempotencyRepo struct {
+	IdempotencyRepository
+	remaining int64
+	calls     int
+}
+
+func (r *batchIdempotencyRepo) DeleteExpired(_ context.Context) (int64, common.AppError) {
+	r.calls++
+
+	deleted := min(r.remaining, IdempotencyPurgeBatchSize)
+	r.remaining -= deleted
+
+	return deleted, nil
+}
+
+func TestIdempotencyKeyPurgerDrainsBatches(t *testing.T) {
+	repo := &batchIdempotencyRepo{remaining: 2*IdempotencyPurgeBatchSize + 5}
+
+	NewIdempotencyKeyPurger(repo).purge(context.Background())
+
+	assert.Zero(t, repo.remaining)
+	assert.Equal(t, 3, repo.calls, "stops after the first short batch")
+}
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PostingDirectionDebit  = "debit"
+	PostingDirectionCredit = "credit"
+
+	JournalEntryTypeOpeningBalance = "opening_balance"
+
+	// SystemAccountOpeningBalance is the counterparty for balances carried over into the ledger.
+	SystemAccountOpeningBalance = "opening_balance_equity"
+)
+
+// JournalEntry is a single balanced business event in the ledger, e.g. a transfer or a deposit.
+// The sum of debit postings equals the sum of credit postings for every currency in the entry.
+type JournalEntry struct {
+	ID          int64     `json:"-"`
+	UUID        uuid.UUID `json:"uuid"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Postings    []Posting `json:"postings"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Posting moves an amount into (credit) or out of (debit) exactly one account.
+// Wallets are liabilities of xPay, so a credit increases a wallet balance and a debit decreases it.
+type Posting struct {
+	ID             int64     `json:"-"`
+	JournalEntryID int64     `json:"-"`
+	WalletID       *int64    `json:"-"`
+	SystemAccount  *string   `json:"systemAccount,omitempty"`
+	Direction      string    `json:"direction"`
+	AmountInCents  int64     `json:"amountInCents"`
+	Currency       string    `json:"currency"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// LedgerDiscrepancy describes a wallet whose cached balance differs from the sum of its postings.
+type LedgerDiscrepancy struct {
+	WalletUUID           uuid.UUID `json:"walletUuid"`
+	CachedBalanceInCents int64     `json:"cachedBalanceInCents"`
+	PostedBalanceInCents int64     `json:"postedBalanceInCents"`
+}
+
+// LedgerConsistencyReport is the result of reconciling wallets.balance against the postings.
+type LedgerConsistencyReport struct {
+	CheckedAt           time.Time           `json:"checkedAt"`
+	WalletDiscrepancies []LedgerDiscrepancy `json:"walletDiscrepancies"`
+	UnbalancedEntries   []uuid.UUID         `json:"unbalancedEntries"`
+}
+
+// IsConsistent reports whether every wallet balance is explained by postings and every entry balances.
+func (r *LedgerConsistencyReport) IsConsistent() bool {
+	return len(r.WalletDiscrepancies) == 0 && len(r.UnbalancedEntries) == 0
+}
+
+// NewJournalEntry creates an entry of the given type with its postings, ready to be posted.
+func NewJournalEntry(entryType, description string, postings ...Posting) *JournalEntry {
+	return &JournalEntry{
+		UUID:        uuid.New(),
+		Type:        entryType,
+		Description: description,
+		Postings:    postings,
+	}
+}
+
+// NewWalletPosting creates a posting against a wallet account.
+func NewWalletPosting(walletID int64, direction string, amountInCents int64, currency string) Posting {
+	return Posting{
+		WalletID:      &walletID,
+		Direction:     direction,
+		AmountInCents: amountInCents,
+		Currency:      currency,
+	}
+}
+
+// NewSystemPosting creates a posting against a named system account.
+func NewSystemPosting(account string, direction string, amountInCents int64, currency string) Posting {
+	return Posting{
+		SystemAccount: &account,
+		Direction:     direction,
+		AmountInCents: amountInCents,
+		Currency:      currency,
+	}
+}
+
+// Validate checks the double-entry invariants before an entry is written:
+// at least two postings, positive amounts, one account per posting and debits equal to credits per currency.
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return errors.New("journal entry needs at least two postings")
+	}
+
+	balances := make(map[string]int64)
+	for _, p := range e.Postings {
+		if p.AmountInCents <= 0 {
+			return errors.New("posting amount must be positive")
+		}
+
+		if (p.WalletID == nil) == (p.SystemAccount == nil) {
+			return errors.New("posting must reference either a wallet or a system account")
+		}
+
+		switch p.Direction {
+		case PostingDirectionDebit:
+			balances[p.Currency] += p.AmountInCents
+		case PostingDirectionCredit:
+			balances[p.Currency] -= p.AmountInCents
+		default:
+			return fmt.Errorf("invalid posting direction %q", p.Direction)
+		}
+	}
+
+	for currency, balance := range balances {
+		if balance != 0 {
+			return fmt.Errorf("journal entry is not balanced for %s: debits and credits differ by %d", currency, balance)
+		}
+	}
+
+	return nil
+}
+
+// walletDelta returns the signed change this posting applies to its wallet balance.
+func (p *Posting) walletDelta() int64 {
+	if p.Direction == PostingDirectionCredit {
+		return p.AmountInCents
+	}
+
+	return -p.AmountInCents
+}
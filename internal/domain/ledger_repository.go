@@ -0,0 +1,256 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+)
+
+// LedgerRepository defines the interface for double-entry ledger operations.
+// Wallet balances are a cache of the postings and are only changed by posting journal entries.
+type LedgerRepository interface {
+	Post(ctx context.Context, entry *JournalEntry) (*JournalEntry, common.AppError)
+	CheckConsistency(ctx context.Context) (*LedgerConsistencyReport, common.AppError)
+}
+
+type ledgerRepository struct {
+	db *sql.DB
+}
+
+// NewLedgerRepository creates a new instance of LedgerRepository.
+func NewLedgerRepository(db *sql.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+// Post writes a balanced journal entry and applies it to the cached wallet balances.
+// It uses a serializable transaction so the entry and the balance updates are atomic.
+func (r *ledgerRepository) Post(ctx context.Context, entry *JournalEntry) (*JournalEntry, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Post Journal Entry")
+
+	if appErr := postJournalEntry(ctx, tx, entry); appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return entry, nil
+}
+
+// CheckConsistency proves that every cached wallet balance equals the sum of its postings
+// and that every journal entry balances. It reads from a single repeatable read snapshot,
+// so concurrent postings can't produce false discrepancies.
+func (r *ledgerRepository) CheckConsistency(ctx context.Context) (*LedgerConsistencyReport, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Check Ledger Consistency")
+
+	report := &LedgerConsistencyReport{
+		CheckedAt:           time.Now().UTC(),
+		WalletDiscrepancies: []LedgerDiscrepancy{},
+		UnbalancedEntries:   []uuid.UUID{},
+	}
+
+	if appErr := r.findWalletDiscrepancies(ctx, tx, report); appErr != nil {
+		return nil, appErr
+	}
+
+	if appErr := r.findUnbalancedEntries(ctx, tx, report); appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return report, nil
+}
+
+// findWalletDiscrepancies compares wallets.balance with the signed sum of the wallet's postings.
+func (r *ledgerRepository) findWalletDiscrepancies(ctx context.Context, tx *sql.Tx, report *LedgerConsistencyReport) common.AppError {
+	query := `SELECT w.uuid, w.balance, COALESCE(p.posted, 0)
+              FROM wallets w
+              LEFT JOIN (
+                  SELECT wallet_id, SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS posted
+                  FROM postings
+                  WHERE wallet_id IS NOT NULL
+                  GROUP BY wallet_id
+              ) p ON p.wallet_id = w.id
+              WHERE w.balance <> COALESCE(p.posted, 0)
+              ORDER BY w.id`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		slog.Error("failed to query wallet discrepancies", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	for rows.Next() {
+		var d LedgerDiscrepancy
+		if err := rows.Scan(&d.WalletUUID, &d.CachedBalanceInCents, &d.PostedBalanceInCents); err != nil {
+			slog.Error("failed to scan wallet discrepancy", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		report.WalletDiscrepancies = append(report.WalletDiscrepancies, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// findUnbalancedEntries lists journal entries whose debits and credits differ for any currency.
+func (r *ledgerRepository) findUnbalancedEntries(ctx context.Context, tx *sql.Tx, report *LedgerConsistencyReport) common.AppError {
+	query := `SELECT DISTINCT je.id, je.uuid
+              FROM journal_entries je
+              JOIN postings p ON p.journal_entry_id = je.id
+              GROUP BY je.id, je.uuid, p.currency
+              HAVING SUM(CASE WHEN p.direction = 'debit' THEN p.amount ELSE -p.amount END) <> 0
+              ORDER BY je.id`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		slog.Error("failed to query unbalanced journal entries", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	for rows.Next() {
+		var entryID int64
+		var entryUUID uuid.UUID
+		if err := rows.Scan(&entryID, &entryUUID); err != nil {
+			slog.Error("failed to scan unbalanced journal entry", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		report.UnbalancedEntries = append(report.UnbalancedEntries, entryUUID)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// postJournalEntry writes an entry with its postings inside the caller's transaction, applies
+// the net change to each affected wallet balance and records the wallet transactions. Other repositories
+// use it so money movements and their ledger entries commit or roll back together. Wallets are updated
+// in id order to avoid deadlocks, and a balance that would go negative is reported as insufficient funds.
+func postJournalEntry(ctx context.Context, tx *sql.Tx, entry *JournalEntry) common.AppError {
+	if err := entry.Validate(); err != nil {
+		slog.Error("invalid journal entry", "type", entry.Type, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedServer, err)
+	}
+
+	if entry.UUID == uuid.Nil {
+		entry.UUID = uuid.New()
+	}
+
+	entryQuery := `INSERT INTO journal_entries (uuid, type, description)
+                   VALUES ($1, $2, $3)
+                   RETURNING id, created_at`
+
+	err := tx.QueryRowContext(ctx, entryQuery, entry.UUID, entry.Type, entry.Description).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		slog.Error("failed to insert journal entry", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	postingQuery := `INSERT INTO postings (journal_entry_id, wallet_id, system_account, direction, amount, currency)
+                     VALUES ($1, $2, $3, $4, $5, $6)
+                     RETURNING id, created_at`
+
+	walletDeltas := make(map[int64]int64)
+	for i := range entry.Postings {
+		p := &entry.Postings[i]
+		p.JournalEntryID = entry.ID
+
+		err := tx.QueryRowContext(ctx, postingQuery,
+			p.JournalEntryID, p.WalletID, p.SystemAccount, p.Direction, p.AmountInCents, p.Currency).
+			Scan(&p.ID, &p.CreatedAt)
+
+		if err != nil {
+			slog.Error("failed to insert posting", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		if p.WalletID != nil {
+			walletDeltas[*p.WalletID] += p.walletDelta()
+		}
+	}
+
+	walletIDs := make([]int64, 0, len(walletDeltas))
+	for walletID := range walletDeltas {
+		walletIDs = append(walletIDs, walletID)
+	}
+
+	slices.Sort(walletIDs)
+
+	// balance of every wallet before the entry, the transactions carry it through the postings
+	balances := make(map[int64]int64, len(walletIDs))
+
+	for _, walletID := range walletIDs {
+		balance, appErr := applyWalletDelta(ctx, tx, walletID, walletDeltas[walletID])
+		if appErr != nil {
+			return appErr
+		}
+
+		balances[walletID] = balance - walletDeltas[walletID]
+	}
+
+	return recordPostedTransactions(ctx, tx, entry, balances)
+}
+
+// applyWalletDelta updates the cached balance of a wallet by the net amount of its postings and returns the new balance.
+// The row is updated even for a zero delta, so the wallet stays locked until the entry commits.
+func applyWalletDelta(ctx context.Context, tx *sql.Tx, walletID int64, delta int64) (int64, common.AppError) {
+	query := `UPDATE wallets SET balance = balance + $1 WHERE id = $2 RETURNING balance`
+
+	var balance int64
+	if err := tx.QueryRowContext(ctx, query, delta, walletID).Scan(&balance); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, common.NewNotFoundError("wallet not found")
+		}
+
+		if isCheckViolation(err) {
+			return 0, common.NewUnprocessableEntityError(common.ErrInsufficientFunds)
+		}
+
+		if isSerializationFailure(err) {
+			return 0, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error("failed to update wallet balance", "walletID", walletID, "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalEntry_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		postings []Posting
+		wantErr  bool
+	}{
+		{
+			name: "Balanced wallet to wallet",
+			postings: []Posting{
+				NewWalletPosting(1, PostingDirectionDebit, 500, WalletCurrencyUSD),
+				NewWalletPosting(2, PostingDirectionCredit, 500, WalletCurrencyUSD),
+			},
+		},
+		{
+			name: "Balanced system to wallet with split credits",
+			postings: []Posting{
+				NewSystemPosting(SystemAccountOpeningBalance, PostingDirectionDebit, 1000, WalletCurrencyUSD),
+				NewWalletPosting(1, PostingDirectionCredit, 400, WalletCurrencyUSD),
+				NewWalletPosting(2, PostingDirectionCredit, 600, WalletCurrencyUSD),
+			},
+		},
+		{
+			name: "Unbalanced amounts",
+			postings: []Posting{
+				NewWalletPosting(1, PostingDirectionDebit, 500, WalletCurrencyUSD),
+				NewWalletPosting(2, PostingDirectionCredit, 499, WalletCurrencyUSD),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Single posting",
+			postings: []Posting{
+				NewWalletPosting(1, PostingDirectionCredit, 500, WalletCurrencyUSD),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Zero amount",
+			postings: []Posting{
+				NewWalletPosting(1, PostingDirectionDebit, 0, WalletCurrencyUSD),
+				NewWalletPosting(2, PostingDirectionCredit, 0, WalletCurrencyUSD),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid direction",
+			postings: []Posting{
+				NewWalletPosting(1, "sideways", 500, WalletCurrencyUSD),
+				NewWalletPosting(2, PostingDirectionCredit, 500, WalletCurrencyUSD),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Posting without an account",
+			postings: []Posting{
+				{Direction: PostingDirectionDebit, AmountInCents: 500, Currency: WalletCurrencyUSD},
+				NewWalletPosting(2, PostingDirectionCredit, 500, WalletCurrencyUSD),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := NewJournalEntry(JournalEntryTypeOpeningBalance, tt.name, tt.postings...)
+			err := entry.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
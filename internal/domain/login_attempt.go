@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"time"
+)
+
+const (
+	LoginOutcomeSucceeded      string = "succeeded"
+	LoginOutcomeMFARequired    string = "mfa_required"
+	LoginOutcomeInvalidPass    string = "invalid_password"
+	LoginOutcomeInvalidMFACode string = "invalid_mfa_code"
+	LoginOutcomeLocked         string = "locked"
+	LoginOutcomeUnknownEmail   string = "unknown_email"
+
+	// LoginLockoutThreshold is the number of consecutive failed logins that locks the account for the first time.
+	LoginLockoutThreshold = 3
+
+	// LoginLockoutBase is the lockout after the first failure over the limit, it doubles with every further failure.
+	LoginLockoutBase = 30 * time.Second
+
+	// LoginLockoutMax caps the lockout, so a locked out user can always log in again after a while.
+	LoginLockoutMax = 30 * time.Minute
+
+	LoginHistoryDefaultLimit = 20
+	LoginHistoryMaxLimit     = 100
+)
+
+// LoginAttempt records one password or second factor login attempt for an account.
+type LoginAttempt struct {
+	ID        int64     `json:"-"`
+	UserID    *int64    `json:"-"`
+	Email     string    `json:"email"`
+	IPAddress string    `json:"ipAddress"`
+	UserAgent string    `json:"userAgent"`
+	Outcome   string    `json:"outcome"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LoginLockout returns how long an account is locked after the given number of consecutive failed logins.
+// Failures below LoginLockoutThreshold aren't penalized, reaching it locks the account for LoginLockoutBase
+// and every further failure doubles the lockout up to LoginLockoutMax.
+func LoginLockout(failedAttempts int) time.Duration {
+	if failedAttempts < LoginLockoutThreshold {
+		return 0
+	}
+
+	lockout := LoginLockoutBase
+	for range failedAttempts - LoginLockoutThreshold {
+		lockout *= 2
+		if lockout >= LoginLockoutMax {
+			return LoginLockoutMax
+		}
+	}
+
+	return lockout
+}
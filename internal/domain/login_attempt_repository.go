@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// LoginAttemptRepository defines the interface for the login history.
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, attempt *LoginAttempt) common.AppError
+	ListByUserID(ctx context.Context, userID int64, limit int) ([]LoginAttempt, common.AppError)
+}
+
+type loginAttemptRepository struct {
+	db *sql.DB
+}
+
+// NewLoginAttemptRepository creates a new instance of LoginAttemptRepository.
+func NewLoginAttemptRepository(db *sql.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// Create records a login attempt.
+func (r *loginAttemptRepository) Create(ctx context.Context, a *LoginAttempt) common.AppError {
+	query := `INSERT INTO login_attempts (user_id, email, ip_address, user_agent, outcome)
+              VALUES ($1, $2, $3, $4, $5)
+              RETURNING id, created_at`
+
+	if err := r.db.QueryRowContext(ctx, query, a.UserID, a.Email, a.IPAddress, a.UserAgent, a.Outcome).Scan(&a.ID, &a.CreatedAt); err != nil {
+		slog.Error("failed to create login attempt", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// ListByUserID returns the user's most recent login attempts, newest first.
+func (r *loginAttemptRepository) ListByUserID(ctx context.Context, userID int64, limit int) ([]LoginAttempt, common.AppError) {
+	query := `SELECT id, user_id, email, ip_address, user_agent, outcome, created_at
+              FROM login_attempts WHERE user_id = $1
+              ORDER BY created_at DESC, id DESC
+              LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		slog.Error("failed to list login attempts", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	attempts := make([]LoginAttempt, 0, limit)
+	for rows.Next() {
+		var a LoginAttempt
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Email, &a.IPAddress, &a.UserAgent, &a.Outcome, &a.CreatedAt); err != nil {
+			slog.Error("failed to scan login attempt", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		attempts = append(attempts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating login attempts", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginLockout(t *testing.T) {
+	tests := []struct {
+		failedAttempts int
+		want           time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 0},
+		{3, 30 * time.Second},
+		{4, 1 * time.Minute},
+		{5, 2 * time.Minute},
+		{8, 16 * time.Minute},
+		{9, LoginLockoutMax},
+		{1000, LoginLockoutMax},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, LoginLockout(tt.failedAttempts), "failed attempts: %d", tt.failedAttempts)
+	}
+}
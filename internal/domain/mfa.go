@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// MFA is a user's TOTP enrollment. EncryptedSecret is sealed with secure.OwnerBoundEncryptor for the user's UUID.
+// An enrollment is pending until the user confirms it with a valid code, only enabled enrollments
+// are enforced on login. LastUsedStep is the TOTP time step of the last accepted code.
+type MFA struct {
+	UserID          int64
+	EncryptedSecret []byte
+	EnabledAt       *time.Time
+	LastUsedStep    int64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// IsEnabled reports whether the enrollment was confirmed.
+func (m *MFA) IsEnabled() bool {
+	return m.EnabledAt != nil
+}
+
+// RecoveryCode is the bcrypt hash of a single use code that replaces a TOTP code, e.g. when the device is lost.
+type RecoveryCode struct {
+	ID       int64
+	UserID   int64
+	CodeHash string
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// MFARepository defines the interface for TOTP enrollments and recovery codes.
+type MFARepository interface {
+	FindByUserID(ctx context.Context, userID int64) (*MFA, common.AppError)
+	IsEnabled(ctx context.Context, userID int64) (bool, common.AppError)
+	SavePending(ctx context.Context, userID int64, encryptedSecret []byte) common.AppError
+	Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) common.AppError
+	RecordTOTPUse(ctx context.Context, userID int64, step int64) common.AppError
+	ListUnusedRecoveryCodes(ctx context.Context, userID int64) ([]RecoveryCode, common.AppError)
+	UseRecoveryCode(ctx context.Context, id int64) common.AppError
+}
+
+type mfaRepository struct {
+	db *sql.DB
+}
+
+// NewMFARepository creates a new instance of MFARepository.
+func NewMFARepository(db *sql.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+// FindByUserID retrieves the user's enrollment, pending or enabled.
+func (r *mfaRepository) FindByUserID(ctx context.Context, userID int64) (*MFA, common.AppError) {
+	query := `SELECT user_id, encrypted_secret, enabled_at, last_used_step, created_at, updated_at
+              FROM user_mfa WHERE user_id = $1`
+
+	var m MFA
+	var enabledAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&m.UserID, &m.EncryptedSecret, &enabledAt, &m.LastUsedStep, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewNotFoundError(common.ErrMFANotSetUp)
+		}
+
+		slog.Error("failed to find mfa enrollment", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if enabledAt.Valid {
+		m.EnabledAt = &enabledAt.Time
+	}
+
+	return &m, nil
+}
+
+// IsEnabled reports whether the user confirmed a TOTP enrollment, login asks for a second factor if so.
+func (r *mfaRepository) IsEnabled(ctx context.Context, userID int64) (bool, common.AppError) {
+	query := `SELECT EXISTS(SELECT 1 FROM user_mfa WHERE user_id = $1 AND enabled_at IS NOT NULL)`
+
+	var enabled bool
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&enabled); err != nil {
+		slog.Error("failed to check mfa enrollment", "err", err)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return enabled, nil
+}
+
+// SavePending stores a new secret for an unconfirmed enrollment, replacing an earlier pending one.
+// An enabled enrollment is never replaced, it returns a conflict instead.
+func (r *mfaRepository) SavePending(ctx context.Context, userID int64, encryptedSecret []byte) common.AppError {
+	query := `INSERT INTO user_mfa (user_id, encrypted_secret) VALUES ($1, $2)
+              ON CONFLICT (user_id) DO UPDATE
+              SET encrypted_secret = EXCLUDED.encrypted_secret, last_used_step = 0
+              WHERE user_mfa.enabled_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, userID, encryptedSecret)
+	if err != nil {
+		slog.Error("failed to save pending mfa enrollment", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return requireAffectedRow(result, common.NewConflictError(common.ErrMFAAlreadyEnabled))
+}
+
+// Enable confirms a pending enrollment and replaces the user's recovery codes in one transaction.
+// step is the time step of the code used to confirm, so that code can't be used to log in afterwards.
+func (r *mfaRepository) Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) common.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Enable MFA")
+
+	enableQuery := `UPDATE user_mfa SET enabled_at = CURRENT_TIMESTAMP, last_used_step = $2
+                    WHERE user_id = $1 AND enabled_at IS NULL`
+
+	result, err := tx.ExecContext(ctx, enableQuery, userID, step)
+	if err != nil {
+		slog.Error("failed to enable mfa", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if appErr := requireAffectedRow(result, common.NewConflictError(common.ErrMFAAlreadyEnabled)); appErr != nil {
+		return appErr
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		slog.Error("failed to delete old recovery codes", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	insertQuery := `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+
+	for _, hash := range recoveryCodeHashes {
+		if _, err = tx.ExecContext(ctx, insertQuery, userID, hash); err != nil {
+			slog.Error("failed to create recovery code", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// RecordTOTPUse moves the last used time step forward. It fails if a code of this or a later step
+// was already accepted, so a code seen by an attacker can't be replayed within its validity window.
+func (r *mfaRepository) RecordTOTPUse(ctx context.Context, userID int64, step int64) common.AppError {
+	query := `UPDATE user_mfa SET last_used_step = $2
+              WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2`
+
+	result, err := r.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		slog.Error("failed to record totp use", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return requireAffectedRow(result, common.NewUnauthorizedError(common.ErrInvalidMFACode))
+}
+
+// ListUnusedRecoveryCodes returns the recovery codes the user can still log in with.
+func (r *mfaRepository) ListUnusedRecoveryCodes(ctx context.Context, userID int64) ([]RecoveryCode, common.AppError) {
+	query := `SELECT id, user_id, code_hash FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		slog.Error("failed to list recovery codes", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	var codes []RecoveryCode
+	for rows.Next() {
+		var code RecoveryCode
+		if err := rows.Scan(&code.ID, &code.UserID, &code.CodeHash); err != nil {
+			slog.Error("failed to scan recovery code", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		codes = append(codes, code)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating recovery codes", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return codes, nil
+}
+
+// UseRecoveryCode marks a recovery code as used, it fails if a concurrent login used it first.
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, id int64) common.AppError {
+	query := `UPDATE mfa_recovery_codes SET used_at = CURRENT_TIMESTAMP WHERE id = $1 AND used_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		slog.Error("failed to use recovery code", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return requireAffectedRow(result, common.NewUnauthorizedError(common.ErrInvalidMFACode))
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// currencyExponents are the ISO 4217 minor-unit exponents of the wallet currencies. Amounts are stored as integers
+// of the minor unit: 1 USD is 100 cents, 1 BDT is 100 poisha, 1 JPY has no minor unit and 1 KWD is 1000 fils.
+// Keep in sync with the wallet_currency enum and the currency binding of dto.CreateWalletRequest.
+var currencyExponents = map[string]int{
+	WalletCurrencyUSD: 2,
+	WalletCurrencyEUR: 2,
+	WalletCurrencyGBP: 2,
+	WalletCurrencyBDT: 2,
+	WalletCurrencyINR: 2,
+	WalletCurrencySGD: 2,
+	WalletCurrencyAED: 2,
+	WalletCurrencyJPY: 0,
+	WalletCurrencyKWD: 3,
+}
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidAmount       = errors.New("amount must be positive")
+	ErrInvalidRate         = errors.New("exchange rate must be positive")
+	ErrAmountTooSmall      = errors.New("converted amount is less than the smallest unit of the currency")
+)
+
+// IsSupportedCurrency checks if wallets can hold the currency.
+func IsSupportedCurrency(currency string) bool {
+	_, ok := currencyExponents[currency]
+	return ok
+}
+
+// SupportedCurrencies returns the wallet currencies, sorted.
+func SupportedCurrencies() []string {
+	currencies := make([]string, 0, len(currencyExponents))
+	for currency := range currencyExponents {
+		currencies = append(currencies, currency)
+	}
+
+	sort.Strings(currencies)
+
+	return currencies
+}
+
+// CurrencyExponent returns the number of decimal places of the currency's minor unit.
+func CurrencyExponent(currency string) (int, bool) {
+	exponent, ok := currencyExponents[currency]
+	return exponent, ok
+}
+
+// Money is an amount in the minor unit of its currency, e.g. {1050, "USD"} is 10.50 USD and {1050, "JPY"} is 1050 JPY.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney creates a positive amount of a supported currency.
+func NewMoney(amount int64, currency string) (Money, error) {
+	if !IsSupportedCurrency(currency) {
+		return Money{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
+	if amount <= 0 {
+		return Money{}, ErrInvalidAmount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// String formats the amount in major units with the currency's decimal places, e.g. "10.50 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Decimal formats the amount in major units with the currency's decimal places and without the currency, e.g. "10.50".
+// Negative amounts get a leading minus sign.
+func (m Money) Decimal() string {
+	exponent := currencyExponents[m.Currency]
+
+	value := new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(exponent))
+
+	return value.FloatString(exponent)
+}
+
+// Convert converts the amount to another currency at rate, the price of one major unit of m's currency in the other one.
+// The result is rounded down to the target's minor unit, fractions of a minor unit are never paid out.
+func (m Money) Convert(rate *big.Rat, currency string) (Money, error) {
+	fromExponent, ok := CurrencyExponent(m.Currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, m.Currency)
+	}
+
+	toExponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
+	if rate == nil || rate.Sign() <= 0 {
+		return Money{}, ErrInvalidRate
+	}
+
+	// amount / 10^fromExponent major units * rate * 10^toExponent minor units
+	converted := new(big.Rat).SetInt64(m.Amount)
+	converted.Mul(converted, rate)
+	converted.Mul(converted, new(big.Rat).SetFrac(pow10(toExponent), pow10(fromExponent)))
+
+	minorUnits := new(big.Int).Quo(converted.Num(), converted.Denom())
+	if !minorUnits.IsInt64() {
+		return Money{}, fmt.Errorf("converted amount of %s overflows", m)
+	}
+
+	if minorUnits.Sign() == 0 {
+		return Money{}, ErrAmountTooSmall
+	}
+
+	return NewMoney(minorUnits.Int64(), currency)
+}
+
+// ParseRate parses a decimal exchange rate like "0.9214", it must be positive.
+func ParseRate(value string) (*big.Rat, error) {
+	// big.Rat also parses fractions like "1/3", rates are always written as decimals
+	if strings.Contains(value, "/") {
+		return nil, fmt.Errorf("%w: %q is not a decimal number", ErrInvalidRate, value)
+	}
+
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, value)
+	}
+
+	return rate, nil
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "10.50 USD", Money{Amount: 1050, Currency: WalletCurrencyUSD}.String())
+	assert.Equal(t, "1050 JPY", Money{Amount: 1050, Currency: WalletCurrencyJPY}.String())
+	assert.Equal(t, "1.050 KWD", Money{Amount: 1050, Currency: WalletCurrencyKWD}.String())
+	assert.Equal(t, "-0.05", Money{Amount: -5, Currency: WalletCurrencyUSD}.Decimal())
+}
+
+func TestNewMoney(t *testing.T) {
+	_, err := NewMoney(100, "XYZ")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+	_, err = NewMoney(0, WalletCurrencyUSD)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	m, err := NewMoney(100, WalletCurrencyEUR)
+	require.NoError(t, err)
+	assert.Equal(t, Money{Amount: 100, Currency: WalletCurrencyEUR}, m)
+}
+
+func TestMoneyConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		source Money
+		rate   string
+		to     string
+		want   int64
+	}{
+		{"USD to EUR", Money{Amount: 10000, Currency: WalletCurrencyUSD}, "0.9215", WalletCurrencyEUR, 9215},
+		{"Rounds Down", Money{Amount: 1, Currency: WalletCurrencyUSD}, "83.427", WalletCurrencyINR, 83},
+		{"USD to JPY", Money{Amount: 1050, Currency: WalletCurrencyUSD}, "151.37", WalletCurrencyJPY, 1589},
+		{"JPY to USD", Money{Amount: 1000, Currency: WalletCurrencyJPY}, "0.0066", WalletCurrencyUSD, 660},
+		{"USD to KWD", Money{Amount: 10000, Currency: WalletCurrencyUSD}, "0.3074", WalletCurrencyKWD, 30740},
+		{"KWD to JPY", Money{Amount: 1, Currency: WalletCurrencyKWD}, "492.42", WalletCurrencyJPY, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := ParseRate(tt.rate)
+			require.NoError(t, err)
+
+			got, err := tt.source.Convert(rate, tt.to)
+			if tt.want == 0 {
+				assert.ErrorIs(t, err, ErrAmountTooSmall)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Money{Amount: tt.want, Currency: tt.to}, got)
+		})
+	}
+}
+
+func TestMoneyConvertRejectsInvalidInput(t *testing.T) {
+	usd := Money{Amount: 100, Currency: WalletCurrencyUSD}
+
+	_, err := usd.Convert(big.NewRat(1, 1), "XYZ")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+	_, err = usd.Convert(big.NewRat(0, 1), WalletCurrencyEUR)
+	assert.ErrorIs(t, err, ErrInvalidRate)
+
+	_, err = usd.Convert(nil, WalletCurrencyEUR)
+	assert.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestParseRate(t *testing.T) {
+	for _, value := range []string{"", "abc", "0", "-1.5", "1/3"} {
+		_, err := ParseRate(value)
+		assert.ErrorIs(t, err, ErrInvalidRate, "value: %q", value)
+	}
+
+	rate, err := ParseRate("0.9215000000")
+	require.NoError(t, err)
+	assert.Equal(t, "0.9215", FormatRate(rate))
+}
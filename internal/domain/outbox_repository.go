@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// OutboxRepository defines the interface for the relay side of the event outbox,
+// events are written by the repositories of the state changes they are about.
+type OutboxRepository interface {
+	ClaimBatch(ctx context.Context, limit int) ([]OutboxEvent, common.AppError)
+	MarkPublished(ctx context.Context, ids []int64) common.AppError
+	MarkFailed(ctx context.Context, events []OutboxEvent, reason string) common.AppError
+	DeletePublished(ctx context.Context, before time.Time) (int64, common.AppError)
+}
+
+type outboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository creates a new instance of OutboxRepository.
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// ClaimBatch counts a publishing attempt of up to limit due unpublished events and returns them oldest first.
+// next_attempt_at is pushed past OutboxLockTTL, so no other relay claims them while they are published,
+// SKIP LOCKED lets several instances claim different events concurrently.
+func (r *outboxRepository) ClaimBatch(ctx context.Context, limit int) ([]OutboxEvent, common.AppError) {
+	query := `UPDATE outbox_events
+              SET attempts = attempts + 1, next_attempt_at = $1
+              WHERE id IN (
+                  SELECT id FROM outbox_events
+                  WHERE published_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP
+                  ORDER BY next_attempt_at, id
+                  LIMIT $2
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING id, event_id, event_type, version, aggregate_type, aggregate_id, COALESCE(request_id, ''),
+                  payload, occurred_at, attempts`
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now().Add(OutboxLockTTL), limit)
+	if err != nil {
+		slog.Error("failed to claim outbox events", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	events := make([]OutboxEvent, 0, limit)
+	for rows.Next() {
+		var o OutboxEvent
+		e := &o.Event
+		if err := rows.Scan(&o.ID, &e.ID, &e.Type, &e.Version, &e.AggregateType, &e.AggregateID, &e.RequestID,
+			&e.Data, &e.OccurredAt, &o.Attempts); err != nil {
+			slog.Error("failed to scan outbox event", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		events = append(events, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	// RETURNING doesn't keep the order of the subquery
+	slices.SortFunc(events, func(a, b OutboxEvent) int { return cmp.Compare(a.ID, b.ID) })
+
+	return events, nil
+}
+
+// MarkPublished records that the broker acknowledged the events.
+func (r *outboxRepository) MarkPublished(ctx context.Context, ids []int64) common.AppError {
+	query := `UPDATE outbox_events SET published_at = CURRENT_TIMESTAMP, last_error = NULL WHERE id = ANY($1)`
+
+	if _, err := r.db.ExecContext(ctx, query, ids); err != nil {
+		slog.Error("failed to mark outbox events published", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// MarkFailed schedules the events for another attempt after their backoff and records why publishing failed.
+func (r *outboxRepository) MarkFailed(ctx context.Context, events []OutboxEvent, reason string) common.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Mark Outbox Events Failed")
+
+	query := `UPDATE outbox_events SET next_attempt_at = $1, last_error = $2 WHERE id = $3`
+
+	now := time.Now()
+	for _, o := range events {
+		if _, err = tx.ExecContext(ctx, query, now.Add(OutboxRetryDelay(o.Attempts)), reason, o.ID); err != nil {
+			slog.Error("failed to reschedule outbox event", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// DeletePublished deletes the events published before the given time and returns how many were deleted.
+func (r *outboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, common.AppError) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM outbox_events WHERE published_at < $1`, before)
+	if err != nil {
+		slog.Error("failed to delete published outbox events", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return deleted, nil
+}
+
+// recordEvent writes the event to the outbox and queues its webhook deliveries to the owners' endpoints,
+// inside the transaction of the state change it is about, so neither is lost nor sent for a rolled back change.
+func recordEvent(ctx context.Context, tx *sql.Tx, event *Event, ownerIDs ...int64) common.AppError {
+	query := `INSERT INTO outbox_events (event_id, event_type, version, aggregate_type, aggregate_id, request_id, payload, occurred_at)
+              VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)`
+
+	if _, err := tx.ExecContext(ctx, query, event.ID, event.Type, event.Version, event.AggregateType, event.AggregateID,
+		event.RequestID, event.Data, event.OccurredAt); err != nil {
+		if isSerializationFailure(err) {
+			return common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error("failed to record outbox event", "eventType", event.Type, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return enqueueWebhookDeliveries(ctx, tx, event, ownerIDs)
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PaymentIntentStatusRequiresConfirmation = "requires_confirmation"
+	PaymentIntentStatusAuthorized           = "authorized"
+	PaymentIntentStatusCaptured             = "captured"
+	PaymentIntentStatusVoided               = "voided"
+	PaymentIntentStatusExpired              = "expired"
+
+	JournalEntryTypePayment = "payment"
+
+	// PaymentIntentConfirmTTL is how long a customer has to confirm an intent after the merchant created it.
+	PaymentIntentConfirmTTL = 24 * time.Hour
+
+	// PaymentIntentHoldTTL is how long the funds of a confirmed intent stay on hold, the merchant must capture
+	// before the hold expires and the funds are released to the customer.
+	PaymentIntentHoldTTL = 7 * 24 * time.Hour
+
+	// PaymentIntentExpiryBatchSize is how many expired intents are released in one batch of the expirer.
+	PaymentIntentExpiryBatchSize = 100
+
+	// PaymentIntentExpiryTimeout is how long the expirer may take to release one batch.
+	PaymentIntentExpiryTimeout = 30 * time.Second
+)
+
+var ErrCaptureExceedsAuthorized = errors.New("capture amount exceeds the authorized amount")
+
+// PaymentIntent is a merchant's request to be paid an amount from a customer's wallet.
+// Confirming it places a hold on the customer's funds, which the merchant captures, in full or in part, or voids.
+// A partial capture releases the rest of the hold. Unconfirmed intents and uncaptured holds expire at ExpiresAt.
+// Captured intents can be refunded up to the captured amount.
+type PaymentIntent struct {
+	ID                    int64      `json:"-"`
+	UUID                  uuid.UUID  `json:"uuid"`
+	MerchantID            int64      `json:"-"`
+	MerchantWalletID      int64      `json:"-"`
+	MerchantWalletUUID    uuid.UUID  `json:"merchantWalletUuid"`
+	CustomerWalletID      *int64     `json:"-"`
+	CustomerWalletUUID    *uuid.UUID `json:"customerWalletUuid,omitempty"`
+	AmountInCents         int64      `json:"amountInCents"`
+	CapturedAmountInCents int64      `json:"capturedAmountInCents"`
+	RefundedAmountInCents int64      `json:"refundedAmountInCents"`
+	Currency              string     `json:"currency"`
+	Status                string     `json:"status"`
+	Description           string     `json:"description"`
+	JournalEntryID        *int64     `json:"-"`
+	ExpiresAt             time.Time  `json:"expiresAt"`
+	AuthorizedAt          *time.Time `json:"authorizedAt,omitempty"`
+	CapturedAt            *time.Time `json:"capturedAt,omitempty"`
+	CanceledAt            *time.Time `json:"canceledAt,omitempty"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+}
+
+// NewPaymentIntent creates an intent paying amount into the merchant's wallet, in the wallet's currency.
+// The customer must confirm it within PaymentIntentConfirmTTL.
+func NewPaymentIntent(merchantWallet *Wallet, amountInCents int64, description string, now time.Time) *PaymentIntent {
+	return &PaymentIntent{
+		UUID:               uuid.New(),
+		MerchantID:         merchantWallet.UserID,
+		MerchantWalletID:   merchantWallet.ID,
+		MerchantWalletUUID: merchantWallet.UUID,
+		AmountInCents:      amountInCents,
+		Currency:           merchantWallet.Currency,
+		Status:             PaymentIntentStatusRequiresConfirmation,
+		Description:        description,
+		ExpiresAt:          now.Add(PaymentIntentConfirmTTL),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+}
+
+// IsPending reports whether the intent is waiting for the customer or the merchant.
+func (p *PaymentIntent) IsPending() bool {
+	return p.Status == PaymentIntentStatusRequiresConfirmation || p.Status == PaymentIntentStatusAuthorized
+}
+
+// IsExpired reports whether a pending intent ran out of time, it is expired even before the expirer released it.
+func (p *PaymentIntent) IsExpired(now time.Time) bool {
+	return p.IsPending() && !now.Before(p.ExpiresAt)
+}
+
+// CaptureAmount returns the amount to capture, the full authorized amount when none is requested.
+func (p *PaymentIntent) CaptureAmount(requested *int64) (int64, error) {
+	if requested == nil {
+		return p.AmountInCents, nil
+	}
+
+	if *requested > p.AmountInCents {
+		return 0, ErrCaptureExceedsAuthorized
+	}
+
+	return *requested, nil
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// PaymentIntentExpirer expires unconfirmed intents and releases uncaptured holds once they run out of time.
+// Until it runs, expired intents can't be confirmed or captured, so a late run only delays the release.
+type PaymentIntentExpirer struct {
+	repo PaymentIntentRepository
+}
+
+// NewPaymentIntentExpirer creates a PaymentIntentExpirer for the intents of repo.
+func NewPaymentIntentExpirer(repo PaymentIntentRepository) *PaymentIntentExpirer {
+	return &PaymentIntentExpirer{repo: repo}
+}
+
+// Run expires the intents past their expiry every interval until ctx is done.
+func (e *PaymentIntentExpirer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.expire(ctx)
+		}
+	}
+}
+
+// expire releases expired intents batch by batch, until a batch comes back short.
+func (e *PaymentIntentExpirer) expire(ctx context.Context) {
+	for ctx.Err() == nil {
+		expireCtx, cancel := context.WithTimeout(ctx, PaymentIntentExpiryTimeout)
+		expired, appErr := e.repo.ExpireIntents(expireCtx)
+		cancel()
+
+		if appErr != nil {
+			slog.Error("failed to expire payment intents", "err", appErr.Error())
+			return
+		}
+
+		if expired > 0 {
+			slog.Info("expired payment intents", "count", expired)
+		}
+
+		if expired < PaymentIntentExpiryBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,487 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+)
+
+// PaymentIntentRepository defines the interface for merchant payment intents and the holds they place on wallets.
+// Held funds stay in the customer's balance, wallets.held_balance <= balance keeps them from being spent.
+type PaymentIntentRepository interface {
+	Create(ctx context.Context, intent *PaymentIntent) common.AppError
+	FindByMerchant(ctx context.Context, merchantID int64, intentUUID string) (*PaymentIntent, common.AppError)
+	Confirm(ctx context.Context, intentUUID string, customerWalletID int64) (*PaymentIntent, common.AppError)
+	Capture(ctx context.Context, merchantID int64, intentUUID string, amountInCents *int64) (*PaymentIntent, common.AppError)
+	Void(ctx context.Context, merchantID int64, intentUUID string) (*PaymentIntent, common.AppError)
+	ExpireIntents(ctx context.Context) (int64, common.AppError)
+}
+
+type paymentIntentRepository struct {
+	db *sql.DB
+}
+
+// NewPaymentIntentRepository creates a new instance of PaymentIntentRepository.
+func NewPaymentIntentRepository(db *sql.DB) PaymentIntentRepository {
+	return &paymentIntentRepository{db: db}
+}
+
+const paymentIntentColumns = `p.id, p.uuid, p.merchant_id, p.merchant_wallet_id, mw.uuid, p.customer_wallet_id, cw.uuid,
+                  p.amount, p.captured_amount, p.refunded_amount, p.currency, p.status, p.description, p.journal_entry_id,
+                  p.expires_at, p.authorized_at, p.captured_at, p.canceled_at, p.created_at, p.updated_at
+              FROM payment_intents p
+              JOIN wallets mw ON mw.id = p.merchant_wallet_id
+              LEFT JOIN wallets cw ON cw.id = p.customer_wallet_id`
+
+// Create stores a new intent, it waits for the customer's confirmation.
+func (r *paymentIntentRepository) Create(ctx context.Context, p *PaymentIntent) common.AppError {
+	query := `INSERT INTO payment_intents (uuid, merchant_id, merchant_wallet_id, amount, currency, status, description,
+                  expires_at, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+              RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query, p.UUID, p.MerchantID, p.MerchantWalletID, p.AmountInCents, p.Currency, p.Status,
+		p.Description, p.ExpiresAt, p.CreatedAt, p.UpdatedAt).Scan(&p.ID)
+	if err != nil {
+		slog.Error("failed to create payment intent", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// FindByMerchant finds an intent of the merchant, intents of other merchants are reported as not found.
+func (r *paymentIntentRepository) FindByMerchant(ctx context.Context, merchantID int64, intentUUID string) (*PaymentIntent, common.AppError) {
+	query := `SELECT ` + paymentIntentColumns + ` WHERE p.uuid = $1 AND p.merchant_id = $2`
+
+	p, err := scanPaymentIntent(r.db.QueryRowContext(ctx, query, intentUUID, merchantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewNotFoundError(common.ErrPaymentIntentNotFound)
+		}
+
+		slog.Error("failed to find payment intent", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return p, nil
+}
+
+// Confirm pays the intent from the customer's wallet by placing a hold on its amount. It uses a serializable
+// transaction and locks the intent and the wallet, so an intent is confirmed once and the hold can't exceed
+// the wallet's available balance.
+func (r *paymentIntentRepository) Confirm(ctx context.Context, intentUUID string, customerWalletID int64) (*PaymentIntent, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Confirm Payment Intent")
+
+	p, appErr := lockPaymentIntent(ctx, tx, intentUUID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if appErr := checkPaymentIntentStatus(p, PaymentIntentStatusRequiresConfirmation, "confirmed"); appErr != nil {
+		return nil, appErr
+	}
+
+	if customerWalletID == p.MerchantWalletID {
+		return nil, common.NewUnprocessableEntityError(common.ErrPaymentIntentOwnWallet)
+	}
+
+	customerWallet, appErr := lockCustomerWallet(ctx, tx, customerWalletID, p.Currency)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if appErr := changeHeldBalance(ctx, tx, customerWalletID, p.AmountInCents); appErr != nil {
+		return nil, appErr
+	}
+
+	query := `UPDATE payment_intents
+              SET status = $1, customer_wallet_id = $2, authorized_at = CURRENT_TIMESTAMP, expires_at = $3
+              WHERE id = $4
+              RETURNING authorized_at, expires_at, updated_at`
+
+	var authorizedAt time.Time
+	err = tx.QueryRowContext(ctx, query, PaymentIntentStatusAuthorized, customerWalletID, time.Now().Add(PaymentIntentHoldTTL), p.ID).
+		Scan(&authorizedAt, &p.ExpiresAt, &p.UpdatedAt)
+	if err != nil {
+		slog.Error("failed to authorize payment intent", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	p.Status = PaymentIntentStatusAuthorized
+	p.CustomerWalletID = &customerWalletID
+	p.CustomerWalletUUID = &customerWallet.UUID
+	p.AuthorizedAt = &authorizedAt
+
+	return p, nil
+}
+
+// Capture releases the hold of an authorized intent and moves the captured amount from the customer's wallet
+// to the merchant's wallet, the rest of the hold goes back to the customer. The hold release, the ledger entry
+// and the captured intent commit together in a serializable transaction.
+func (r *paymentIntentRepository) Capture(ctx context.Context, merchantID int64, intentUUID string, amountInCents *int64) (*PaymentIntent, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Capture Payment Intent")
+
+	p, appErr := lockMerchantPaymentIntent(ctx, tx, merchantID, intentUUID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if appErr := checkPaymentIntentStatus(p, PaymentIntentStatusAuthorized, "captured"); appErr != nil {
+		return nil, appErr
+	}
+
+	amount, err := p.CaptureAmount(amountInCents)
+	if err != nil {
+		return nil, common.NewUnprocessableEntityError(err.Error())
+	}
+
+	wallets, appErr := lockWalletPair(ctx, tx, *p.CustomerWalletID, p.MerchantWalletID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if merchantWallet, ok := wallets[p.MerchantWalletID]; !ok || merchantWallet.Status != WalletStatusActive {
+		return nil, common.NewUnprocessableEntityError("merchant wallet is not active")
+	}
+
+	if appErr := changeHeldBalance(ctx, tx, *p.CustomerWalletID, -p.AmountInCents); appErr != nil {
+		return nil, appErr
+	}
+
+	entry := NewJournalEntry(JournalEntryTypePayment, fmt.Sprintf("Payment %s", p.UUID),
+		NewWalletPosting(*p.CustomerWalletID, PostingDirectionDebit, amount, p.Currency),
+		NewWalletPosting(p.MerchantWalletID, PostingDirectionCredit, amount, p.Currency),
+	)
+
+	if appErr := postJournalEntry(ctx, tx, entry); appErr != nil {
+		return nil, appErr
+	}
+
+	query := `UPDATE payment_intents
+              SET status = $1, captured_amount = $2, journal_entry_id = $3, captured_at = CURRENT_TIMESTAMP
+              WHERE id = $4
+              RETURNING captured_at, updated_at`
+
+	var capturedAt time.Time
+	if err = tx.QueryRowContext(ctx, query, PaymentIntentStatusCaptured, amount, entry.ID, p.ID).Scan(&capturedAt, &p.UpdatedAt); err != nil {
+		slog.Error("failed to capture payment intent", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	p.Status = PaymentIntentStatusCaptured
+	p.CapturedAmountInCents = amount
+	p.JournalEntryID = &entry.ID
+	p.CapturedAt = &capturedAt
+
+	return p, nil
+}
+
+// Void cancels a pending intent, the hold of an authorized intent is released to the customer.
+func (r *paymentIntentRepository) Void(ctx context.Context, merchantID int64, intentUUID string) (*PaymentIntent, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Void Payment Intent")
+
+	p, appErr := lockMerchantPaymentIntent(ctx, tx, merchantID, intentUUID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if !p.IsPending() {
+		return nil, common.NewConflictError(fmt.Sprintf("payment intent can't be voided, it is %s", p.Status))
+	}
+
+	if appErr := cancelPaymentIntent(ctx, tx, p, PaymentIntentStatusVoided); appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return p, nil
+}
+
+// ExpireIntents expires a batch of pending intents past their expiry and releases their holds,
+// it returns how many were expired. Every intent is expired in its own transaction, so one failure
+// doesn't keep the other holds from being released.
+func (r *paymentIntentRepository) ExpireIntents(ctx context.Context) (int64, common.AppError) {
+	query := `SELECT uuid FROM payment_intents
+              WHERE status IN ($1, $2) AND expires_at <= CURRENT_TIMESTAMP
+              ORDER BY expires_at
+              LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, PaymentIntentStatusRequiresConfirmation, PaymentIntentStatusAuthorized, PaymentIntentExpiryBatchSize)
+	if err != nil {
+		slog.Error("failed to find expired payment intents", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	var intentUUIDs []uuid.UUID
+	for rows.Next() {
+		var intentUUID uuid.UUID
+		if err := rows.Scan(&intentUUID); err != nil {
+			slog.Error("failed to scan expired payment intent", "err", err)
+			return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		intentUUIDs = append(intentUUIDs, intentUUID)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	var expired int64
+	for _, intentUUID := range intentUUIDs {
+		ok, appErr := r.expireIntent(ctx, intentUUID.String())
+		if appErr != nil {
+			slog.Error("failed to expire payment intent", "intentUUID", intentUUID, "err", appErr.Error())
+			continue
+		}
+
+		if ok {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// expireIntent expires one intent, it reports false when the intent was captured or voided in the meantime.
+func (r *paymentIntentRepository) expireIntent(ctx context.Context, intentUUID string) (bool, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Expire Payment Intent")
+
+	p, appErr := lockPaymentIntent(ctx, tx, intentUUID)
+	if appErr != nil {
+		return false, appErr
+	}
+
+	if !p.IsExpired(time.Now()) {
+		return false, nil
+	}
+
+	if appErr := cancelPaymentIntent(ctx, tx, p, PaymentIntentStatusExpired); appErr != nil {
+		return false, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return true, nil
+}
+
+// lockPaymentIntent locks an intent for a change of its status.
+func lockPaymentIntent(ctx context.Context, tx *sql.Tx, intentUUID string) (*PaymentIntent, common.AppError) {
+	query := `SELECT ` + paymentIntentColumns + ` WHERE p.uuid = $1 FOR UPDATE OF p`
+
+	p, err := scanPaymentIntent(tx.QueryRowContext(ctx, query, intentUUID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewNotFoundError(common.ErrPaymentIntentNotFound)
+		}
+
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error("failed to lock payment intent", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return p, nil
+}
+
+// lockMerchantPaymentIntent locks an intent of the merchant, intents of other merchants are reported as not found.
+func lockMerchantPaymentIntent(ctx context.Context, tx *sql.Tx, merchantID int64, intentUUID string) (*PaymentIntent, common.AppError) {
+	p, appErr := lockPaymentIntent(ctx, tx, intentUUID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if p.MerchantID != merchantID {
+		return nil, common.NewNotFoundError(common.ErrPaymentIntentNotFound)
+	}
+
+	return p, nil
+}
+
+// checkPaymentIntentStatus verifies that the intent has the status required for the action and hasn't expired.
+func checkPaymentIntentStatus(p *PaymentIntent, status, action string) common.AppError {
+	if p.IsExpired(time.Now()) {
+		return common.NewUnprocessableEntityError(common.ErrPaymentIntentExpired)
+	}
+
+	if p.Status != status {
+		return common.NewConflictError(fmt.Sprintf("payment intent can't be %s, it is %s", action, p.Status))
+	}
+
+	return nil
+}
+
+// lockCustomerWallet locks the wallet an intent is paid from and verifies it is active and holds the intent's currency.
+func lockCustomerWallet(ctx context.Context, tx *sql.Tx, walletID int64, currency string) (*Wallet, common.AppError) {
+	query := `SELECT id, uuid, currency, status FROM wallets WHERE id = $1 FOR UPDATE`
+
+	var w Wallet
+	if err := tx.QueryRowContext(ctx, query, walletID).Scan(&w.ID, &w.UUID, &w.Currency, &w.Status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewNotFoundError("wallet not found")
+		}
+
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error("failed to lock customer wallet", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if w.Status != WalletStatusActive {
+		return nil, common.NewUnprocessableEntityError(fmt.Sprintf("wallet is %s", w.Status))
+	}
+
+	if w.Currency != currency {
+		return nil, common.NewUnprocessableEntityError(fmt.Sprintf("the payment is in %s, but the wallet holds %s", currency, w.Currency))
+	}
+
+	return &w, nil
+}
+
+// changeHeldBalance places a hold on a wallet's funds for a positive delta and releases it for a negative one.
+// A hold larger than the available balance violates wallets.held_balance <= balance and is reported as insufficient funds.
+func changeHeldBalance(ctx context.Context, tx *sql.Tx, walletID int64, delta int64) common.AppError {
+	query := `UPDATE wallets SET held_balance = held_balance + $1 WHERE id = $2`
+
+	if _, err := tx.ExecContext(ctx, query, delta, walletID); err != nil {
+		if isCheckViolation(err) {
+			return common.NewUnprocessableEntityError(common.ErrInsufficientFunds)
+		}
+
+		if isSerializationFailure(err) {
+			return common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error("failed to update wallet held balance", "walletID", walletID, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// cancelPaymentIntent voids or expires a locked pending intent and releases its hold.
+func cancelPaymentIntent(ctx context.Context, tx *sql.Tx, p *PaymentIntent, status string) common.AppError {
+	if p.Status == PaymentIntentStatusAuthorized {
+		if appErr := changeHeldBalance(ctx, tx, *p.CustomerWalletID, -p.AmountInCents); appErr != nil {
+			return appErr
+		}
+	}
+
+	query := `UPDATE payment_intents SET status = $1, canceled_at = CURRENT_TIMESTAMP WHERE id = $2
+              RETURNING canceled_at, updated_at`
+
+	var canceledAt time.Time
+	if err := tx.QueryRowContext(ctx, query, status, p.ID).Scan(&canceledAt, &p.UpdatedAt); err != nil {
+		slog.Error("failed to cancel payment intent", "status", status, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	p.Status = status
+	p.CanceledAt = &canceledAt
+
+	return nil
+}
+
+func scanPaymentIntent(row *sql.Row) (*PaymentIntent, error) {
+	var p PaymentIntent
+	var customerWalletID, journalEntryID sql.NullInt64
+	var customerWalletUUID uuid.NullUUID
+	var authorizedAt, capturedAt, canceledAt sql.NullTime
+
+	err := row.Scan(&p.ID, &p.UUID, &p.MerchantID, &p.MerchantWalletID, &p.MerchantWalletUUID, &customerWalletID, &customerWalletUUID,
+		&p.AmountInCents, &p.CapturedAmountInCents, &p.RefundedAmountInCents, &p.Currency, &p.Status, &p.Description, &journalEntryID,
+		&p.ExpiresAt, &authorizedAt, &capturedAt, &canceledAt, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if customerWalletID.Valid {
+		p.CustomerWalletID = &customerWalletID.Int64
+		p.CustomerWalletUUID = &customerWalletUUID.UUID
+	}
+
+	if journalEntryID.Valid {
+		p.JournalEntryID = &journalEntryID.Int64
+	}
+
+	if authorizedAt.Valid {
+		p.AuthorizedAt = &authorizedAt.Time
+	}
+
+	if capturedAt.Valid {
+		p.CapturedAt = &capturedAt.Time
+	}
+
+	if canceledAt.Valid {
+		p.CanceledAt = &canceledAt.Time
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPaymentIntent(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	wallet := &Wallet{ID: 7, UUID: uuid.New(), UserID: 3, Currency: "EUR"}
+
+	p := NewPaymentIntent(wallet, 4200, "Order 1001", now)
+
+	assert.Equal(t, int64(3), p.MerchantID)
+	assert.Equal(t, int64(7), p.MerchantWalletID)
+	assert.Equal(t, wallet.UUID, p.MerchantWalletUUID)
+	assert.Equal(t, "EUR", p.Currency, "intent must be in the merchant wallet's currency")
+	assert.Equal(t, PaymentIntentStatusRequiresConfirmation, p.Status)
+	assert.Equal(t, now.Add(PaymentIntentConfirmTTL), p.ExpiresAt)
+}
+
+func TestPaymentIntentIsExpired(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		status    string
+		expiresAt time.Time
+		want      bool
+	}{
+		{"Unconfirmed Before Expiry", PaymentIntentStatusRequiresConfirmation, now.Add(time.Minute), false},
+		{"Unconfirmed At Expiry", PaymentIntentStatusRequiresConfirmation, now, true},
+		{"Authorized After Expiry", PaymentIntentStatusAuthorized, now.Add(-time.Minute), true},
+		{"Captured After Expiry", PaymentIntentStatusCaptured, now.Add(-time.Minute), false},
+		{"Voided After Expiry", PaymentIntentStatusVoided, now.Add(-time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PaymentIntent{Status: tt.status, ExpiresAt: tt.expiresAt}
+			assert.Equal(t, tt.want, p.IsExpired(now))
+		})
+	}
+}
+
+func TestPaymentIntentCaptureAmount(t *testing.T) {
+	p := &PaymentIntent{AmountInCents: 5000}
+	partial, exceeding := int64(1250), int64(5001)
+
+	amount, err := p.CaptureAmount(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), amount, "captures the full amount by default")
+
+	amount, err = p.CaptureAmount(&partial)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1250), amount)
+
+	_, err = p.CaptureAmount(&exceeding)
+	assert.ErrorIs(t, err, ErrCaptureExceedsAuthorized)
+}
+
+func TestWalletAvailableInCents(t *testing.T) {
+	w := &Wallet{BalanceInCents: 10000, HeldInCents: 2500}
+	assert.Equal(t, int64(7500), w.AvailableInCents())
+}
+
+// batchPaymentIntentRepo expires up to PaymentIntentExpiryBatchSize of its remaining expired intents per call.
+type batchPaymentIntentRepo struct {
+	PaymentIntentRepository
+	remaining int64
+	calls     int
+}
+
+func (r *batchPaymentIntentRepo) ExpireIntents(_ context.Context) (int64, common.AppError) {
+	r.calls++
+
+	expired := min(r.remaining, PaymentIntentExpiryBatchSize)
+	r.remaining -= expired
+
+	return expired, nil
+}
+
+func TestPaymentIntentExpirerDrainsBatches(t *testing.T) {
+	repo := &batchPaymentIntentRepo{remaining: 2*PaymentIntentExpiryBatchSize + 5}
+
+	NewPaymentIntentExpirer(repo).expire(context.Background())
+
+	assert.Zero(t, repo.remaining)
+	assert.Equal(t, 3, repo.calls, "stops after the first short batch")
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PolicyChangeGrant  string = "grant"
+	PolicyChangeRevoke string = "revoke"
+
+	// Access scopes limit whose resources a grant covers on routes with a user_uuid.
+	// Self is the caller's own, Onboarded adds the users the caller created and Any is every user.
+	PolicyScopeSelf      string = "self"
+	PolicyScopeOnboarded string = "onboarded"
+	PolicyScopeAny       string = "any"
+
+	PolicyChangesDefaultLimit = 50
+	PolicyChangesMaxLimit     = 200
+)
+
+// PolicyAction is a named API route that roles can be granted, e.g. UpdateWalletStatus.
+type PolicyAction struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Route  string `json:"route"`
+}
+
+// PolicyGrant allows a role to call an action on the resources of the users in its scope.
+type PolicyGrant struct {
+	Role      string    `json:"role"`
+	Action    string    `json:"action"`
+	Scope     string    `json:"scope"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	GrantedAt time.Time `json:"grantedAt"`
+}
+
+// PolicyChange records a grant or revocation. ActorUUID is the admin who made it, or nil when
+// the grant was seeded from the embedded policy.
+type PolicyChange struct {
+	ID        int64      `json:"id"`
+	ActorUUID *uuid.UUID `json:"actorUuid"`
+	Type      string     `json:"type"`
+	Role      string     `json:"role"`
+	Action    string     `json:"action"`
+	Scope     string     `json:"scope,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// IsValidPolicyScope checks if the provided access scope is valid.
+func IsValidPolicyScope(scope string) bool {
+	return scope == PolicyScopeSelf || scope == PolicyScopeOnboarded || scope == PolicyScopeAny
+}
+
+// CanAccessUser reports whether the scope lets caller act on the resources of owner.
+func CanAccessUser(scope string, caller, owner *User) bool {
+	switch scope {
+	case PolicyScopeAny:
+		return true
+	case PolicyScopeOnboarded:
+		return owner.ID == caller.ID || (owner.CreatedBy != nil && *owner.CreatedBy == caller.ID)
+	case PolicyScopeSelf:
+		return owner.ID == caller.ID
+	default:
+		return false
+	}
+}
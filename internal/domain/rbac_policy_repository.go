@@ -0,0 +1,314 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+)
+
+// RBACPolicyRepository defines the interface for the role based access control policy store.
+type RBACPolicyRepository interface {
+	Seed(ctx context.Context, roles []string, actions []PolicyAction, grants []PolicyGrant) common.AppError
+	Version(ctx context.Context) (int64, common.AppError)
+	ListActions(ctx context.Context) ([]PolicyAction, common.AppError)
+	ListGrants(ctx context.Context) ([]PolicyGrant, common.AppError)
+	Grant(ctx context.Context, role, action, scope string, actorID int64) common.AppError
+	Revoke(ctx context.Context, role, action string, actorID int64) common.AppError
+	ListChanges(ctx context.Context, limit int) ([]PolicyChange, common.AppError)
+}
+
+type rbacPolicyRepository struct {
+	db *sql.DB
+}
+
+// NewRBACPolicyRepository creates a new instance of RBACPolicyRepository.
+func NewRBACPolicyRepository(db *sql.DB) RBACPolicyRepository {
+	return &rbacPolicyRepository{db: db}
+}
+
+// Seed stores the roles and actions of the embedded policy. The default grants are only stored for actions
+// the database didn't know yet, so changes made by admins are kept across restarts while actions added
+// in a new release get their default grants. Seeded grants are recorded as changes without an actor.
+func (r *rbacPolicyRepository) Seed(ctx context.Context, roles []string, actions []PolicyAction, grants []PolicyGrant) common.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Seed RBAC Policy")
+
+	for _, role := range roles {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO rbac_roles (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, role); err != nil {
+			slog.Error("failed to seed rbac role", "err", err, "role", role)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+	}
+
+	newActions := make(map[string]bool)
+	for _, action := range actions {
+		inserted, appErr := upsertPolicyAction(ctx, tx, action)
+		if appErr != nil {
+			return appErr
+		}
+
+		newActions[action.Name] = inserted
+	}
+
+	for _, g := range grants {
+		if !newActions[g.Action] {
+			continue
+		}
+
+		if appErr := grantPolicyAction(ctx, tx, g.Role, g.Action, g.Scope, nil); appErr != nil {
+			return appErr
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// Version returns the id of the latest policy change, it increases with every grant and revocation.
+func (r *rbacPolicyRepository) Version(ctx context.Context) (int64, common.AppError) {
+	var version int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM rbac_policy_changes`).Scan(&version); err != nil {
+		slog.Error("failed to get rbac policy version", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return version, nil
+}
+
+// ListActions returns every action that can be granted, ordered by route.
+func (r *rbacPolicyRepository) ListActions(ctx context.Context) ([]PolicyAction, common.AppError) {
+	query := `SELECT name, method, route FROM rbac_actions ORDER BY route, method`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.Error("failed to list rbac actions", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	var actions []PolicyAction
+	for rows.Next() {
+		var a PolicyAction
+		if err := rows.Scan(&a.Name, &a.Method, &a.Route); err != nil {
+			slog.Error("failed to scan rbac action", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		actions = append(actions, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating rbac actions", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return actions, nil
+}
+
+// ListGrants returns every grant with the method and route of its action, ordered by role.
+func (r *rbacPolicyRepository) ListGrants(ctx context.Context) ([]PolicyGrant, common.AppError) {
+	query := `SELECT ra.role, ra.action, ra.scope, a.method, a.route, ra.created_at
+              FROM rbac_role_actions ra
+              JOIN rbac_actions a ON a.name = ra.action
+              ORDER BY ra.role, a.route, a.method`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.Error("failed to list rbac grants", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	var grants []PolicyGrant
+	for rows.Next() {
+		var g PolicyGrant
+		if err := rows.Scan(&g.Role, &g.Action, &g.Scope, &g.Method, &g.Route, &g.GrantedAt); err != nil {
+			slog.Error("failed to scan rbac grant", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		grants = append(grants, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating rbac grants", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return grants, nil
+}
+
+// Grant allows the role to call the action within the scope and records the change.
+// Granting an existing grant with another scope changes its scope, with the same scope it's a no-op.
+func (r *rbacPolicyRepository) Grant(ctx context.Context, role, action, scope string, actorID int64) common.AppError {
+	return r.changeGrant(ctx, role, action, actorID, func(ctx context.Context, tx *sql.Tx) common.AppError {
+		return grantPolicyAction(ctx, tx, role, action, scope, &actorID)
+	})
+}
+
+// Revoke removes the role's grant of the action and records the change, revoking a missing grant is a no-op.
+func (r *rbacPolicyRepository) Revoke(ctx context.Context, role, action string, actorID int64) common.AppError {
+	return r.changeGrant(ctx, role, action, actorID, func(ctx context.Context, tx *sql.Tx) common.AppError {
+		return revokePolicyAction(ctx, tx, role, action, &actorID)
+	})
+}
+
+// ListChanges returns the most recent policy changes, newest first.
+func (r *rbacPolicyRepository) ListChanges(ctx context.Context, limit int) ([]PolicyChange, common.AppError) {
+	query := `SELECT c.id, u.uuid, c.change_type, c.role, c.action, COALESCE(c.scope::TEXT, ''), c.created_at
+              FROM rbac_policy_changes c
+              LEFT JOIN users u ON u.id = c.actor_user_id
+              ORDER BY c.id DESC
+              LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		slog.Error("failed to list rbac policy changes", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	changes := make([]PolicyChange, 0, limit)
+	for rows.Next() {
+		var c PolicyChange
+		var actorUUID uuid.NullUUID
+		if err := rows.Scan(&c.ID, &actorUUID, &c.Type, &c.Role, &c.Action, &c.Scope, &c.CreatedAt); err != nil {
+			slog.Error("failed to scan rbac policy change", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		if actorUUID.Valid {
+			c.ActorUUID = &actorUUID.UUID
+		}
+
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error iterating rbac policy changes", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return changes, nil
+}
+
+// changeGrant runs change in a transaction, after checking the role and action exist.
+func (r *rbacPolicyRepository) changeGrant(ctx context.Context, role, action string, actorID int64, change func(context.Context, *sql.Tx) common.AppError) common.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Change RBAC Grant")
+
+	var exists bool
+	existsQuery := `SELECT EXISTS (SELECT 1 FROM rbac_roles WHERE name = $1) AND EXISTS (SELECT 1 FROM rbac_actions WHERE name = $2)`
+
+	if err = tx.QueryRowContext(ctx, existsQuery, role, action).Scan(&exists); err != nil {
+		slog.Error("failed to check rbac role and action", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if !exists {
+		return common.NewNotFoundError(common.ErrPolicyRoleOrActionNotFound)
+	}
+
+	if appErr := change(ctx, tx); appErr != nil {
+		return appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// upsertPolicyAction stores a new action, or updates the route of a known one. It reports whether the action is new.
+func upsertPolicyAction(ctx context.Context, tx *sql.Tx, action PolicyAction) (bool, common.AppError) {
+	insertQuery := `INSERT INTO rbac_actions (name, method, route) VALUES ($1, $2, $3) ON CONFLICT (name) DO NOTHING`
+
+	result, err := tx.ExecContext(ctx, insertQuery, action.Name, action.Method, action.Route)
+	if err != nil {
+		slog.Error("failed to seed rbac action", "err", err, "action", action.Name)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if rowsAffected == 1 {
+		return true, nil
+	}
+
+	updateQuery := `UPDATE rbac_actions SET method = $2, route = $3 WHERE name = $1 AND (method <> $2 OR route <> $3)`
+
+	if _, err = tx.ExecContext(ctx, updateQuery, action.Name, action.Method, action.Route); err != nil {
+		slog.Error("failed to update rbac action", "err", err, "action", action.Name)
+		return false, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return false, nil
+}
+
+func grantPolicyAction(ctx context.Context, tx *sql.Tx, role, action, scope string, actorID *int64) common.AppError {
+	query := `INSERT INTO rbac_role_actions (role, action, scope) VALUES ($1, $2, $3)
+              ON CONFLICT (role, action) DO UPDATE SET scope = EXCLUDED.scope
+              WHERE rbac_role_actions.scope <> EXCLUDED.scope`
+
+	return changePolicyGrant(ctx, tx, query, []any{role, action, scope}, PolicyChangeGrant, role, action, &scope, actorID)
+}
+
+func revokePolicyAction(ctx context.Context, tx *sql.Tx, role, action string, actorID *int64) common.AppError {
+	query := `DELETE FROM rbac_role_actions WHERE role = $1 AND action = $2`
+
+	return changePolicyGrant(ctx, tx, query, []any{role, action}, PolicyChangeRevoke, role, action, nil, actorID)
+}
+
+// changePolicyGrant runs the grant or revoke query and records the change, if the query changed anything.
+func changePolicyGrant(ctx context.Context, tx *sql.Tx, query string, args []any, changeType, role, action string, scope *string, actorID *int64) common.AppError {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		slog.Error("failed to change rbac grant", "err", err, "change", changeType, "role", role, "action", action)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return nil
+	}
+
+	changeQuery := `INSERT INTO rbac_policy_changes (actor_user_id, change_type, role, action, scope) VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err = tx.ExecContext(ctx, changeQuery, actorID, changeType, role, action, scope); err != nil {
+		slog.Error("failed to record rbac policy change", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
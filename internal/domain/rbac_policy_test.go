@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanAccessUser(t *testing.T) {
+	agent := &User{ID: 1, Role: UserRoleAgent}
+	onboarded := &User{ID: 2, CreatedBy: &agent.ID}
+	other := &User{ID: 3}
+
+	tests := []struct {
+		name  string
+		scope string
+		owner *User
+		want  bool
+	}{
+		{"self own", PolicyScopeSelf, agent, true},
+		{"self onboarded", PolicyScopeSelf, onboarded, false},
+		{"onboarded own", PolicyScopeOnboarded, agent, true},
+		{"onboarded onboarded", PolicyScopeOnboarded, onboarded, true},
+		{"onboarded other", PolicyScopeOnboarded, other, false},
+		{"any other", PolicyScopeAny, other, true},
+		{"unknown scope", "everyone", agent, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CanAccessUser(tt.scope, agent, tt.owner))
+		})
+	}
+}
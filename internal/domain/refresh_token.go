@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is the stored, hashed form of a refresh token handed to a client.
+// Tokens issued from the same login share a FamilyID. A token can be used once, using it rotates
+// it into the next token of the family. Presenting a used token again means it was stolen,
+// so the whole family is revoked and both the thief and the user have to log in again.
+type RefreshToken struct {
+	ID         int64
+	UserID     int64
+	FamilyID   uuid.UUID
+	TokenHash  []byte
+	ReplacedBy *int64
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
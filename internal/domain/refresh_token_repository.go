@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// RefreshTokenRepository defines the interface for refresh token storage, rotation and revocation.
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *RefreshToken) common.AppError
+	Rotate(ctx context.Context, presentedHash []byte, next *RefreshToken) common.AppError
+	RevokeFamily(ctx context.Context, tokenHash []byte, userID int64) common.AppError
+}
+
+type refreshTokenRepository struct {
+	db *sql.DB
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository.
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// Create stores the first token of a new family, issued on login or registration.
+func (r *refreshTokenRepository) Create(ctx context.Context, t *RefreshToken) common.AppError {
+	query := `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+              VALUES ($1, $2, $3, $4)
+              RETURNING id, created_at`
+
+	if err := r.db.QueryRowContext(ctx, query, t.UserID, t.FamilyID, t.TokenHash, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt); err != nil {
+		slog.Error("failed to create refresh token", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// Rotate exchanges the presented token for next, which joins the presented token's family and user.
+// The presented token row is locked, so two concurrent uses of one token can't both rotate it.
+// If the presented token was already used, the whole family is revoked and committed before
+// the reuse error is returned, because an attacker might hold any of the family's tokens.
+func (r *refreshTokenRepository) Rotate(ctx context.Context, presentedHash []byte, next *RefreshToken) common.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Rotate Refresh Token")
+
+	current, appErr := findRefreshTokenForUpdate(ctx, tx, presentedHash)
+	if appErr != nil {
+		return appErr
+	}
+
+	switch {
+	case current.RevokedAt != nil:
+		return common.NewUnauthorizedError(common.ErrInvalidRefreshToken)
+
+	case current.UsedAt != nil:
+		if appErr := revokeRefreshTokenFamily(ctx, tx, current); appErr != nil {
+			return appErr
+		}
+
+		if err = tx.Commit(); err != nil {
+			slog.Error(common.ErrTxCommit, "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		slog.Warn("refresh token reuse detected, revoked token family", "userID", current.UserID, "familyID", current.FamilyID)
+		return common.NewUnauthorizedError(common.ErrRefreshTokenReused)
+
+	case !current.ExpiresAt.After(time.Now()):
+		return common.NewUnauthorizedError(common.ErrInvalidRefreshToken)
+	}
+
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+
+	insertQuery := `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+                    VALUES ($1, $2, $3, $4)
+                    RETURNING id, created_at`
+
+	if err = tx.QueryRowContext(ctx, insertQuery, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt).Scan(&next.ID, &next.CreatedAt); err != nil {
+		slog.Error("failed to create rotated refresh token", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	updateQuery := `UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP, replaced_by = $1 WHERE id = $2`
+
+	if _, err = tx.ExecContext(ctx, updateQuery, next.ID, current.ID); err != nil {
+		slog.Error("failed to mark refresh token as used", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every token of the family the given token belongs to, used on logout.
+// Tokens of other users are ignored, so a logout can't revoke someone else's session.
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, tokenHash []byte, userID int64) common.AppError {
+	query := `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+              WHERE revoked_at IS NULL AND family_id = (
+                  SELECT family_id FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2
+              )`
+
+	if _, err := r.db.ExecContext(ctx, query, tokenHash, userID); err != nil {
+		slog.Error("failed to revoke refresh token family", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+func findRefreshTokenForUpdate(ctx context.Context, tx *sql.Tx, tokenHash []byte) (*RefreshToken, common.AppError) {
+	query := `SELECT id, user_id, family_id, expires_at, used_at, revoked_at
+              FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`
+
+	var t RefreshToken
+	var usedAt, revokedAt sql.NullTime
+
+	err := tx.QueryRowContext(ctx, query, tokenHash).Scan(&t.ID, &t.UserID, &t.FamilyID, &t.ExpiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewUnauthorizedError(common.ErrInvalidRefreshToken)
+		}
+
+		slog.Error("failed to find refresh token", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if usedAt.Valid {
+		t.UsedAt = &usedAt.Time
+	}
+
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+
+	return &t, nil
+}
+
+func revokeRefreshTokenFamily(ctx context.Context, tx *sql.Tx, t *RefreshToken) common.AppError {
+	query := `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE family_id = $1 AND revoked_at IS NULL`
+
+	if _, err := tx.ExecContext(ctx, query, t.FamilyID); err != nil {
+		slog.Error("failed to revoke refresh token family", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
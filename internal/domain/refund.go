@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+
+	JournalEntryTypeRefund = "refund"
+)
+
+var (
+	ErrRefundExceedsRefundable = errors.New("refund amount exceeds the amount left to refund")
+	ErrPaymentFullyRefunded    = errors.New("payment is already fully refunded")
+)
+
+// Refund gives back all or part of a captured payment, it moves funds from the merchant's wallet to the wallet
+// the customer paid from. The refunds of a payment never add up to more than its captured amount.
+// A refund the merchant's wallet can't cover is stored as failed with its reason, nothing is moved.
+type Refund struct {
+	ID                int64     `json:"-"`
+	UUID              uuid.UUID `json:"uuid"`
+	PaymentIntentID   int64     `json:"-"`
+	PaymentIntentUUID uuid.UUID `json:"paymentIntentUuid"`
+	AmountInCents     int64     `json:"amountInCents"`
+	Currency          string    `json:"currency"`
+	Status            string    `json:"status"`
+	Reason            string    `json:"reason"`
+	FailureReason     string    `json:"failureReason,omitempty"`
+	JournalEntryID    *int64    `json:"-"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// NewRefund creates a refund of amount for a captured intent, in the intent's currency.
+func NewRefund(p *PaymentIntent, amountInCents int64, reason string, now time.Time) *Refund {
+	return &Refund{
+		UUID:              uuid.New(),
+		PaymentIntentID:   p.ID,
+		PaymentIntentUUID: p.UUID,
+		AmountInCents:     amountInCents,
+		Currency:          p.Currency,
+		Status:            RefundStatusSucceeded,
+		Reason:            reason,
+		CreatedAt:         now,
+	}
+}
+
+// RefundableInCents returns the captured amount that wasn't refunded yet.
+func (p *PaymentIntent) RefundableInCents() int64 {
+	return p.CapturedAmountInCents - p.RefundedAmountInCents
+}
+
+// RefundAmount returns the amount to refund, everything left to refund when none is requested.
+func (p *PaymentIntent) RefundAmount(requested *int64) (int64, error) {
+	refundable := p.RefundableInCents()
+	if refundable <= 0 {
+		return 0, ErrPaymentFullyRefunded
+	}
+
+	if requested == nil {
+		return refundable, nil
+	}
+
+	if *requested > refundable {
+		return 0, ErrRefundExceedsRefundable
+	}
+
+	return *requested, nil
+}
@@ -0,0 +1,244 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// RefundRepository defines the interface for refunds of captured payment intents.
+type RefundRepository interface {
+	Create(ctx context.Context, merchantID int64, intentUUID string, amountInCents *int64, reason string) (*Refund, common.AppError)
+	ListByPaymentIntent(ctx context.Context, merchantID int64, intentUUID string) ([]Refund, common.AppError)
+}
+
+type refundRepository struct {
+	db *sql.DB
+}
+
+// NewRefundRepository creates a new instance of RefundRepository.
+func NewRefundRepository(db *sql.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+// Create refunds amountInCents of a captured intent of the merchant, everything left to refund when it is nil.
+// It uses a serializable transaction and locks the intent and both wallets, so concurrent refunds can't add up
+// to more than the captured amount. The refund, its journal entry and the refunded amount of the intent commit
+// together. When a wallet isn't active or the merchant's available balance can't cover the refund, the refund
+// is stored as failed with a failed transaction in the merchant's history, and no funds move.
+func (r *refundRepository) Create(ctx context.Context, merchantID int64, intentUUID string, amountInCents *int64, reason string) (*Refund, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Create Refund")
+
+	p, appErr := lockMerchantPaymentIntent(ctx, tx, merchantID, intentUUID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if appErr := checkPaymentIntentStatus(p, PaymentIntentStatusCaptured, "refunded"); appErr != nil {
+		return nil, appErr
+	}
+
+	amount, err := p.RefundAmount(amountInCents)
+	if err != nil {
+		if errors.Is(err, ErrPaymentFullyRefunded) {
+			return nil, common.NewConflictError(err.Error())
+		}
+
+		return nil, common.NewUnprocessableEntityError(err.Error())
+	}
+
+	refund := NewRefund(p, amount, reason, time.Now().UTC())
+
+	failureReason, appErr := refundFailureReason(ctx, tx, p, amount)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if failureReason != "" {
+		appErr = failRefund(ctx, tx, p, refund, failureReason)
+	} else {
+		appErr = postRefund(ctx, tx, p, refund)
+	}
+
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return refund, nil
+}
+
+// ListByPaymentIntent returns the refunds of an intent of the merchant, oldest first, failed ones included.
+func (r *refundRepository) ListByPaymentIntent(ctx context.Context, merchantID int64, intentUUID string) ([]Refund, common.AppError) {
+	var intentID int64
+
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM payment_intents WHERE uuid = $1 AND merchant_id = $2`, intentUUID, merchantID).Scan(&intentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewNotFoundError(common.ErrPaymentIntentNotFound)
+		}
+
+		slog.Error("failed to find payment intent", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	query := `SELECT r.id, r.uuid, r.payment_intent_id, p.uuid, r.amount, r.currency, r.status, r.reason,
+                  COALESCE(r.failure_reason, ''), r.journal_entry_id, r.created_at
+              FROM refunds r
+              JOIN payment_intents p ON p.id = r.payment_intent_id
+              WHERE r.payment_intent_id = $1
+              ORDER BY r.created_at, r.id`
+
+	rows, err := r.db.QueryContext(ctx, query, intentID)
+	if err != nil {
+		slog.Error("failed to list refunds", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	refunds := make([]Refund, 0)
+	for rows.Next() {
+		var refund Refund
+		var journalEntryID sql.NullInt64
+
+		if err := rows.Scan(&refund.ID, &refund.UUID, &refund.PaymentIntentID, &refund.PaymentIntentUUID, &refund.AmountInCents,
+			&refund.Currency, &refund.Status, &refund.Reason, &refund.FailureReason, &journalEntryID, &refund.CreatedAt); err != nil {
+			slog.Error("failed to scan refund", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		if journalEntryID.Valid {
+			refund.JournalEntryID = &journalEntryID.Int64
+		}
+
+		refunds = append(refunds, refund)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return refunds, nil
+}
+
+// refundFailureReason locks the merchant's and the customer's wallets and returns why the refund can't be paid,
+// or an empty reason when it can. Funds on hold in the merchant's wallet can't be refunded.
+func refundFailureReason(ctx context.Context, tx *sql.Tx, p *PaymentIntent, amount int64) (string, common.AppError) {
+	wallets, appErr := lockWalletPair(ctx, tx, p.MerchantWalletID, *p.CustomerWalletID)
+	if appErr != nil {
+		return "", appErr
+	}
+
+	merchantWallet, ok := wallets[p.MerchantWalletID]
+	if !ok {
+		return "", common.NewNotFoundError("merchant wallet not found")
+	}
+
+	customerWallet, ok := wallets[*p.CustomerWalletID]
+	if !ok {
+		return "", common.NewNotFoundError("customer wallet not found")
+	}
+
+	if merchantWallet.Status != WalletStatusActive {
+		return fmt.Sprintf("merchant wallet is %s", merchantWallet.Status), nil
+	}
+
+	if customerWallet.Status != WalletStatusActive {
+		return fmt.Sprintf("customer wallet is %s", customerWallet.Status), nil
+	}
+
+	var available int64
+	if err := tx.QueryRowContext(ctx, `SELECT balance - held_balance FROM wallets WHERE id = $1`, p.MerchantWalletID).Scan(&available); err != nil {
+		slog.Error("failed to read merchant wallet balance", "err", err)
+		return "", common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if available < amount {
+		return common.ErrInsufficientFunds, nil
+	}
+
+	return "", nil
+}
+
+// postRefund moves the refund from the merchant's wallet to the customer's wallet and adds it to the intent's refunded amount.
+func postRefund(ctx context.Context, tx *sql.Tx, p *PaymentIntent, refund *Refund) common.AppError {
+	entry := NewJournalEntry(JournalEntryTypeRefund, fmt.Sprintf("Refund %s of payment %s", refund.UUID, p.UUID),
+		NewWalletPosting(p.MerchantWalletID, PostingDirectionDebit, refund.AmountInCents, refund.Currency),
+		NewWalletPosting(*p.CustomerWalletID, PostingDirectionCredit, refund.AmountInCents, refund.Currency),
+	)
+
+	if appErr := postJournalEntry(ctx, tx, entry); appErr != nil {
+		return appErr
+	}
+
+	refund.JournalEntryID = &entry.ID
+
+	if appErr := insertRefund(ctx, tx, refund); appErr != nil {
+		return appErr
+	}
+
+	query := `UPDATE payment_intents SET refunded_amount = refunded_amount + $1 WHERE id = $2 RETURNING refunded_amount`
+
+	if err := tx.QueryRowContext(ctx, query, refund.AmountInCents, p.ID).Scan(&p.RefundedAmountInCents); err != nil {
+		slog.Error("failed to update refunded amount", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// failRefund stores a refund that couldn't be paid, with a failed transaction in the merchant's history.
+func failRefund(ctx context.Context, tx *sql.Tx, p *PaymentIntent, refund *Refund, reason string) common.AppError {
+	refund.Status = RefundStatusFailed
+	refund.FailureReason = reason
+
+	if appErr := insertRefund(ctx, tx, refund); appErr != nil {
+		return appErr
+	}
+
+	attempt := &Transaction{
+		WalletID:      p.MerchantWalletID,
+		Type:          JournalEntryTypeRefund,
+		Direction:     PostingDirectionDebit,
+		AmountInCents: refund.AmountInCents,
+		Currency:      refund.Currency,
+		Description:   fmt.Sprintf("Refund %s of payment %s failed", refund.UUID, p.UUID),
+	}
+
+	return recordFailedTransaction(ctx, tx, attempt)
+}
+
+func insertRefund(ctx context.Context, tx *sql.Tx, refund *Refund) common.AppError {
+	query := `INSERT INTO refunds (uuid, payment_intent_id, amount, currency, status, reason, failure_reason, journal_entry_id, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9)
+              RETURNING id`
+
+	if err := tx.QueryRowContext(ctx, query, refund.UUID, refund.PaymentIntentID, refund.AmountInCents, refund.Currency, refund.Status,
+		refund.Reason, refund.FailureReason, refund.JournalEntryID, refund.CreatedAt).Scan(&refund.ID); err != nil {
+		slog.Error("failed to create refund", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
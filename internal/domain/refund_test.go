@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRefund(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	p := &PaymentIntent{ID: 9, UUID: uuid.New(), Currency: "GBP", CapturedAmountInCents: 3000}
+
+	r := NewRefund(p, 1000, "Damaged item", now)
+
+	assert.Equal(t, int64(9), r.PaymentIntentID)
+	assert.Equal(t, p.UUID, r.PaymentIntentUUID)
+	assert.Equal(t, "GBP", r.Currency, "refund must be in the payment's currency")
+	assert.Equal(t, RefundStatusSucceeded, r.Status)
+	assert.NotEqual(t, uuid.Nil, r.UUID)
+}
+
+func TestPaymentIntentRefundAmount(t *testing.T) {
+	p := &PaymentIntent{AmountInCents: 5000, CapturedAmountInCents: 4000, RefundedAmountInCents: 1500}
+	partial, remaining, exceeding := int64(500), int64(2500), int64(2501)
+
+	amount, err := p.RefundAmount(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2500), amount, "refunds everything left of the captured amount by default")
+
+	amount, err = p.RefundAmount(&partial)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), amount)
+
+	amount, err = p.RefundAmount(&remaining)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2500), amount)
+
+	_, err = p.RefundAmount(&exceeding)
+	assert.ErrorIs(t, err, ErrRefundExceedsRefundable)
+
+	p.RefundedAmountInCents = p.CapturedAmountInCents
+
+	_, err = p.RefundAmount(nil)
+	assert.ErrorIs(t, err, ErrPaymentFullyRefunded)
+
+	_, err = p.RefundAmount(&partial)
+	assert.ErrorIs(t, err, ErrPaymentFullyRefunded)
+}
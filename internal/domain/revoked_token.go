@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokedToken is an access token, identified by its jti claim, that was revoked before it expired.
+type RevokedToken struct {
+	JTI       uuid.UUID
+	UserID    int64
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
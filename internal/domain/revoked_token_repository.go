@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// RevokedTokenRepository defines the interface for the persisted access token denylist.
+type RevokedTokenRepository interface {
+	Create(ctx context.Context, token *RevokedToken) common.AppError
+	FindRevokedSince(ctx context.Context, since time.Time) ([]RevokedToken, common.AppError)
+	DeleteExpired(ctx context.Context) (int64, common.AppError)
+}
+
+type revokedTokenRepository struct {
+	db *sql.DB
+}
+
+// NewRevokedTokenRepository creates a new instance of RevokedTokenRepository.
+func NewRevokedTokenRepository(db *sql.DB) RevokedTokenRepository {
+	return &revokedTokenRepository{db: db}
+}
+
+// Create revokes an access token. Revoking an already revoked token is a no-op.
+func (r *revokedTokenRepository) Create(ctx context.Context, t *RevokedToken) common.AppError {
+	query := `INSERT INTO revoked_tokens (jti, user_id, expires_at)
+              VALUES ($1, $2, $3)
+              ON CONFLICT (jti) DO UPDATE SET jti = EXCLUDED.jti
+              RETURNING revoked_at`
+
+	if err := r.db.QueryRowContext(ctx, query, t.JTI, t.UserID, t.ExpiresAt).Scan(&t.RevokedAt); err != nil {
+		slog.Error("failed to revoke token", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// FindRevokedSince returns the unexpired tokens revoked after since, ordered by revocation time.
+func (r *revokedTokenRepository) FindRevokedSince(ctx context.Context, since time.Time) ([]RevokedToken, common.AppError) {
+	query := `SELECT jti, user_id, expires_at, revoked_at FROM revoked_tokens
+              WHERE revoked_at > $1 AND expires_at > CURRENT_TIMESTAMP
+              ORDER BY revoked_at`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		slog.Error("failed to find revoked tokens", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	var tokens []RevokedToken
+	for rows.Next() {
+		var t RevokedToken
+		if err := rows.Scan(&t.JTI, &t.UserID, &t.ExpiresAt, &t.RevokedAt); err != nil {
+			slog.Error("failed to scan revoked token", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return tokens, nil
+}
+
+// DeleteExpired purges revocations of tokens that expired anyway, returns the number of deleted rows.
+func (r *revokedTokenRepository) DeleteExpired(ctx context.Context) (int64, common.AppError) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		slog.Error("failed to delete expired revoked tokens", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatementFormatCSV = "csv"
+	StatementFormatOFX = "ofx"
+	StatementFormatPDF = "pdf"
+
+	StatementExportStatusPending    = "pending"
+	StatementExportStatusProcessing = "processing"
+	StatementExportStatusReady      = "ready"
+	StatementExportStatusFailed     = "failed"
+
+	// StatementSyncMaxDays is the longest period rendered in the request, longer periods are exported in the background.
+	StatementSyncMaxDays = 31
+
+	// StatementMaxDays is the longest period a statement can cover.
+	StatementMaxDays = 366
+
+	// StatementExportTTL is how long a finished export can be downloaded, it is deleted afterwards.
+	StatementExportTTL = 7 * 24 * time.Hour
+
+	// StatementExportTimeout is how long a worker may take to generate an export.
+	StatementExportTimeout = 2 * time.Minute
+
+	// StatementExportLockTTL is how long an export can be processing before another worker takes it over,
+	// e.g. because the instance generating it crashed. It must be longer than StatementExportTimeout.
+	StatementExportLockTTL = 5 * time.Minute
+
+	// StatementExportMaxAttempts is how often an export is taken over before it is given up.
+	StatementExportMaxAttempts = 3
+
+	// StatementExportAbandonedReason is the failure reason of exports whose last attempt never finished.
+	StatementExportAbandonedReason = "the statement export didn't finish in time, please request it again"
+)
+
+// Statement is the account statement of a wallet for a period: the balance at its start, the completed
+// transactions in it, oldest first, and the balance at its end. The period starts at From and ends before To.
+type Statement struct {
+	WalletUUID          uuid.UUID
+	Currency            string
+	From                time.Time
+	To                  time.Time
+	OpeningBalance      int64
+	ClosingBalance      int64
+	TotalCreditsInCents int64
+	TotalDebitsInCents  int64
+	Transactions        []Transaction
+	GeneratedAt         time.Time
+}
+
+// NewStatement creates the statement of a wallet from its opening balance and the period's transactions, oldest first.
+// The closing balance is the balance after the last transaction, the transactions carry the running balance.
+func NewStatement(wallet *Wallet, from, to time.Time, openingBalance int64, transactions []Transaction, now time.Time) *Statement {
+	s := &Statement{
+		WalletUUID:     wallet.UUID,
+		Currency:       wallet.Currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: openingBalance,
+		ClosingBalance: openingBalance,
+		Transactions:   transactions,
+		GeneratedAt:    now,
+	}
+
+	for _, t := range transactions {
+		if t.Direction == PostingDirectionCredit {
+			s.TotalCreditsInCents += t.AmountInCents
+		} else {
+			s.TotalDebitsInCents += t.AmountInCents
+		}
+
+		s.ClosingBalance = t.BalanceAfterInCents
+	}
+
+	return s
+}
+
+// StatementFile is a rendered statement.
+type StatementFile struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// StatementExport is a statement generated in the background, for periods longer than StatementSyncMaxDays.
+// The file can be downloaded once the export is ready, until it expires.
+type StatementExport struct {
+	ID            int64      `json:"-"`
+	UUID          uuid.UUID  `json:"uuid"`
+	WalletID      int64      `json:"-"`
+	From          time.Time  `json:"from"`
+	To            time.Time  `json:"to"`
+	Format        string     `json:"format"`
+	Status        string     `json:"status"`
+	FailureReason string     `json:"failureReason,omitempty"`
+	Attempts      int        `json:"-"`
+	StartedAt     *time.Time `json:"-"`
+	CompletedAt   *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// NewStatementExport creates a pending export of a wallet's statement.
+func NewStatementExport(walletID int64, from, to time.Time, format string, now time.Time) *StatementExport {
+	return &StatementExport{
+		UUID:      uuid.New(),
+		WalletID:  walletID,
+		From:      from,
+		To:        to,
+		Format:    format,
+		Status:    StatementExportStatusPending,
+		ExpiresAt: now.Add(StatementExportTTL),
+		CreatedAt: now,
+	}
+}
+
+// IsReady reports whether the export's file can be downloaded.
+func (e *StatementExport) IsReady() bool {
+	return e.Status == StatementExportStatusReady
+}
+
+// IsSyncStatementPeriod reports whether a statement of the period is rendered in the request.
+func IsSyncStatementPeriod(from, to time.Time) bool {
+	return to.Sub(from) <= StatementSyncMaxDays*24*time.Hour
+}
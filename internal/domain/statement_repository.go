@@ -0,0 +1,278 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// StatementRepository defines the interface for reading statements and storing statement exports.
+type StatementRepository interface {
+	Build(ctx context.Context, walletID int64, from, to time.Time) (*Statement, common.AppError)
+	CreateExport(ctx context.Context, export *StatementExport) common.AppError
+	FindExport(ctx context.Context, walletID int64, exportUUID string) (*StatementExport, common.AppError)
+	FindExportFile(ctx context.Context, exportID int64) (*StatementFile, common.AppError)
+	ClaimExport(ctx context.Context) (*StatementExport, common.AppError)
+	CompleteExport(ctx context.Context, export *StatementExport, file *StatementFile) common.AppError
+	FailExport(ctx context.Context, export *StatementExport, reason string) common.AppError
+	DeleteExpiredExports(ctx context.Context) (int64, common.AppError)
+}
+
+type statementRepository struct {
+	db *sql.DB
+}
+
+// NewStatementRepository creates a new instance of StatementRepository.
+func NewStatementRepository(db *sql.DB) StatementRepository {
+	return &statementRepository{db: db}
+}
+
+// Build reads the statement of a wallet for the period from a single repeatable read snapshot, so the opening
+// balance, the transactions and the closing balance agree even while funds move. Failed transactions are left out.
+func (r *statementRepository) Build(ctx context.Context, walletID int64, from, to time.Time) (*Statement, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Build Statement")
+
+	var wallet Wallet
+	err = tx.QueryRowContext(ctx, `SELECT id, uuid, currency FROM wallets WHERE id = $1`, walletID).Scan(&wallet.ID, &wallet.UUID, &wallet.Currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewNotFoundError("wallet not found")
+		}
+
+		slog.Error("failed to find statement wallet", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	openingQuery := `SELECT balance_after FROM wallet_transactions
+                     WHERE wallet_id = $1 AND status = $2 AND created_at < $3
+                     ORDER BY created_at DESC, id DESC
+                     LIMIT 1`
+
+	var openingBalance int64
+	err = tx.QueryRowContext(ctx, openingQuery, walletID, TransactionStatusCompleted, from).Scan(&openingBalance)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to find statement opening balance", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	transactions, appErr := listStatementTransactions(ctx, tx, walletID, from, to)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return NewStatement(&wallet, from, to, openingBalance, transactions, time.Now().UTC()), nil
+}
+
+// CreateExport stores a pending export, a worker generates its file later.
+func (r *statementRepository) CreateExport(ctx context.Context, e *StatementExport) common.AppError {
+	query := `INSERT INTO statement_exports (uuid, wallet_id, period_start, period_end, format, status, expires_at, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+              RETURNING id`
+
+	if err := r.db.QueryRowContext(ctx, query, e.UUID, e.WalletID, e.From, e.To, e.Format, e.Status, e.ExpiresAt, e.CreatedAt).Scan(&e.ID); err != nil {
+		slog.Error("failed to create statement export", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// FindExport finds an unexpired export of the wallet, without its file.
+func (r *statementRepository) FindExport(ctx context.Context, walletID int64, exportUUID string) (*StatementExport, common.AppError) {
+	query := `SELECT id, uuid, wallet_id, period_start, period_end, format, status, COALESCE(failure_reason, ''),
+              attempts, started_at, completed_at, expires_at, created_at
+              FROM statement_exports
+              WHERE wallet_id = $1 AND uuid = $2 AND expires_at > CURRENT_TIMESTAMP`
+
+	e, err := scanStatementExport(r.db.QueryRowContext(ctx, query, walletID, exportUUID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewNotFoundError(common.ErrStatementExportNotFound)
+		}
+
+		slog.Error("failed to find statement export", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return e, nil
+}
+
+// FindExportFile reads the generated file of a ready export.
+func (r *statementRepository) FindExportFile(ctx context.Context, exportID int64) (*StatementFile, common.AppError) {
+	query := `SELECT file_name, content_type, content FROM statement_exports WHERE id = $1 AND status = $2`
+
+	var f StatementFile
+	if err := r.db.QueryRowContext(ctx, query, exportID, StatementExportStatusReady).Scan(&f.FileName, &f.ContentType, &f.Content); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewConflictError(common.ErrStatementExportNotReady)
+		}
+
+		slog.Error("failed to read statement export file", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return &f, nil
+}
+
+// ClaimExport marks the oldest pending export as processing and returns it, or nil when there is none.
+// Exports stuck in processing for longer than StatementExportLockTTL are taken over, until StatementExportMaxAttempts.
+// Stuck exports without attempts left, e.g. because the worker crashed on the last one, are failed in the same
+// statement, so clients polling them see a final status. SKIP LOCKED lets several instances claim different
+// exports concurrently, the two updates never touch the same export.
+func (r *statementRepository) ClaimExport(ctx context.Context) (*StatementExport, common.AppError) {
+	query := `WITH abandoned AS (
+                  UPDATE statement_exports
+                  SET status = $5, failure_reason = $6, completed_at = CURRENT_TIMESTAMP
+                  WHERE status = $1 AND started_at < $3 AND attempts >= $4
+              )
+              UPDATE statement_exports
+              SET status = $1, started_at = CURRENT_TIMESTAMP, attempts = attempts + 1
+              WHERE id = (
+                  SELECT id FROM statement_exports
+                  WHERE (status = $2 OR (status = $1 AND started_at < $3)) AND attempts < $4
+                  ORDER BY created_at
+                  LIMIT 1
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING id, uuid, wallet_id, period_start, period_end, format, status, COALESCE(failure_reason, ''),
+                  attempts, started_at, completed_at, expires_at, created_at`
+
+	e, err := scanStatementExport(r.db.QueryRowContext(ctx, query, StatementExportStatusProcessing, StatementExportStatusPending,
+		time.Now().Add(-StatementExportLockTTL), StatementExportMaxAttempts, StatementExportStatusFailed, StatementExportAbandonedReason))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		slog.Error("failed to claim statement export", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return e, nil
+}
+
+// CompleteExport stores the generated file, the export can be downloaded until it expires.
+func (r *statementRepository) CompleteExport(ctx context.Context, e *StatementExport, f *StatementFile) common.AppError {
+	query := `UPDATE statement_exports
+              SET status = $1, file_name = $2, content_type = $3, content = $4, completed_at = CURRENT_TIMESTAMP
+              WHERE id = $5 AND status = $6
+              RETURNING completed_at`
+
+	if err := r.db.QueryRowContext(ctx, query, StatementExportStatusReady, f.FileName, f.ContentType, f.Content,
+		e.ID, StatementExportStatusProcessing).Scan(&e.CompletedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NewConflictError("statement export is no longer processing")
+		}
+
+		slog.Error("failed to complete statement export", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	e.Status = StatementExportStatusReady
+
+	return nil
+}
+
+// FailExport records why an export couldn't be generated.
+func (r *statementRepository) FailExport(ctx context.Context, e *StatementExport, reason string) common.AppError {
+	query := `UPDATE statement_exports SET status = $1, failure_reason = $2, completed_at = CURRENT_TIMESTAMP
+              WHERE id = $3 AND status = $4`
+
+	if _, err := r.db.ExecContext(ctx, query, StatementExportStatusFailed, reason, e.ID, StatementExportStatusProcessing); err != nil {
+		slog.Error("failed to mark statement export as failed", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	e.Status = StatementExportStatusFailed
+	e.FailureReason = reason
+
+	return nil
+}
+
+// DeleteExpiredExports deletes expired exports with their files and returns how many were deleted.
+func (r *statementRepository) DeleteExpiredExports(ctx context.Context) (int64, common.AppError) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM statement_exports WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		slog.Error("failed to delete expired statement exports", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return 0, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return deleted, nil
+}
+
+// listStatementTransactions reads the completed transactions of the period, oldest first.
+func listStatementTransactions(ctx context.Context, tx *sql.Tx, walletID int64, from, to time.Time) ([]Transaction, common.AppError) {
+	query := `SELECT id, uuid, wallet_id, type, direction, status, amount, currency, balance_after, description, created_at
+              FROM wallet_transactions
+              WHERE wallet_id = $1 AND status = $2 AND created_at >= $3 AND created_at < $4
+              ORDER BY created_at, id`
+
+	rows, err := tx.QueryContext(ctx, query, walletID, TransactionStatusCompleted, from, to)
+	if err != nil {
+		slog.Error("failed to list statement transactions", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	var transactions []Transaction
+
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.UUID, &t.WalletID, &t.Type, &t.Direction, &t.Status,
+			&t.AmountInCents, &t.Currency, &t.BalanceAfterInCents, &t.Description, &t.CreatedAt); err != nil {
+			slog.Error("failed to scan statement transaction", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return transactions, nil
+}
+
+func scanStatementExport(row *sql.Row) (*StatementExport, error) {
+	var e StatementExport
+	var startedAt, completedAt sql.NullTime
+
+	err := row.Scan(&e.ID, &e.UUID, &e.WalletID, &e.From, &e.To, &e.Format, &e.Status, &e.FailureReason,
+		&e.Attempts, &startedAt, &completedAt, &e.ExpiresAt, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if startedAt.Valid {
+		e.StartedAt = &startedAt.Time
+	}
+
+	if completedAt.Valid {
+		e.CompletedAt = &completedAt.Time
+	}
+
+	return &e, nil
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStatement(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	wallet := &Wallet{UUID: uuid.New(), Currency: "EUR"}
+
+	transactions := []Transaction{
+		{Direction: PostingDirectionCredit, AmountInCents: 5000, BalanceAfterInCents: 6000},
+		{Direction: PostingDirectionDebit, AmountInCents: 1500, BalanceAfterInCents: 4500},
+		{Direction: PostingDirectionCredit, AmountInCents: 250, BalanceAfterInCents: 4750},
+	}
+
+	s := NewStatement(wallet, from, from.AddDate(0, 1, 0), 1000, transactions, from)
+
+	assert.Equal(t, wallet.UUID, s.WalletUUID)
+	assert.Equal(t, "EUR", s.Currency)
+	assert.Equal(t, int64(1000), s.OpeningBalance)
+	assert.Equal(t, int64(4750), s.ClosingBalance)
+	assert.Equal(t, int64(5250), s.TotalCreditsInCents)
+	assert.Equal(t, int64(1500), s.TotalDebitsInCents)
+	assert.Equal(t, s.OpeningBalance+s.TotalCreditsInCents-s.TotalDebitsInCents, s.ClosingBalance)
+}
+
+func TestNewStatementWithoutTransactions(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	s := NewStatement(&Wallet{UUID: uuid.New(), Currency: "USD"}, from, from.AddDate(0, 0, 1), 1234, nil, from)
+
+	assert.Equal(t, int64(1234), s.ClosingBalance, "closing balance must equal opening balance")
+	assert.Zero(t, s.TotalCreditsInCents)
+	assert.Zero(t, s.TotalDebitsInCents)
+}
+
+func TestIsSyncStatementPeriod(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, IsSyncStatementPeriod(from, from.AddDate(0, 0, 1)))
+	assert.True(t, IsSyncStatementPeriod(from, from.AddDate(0, 0, StatementSyncMaxDays)))
+	assert.False(t, IsSyncStatementPeriod(from, from.AddDate(0, 0, StatementSyncMaxDays+1)))
+}
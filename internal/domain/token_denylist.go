@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+)
+
+// denylistSyncOverlap re-reads revocations this far behind the newest one seen,
+// so a revocation committed slightly out of order with its revoked_at timestamp isn't missed.
+const denylistSyncOverlap = 1 * time.Minute
+
+// TokenDenylist answers whether an access token was revoked without a database round trip per request.
+// Revocations are written to Postgres first and then cached in memory. Sync pulls revocations made by
+// other instances, or before a restart, into the cache. Entries are dropped once their token expired.
+type TokenDenylist struct {
+	repo RevokedTokenRepository
+
+	mu        sync.RWMutex
+	entries   map[uuid.UUID]time.Time
+	watermark time.Time
+}
+
+// NewTokenDenylist creates an empty TokenDenylist, call Sync to load the persisted revocations.
+func NewTokenDenylist(repo RevokedTokenRepository) *TokenDenylist {
+	return &TokenDenylist{
+		repo:    repo,
+		entries: make(map[uuid.UUID]time.Time),
+	}
+}
+
+// Revoke persists the revocation of an access token and adds it to the cache.
+func (d *TokenDenylist) Revoke(ctx context.Context, jti uuid.UUID, userID int64, expiresAt time.Time) common.AppError {
+	token := &RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt}
+	if appErr := d.repo.Create(ctx, token); appErr != nil {
+		return appErr
+	}
+
+	d.mu.Lock()
+	d.entries[jti] = expiresAt
+	d.mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked reports whether the token with this jti was revoked and hasn't expired yet.
+func (d *TokenDenylist) IsRevoked(jti uuid.UUID) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	expiresAt, ok := d.entries[jti]
+
+	return ok && time.Now().Before(expiresAt)
+}
+
+// Sync loads revocations newer than the last sync into the cache and evicts expired entries.
+func (d *TokenDenylist) Sync(ctx context.Context) common.AppError {
+	d.mu.RLock()
+	since := d.watermark
+	d.mu.RUnlock()
+
+	if !since.IsZero() {
+		since = since.Add(-denylistSyncOverlap)
+	}
+
+	tokens, appErr := d.repo.FindRevokedSince(ctx, since)
+	if appErr != nil {
+		return appErr
+	}
+
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, t := range tokens {
+		d.entries[t.JTI] = t.ExpiresAt
+		if t.RevokedAt.After(d.watermark) {
+			d.watermark = t.RevokedAt
+		}
+	}
+
+	for jti, expiresAt := range d.entries {
+		if !now.Before(expiresAt) {
+			delete(d.entries, jti)
+		}
+	}
+
+	return nil
+}
+
+// Run syncs the cache and purges expired revocations from the database every interval until ctx is done.
+func (d *TokenDenylist) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.syncAndPurge(ctx)
+		}
+	}
+}
+
+func (d *TokenDenylist) syncAndPurge(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, common.Timeouts.Auth.Write)
+	defer cancel()
+
+	if appErr := d.Sync(ctx); appErr != nil {
+		slog.Error("failed to sync token denylist", "err", appErr.Error())
+	}
+
+	if _, appErr := d.repo.DeleteExpired(ctx); appErr != nil {
+		slog.Error("failed to purge expired revoked tokens", "err", appErr.Error())
+	}
+}
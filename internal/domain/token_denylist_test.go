@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRevokedTokenRepo stores revocations in memory and records the since argument of each sync.
+type memoryRevokedTokenRepo struct {
+	tokens []RevokedToken
+	since  []time.Time
+}
+
+func (r *memoryRevokedTokenRepo) Create(_ context.Context, t *RevokedToken) common.AppError {
+	t.RevokedAt = time.Now()
+	r.tokens = append(r.tokens, *t)
+	return nil
+}
+
+func (r *memoryRevokedTokenRepo) FindRevokedSince(_ context.Context, since time.Time) ([]RevokedToken, common.AppError) {
+	r.since = append(r.since, since)
+
+	var tokens []RevokedToken
+	for _, t := range r.tokens {
+		if t.RevokedAt.After(since) {
+			tokens = append(tokens, t)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (r *memoryRevokedTokenRepo) DeleteExpired(_ context.Context) (int64, common.AppError) {
+	return 0, nil
+}
+
+func TestTokenDenylistRevoke(t *testing.T) {
+	repo := &memoryRevokedTokenRepo{}
+	denylist := NewTokenDenylist(repo)
+
+	jti := uuid.New()
+	require.Nil(t, denylist.Revoke(context.Background(), jti, 1, time.Now().Add(time.Hour)))
+
+	assert.True(t, denylist.IsRevoked(jti))
+	assert.False(t, denylist.IsRevoked(uuid.New()))
+	assert.Len(t, repo.tokens, 1, "revocation must be persisted")
+}
+
+func TestTokenDenylistSyncLoadsPersistedRevocations(t *testing.T) {
+	now := time.Now()
+	revokedElsewhere, expired := uuid.New(), uuid.New()
+
+	repo := &memoryRevokedTokenRepo{tokens: []RevokedToken{
+		{JTI: revokedElsewhere, ExpiresAt: now.Add(time.Hour), RevokedAt: now.Add(-time.Minute)},
+		{JTI: expired, ExpiresAt: now.Add(-time.Second), RevokedAt: now.Add(-time.Hour)},
+	}}
+
+	denylist := NewTokenDenylist(repo)
+	require.Nil(t, denylist.Sync(context.Background()))
+
+	assert.True(t, denylist.IsRevoked(revokedElsewhere))
+	assert.False(t, denylist.IsRevoked(expired), "expired tokens are rejected by their exp claim already")
+
+	require.Nil(t, denylist.Sync(context.Background()))
+	assert.True(t, repo.since[0].IsZero(), "first sync loads everything")
+	assert.Equal(t, now.Add(-time.Minute).Add(-denylistSyncOverlap), repo.since[1], "later syncs start at the watermark minus the overlap")
+}
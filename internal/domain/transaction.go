@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TransactionStatusCompleted = "completed"
+	TransactionStatusFailed    = "failed"
+
+	TransactionHistoryDefaultLimit = 20
+	TransactionHistoryMaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Transaction is one line of a wallet's history. Completed transactions are written together with the ledger
+// posting that moved the wallet's funds and have the type of its journal entry, failed ones record attempts
+// that didn't move funds, e.g. declined deposits.
+// BalanceAfterInCents is the wallet balance right after the transaction, unchanged for failed transactions.
+type Transaction struct {
+	ID                  int64     `json:"-"`
+	UUID                uuid.UUID `json:"uuid"`
+	WalletID            int64     `json:"-"`
+	JournalEntryID      *int64    `json:"-"`
+	PostingID           *int64    `json:"-"`
+	Type                string    `json:"type"`
+	Direction           string    `json:"direction"`
+	Status              string    `json:"status"`
+	AmountInCents       int64     `json:"amountInCents"`
+	Currency            string    `json:"currency"`
+	BalanceAfterInCents int64     `json:"balanceAfterInCents"`
+	Description         string    `json:"description"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
+
+// TransactionFilters defines the filters for a page of a wallet's history, Used in List().
+// From is inclusive and To is exclusive, amounts are compared in the wallet currency's minor unit.
+type TransactionFilters struct {
+	WalletID  int64
+	Type      *string
+	Status    *string
+	From      *time.Time
+	To        *time.Time
+	MinAmount *int64
+	MaxAmount *int64
+	After     *TransactionCursor
+	Limit     int
+}
+
+// TransactionPage is a page of a wallet's history, newest first. NextCursor is empty on the last page.
+type TransactionPage struct {
+	Transactions []Transaction
+	NextCursor   string
+}
+
+// TransactionCursor is the position of the last transaction of a page, the next page starts right after it.
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// CursorAfter returns the cursor of a transaction, pointing to the transaction that follows it.
+func CursorAfter(t *Transaction) *TransactionCursor {
+	return &TransactionCursor{CreatedAt: t.CreatedAt, ID: t.ID}
+}
+
+// Encode returns the cursor as an opaque URL-safe string.
+// Postgres stores timestamps with microsecond precision, so the position survives the round trip.
+func (c *TransactionCursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeTransactionCursor parses a cursor returned by Encode.
+func DecodeTransactionCursor(value string) (*TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	transactionID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || transactionID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &TransactionCursor{CreatedAt: time.UnixMicro(createdAt).UTC(), ID: transactionID}, nil
+}
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// TransactionRepository defines the interface for reading wallet transaction history.
+// Transactions are written by the repositories that move funds, inside their own transactions.
+type TransactionRepository interface {
+	List(ctx context.Context, filters TransactionFilters) (*TransactionPage, common.AppError)
+}
+
+type transactionRepository struct {
+	db *sql.DB
+}
+
+// NewTransactionRepository creates a new instance of TransactionRepository.
+func NewTransactionRepository(db *sql.DB) TransactionRepository {
+	return &transactionRepository{db: db}
+}
+
+// List returns a page of a wallet's transactions matching the filters, newest first. Pages are read with
+// keyset pagination on (created_at, id), so a page costs the same on the millionth row as on the first.
+func (r *transactionRepository) List(ctx context.Context, filters TransactionFilters) (*TransactionPage, common.AppError) {
+	query, args := buildTransactionListQuery(filters)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Error("failed to list transactions", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+	defer rows.Close()
+
+	transactions := make([]Transaction, 0, filters.Limit+1)
+
+	for rows.Next() {
+		var t Transaction
+		var journalEntryID, postingID sql.NullInt64
+
+		if err := rows.Scan(&t.ID, &t.UUID, &t.WalletID, &journalEntryID, &postingID, &t.Type, &t.Direction, &t.Status,
+			&t.AmountInCents, &t.Currency, &t.BalanceAfterInCents, &t.Description, &t.CreatedAt); err != nil {
+			slog.Error("failed to scan transaction", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		if journalEntryID.Valid {
+			t.JournalEntryID = &journalEntryID.Int64
+		}
+
+		if postingID.Valid {
+			t.PostingID = &postingID.Int64
+		}
+
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	page := &TransactionPage{Transactions: transactions}
+
+	// one row more than the limit was read to find out whether there is a next page
+	if len(transactions) > filters.Limit {
+		page.Transactions = transactions[:filters.Limit]
+		page.NextCursor = CursorAfter(&page.Transactions[filters.Limit-1]).Encode()
+	}
+
+	return page, nil
+}
+
+// buildTransactionListQuery constructs the SQL query and arguments for a page of transactions.
+// The wallet_id and created_at, id order matches the history indexes, amount filters are checked while walking them.
+func buildTransactionListQuery(filters TransactionFilters) (string, []any) {
+	query := `SELECT id, uuid, wallet_id, journal_entry_id, posting_id, type, direction, status,
+              amount, currency, balance_after, description, created_at
+              FROM wallet_transactions
+              WHERE wallet_id = $1`
+	args := []any{filters.WalletID}
+
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+
+	if filters.Type != nil {
+		addCondition("type = $%d", *filters.Type)
+	}
+
+	if filters.Status != nil {
+		addCondition("status = $%d", *filters.Status)
+	}
+
+	if filters.From != nil {
+		addCondition("created_at >= $%d", *filters.From)
+	}
+
+	if filters.To != nil {
+		addCondition("created_at < $%d", *filters.To)
+	}
+
+	if filters.MinAmount != nil {
+		addCondition("amount >= $%d", *filters.MinAmount)
+	}
+
+	if filters.MaxAmount != nil {
+		addCondition("amount <= $%d", *filters.MaxAmount)
+	}
+
+	if filters.After != nil {
+		args = append(args, filters.After.CreatedAt, filters.After.ID)
+		query += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	args = append(args, filters.Limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	return query, args
+}
+
+// recordPostedTransactions writes a completed transaction for every wallet posting of a posted entry.
+// balances holds the balance of each wallet before the entry, it is carried through the entry's postings.
+// created_at is the clock time of the insert, which happens while the wallets are locked,
+// so the history order of a wallet is the order its balance changed in.
+func recordPostedTransactions(ctx context.Context, tx *sql.Tx, entry *JournalEntry, balances map[int64]int64) common.AppError {
+	query := `INSERT INTO wallet_transactions (wallet_id, journal_entry_id, posting_id, type, direction, status,
+                  amount, currency, balance_after, description)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	for i := range entry.Postings {
+		p := &entry.Postings[i]
+		if p.WalletID == nil {
+			continue
+		}
+
+		balances[*p.WalletID] += p.walletDelta()
+
+		if _, err := tx.ExecContext(ctx, query, *p.WalletID, entry.ID, p.ID, entry.Type, p.Direction, TransactionStatusCompleted,
+			p.AmountInCents, p.Currency, balances[*p.WalletID], entry.Description); err != nil {
+			slog.Error("failed to record wallet transaction", "err", err)
+			return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+	}
+
+	return nil
+}
+
+// recordFailedTransaction writes a failed transaction for an attempt that didn't move funds, with the wallet's
+// current balance. The wallet is share locked, so no posting can change the balance before the insert.
+func recordFailedTransaction(ctx context.Context, tx *sql.Tx, t *Transaction) common.AppError {
+	if err := tx.QueryRowContext(ctx, `SELECT balance FROM wallets WHERE id = $1 FOR SHARE`, t.WalletID).Scan(&t.BalanceAfterInCents); err != nil {
+		slog.Error("failed to read wallet balance", "walletID", t.WalletID, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	query := `INSERT INTO wallet_transactions (wallet_id, type, direction, status, amount, currency, balance_after, description)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+              RETURNING id, uuid, created_at`
+
+	t.Status = TransactionStatusFailed
+
+	if err := tx.QueryRowContext(ctx, query, t.WalletID, t.Type, t.Direction, t.Status,
+		t.AmountInCents, t.Currency, t.BalanceAfterInCents, t.Description).Scan(&t.ID, &t.UUID, &t.CreatedAt); err != nil {
+		slog.Error("failed to record failed wallet transaction", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
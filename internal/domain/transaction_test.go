@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 7, 1, 12, 30, 45, 123456000, time.UTC)
+	cursor := CursorAfter(&Transaction{ID: 42, CreatedAt: createdAt})
+
+	decoded, err := DecodeTransactionCursor(cursor.Encode())
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(42), decoded.ID)
+	assert.True(t, createdAt.Equal(decoded.CreatedAt))
+}
+
+func TestDecodeTransactionCursorRejectsMalformedCursors(t *testing.T) {
+	for _, value := range []string{"", "not base64!", "MTIz", "YWJjOjQy", "MTIzOmFiYw", "MTIzOjA"} {
+		_, err := DecodeTransactionCursor(value)
+		assert.ErrorIs(t, err, ErrInvalidCursor, "cursor: %q", value)
+	}
+}
+
+func TestBuildTransactionListQuery(t *testing.T) {
+	transactionType, status := JournalEntryTypeDeposit, TransactionStatusFailed
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	minAmount := int64(500)
+	after := &TransactionCursor{CreatedAt: from.Add(time.Hour), ID: 7}
+
+	query, args := buildTransactionListQuery(TransactionFilters{
+		WalletID:  3,
+		Type:      &transactionType,
+		Status:    &status,
+		From:      &from,
+		MinAmount: &minAmount,
+		After:     after,
+		Limit:     20,
+	})
+
+	assert.Contains(t, query, "wallet_id = $1 AND type = $2 AND status = $3 AND created_at >= $4 AND amount >= $5")
+	assert.Contains(t, query, "AND (created_at, id) < ($6, $7)")
+	assert.True(t, strings.HasSuffix(query, "ORDER BY created_at DESC, id DESC LIMIT $8"))
+	assert.Equal(t, []any{int64(3), transactionType, status, from, minAmount, after.CreatedAt, after.ID, 21}, args)
+}
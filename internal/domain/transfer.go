@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TransferStatusCompleted = "completed"
+
+	JournalEntryTypeTransfer = "transfer"
+)
+
+// Transfer moves funds from one wallet to another wallet of the same currency.
+// Each transfer is backed by exactly one journal entry in the ledger.
+type Transfer struct {
+	ID                  int64     `json:"-"`
+	UUID                uuid.UUID `json:"uuid"`
+	SenderWalletID      int64     `json:"-"`
+	SenderWalletUUID    uuid.UUID `json:"senderWalletUuid"`
+	RecipientWalletID   int64     `json:"-"`
+	RecipientWalletUUID uuid.UUID `json:"recipientWalletUuid"`
+	InitiatedBy         int64     `json:"-"`
+	JournalEntryID      int64     `json:"-"`
+	AmountInCents       int64     `json:"amountInCents"`
+	Currency            string    `json:"currency"`
+	Status              string    `json:"status"`
+	Note                string    `json:"note"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+)
+
+// TransferRepository defines the interface for wallet to wallet transfer operations.
+type TransferRepository interface {
+	Create(ctx context.Context, transfer *Transfer) (*Transfer, common.AppError)
+}
+
+type transferRepository struct {
+	db *sql.DB
+}
+
+// NewTransferRepository creates a new instance of TransferRepository.
+func NewTransferRepository(db *sql.DB) TransferRepository {
+	return &transferRepository{db: db}
+}
+
+// Create debits the sender wallet, credits the recipient wallet and records the transfer.
+// It uses a serializable transaction and locks both wallets, so status checks, the ledger entry,
+// the balance updates, the transfer record and its transfer.completed event are atomic.
+// The wallets.balance >= 0 check rejects overdrafts.
+func (r *transferRepository) Create(ctx context.Context, t *Transfer) (*Transfer, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Create Transfer")
+
+	wallets, appErr := r.lockTransferWallets(ctx, tx, t)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	entry := NewJournalEntry(JournalEntryTypeTransfer, fmt.Sprintf("Transfer %s", t.UUID),
+		NewWalletPosting(t.SenderWalletID, PostingDirectionDebit, t.AmountInCents, t.Currency),
+		NewWalletPosting(t.RecipientWalletID, PostingDirectionCredit, t.AmountInCents, t.Currency),
+	)
+
+	if appErr := postJournalEntry(ctx, tx, entry); appErr != nil {
+		return nil, appErr
+	}
+
+	t.JournalEntryID = entry.ID
+
+	query := `INSERT INTO transfers (uuid, sender_wallet_id, recipient_wallet_id, initiated_by, journal_entry_id, amount, currency, status, note)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+              RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, query,
+		t.UUID, t.SenderWalletID, t.RecipientWalletID, t.InitiatedBy, t.JournalEntryID, t.AmountInCents, t.Currency, t.Status, t.Note).
+		Scan(&t.ID, &t.CreatedAt)
+
+	if err != nil {
+		slog.Error("failed to create transfer", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	event, err := NewEvent(ctx, EventTransferCompleted, AggregateTypeTransfer, t.UUID, t, time.Now().UTC())
+	if err != nil {
+		slog.Error("failed to create transfer completed event", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedEvent, err)
+	}
+
+	// both parties are notified, once when they own both wallets
+	if appErr := recordEvent(ctx, tx, event, wallets[t.SenderWalletID].UserID, wallets[t.RecipientWalletID].UserID); appErr != nil {
+		return nil, appErr
+	}
+
+	if err = tx.Commit(); err != nil {
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return t, nil
+}
+
+// lockTransferWallets locks the sender and recipient wallets in id order, and verifies
+// that both are active and hold the transfer currency. It returns the locked wallets by id.
+func (r *transferRepository) lockTransferWallets(ctx context.Context, tx *sql.Tx, t *Transfer) (map[int64]*Wallet, common.AppError) {
+	if t.SenderWalletID == t.RecipientWalletID {
+		return nil, common.NewBadRequestError("cannot transfer to the same wallet")
+	}
+
+	wallets, appErr := lockWalletPair(ctx, tx, t.SenderWalletID, t.RecipientWalletID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	sender, ok := wallets[t.SenderWalletID]
+	if !ok {
+		return nil, common.NewNotFoundError("sender wallet not found")
+	}
+
+	recipient, ok := wallets[t.RecipientWalletID]
+	if !ok {
+		return nil, common.NewNotFoundError("recipient wallet not found")
+	}
+
+	if sender.Status != WalletStatusActive {
+		return nil, common.NewUnprocessableEntityError(fmt.Sprintf("sender wallet is %s", sender.Status))
+	}
+
+	if recipient.Status != WalletStatusActive {
+		return nil, common.NewUnprocessableEntityError(fmt.Sprintf("recipient wallet is %s", recipient.Status))
+	}
+
+	if sender.Currency != t.Currency || recipient.Currency != t.Currency {
+		return nil, common.NewUnprocessableEntityError("sender and recipient wallets must hold the transfer currency")
+	}
+
+	t.SenderWalletUUID = sender.UUID
+	t.RecipientWalletUUID = recipient.UUID
+
+	return wallets, nil
+}
@@ -27,6 +27,36 @@ type User struct {
 	Role         string    `json:"role"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
+
+	// CreatedBy is the admin or agent who onboarded the user, nil for self registered users
+	CreatedBy *int64 `json:"-"`
+
+	// EmailVerifiedAt is set once the user proved they own the email address, unverified users can't create wallets
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+
+	// SessionsRevokedAt invalidates every access token issued before it, see IsSessionRevoked
+	SessionsRevokedAt *time.Time `json:"-"`
+
+	// FailedLoginAttempts counts consecutive failed logins, logins are rejected until LoginLockedUntil
+	FailedLoginAttempts int        `json:"-"`
+	LoginLockedUntil    *time.Time `json:"-"`
+}
+
+// IsEmailVerified reports whether the user verified their email address.
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsSessionRevoked reports whether a token issued at issuedAt was revoked with all of the user's sessions.
+// Token iat claims have second precision, so the revocation time is truncated to the second as well: a token
+// issued in the same second as the revocation stays valid, otherwise tokens issued right after it would be rejected.
+func (u *User) IsSessionRevoked(issuedAt time.Time) bool {
+	return u.SessionsRevokedAt != nil && issuedAt.Before(u.SessionsRevokedAt.Truncate(time.Second))
+}
+
+// IsLoginLocked reports whether logins to the account are blocked at the given time.
+func (u *User) IsLoginLocked(now time.Time) bool {
+	return u.LoginLockedUntil != nil && now.Before(*u.LoginLockedUntil)
 }
 
 // IsValidUserRole checks if the provided role is valid.
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/ashtishad/xpay/internal/common"
 )
@@ -15,6 +16,10 @@ type UserRepository interface {
 	FindIDFromUUID(ctx context.Context, uuid string) (int64, common.AppError)
 	Create(ctx context.Context, user *User) (*User, common.AppError)
 	FindBy(ctx context.Context, dbColumnName string, value any) (*User, common.AppError)
+	RevokeAllSessions(ctx context.Context, userID int64) common.AppError
+	RecordLoginFailure(ctx context.Context, userID int64) (*time.Time, common.AppError)
+	ResetLoginFailures(ctx context.Context, userID int64) (*time.Time, common.AppError)
+	FindLoginLock(ctx context.Context, userID int64) (*time.Time, common.AppError)
 }
 
 type userRepository struct {
@@ -95,13 +100,13 @@ func (r *userRepository) checkUserExistsByEmail(ctx context.Context, tx *sql.Tx,
 // insertUser performs the actual user insertion within the Create transaction.
 // Returns the new user's ID or InternalServerError on failure.
 func (r *userRepository) insertUser(ctx context.Context, tx *sql.Tx, u *User) (int64, common.AppError) {
-	queryCreateUser := `INSERT INTO users (uuid, full_name, email, password_hash, status, role, created_at, updated_at)
-                        VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+	queryCreateUser := `INSERT INTO users (uuid, full_name, email, password_hash, status, role, created_at, updated_at, created_by)
+                        VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
                         RETURNING id`
 
 	var createdID int64
 	err := tx.QueryRowContext(ctx, queryCreateUser,
-		u.UUID, u.FullName, u.Email, u.PasswordHash, u.Status, u.Role, u.CreatedAt, u.UpdatedAt).Scan(&createdID)
+		u.UUID, u.FullName, u.Email, u.PasswordHash, u.Status, u.Role, u.CreatedAt, u.UpdatedAt, u.CreatedBy).Scan(&createdID)
 
 	if err != nil {
 		slog.Error("failed to create user", "err", err)
@@ -120,9 +125,12 @@ func (r *userRepository) FindBy(ctx context.Context, dbColumnName string, value
 	}
 
 	var user User
+	var sessionsRevokedAt, loginLockedUntil, emailVerifiedAt sql.NullTime
+	var createdBy sql.NullInt64
 	err = r.db.QueryRowContext(ctx, query, value).Scan(
 		&user.ID, &user.UUID, &user.FullName, &user.Email, &user.PasswordHash,
-		&user.Status, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+		&user.Status, &user.Role, &user.CreatedAt, &user.UpdatedAt, &sessionsRevokedAt,
+		&user.FailedLoginAttempts, &loginLockedUntil, &emailVerifiedAt, &createdBy)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -133,13 +141,191 @@ func (r *userRepository) FindBy(ctx context.Context, dbColumnName string, value
 		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
+	if sessionsRevokedAt.Valid {
+		user.SessionsRevokedAt = &sessionsRevokedAt.Time
+	}
+
+	if loginLockedUntil.Valid {
+		user.LoginLockedUntil = &loginLockedUntil.Time
+	}
+
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+
+	if createdBy.Valid {
+		user.CreatedBy = &createdBy.Int64
+	}
+
 	return &user, nil
 }
 
+// RevokeAllSessions logs a user out everywhere. It invalidates every access token issued so far
+// and revokes all refresh tokens of the user in one transaction.
+func (r *userRepository) RevokeAllSessions(ctx context.Context, userID int64) common.AppError {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Revoke All Sessions")
+
+	result, err := tx.ExecContext(ctx, `UPDATE users SET sessions_revoked_at = CURRENT_TIMESTAMP WHERE id = $1`, userID)
+	if err != nil {
+		slog.Error("failed to revoke user sessions", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("failed to get rows affected", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if rowsAffected == 0 {
+		return common.NewNotFoundError("user not found")
+	}
+
+	queryRevokeRefreshTokens := `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err = tx.ExecContext(ctx, queryRevokeRefreshTokens, userID); err != nil {
+		slog.Error("failed to revoke user refresh tokens", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil
+}
+
+// RecordLoginFailure counts a failed login and locks the account according to LoginLockout.
+// Credentials are verified before the failure is recorded, so concurrent attempts can all pass the lockout check
+// of the login. The user's row is locked while the lockout is checked again and the failure counted: failures
+// recorded after a lockout started aren't counted and get the lockout, so concurrent guesses can't outrun it.
+// It returns the time the account is locked until, by this failure or an earlier one, or nil if it isn't locked.
+func (r *userRepository) RecordLoginFailure(ctx context.Context, userID int64) (*time.Time, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Record Login Failure")
+
+	failedAttempts, lockedUntil, appErr := lockLoginState(ctx, tx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if lockedUntil != nil {
+		return lockedUntil, nil
+	}
+
+	failedAttempts++
+	if lockout := LoginLockout(failedAttempts); lockout > 0 {
+		until := time.Now().Add(lockout)
+		lockedUntil = &until
+	}
+
+	query := `UPDATE users SET failed_login_attempts = $2, login_locked_until = COALESCE($3, login_locked_until) WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, query, userID, failedAttempts, lockedUntil); err != nil {
+		slog.Error("failed to record login failure", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return lockedUntil, nil
+}
+
+// ResetLoginFailures clears the failed login counter and lockout after a successful login. Like RecordLoginFailure
+// it checks the lockout again with the user's row locked: if concurrent failed logins locked the account while the
+// credentials were verified, nothing is reset and the time the account is locked until is returned.
+func (r *userRepository) ResetLoginFailures(ctx context.Context, userID int64) (*time.Time, common.AppError) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error(common.ErrTXBegin, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer rollBackOnError(tx, "Reset Login Failures")
+
+	failedAttempts, lockedUntil, appErr := lockLoginState(ctx, tx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if lockedUntil != nil {
+		return lockedUntil, nil
+	}
+
+	if failedAttempts > 0 {
+		query := `UPDATE users SET failed_login_attempts = 0, login_locked_until = NULL WHERE id = $1`
+		if _, err = tx.ExecContext(ctx, query, userID); err != nil {
+			slog.Error("failed to reset login failures", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(common.ErrTxCommit, "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return nil, nil
+}
+
+// FindLoginLock returns the time the account is locked until, or nil if it isn't locked.
+func (r *userRepository) FindLoginLock(ctx context.Context, userID int64) (*time.Time, common.AppError) {
+	var lockedUntil sql.NullTime
+
+	query := `SELECT login_locked_until FROM users WHERE id = $1 AND login_locked_until > CURRENT_TIMESTAMP`
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&lockedUntil); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		slog.Error("failed to find login lock", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return &lockedUntil.Time, nil
+}
+
+// lockLoginState locks the user's row for the rest of the transaction and returns the failed login counter
+// and the time the account is locked until, nil if the lockout is over.
+func lockLoginState(ctx context.Context, tx *sql.Tx, userID int64) (int, *time.Time, common.AppError) {
+	var failedAttempts int
+	var lockedUntil sql.NullTime
+
+	query := `SELECT failed_login_attempts, login_locked_until FROM users WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, query, userID).Scan(&failedAttempts, &lockedUntil); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, common.NewNotFoundError("user not found")
+		}
+
+		slog.Error("failed to lock user login state", "err", err)
+		return 0, nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if lockedUntil.Valid && lockedUntil.Time.After(time.Now()) {
+		return failedAttempts, &lockedUntil.Time, nil
+	}
+
+	return failedAttempts, nil, nil
+}
+
 // generateFindByQuery creates SQL query for FindBy method, supporting id, uuid, and email fields.
 // Returns the query string or an error for invalid db field.
 func generateFindByQuery(fieldName string) (string, error) {
-	baseQuery := `SELECT id, uuid, full_name, email, password_hash, status, role, created_at, updated_at
+	baseQuery := `SELECT id, uuid, full_name, email, password_hash, status, role, created_at, updated_at, sessions_revoked_at,
+                  failed_login_attempts, login_locked_until, email_verified_at, created_by
                   FROM users WHERE `
 
 	var condition string
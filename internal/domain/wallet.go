@@ -12,15 +12,31 @@ const (
 	WalletStatusBlocked  = "blocked"
 
 	WalletCurrencyUSD = "USD"
+	WalletCurrencyEUR = "EUR"
+	WalletCurrencyGBP = "GBP"
+	WalletCurrencyBDT = "BDT"
+	WalletCurrencyINR = "INR"
+	WalletCurrencySGD = "SGD"
+	WalletCurrencyAED = "AED"
+	WalletCurrencyJPY = "JPY"
+	WalletCurrencyKWD = "KWD"
 )
 
+// Wallet holds a balance in one currency, BalanceInCents is in the currency's minor unit, see CurrencyExponent.
+// HeldInCents is the part of the balance on hold for authorized payment intents, it can't be spent.
 type Wallet struct {
 	ID             int64     `json:"-"`
 	UUID           uuid.UUID `json:"uuid"`
 	UserID         int64     `json:"-"`
 	BalanceInCents int64     `json:"balanceInCents"`
+	HeldInCents    int64     `json:"heldInCents"`
 	Currency       string    `json:"currency"`
 	Status         string    `json:"status"`
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt"`
 }
+
+// AvailableInCents is the balance that can be spent, the balance without held funds.
+func (w *Wallet) AvailableInCents() int64 {
+	return w.BalanceInCents - w.HeldInCents
+}
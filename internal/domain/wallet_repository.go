@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/ashtishad/xpay/internal/common"
 )
@@ -16,6 +17,7 @@ type WalletRepository interface {
 	Create(ctx context.Context, wallet *Wallet) (*Wallet, common.AppError)
 	UpdateStatus(ctx context.Context, walletUUID string, status string) common.AppError
 	FindBy(ctx context.Context, dbColumnName string, value any) (*Wallet, common.AppError)
+	FindByUserAndCurrency(ctx context.Context, userID int64, currency string) (*Wallet, common.AppError)
 	GetBalance(ctx context.Context, walletUUID string) (int64, common.AppError)
 }
 
@@ -78,6 +80,7 @@ func (r *walletRepository) Create(ctx context.Context, wallet *Wallet) (*Wallet,
 
 // UpdateStatus changes the status of a wallet.
 // It uses a serializable transaction to ensure atomic updates and prevent conflicts.
+// An actual change records a wallet.status_changed event in the same transaction.
 // The method returns a NotFoundError if the wallet doesn't exist.
 func (r *walletRepository) UpdateStatus(ctx context.Context, walletUUID string, status string) common.AppError {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
@@ -88,26 +91,51 @@ func (r *walletRepository) UpdateStatus(ctx context.Context, walletUUID string,
 
 	defer rollBackOnError(tx, "Update Wallet Status")
 
-	query := `UPDATE wallets SET status = $1 WHERE uuid = $2`
-
-	result, err := tx.ExecContext(ctx, query, status, walletUUID)
+	var w Wallet
+	err = tx.QueryRowContext(ctx, `SELECT id, uuid, user_id, currency, status FROM wallets WHERE uuid = $1 FOR UPDATE`, walletUUID).
+		Scan(&w.ID, &w.UUID, &w.UserID, &w.Currency, &w.Status)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NewNotFoundError("wallet not found")
+		}
+
+		if isSerializationFailure(err) {
+			return common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error("failed to lock wallet", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	if w.Status == status {
+		return nil
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE wallets SET status = $1 WHERE id = $2`, status, w.ID); err != nil {
 		slog.Error("failed to update wallet status", "err", err)
 		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	event, err := NewEvent(ctx, EventWalletStatusChanged, AggregateTypeWallet, w.UUID, WalletStatusChangedData{
+		WalletUUID:     w.UUID,
+		Currency:       w.Currency,
+		PreviousStatus: w.Status,
+		Status:         status,
+	}, time.Now().UTC())
 	if err != nil {
-		slog.Error("failed to get rows affected", "err", err)
-		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		slog.Error("failed to create wallet status event", "err", err)
+		return common.NewInternalServerError(common.ErrUnexpectedEvent, err)
 	}
 
-	if rowsAffected == 0 {
-		slog.Warn("row affected is zero")
-		return common.NewNotFoundError("wallet not found")
+	if appErr := recordEvent(ctx, tx, event, w.UserID); appErr != nil {
+		return appErr
 	}
 
 	if err = tx.Commit(); err != nil {
+		if isSerializationFailure(err) {
+			return common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
 		slog.Error(common.ErrTxCommit, "err", err)
 		return common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
 	}
@@ -135,7 +163,7 @@ func (r *walletRepository) FindBy(ctx context.Context, dbColumnName string, valu
 
 	var wallet Wallet
 	err = tx.QueryRowContext(ctx, query, value).Scan(
-		&wallet.ID, &wallet.UUID, &wallet.UserID, &wallet.BalanceInCents, &wallet.Currency,
+		&wallet.ID, &wallet.UUID, &wallet.UserID, &wallet.BalanceInCents, &wallet.HeldInCents, &wallet.Currency,
 		&wallet.Status, &wallet.CreatedAt, &wallet.UpdatedAt)
 
 	if err != nil {
@@ -154,7 +182,31 @@ func (r *walletRepository) FindBy(ctx context.Context, dbColumnName string, valu
 	return &wallet, nil
 }
 
+// FindByUserAndCurrency retrieves a user's wallet for a currency, whatever its status.
+// A user has at most one wallet per currency (unique_user_currency).
+func (r *walletRepository) FindByUserAndCurrency(ctx context.Context, userID int64, currency string) (*Wallet, common.AppError) {
+	query := `SELECT id, uuid, user_id, balance, held_balance, currency, status, created_at, updated_at
+              FROM wallets WHERE user_id = $1 AND currency = $2`
+
+	var wallet Wallet
+	err := r.db.QueryRowContext(ctx, query, userID, currency).Scan(
+		&wallet.ID, &wallet.UUID, &wallet.UserID, &wallet.BalanceInCents, &wallet.HeldInCents, &wallet.Currency,
+		&wallet.Status, &wallet.CreatedAt, &wallet.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewNotFoundError(fmt.Sprintf("no %s wallet found for user", currency))
+		}
+
+		slog.Error("failed to get wallet by user and currency", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return &wallet, nil
+}
+
 // GetBalance retrieves the current balance of a wallet given its UUID.
+// The balance is the ledger's cached sum of the wallet's postings, see LedgerRepository.
 // It uses a READ COMMITTED transaction to ensure consistent reads.
 func (r *walletRepository) GetBalance(ctx context.Context, walletUUID string) (int64, common.AppError) {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
@@ -209,15 +261,15 @@ func (r *walletRepository) checkExistingWallet(ctx context.Context, tx *sql.Tx,
 
 // insertWallet is a helper method for the Create operation.
 // It performs the actual insertion of the wallet into the database.
-// It handles unique constraint violations and returns appropriate errors.
+// New wallets always start at a zero balance, funds only arrive through ledger postings.
 func (r *walletRepository) insertWallet(ctx context.Context, tx *sql.Tx, wallet *Wallet) (*Wallet, common.AppError) {
-	query := `INSERT INTO wallets (uuid, user_id, balance, currency, status)
-              VALUES ($1, $2, $3, $4, $5)
-              RETURNING id, created_at, updated_at`
+	query := `INSERT INTO wallets (uuid, user_id, currency, status)
+              VALUES ($1, $2, $3, $4)
+              RETURNING id, balance, created_at, updated_at`
 
 	err := tx.QueryRowContext(ctx, query,
-		wallet.UUID, wallet.UserID, wallet.BalanceInCents, wallet.Currency, wallet.Status).
-		Scan(&wallet.ID, &wallet.CreatedAt, &wallet.UpdatedAt)
+		wallet.UUID, wallet.UserID, wallet.Currency, wallet.Status).
+		Scan(&wallet.ID, &wallet.BalanceInCents, &wallet.CreatedAt, &wallet.UpdatedAt)
 
 	if err != nil {
 		slog.Error("failed to create wallet", "err", err)
@@ -231,7 +283,7 @@ func (r *walletRepository) insertWallet(ctx context.Context, tx *sql.Tx, wallet
 // It generates the appropriate SQL query based on the column name provided.
 // This method centralizes query generation and helps prevent SQL injection.
 func (r *walletRepository) generateFindByQuery(fieldName string) (string, error) {
-	baseQuery := `SELECT id, uuid, user_id, balance, currency, status, created_at, updated_at
+	baseQuery := `SELECT id, uuid, user_id, balance, held_balance, currency, status, created_at, updated_at
 				  FROM wallets WHERE `
 
 	switch fieldName {
@@ -245,3 +297,39 @@ func (r *walletRepository) generateFindByQuery(fieldName string) (string, error)
 		return "", errors.New("invalid db field name for wallet lookup")
 	}
 }
+
+// lockWalletPair locks two wallets in id order, so concurrent money movements between them can't deadlock,
+// and returns the found ones by id with their uuid, owner, currency and status.
+func lockWalletPair(ctx context.Context, tx *sql.Tx, firstID, secondID int64) (map[int64]*Wallet, common.AppError) {
+	query := `SELECT id, uuid, user_id, currency, status FROM wallets WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, firstID, secondID)
+	if err != nil {
+		if isSerializationFailure(err) {
+			return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+		}
+
+		slog.Error("failed to lock wallets", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	defer closeRows(ctx, rows)
+
+	wallets := make(map[int64]*Wallet, 2)
+	for rows.Next() {
+		var w Wallet
+		if err := rows.Scan(&w.ID, &w.UUID, &w.UserID, &w.Currency, &w.Status); err != nil {
+			slog.Error("failed to scan locked wallet", "err", err)
+			return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+		}
+
+		wallets[w.ID] = &w
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.Error("error iterating over rows", "err", err)
+		return nil, common.NewInternalServerError(common.ErrUnexpectedDatabase, err)
+	}
+
+	return wallets, nil
+}
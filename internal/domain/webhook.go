@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookEventWalletStatusChanged = EventWalletStatusChanged
+	WebhookEventCardAdded           = EventCardAdded
+	WebhookEventTransferCompleted   = EventTransferCompleted
+
+	WebhookEndpointStatusActive   = "active"
+	WebhookEndpointStatusDisabled = "disabled"
+
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusDead      = "dead"
+
+	// WebhookMaxAttempts is how often a delivery is attempted before it is dead-lettered.
+	WebhookMaxAttempts = 10
+
+	// WebhookRetryBaseDelay is the delay before the first retry, it doubles with every failed attempt up to WebhookRetryMaxDelay.
+	WebhookRetryBaseDelay = time.Minute
+	WebhookRetryMaxDelay  = 6 * time.Hour
+
+	// WebhookRequestTimeout is how long an endpoint has to respond, slower responses count as failed attempts.
+	WebhookRequestTimeout = 10 * time.Second
+
+	// WebhookDeliveryLockTTL is how long a claimed delivery is hidden from other workers. A delivery whose worker
+	// crashed before recording the attempt is retried afterwards. It must be longer than WebhookRequestTimeout.
+	WebhookDeliveryLockTTL = time.Minute
+
+	// An endpoint is disabled once its last WebhookDisableMinFailures attempts failed, and it has been failing
+	// for WebhookDisableAfter, so a short outage under heavy traffic doesn't disable it.
+	WebhookDisableMinFailures = 10
+	WebhookDisableAfter       = 24 * time.Hour
+
+	WebhookDeliveriesDefaultLimit = 20
+	WebhookDeliveriesMaxLimit     = 100
+)
+
+// WebhookEventTypes lists the events an endpoint can subscribe to.
+var WebhookEventTypes = []string{WebhookEventWalletStatusChanged, WebhookEventCardAdded, WebhookEventTransferCompleted}
+
+// WebhookEndpoint is a URL of a user that receives the events it subscribed to, signed with its secret.
+// ConsecutiveFailures and FailingSince track failed attempts since the last successful one.
+type WebhookEndpoint struct {
+	ID                  int64      `json:"-"`
+	UUID                uuid.UUID  `json:"uuid"`
+	UserID              int64      `json:"-"`
+	URL                 string     `json:"url"`
+	EncryptedSecret     []byte     `json:"-"`
+	EventTypes          []string   `json:"eventTypes"`
+	Status              string     `json:"status"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	FailingSince        *time.Time `json:"failingSince,omitempty"`
+	DisabledReason      string     `json:"disabledReason,omitempty"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+// NewWebhookEndpoint creates an active endpoint of the user, the secret is encrypted by the caller.
+func NewWebhookEndpoint(userID int64, url string, eventTypes []string) *WebhookEndpoint {
+	types := slices.Clone(eventTypes)
+	slices.Sort(types)
+
+	return &WebhookEndpoint{
+		UUID:       uuid.New(),
+		UserID:     userID,
+		URL:        url,
+		EventTypes: slices.Compact(types),
+		Status:     WebhookEndpointStatusActive,
+	}
+}
+
+// IsActive reports whether events are delivered to the endpoint.
+func (e *WebhookEndpoint) IsActive() bool {
+	return e.Status == WebhookEndpointStatusActive
+}
+
+// ShouldDisable reports whether the endpoint kept failing long enough to be disabled.
+func (e *WebhookEndpoint) ShouldDisable(now time.Time) bool {
+	return e.ConsecutiveFailures >= WebhookDisableMinFailures && e.FailingSince != nil && now.Sub(*e.FailingSince) >= WebhookDisableAfter
+}
+
+// WebhookEvent is the JSON body delivered to the endpoints subscribed to its type.
+type WebhookEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewWebhookEvent creates the webhook body of a domain event, it keeps the event ID so endpoints
+// can drop duplicates the same way stream consumers do.
+func NewWebhookEvent(e *Event) *WebhookEvent {
+	return &WebhookEvent{
+		ID:        e.ID,
+		Type:      e.Type,
+		CreatedAt: e.OccurredAt,
+		Data:      e.Data,
+	}
+}
+
+// Payload returns the JSON body of the event.
+func (e *WebhookEvent) Payload() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// WalletStatusChangedData is the data of a wallet.status_changed event.
+type WalletStatusChangedData struct {
+	WalletUUID     uuid.UUID `json:"walletUuid"`
+	Currency       string    `json:"currency"`
+	PreviousStatus string    `json:"previousStatus"`
+	Status         string    `json:"status"`
+}
+
+// CardAddedData is the data of a card.added event, it never carries the card number.
+type CardAddedData struct {
+	CardUUID   uuid.UUID `json:"cardUuid"`
+	WalletUUID uuid.UUID `json:"walletUuid"`
+	Provider   string    `json:"provider"`
+	Type       string    `json:"type"`
+	LastFour   string    `json:"lastFour"`
+}
+
+// WebhookDelivery is the delivery of one event to one endpoint. A pending delivery is attempted at NextAttemptAt,
+// failed attempts are retried with exponential backoff until WebhookMaxAttempts, then it is dead-lettered.
+type WebhookDelivery struct {
+	ID            int64            `json:"-"`
+	UUID          uuid.UUID        `json:"uuid"`
+	EndpointID    int64            `json:"-"`
+	EventID       uuid.UUID        `json:"eventId"`
+	EventType     string           `json:"eventType"`
+	Payload       json.RawMessage  `json:"payload"`
+	Status        string           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	NextAttemptAt *time.Time       `json:"nextAttemptAt,omitempty"`
+	LastAttemptAt *time.Time       `json:"lastAttemptAt,omitempty"`
+	DeliveredAt   *time.Time       `json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	AttemptLog    []WebhookAttempt `json:"attemptLog,omitempty"`
+}
+
+// WebhookAttempt is one request of a delivery. ResponseStatus is zero when no response was received,
+// Error then says why, e.g. a timeout.
+type WebhookAttempt struct {
+	ID             int64     `json:"-"`
+	DeliveryID     int64     `json:"-"`
+	Attempt        int       `json:"attempt"`
+	ResponseStatus int       `json:"responseStatus,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"durationMs"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Succeeded reports whether the endpoint accepted the event with a 2xx response.
+func (a *WebhookAttempt) Succeeded() bool {
+	return a.ResponseStatus >= 200 && a.ResponseStatus < 300
+}
+
+// WebhookRetryDelay returns the delay before the next attempt after the given number of failed attempts.
+func WebhookRetryDelay(attempts int) time.Duration {
+	delay := WebhookRetryBaseDelay
+	for i := 1; i < attempts && delay < WebhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, WebhookRetryMaxDelay)
+}
+
+// ApplyAttempt moves the delivery to its status after the attempt: succeeded, dead once it ran out of
+// attempts, or pending with the next attempt scheduled.
+func (d *WebhookDelivery) ApplyAttempt(a *WebhookAttempt, now time.Time) {
+	d.LastAttemptAt = &now
+
+	switch {
+	case a.Succeeded():
+		d.Status = WebhookDeliveryStatusSucceeded
+		d.DeliveredAt = &now
+		d.NextAttemptAt = nil
+
+	case d.Attempts >= WebhookMaxAttempts:
+		d.Status = WebhookDeliveryStatusDead
+		d.NextAttemptAt = nil
+
+	default:
+		next := now.Add(WebhookRetryDelay(d.Attempts))
+		d.Status = WebhookDeliveryStatusPending
+		d.NextAttemptAt = &next
+	}
+}
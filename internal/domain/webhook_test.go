@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhookEndpoint(t *testing.T) {
+	e := NewWebhookEndpoint(7, "https://example.com/hooks",
+		[]string{WebhookEventTransferCompleted, WebhookEventCardAdded, WebhookEventTransferCompleted})
+
+	assert.Equal(t, int64(7), e.UserID)
+	assert.Equal(t, []string{WebhookEventCardAdded, WebhookEventTransferCompleted}, e.EventTypes, "events are sorted without duplicates")
+	assert.True(t, e.IsActive())
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, WebhookRetryMaxDelay},
+		{50, WebhookRetryMaxDelay},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, WebhookRetryDelay(tt.attempts), "after %d attempts", tt.attempts)
+	}
+}
+
+func TestWebhookDeliveryApplyAttempt(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("succeeded", func(t *testing.T) {
+		d := &WebhookDelivery{Status: WebhookDeliveryStatusPending, Attempts: 3}
+		d.ApplyAttempt(&WebhookAttempt{ResponseStatus: 204}, now)
+
+		assert.Equal(t, WebhookDeliveryStatusSucceeded, d.Status)
+		require.NotNil(t, d.DeliveredAt)
+		assert.Equal(t, now, *d.DeliveredAt)
+		assert.Nil(t, d.NextAttemptAt)
+	})
+
+	t.Run("failed is retried with backoff", func(t *testing.T) {
+		d := &WebhookDelivery{Status: WebhookDeliveryStatusPending, Attempts: 2}
+		d.ApplyAttempt(&WebhookAttempt{ResponseStatus: 500}, now)
+
+		assert.Equal(t, WebhookDeliveryStatusPending, d.Status)
+		require.NotNil(t, d.NextAttemptAt)
+		assert.Equal(t, now.Add(2*time.Minute), *d.NextAttemptAt)
+		assert.Nil(t, d.DeliveredAt)
+	})
+
+	t.Run("redirect counts as failed", func(t *testing.T) {
+		d := &WebhookDelivery{Status: WebhookDeliveryStatusPending, Attempts: 1}
+		d.ApplyAttempt(&WebhookAttempt{ResponseStatus: 302}, now)
+
+		assert.Equal(t, WebhookDeliveryStatusPending, d.Status)
+	})
+
+	t.Run("dead-lettered after the last attempt", func(t *testing.T) {
+		d := &WebhookDelivery{Status: WebhookDeliveryStatusPending, Attempts: WebhookMaxAttempts}
+		d.ApplyAttempt(&WebhookAttempt{Error: "timeout"}, now)
+
+		assert.Equal(t, WebhookDeliveryStatusDead, d.Status)
+		assert.Nil(t, d.NextAttemptAt)
+		require.NotNil(t, d.LastAttemptAt)
+		assert.Equal(t, now, *d.LastAttemptAt)
+	})
+}
+
+func TestWebhookEndpointShouldDisable(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	longAgo := now.Add(-WebhookDisableAfter)
+	recently := now.Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		failures     int
+		failingSince *time.Time
+		want         bool
+	}{
+		{"healthy", 0, nil, false},
+		{"many failures during a short outage", 50, &recently, false},
+		{"few failures over a long time", WebhookDisableMinFailures - 1, &longAgo, false},
+		{"kept failing", WebhookDisableMinFailures, &longAgo, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &WebhookEndpoint{ConsecutiveFailures: tt.failures, FailingSince: tt.failingSince}
+			assert.Equal(t, tt.want, e.ShouldDisable(now))
+		})
+	}
+}
+
+func TestWebhookEventPayload(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	domainEvent, err := NewEvent(context.Background(), EventCardAdded, AggregateTypeCard, uuid.New(),
+		CardAddedData{Provider: CardProviderVisa, LastFour: "4242"}, now)
+	require.NoError(t, err)
+
+	event := NewWebhookEvent(domainEvent)
+	assert.Equal(t, domainEvent.ID, event.ID)
+
+	payload, err := event.Payload()
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.Equal(t, event.ID.String(), decoded["id"])
+	assert.Equal(t, WebhookEventCardAdded, decoded["type"])
+	assert.Equal(t, "4242", decoded["data"].(map[string]any)["lastFour"])
+}
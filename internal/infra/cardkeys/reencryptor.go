@@ -0,0 +1,116 @@
+package cardkeys
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+)
+
+// Reencryptor moves stored card numbers to the active key-encryption key in the background, after a key rotation
+// or from the legacy key. Progress lives in the cards themselves, so a restarted or crashed worker resumes with
+// the cards still left. Several instances can run a Reencryptor, a card re-encrypted concurrently is skipped.
+type Reencryptor struct {
+	repo      domain.CardRepository
+	encryptor *secure.CardEncryptor
+}
+
+// NewReencryptor creates a Reencryptor for the cards of repo.
+func NewReencryptor(repo domain.CardRepository, encryptor *secure.CardEncryptor) *Reencryptor {
+	return &Reencryptor{repo: repo, encryptor: encryptor}
+}
+
+// Run re-encrypts all cards left every interval until ctx is done, the first pass starts right away.
+func (r *Reencryptor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.ReencryptAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReencryptAll goes through the cards left in id order, batch by batch, and returns how many it re-encrypted.
+// Cards that fail to re-encrypt are logged and skipped until the next pass.
+func (r *Reencryptor) ReencryptAll(ctx context.Context) int {
+	var afterID int64
+	reencrypted := 0
+
+	for ctx.Err() == nil {
+		lastID, n, more := r.reencryptBatch(ctx, afterID)
+		reencrypted += n
+		afterID = lastID
+
+		if !more {
+			break
+		}
+	}
+
+	if reencrypted > 0 {
+		slog.Info("re-encrypted card numbers", "count", reencrypted, "keyID", r.encryptor.ActiveKeyID())
+	}
+
+	return reencrypted
+}
+
+// reencryptBatch re-encrypts the next batch of cards after afterID, it returns the last card's id,
+// how many cards were re-encrypted and whether more cards may be left.
+func (r *Reencryptor) reencryptBatch(ctx context.Context, afterID int64) (int64, int, bool) {
+	listCtx, cancel := context.WithTimeout(ctx, common.Timeouts.Card.Read)
+	cards, appErr := r.repo.ListForReencryption(listCtx, r.encryptor.ActiveKeyID(), afterID, domain.CardReencryptionBatchSize)
+	cancel()
+
+	if appErr != nil {
+		slog.Error("failed to list cards for re-encryption", "err", appErr.Error())
+		return afterID, 0, false
+	}
+
+	reencrypted := 0
+	for _, card := range cards {
+		afterID = card.ID
+
+		if r.reencrypt(ctx, card) {
+			reencrypted++
+		}
+	}
+
+	return afterID, reencrypted, len(cards) == domain.CardReencryptionBatchSize
+}
+
+func (r *Reencryptor) reencrypt(ctx context.Context, card *domain.Card) bool {
+	ctx, cancel := context.WithTimeout(ctx, common.Timeouts.Card.Write)
+	defer cancel()
+
+	previousKeyID := card.EncryptionKeyID
+
+	encrypted, err := r.encryptor.Reencrypt(ctx, &secure.EncryptedCardNumber{
+		Ciphertext: card.EncryptedCardNumber,
+		DataKey:    card.EncryptedDataKey,
+		KeyID:      card.EncryptionKeyID,
+	})
+	if err != nil {
+		slog.Error("failed to re-encrypt card number", "cardUUID", card.UUID, "keyID", previousKeyID, "err", err)
+		return false
+	}
+
+	card.EncryptedCardNumber = encrypted.Ciphertext
+	card.EncryptedDataKey = encrypted.DataKey
+	card.EncryptionKeyID = encrypted.KeyID
+
+	updated, appErr := r.repo.UpdateEncryption(ctx, card, previousKeyID)
+	if appErr != nil {
+		slog.Error("failed to store re-encrypted card number", "cardUUID", card.UUID, "err", appErr.Error())
+		return false
+	}
+
+	return updated
+}
@@ -0,0 +1,112 @@
+package cardkeys
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCardNumber = "4111111111111111"
+
+var (
+	oldKey = []byte("0123456789abcdef0123456789abcdef")
+	newKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+// memoryCardRepo holds cards in id order.
+type memoryCardRepo struct {
+	domain.CardRepository
+	cards []*domain.Card
+}
+
+func (r *memoryCardRepo) ListForReencryption(_ context.Context, activeKeyID string, afterID int64, limit int) ([]*domain.Card, common.AppError) {
+	var cards []*domain.Card
+	for _, c := range r.cards {
+		if c.ID > afterID && c.EncryptionKeyID != activeKeyID && len(cards) < limit {
+			copied := *c
+			cards = append(cards, &copied)
+		}
+	}
+
+	return cards, nil
+}
+
+func (r *memoryCardRepo) UpdateEncryption(_ context.Context, card *domain.Card, previousKeyID string) (bool, common.AppError) {
+	for _, c := range r.cards {
+		if c.ID == card.ID && c.EncryptionKeyID == previousKeyID {
+			c.EncryptedCardNumber, c.EncryptedDataKey, c.EncryptionKeyID = card.EncryptedCardNumber, card.EncryptedDataKey, card.EncryptionKeyID
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func newTestEncryptor(t *testing.T, activeKeyID string, keys map[string][]byte) *secure.CardEncryptor {
+	t.Helper()
+
+	provider, err := secure.NewLocalKeyProvider(activeKeyID, keys)
+	require.NoError(t, err)
+
+	encryptor, err := secure.NewCardEncryptor(provider, "")
+	require.NoError(t, err)
+
+	return encryptor
+}
+
+func TestReencryptAll(t *testing.T) {
+	ctx := context.Background()
+	before := newTestEncryptor(t, "2025-07", map[string][]byte{"2025-07": oldKey})
+	after := newTestEncryptor(t, "2026-01", map[string][]byte{"2025-07": oldKey, "2026-01": newKey})
+
+	repo := &memoryCardRepo{}
+	for i := range domain.CardReencryptionBatchSize + 5 {
+		encryptor := before
+		if i%10 == 0 {
+			encryptor = after
+		}
+
+		encrypted, err := encryptor.Encrypt(ctx, testCardNumber)
+		require.NoError(t, err)
+
+		repo.cards = append(repo.cards, &domain.Card{
+			ID:                  int64(i + 1),
+			UUID:                uuid.New(),
+			EncryptedCardNumber: encrypted.Ciphertext,
+			EncryptedDataKey:    encrypted.DataKey,
+			EncryptionKeyID:     encrypted.KeyID,
+		})
+	}
+
+	// a corrupted card is skipped, it doesn't stop the others
+	repo.cards[3].EncryptedDataKey = []byte("corrupted")
+
+	reencryptor := NewReencryptor(repo, after)
+	assert.Equal(t, domain.CardReencryptionBatchSize+5-11-1, reencryptor.ReencryptAll(ctx))
+
+	retired := newTestEncryptor(t, "2026-01", map[string][]byte{"2026-01": newKey})
+	for i, c := range repo.cards {
+		if i == 3 {
+			assert.Equal(t, "2025-07", c.EncryptionKeyID)
+			continue
+		}
+
+		assert.Equal(t, "2026-01", c.EncryptionKeyID)
+
+		cardNumber, err := retired.Decrypt(ctx, &secure.EncryptedCardNumber{
+			Ciphertext: c.EncryptedCardNumber,
+			DataKey:    c.EncryptedDataKey,
+			KeyID:      c.EncryptionKeyID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, testCardNumber, cardNumber)
+	}
+
+	assert.Zero(t, reencryptor.ReencryptAll(ctx), "a resumed pass only retries the cards left")
+}
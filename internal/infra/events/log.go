@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// LogPublisher is an EventPublisher for local runs without a broker, it writes every event as a JSON line,
+// e.g. to stdout next to the app log.
+type LogPublisher struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewLogPublisher creates a LogPublisher writing to out.
+func NewLogPublisher(out io.Writer) *LogPublisher {
+	return &LogPublisher{out: out}
+}
+
+// Publish writes the events in order, one JSON envelope per line.
+func (p *LogPublisher) Publish(_ context.Context, events []domain.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := range events {
+		line, err := json.Marshal(&events[i])
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", events[i].ID, err)
+		}
+
+		if _, err = p.out.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write event %s: %w", events[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Close does nothing, the writer is owned by the caller.
+func (p *LogPublisher) Close() error {
+	return nil
+}
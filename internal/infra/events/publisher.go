@@ -0,0 +1,15 @@
+package events
+
+import (
+	"context"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// EventPublisher publishes domain events to a stream. Publish returns once every event was acknowledged,
+// an error means some of them may not have been, the relay publishes them all again later.
+// Consumers therefore see every event at least once and drop duplicates by Event.DedupKey.
+type EventPublisher interface {
+	Publish(ctx context.Context, events []domain.Event) error
+	Close() error
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// Relay publishes the events of the outbox in the background and deletes old published ones.
+// Several instances can run a Relay, every batch is claimed by one of them. An event is marked published only
+// after the publisher acknowledged it, so a crash in between publishes it again: delivery is at least once.
+type Relay struct {
+	repo      domain.OutboxRepository
+	publisher EventPublisher
+}
+
+// NewRelay creates a Relay publishing the events of repo with publisher.
+func NewRelay(repo domain.OutboxRepository, publisher EventPublisher) *Relay {
+	return &Relay{repo: repo, publisher: publisher}
+}
+
+// Run publishes all due events every interval until ctx is done.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				if !r.PublishNext(ctx) {
+					break
+				}
+			}
+
+			r.deletePublished(ctx)
+		}
+	}
+}
+
+// PublishNext claims a batch of due events and publishes it, it reports whether a full batch was published,
+// i.e. whether more events may be due. A batch the publisher rejected is retried after its backoff.
+func (r *Relay) PublishNext(ctx context.Context) bool {
+	claimCtx, cancel := context.WithTimeout(ctx, common.Timeouts.Outbox.Write)
+	batch, appErr := r.repo.ClaimBatch(claimCtx, domain.OutboxBatchSize)
+	cancel()
+
+	if appErr != nil {
+		slog.Error("failed to claim outbox events", "err", appErr.Error())
+		return false
+	}
+
+	if len(batch) == 0 {
+		return false
+	}
+
+	events := make([]domain.Event, len(batch))
+	ids := make([]int64, len(batch))
+	for i := range batch {
+		events[i] = batch[i].Event
+		ids[i] = batch[i].ID
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, domain.OutboxPublishTimeout)
+	err := r.publisher.Publish(publishCtx, events)
+	cancel()
+
+	markCtx, cancel := context.WithTimeout(ctx, common.Timeouts.Outbox.Write)
+	defer cancel()
+
+	if err != nil {
+		slog.Error("failed to publish outbox events", "count", len(batch), "attempt", batch[0].Attempts, "err", err)
+
+		if appErr := r.repo.MarkFailed(markCtx, batch, err.Error()); appErr != nil {
+			slog.Error("failed to reschedule outbox events", "err", appErr.Error())
+		}
+
+		return false
+	}
+
+	if appErr := r.repo.MarkPublished(markCtx, ids); appErr != nil {
+		// the events are published again once their claim expired, consumers drop the duplicates
+		slog.Error("failed to mark outbox events published", "count", len(ids), "err", appErr.Error())
+		return false
+	}
+
+	return len(batch) == domain.OutboxBatchSize
+}
+
+func (r *Relay) deletePublished(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, common.Timeouts.Outbox.Write)
+	defer cancel()
+
+	if _, appErr := r.repo.DeletePublished(ctx, time.Now().Add(-domain.OutboxRetention)); appErr != nil {
+		slog.Error("failed to delete published outbox events", "err", appErr.Error())
+	}
+}
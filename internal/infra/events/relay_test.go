@@ -0,0 +1,152 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOutboxRepo hands out its due unpublished events in order.
+type memoryOutboxRepo struct {
+	events    []*domain.OutboxEvent
+	due       map[int64]time.Time
+	published map[int64]bool
+	lastError string
+}
+
+func newMemoryOutboxRepo(n int) *memoryOutboxRepo {
+	r := &memoryOutboxRepo{due: map[int64]time.Time{}, published: map[int64]bool{}}
+	for i := range n {
+		e, _ := domain.NewEvent(context.Background(), domain.EventCardAdded, domain.AggregateTypeCard, uuid.New(),
+			domain.CardAddedData{LastFour: "4242"}, time.Now())
+		r.events = append(r.events, &domain.OutboxEvent{ID: int64(i + 1), Event: *e})
+		r.due[int64(i+1)] = time.Now()
+	}
+
+	return r
+}
+
+func (r *memoryOutboxRepo) ClaimBatch(_ context.Context, limit int) ([]domain.OutboxEvent, common.AppError) {
+	batch := make([]domain.OutboxEvent, 0, limit)
+	for _, o := range r.events {
+		if len(batch) == limit {
+			break
+		}
+
+		if !r.published[o.ID] && !r.due[o.ID].After(time.Now()) {
+			o.Attempts++
+			r.due[o.ID] = time.Now().Add(domain.OutboxLockTTL)
+			batch = append(batch, *o)
+		}
+	}
+
+	return batch, nil
+}
+
+func (r *memoryOutboxRepo) MarkPublished(_ context.Context, ids []int64) common.AppError {
+	for _, id := range ids {
+		r.published[id] = true
+	}
+
+	return nil
+}
+
+func (r *memoryOutboxRepo) MarkFailed(_ context.Context, events []domain.OutboxEvent, reason string) common.AppError {
+	for _, o := range events {
+		r.due[o.ID] = time.Now().Add(domain.OutboxRetryDelay(o.Attempts))
+	}
+
+	r.lastError = reason
+
+	return nil
+}
+
+func (r *memoryOutboxRepo) DeletePublished(_ context.Context, _ time.Time) (int64, common.AppError) {
+	return 0, nil
+}
+
+// memoryPublisher records the published events, it fails while err is set.
+type memoryPublisher struct {
+	published []domain.Event
+	err       error
+}
+
+func (p *memoryPublisher) Publish(_ context.Context, events []domain.Event) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.published = append(p.published, events...)
+
+	return nil
+}
+
+func (p *memoryPublisher) Close() error {
+	return nil
+}
+
+func TestRelayPublishNext(t *testing.T) {
+	repo := newMemoryOutboxRepo(domain.OutboxBatchSize + 1)
+	publisher := &memoryPublisher{}
+	relay := NewRelay(repo, publisher)
+
+	assert.True(t, relay.PublishNext(context.Background()), "a full batch may leave more events due")
+	assert.False(t, relay.PublishNext(context.Background()))
+	assert.False(t, relay.PublishNext(context.Background()), "nothing is due anymore")
+
+	require.Len(t, publisher.published, domain.OutboxBatchSize+1)
+	for i, e := range publisher.published {
+		assert.Equal(t, repo.events[i].Event.ID, e.ID, "events are published in order")
+		assert.True(t, repo.published[repo.events[i].ID])
+	}
+}
+
+func TestRelayPublishNextFailure(t *testing.T) {
+	repo := newMemoryOutboxRepo(2)
+	publisher := &memoryPublisher{err: errors.New("broker unavailable")}
+	relay := NewRelay(repo, publisher)
+
+	assert.False(t, relay.PublishNext(context.Background()))
+	assert.Empty(t, repo.published)
+	assert.Equal(t, "broker unavailable", repo.lastError)
+	assert.False(t, relay.PublishNext(context.Background()), "failed events wait for their backoff")
+
+	// the backoff passed, the same events are published again with their IDs for consumer dedup
+	for id := range repo.due {
+		repo.due[id] = time.Now()
+	}
+
+	publisher.err = nil
+	relay.PublishNext(context.Background())
+
+	require.Len(t, publisher.published, 2)
+	assert.Equal(t, repo.events[0].Event.DedupKey(), publisher.published[0].DedupKey())
+	assert.Equal(t, 2, repo.events[0].Attempts)
+}
+
+func TestLogPublisher(t *testing.T) {
+	var out bytes.Buffer
+	repo := newMemoryOutboxRepo(2)
+
+	err := NewLogPublisher(&out).Publish(context.Background(), []domain.Event{repo.events[0].Event, repo.events[1].Event})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var e domain.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, repo.events[1].Event.ID, e.ID)
+	assert.Equal(t, domain.EventCardAdded, e.Type)
+	assert.JSONEq(t, string(repo.events[1].Event.Data), string(e.Data))
+}
@@ -0,0 +1,16 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"math/big"
+)
+
+// ErrRateUnavailable means the provider has no rate for the currency pair.
+var ErrRateUnavailable = errors.New("exchange rate unavailable")
+
+// RatesProvider supplies the exchange rates FX quotes are priced at.
+type RatesProvider interface {
+	// Rate returns the price of one major unit of from in to, e.g. 0.92 for USD to EUR.
+	Rate(ctx context.Context, from, to string) (*big.Rat, error)
+}
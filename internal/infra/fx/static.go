@@ -0,0 +1,91 @@
+package fx
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+//go:embed rates.json
+var ratesFS embed.FS
+
+// StaticRates is a RatesProvider with fixed rates against a base currency, cross rates are derived through the base.
+// Rates are loaded from a JSON file, or the embedded rates.json for local development, and change only on restart.
+type StaticRates struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+// ratesFile is the format of the rates file, e.g. {"base": "USD", "rates": {"EUR": "0.9215"}},
+// every rate is the price of one unit of the base currency.
+type ratesFile struct {
+	Base  string            `json:"base"`
+	Rates map[string]string `json:"rates"`
+}
+
+// NewStaticRates creates a StaticRates from decimal rates against base, like "0.9215".
+func NewStaticRates(base string, rates map[string]string) (*StaticRates, error) {
+	if base == "" {
+		return nil, errors.New("base currency is required")
+	}
+
+	s := &StaticRates{
+		base:  base,
+		rates: map[string]*big.Rat{base: big.NewRat(1, 1)},
+	}
+
+	for currency, value := range rates {
+		rate, err := domain.ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("rate for %s: %w", currency, err)
+		}
+
+		s.rates[currency] = rate
+	}
+
+	return s, nil
+}
+
+// LoadStaticRates reads the rates from the JSON file at path, or the embedded rates.json when path is empty.
+func LoadStaticRates(path string) (*StaticRates, error) {
+	var data []byte
+	var err error
+
+	if path == "" {
+		data, err = ratesFS.ReadFile("rates.json")
+	} else {
+		data, err = os.ReadFile(path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fx rates: %w", err)
+	}
+
+	var file ratesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fx rates: %w", err)
+	}
+
+	return NewStaticRates(file.Base, file.Rates)
+}
+
+// Rate returns the price of one unit of from in to, through the base currency.
+func (s *StaticRates) Rate(_ context.Context, from, to string) (*big.Rat, error) {
+	fromRate, ok := s.rates[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s to %s", ErrRateUnavailable, from, to)
+	}
+
+	toRate, ok := s.rates[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s to %s", ErrRateUnavailable, from, to)
+	}
+
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
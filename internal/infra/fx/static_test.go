@@ -0,0 +1,70 @@
+package fx
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticRatesRate(t *testing.T) {
+	rates, err := NewStaticRates("USD", map[string]string{"EUR": "0.92", "JPY": "150"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		from, to string
+		want     *big.Rat
+	}{
+		{"USD", "EUR", big.NewRat(92, 100)},
+		{"EUR", "USD", big.NewRat(100, 92)},
+		{"EUR", "JPY", big.NewRat(15000, 92)},
+		{"USD", "USD", big.NewRat(1, 1)},
+	}
+
+	for _, tt := range tests {
+		got, err := rates.Rate(context.Background(), tt.from, tt.to)
+		require.NoError(t, err)
+		assert.Zero(t, tt.want.Cmp(got), "%s to %s: got %s", tt.from, tt.to, got)
+	}
+
+	_, err = rates.Rate(context.Background(), "USD", "GBP")
+	assert.ErrorIs(t, err, ErrRateUnavailable)
+}
+
+func TestNewStaticRatesRejectsInvalidRates(t *testing.T) {
+	for _, value := range []string{"abc", "0", "-1", "1/3"} {
+		_, err := NewStaticRates("USD", map[string]string{"EUR": value})
+		assert.ErrorIs(t, err, domain.ErrInvalidRate, "value: %q", value)
+	}
+
+	_, err := NewStaticRates("", nil)
+	assert.Error(t, err)
+}
+
+func TestLoadStaticRates(t *testing.T) {
+	embedded, err := LoadStaticRates("")
+	require.NoError(t, err)
+
+	for _, currency := range []string{"EUR", "GBP", "BDT", "INR", "SGD", "AED", "JPY", "KWD"} {
+		_, err := embedded.Rate(context.Background(), "USD", currency)
+		assert.NoError(t, err, "embedded rates miss %s", currency)
+	}
+
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"base":"EUR","rates":{"USD":"1.08"}}`), 0o600))
+
+	file, err := LoadStaticRates(path)
+	require.NoError(t, err)
+
+	rate, err := file.Rate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "1.08", rate.FloatString(2))
+
+	_, err = LoadStaticRates(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,200 @@
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/vault"
+)
+
+// Test card numbers with a fixed outcome on the FakeGateway, every other valid card is approved.
+const (
+	FakeCardDeclined          = "4000000000000002"
+	FakeCardInsufficientFunds = "4000000000009995"
+	FakeCardExpired           = "4000000000000069"
+	FakeCardProcessingError   = "4000000000000119"
+	FakeCardTimeout           = "4000000000000259"
+
+	// FakeCVVIncorrect is declined as an incorrect CVV on 3 digit CVV cards, FakeCVVIncorrectAmex on amex cards.
+	FakeCVVIncorrect     = "999"
+	FakeCVVIncorrectAmex = "9999"
+
+	// FakeMaxChargeInCents is the largest amount the FakeGateway approves in a single charge.
+	FakeMaxChargeInCents = 1_000_000_00
+)
+
+// FakeGateway is a deterministic, in-process PaymentGateway for local development and tests.
+// Outcomes depend only on the card number, expiry and amount, and charge IDs are derived from
+// the request reference, so retrying a charge with the same reference returns the same charge.
+// It exchanges card tokens for the card numbers with the vault, like a card network does for network tokens.
+type FakeGateway struct {
+	mu      sync.Mutex
+	cards   vault.Detokenizer
+	charges map[string]*Charge
+	refunds map[string]int
+}
+
+// NewFakeGateway creates a FakeGateway with no recorded charges, detokenizing cards with cards.
+func NewFakeGateway(cards vault.Detokenizer) *FakeGateway {
+	return &FakeGateway{
+		cards:   cards,
+		charges: make(map[string]*Charge),
+		refunds: make(map[string]int),
+	}
+}
+
+// Charge decides the outcome from the test card numbers and CVVs, then authorizes or captures the amount.
+// A token the vault doesn't know is declined as an invalid card. The CVV is only compared, never kept.
+func (g *FakeGateway) Charge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, ErrTimeout
+	}
+
+	if req.AmountInCents <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	cardNumber, err := g.cards.Detokenize(ctx, req.CardToken, domain.CardDetokenizationPurposeCharge, req.Reference)
+	if err != nil && !errors.Is(err, vault.ErrUnknownToken) {
+		return nil, fmt.Errorf("%w: %w", ErrProcessing, err)
+	}
+
+	switch cardNumber {
+	case FakeCardTimeout:
+		return nil, ErrTimeout
+	case FakeCardProcessingError:
+		return nil, ErrProcessing
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	chargeID := fakeID("ch", req.Reference)
+	if existing, ok := g.charges[chargeID]; ok {
+		charge := *existing
+		return &charge, nil
+	}
+
+	charge := &Charge{
+		ID:            chargeID,
+		Reference:     req.Reference,
+		AmountInCents: req.AmountInCents,
+		Currency:      req.Currency,
+	}
+
+	switch {
+	case errors.Is(err, vault.ErrUnknownToken):
+		charge.Status, charge.DeclineCode = ChargeStatusDeclined, "invalid_card"
+	case cardNumber == FakeCardDeclined:
+		charge.Status, charge.DeclineCode = ChargeStatusDeclined, "card_declined"
+	case cardNumber == FakeCardInsufficientFunds:
+		charge.Status, charge.DeclineCode = ChargeStatusDeclined, "insufficient_funds"
+	case cardNumber == FakeCardExpired || (!req.ExpiryDate.IsZero() && req.ExpiryDate.Before(time.Now())):
+		charge.Status, charge.DeclineCode = ChargeStatusDeclined, "expired_card"
+	case req.CVV == FakeCVVIncorrect || req.CVV == FakeCVVIncorrectAmex:
+		charge.Status, charge.DeclineCode = ChargeStatusDeclined, "incorrect_cvc"
+	case req.AmountInCents > FakeMaxChargeInCents:
+		charge.Status, charge.DeclineCode = ChargeStatusDeclined, "amount_too_large"
+	case req.Capture:
+		charge.Status, charge.CapturedAmountInCents = ChargeStatusCaptured, req.AmountInCents
+	default:
+		charge.Status = ChargeStatusAuthorized
+	}
+
+	g.charges[chargeID] = charge
+
+	result := *charge
+	return &result, nil
+}
+
+// Capture settles an authorized charge for up to the authorized amount.
+func (g *FakeGateway) Capture(ctx context.Context, chargeID string, amountInCents int64) (*Charge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, ErrTimeout
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[chargeID]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+
+	if charge.Status != ChargeStatusAuthorized {
+		return nil, ErrInvalidState
+	}
+
+	if amountInCents <= 0 || amountInCents > charge.AmountInCents {
+		return nil, ErrInvalidAmount
+	}
+
+	charge.Status = ChargeStatusCaptured
+	charge.CapturedAmountInCents = amountInCents
+
+	result := *charge
+	return &result, nil
+}
+
+// Refund returns up to the captured and not yet refunded amount of a charge.
+func (g *FakeGateway) Refund(ctx context.Context, chargeID string, amountInCents int64) (*Refund, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, ErrTimeout
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[chargeID]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+
+	if charge.Status != ChargeStatusCaptured {
+		return nil, ErrInvalidState
+	}
+
+	if amountInCents <= 0 || amountInCents > charge.CapturedAmountInCents-charge.RefundedAmountInCents {
+		return nil, ErrInvalidAmount
+	}
+
+	charge.RefundedAmountInCents += amountInCents
+	g.refunds[chargeID]++
+
+	return &Refund{
+		ID:            fakeID("re", fmt.Sprintf("%s:%d", chargeID, g.refunds[chargeID])),
+		ChargeID:      chargeID,
+		Status:        RefundStatusSucceeded,
+		AmountInCents: amountInCents,
+	}, nil
+}
+
+// FindCharge looks the charge up by the reference it was made for, charges that timed out were never made.
+func (g *FakeGateway) FindCharge(ctx context.Context, reference string) (*Charge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, ErrTimeout
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[fakeID("ch", reference)]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+
+	result := *charge
+	return &result, nil
+}
+
+// fakeID derives a stable, gateway-style identifier from a seed.
+func fakeID(prefix, seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return prefix + "_" + hex.EncodeToString(sum[:12])
+}
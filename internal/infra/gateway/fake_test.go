@@ -0,0 +1,151 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/infra/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCards is an in-memory vault.Detokenizer with the card numbers by token.
+type testCards map[string]string
+
+func (c testCards) Detokenize(_ context.Context, token, _, _ string) (string, error) {
+	cardNumber, ok := c[token]
+	if !ok {
+		return "", vault.ErrUnknownToken
+	}
+
+	return cardNumber, nil
+}
+
+// tokenize issues a token for cardNumber in cards.
+func (c testCards) tokenize(t *testing.T, cardNumber string) string {
+	t.Helper()
+
+	token, err := vault.NewToken(cardNumber)
+	require.NoError(t, err)
+	c[token] = cardNumber
+
+	return token
+}
+
+func TestFakeGatewayCharge(t *testing.T) {
+	future := time.Now().AddDate(1, 0, 0)
+
+	tests := []struct {
+		name        string
+		cardNumber  string
+		expiry      time.Time
+		amount      int64
+		wantErr     error
+		wantStatus  string
+		wantDecline string
+	}{
+		{"Approved", "4111111111111111", future, 1000, nil, ChargeStatusCaptured, ""},
+		{"Declined", FakeCardDeclined, future, 1000, nil, ChargeStatusDeclined, "card_declined"},
+		{"Insufficient Funds", FakeCardInsufficientFunds, future, 1000, nil, ChargeStatusDeclined, "insufficient_funds"},
+		{"Expired Test Card", FakeCardExpired, future, 1000, nil, ChargeStatusDeclined, "expired_card"},
+		{"Past Expiry Date", "4111111111111111", time.Now().AddDate(0, -1, 0), 1000, nil, ChargeStatusDeclined, "expired_card"},
+		{"Amount Too Large", "4111111111111111", future, FakeMaxChargeInCents + 1, nil, ChargeStatusDeclined, "amount_too_large"},
+		{"Processing Error", FakeCardProcessingError, future, 1000, ErrProcessing, "", ""},
+		{"Timeout", FakeCardTimeout, future, 1000, ErrTimeout, "", ""},
+		{"Invalid Amount", "4111111111111111", future, 0, ErrInvalidAmount, "", ""},
+		{"Unknown Token", "", future, 1000, nil, ChargeStatusDeclined, "invalid_card"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards := testCards{}
+			token := "9000000000000000"
+			if tt.cardNumber != "" {
+				token = cards.tokenize(t, tt.cardNumber)
+			}
+
+			g := NewFakeGateway(cards)
+			charge, err := g.Charge(context.Background(), ChargeRequest{
+				Reference:     tt.name,
+				CardToken:     token,
+				ExpiryDate:    tt.expiry,
+				AmountInCents: tt.amount,
+				Currency:      "USD",
+				Capture:       true,
+			})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, charge.Status)
+			assert.Equal(t, tt.wantDecline, charge.DeclineCode)
+		})
+	}
+}
+
+func TestFakeGatewayChargeVerifiesCVV(t *testing.T) {
+	cards := testCards{}
+	token := cards.tokenize(t, "4111111111111111")
+	g := NewFakeGateway(cards)
+
+	for cvv, wantDecline := range map[string]string{"123": "", FakeCVVIncorrect: "incorrect_cvc", "": ""} {
+		charge, err := g.Charge(context.Background(), ChargeRequest{
+			Reference:     "cvv-" + cvv,
+			CardToken:     token,
+			CVV:           cvv,
+			AmountInCents: 1000,
+			Currency:      "USD",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, wantDecline, charge.DeclineCode, cvv)
+	}
+}
+
+func TestFakeGatewayChargeIsIdempotentPerReference(t *testing.T) {
+	cards := testCards{}
+	g := NewFakeGateway(cards)
+	req := ChargeRequest{Reference: "dep-1", CardToken: cards.tokenize(t, "4111111111111111"), AmountInCents: 500, Currency: "USD"}
+
+	first, err := g.Charge(context.Background(), req)
+	require.NoError(t, err)
+
+	second, err := g.Charge(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, ChargeStatusAuthorized, second.Status)
+}
+
+func TestFakeGatewayCaptureAndRefund(t *testing.T) {
+	ctx := context.Background()
+	cards := testCards{}
+	g := NewFakeGateway(cards)
+
+	charge, err := g.Charge(ctx, ChargeRequest{Reference: "dep-2", CardToken: cards.tokenize(t, "4111111111111111"), AmountInCents: 1000, Currency: "USD"})
+	require.NoError(t, err)
+
+	_, err = g.Refund(ctx, charge.ID, 100)
+	assert.ErrorIs(t, err, ErrInvalidState, "authorized charges can't be refunded")
+
+	_, err = g.Capture(ctx, charge.ID, 1001)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	captured, err := g.Capture(ctx, charge.ID, 800)
+	require.NoError(t, err)
+	assert.Equal(t, ChargeStatusCaptured, captured.Status)
+	assert.Equal(t, int64(800), captured.CapturedAmountInCents)
+
+	refund, err := g.Refund(ctx, charge.ID, 500)
+	require.NoError(t, err)
+	assert.Equal(t, RefundStatusSucceeded, refund.Status)
+
+	_, err = g.Refund(ctx, charge.ID, 301)
+	assert.ErrorIs(t, err, ErrInvalidAmount, "refunds can't exceed the captured amount")
+
+	_, err = g.Capture(ctx, "ch_missing", 100)
+	assert.ErrorIs(t, err, ErrChargeNotFound)
+}
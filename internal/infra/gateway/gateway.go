@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	ChargeStatusAuthorized = "authorized"
+	ChargeStatusCaptured   = "captured"
+	ChargeStatusDeclined   = "declined"
+
+	RefundStatusSucceeded = "succeeded"
+)
+
+var (
+	// ErrTimeout means the gateway didn't answer in time, the outcome of the operation is unknown.
+	ErrTimeout = errors.New("payment gateway timed out")
+
+	// ErrProcessing means the gateway failed to process the operation, it can be retried.
+	ErrProcessing = errors.New("payment gateway processing error")
+
+	ErrChargeNotFound = errors.New("charge not found")
+	ErrInvalidAmount  = errors.New("invalid amount for charge")
+	ErrInvalidState   = errors.New("charge is not in a valid state for this operation")
+)
+
+// PaymentGateway abstracts the card processor used to move money between cards and wallets.
+// Declines are reported as a Charge with ChargeStatusDeclined, errors are reserved for
+// failures where the gateway couldn't decide (ErrTimeout, ErrProcessing) or invalid operations.
+type PaymentGateway interface {
+	// Charge authorizes the amount on a card, and captures it right away when req.Capture is set.
+	Charge(ctx context.Context, req ChargeRequest) (*Charge, error)
+
+	// Capture settles an authorized charge, fully or partially. The uncaptured remainder is released.
+	Capture(ctx context.Context, chargeID string, amountInCents int64) (*Charge, error)
+
+	// Refund returns a captured amount, fully or partially, to the card.
+	Refund(ctx context.Context, chargeID string, amountInCents int64) (*Refund, error)
+
+	// FindCharge returns the charge made for a ChargeRequest.Reference, or ErrChargeNotFound when there is none.
+	// It settles charges whose outcome is unknown to us, e.g. after a timeout.
+	FindCharge(ctx context.Context, reference string) (*Charge, error)
+}
+
+// ChargeRequest holds the card and amount for a charge.
+// Reference is our own record ID, gateways use it to deduplicate retried charges.
+// The card is sent as its vault token, like a network token, never as the card number.
+// CVV is optional, gateways verify it with the issuer and must not keep it.
+type ChargeRequest struct {
+	Reference     string
+	CardToken     string
+	CVV           string
+	ExpiryDate    time.Time
+	AmountInCents int64
+	Currency      string
+	Capture       bool
+}
+
+// Charge is the gateway's view of a charge.
+type Charge struct {
+	ID                    string
+	Reference             string
+	Status                string
+	DeclineCode           string
+	AmountInCents         int64
+	CapturedAmountInCents int64
+	RefundedAmountInCents int64
+	Currency              string
+}
+
+// Refund is the gateway's view of a refund against a captured charge.
+type Refund struct {
+	ID            string
+	ChargeID      string
+	Status        string
+	AmountInCents int64
+}
@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// DepositReconciler settles deposits whose charge outcome the deposit request couldn't record, because the gateway
+// timed out or the database failed after the charge. It asks the gateway for the charge of each: a captured charge
+// credits the wallet, a pending deposit without a captured charge is failed. A timed out deposit stays timed out
+// until its charge shows up captured or DepositReconciliationWindow passed. Several instances can run a
+// DepositReconciler, a deposit can only be credited once.
+type DepositReconciler struct {
+	repo    domain.DepositRepository
+	gateway PaymentGateway
+}
+
+// NewDepositReconciler creates a DepositReconciler for the deposits of repo charged through gateway.
+func NewDepositReconciler(repo domain.DepositRepository, gateway PaymentGateway) *DepositReconciler {
+	return &DepositReconciler{repo: repo, gateway: gateway}
+}
+
+// Run reconciles the unsettled deposits every interval until ctx is done.
+func (r *DepositReconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.ReconcileAll(ctx)
+		}
+	}
+}
+
+// ReconcileAll goes through the unsettled deposits in id order, batch by batch, and returns how many it credited.
+// Deposits the gateway can't tell about right now are logged and skipped until the next pass.
+func (r *DepositReconciler) ReconcileAll(ctx context.Context) int {
+	var afterID int64
+	credited := 0
+
+	for ctx.Err() == nil {
+		listCtx, cancel := context.WithTimeout(ctx, common.Timeouts.Deposit.Read)
+		deposits, appErr := r.repo.ListUnsettled(listCtx, afterID, domain.DepositReconciliationBatchSize)
+		cancel()
+
+		if appErr != nil {
+			slog.Error("failed to list unsettled deposits", "err", appErr.Error())
+			break
+		}
+
+		for _, deposit := range deposits {
+			afterID = deposit.ID
+
+			if r.reconcile(ctx, deposit) {
+				credited++
+			}
+		}
+
+		if len(deposits) < domain.DepositReconciliationBatchSize {
+			break
+		}
+	}
+
+	if credited > 0 {
+		slog.Info("credited reconciled deposits", "count", credited)
+	}
+
+	return credited
+}
+
+// reconcile settles one deposit from the gateway's charge, it reports whether the wallet was credited.
+func (r *DepositReconciler) reconcile(ctx context.Context, deposit *domain.Deposit) bool {
+	gatewayCtx, cancel := context.WithTimeout(ctx, common.Timeouts.Gateway.Write)
+	charge, err := r.gateway.FindCharge(gatewayCtx, deposit.UUID.String())
+	cancel()
+
+	ctx, cancel = context.WithTimeout(ctx, common.Timeouts.Deposit.Write)
+	defer cancel()
+
+	switch {
+	case errors.Is(err, ErrChargeNotFound):
+		r.fail(ctx, deposit, domain.DepositStatusFailed, "", "payment gateway has no charge for the deposit")
+		return false
+
+	case err != nil:
+		slog.Error("failed to find deposit charge", "depositUUID", deposit.UUID, "err", err)
+		return false
+
+	case charge.Status == ChargeStatusDeclined:
+		r.fail(ctx, deposit, domain.DepositStatusDeclined, charge.ID, charge.DeclineCode)
+		return false
+
+	case charge.Status != ChargeStatusCaptured:
+		r.fail(ctx, deposit, domain.DepositStatusFailed, charge.ID, "charge was not captured")
+		return false
+
+	case charge.CapturedAmountInCents != deposit.AmountInCents || charge.Currency != deposit.Currency:
+		slog.Error("deposit charge captured a different amount", "depositUUID", deposit.UUID, "chargeID", charge.ID,
+			"capturedInCents", charge.CapturedAmountInCents, "currency", charge.Currency)
+		return false
+	}
+
+	if _, appErr := r.repo.MarkSucceeded(ctx, deposit, charge.ID); appErr != nil {
+		slog.Error("failed to credit reconciled deposit", "depositUUID", deposit.UUID, "err", appErr.Error())
+		return false
+	}
+
+	slog.Info("credited reconciled deposit", "depositUUID", deposit.UUID, "chargeID", charge.ID)
+
+	return true
+}
+
+// fail records the outcome of a pending deposit without a captured charge. Timed out deposits already
+// have a final status, they are left for a later pass in case the gateway still captures their charge.
+func (r *DepositReconciler) fail(ctx context.Context, deposit *domain.Deposit, status, chargeID, reason string) {
+	if deposit.Status != domain.DepositStatusPending {
+		return
+	}
+
+	if _, appErr := r.repo.MarkFailed(ctx, deposit, status, chargeID, reason); appErr != nil {
+		slog.Error("failed to mark reconciled deposit as failed", "depositUUID", deposit.UUID, "err", appErr.Error())
+	}
+}
@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDepositRepo holds the unsettled deposits in id order.
+type memoryDepositRepo struct {
+	domain.DepositRepository
+	deposits []*domain.Deposit
+}
+
+func (r *memoryDepositRepo) ListUnsettled(_ context.Context, afterID int64, limit int) ([]*domain.Deposit, common.AppError) {
+	var deposits []*domain.Deposit
+	for _, d := range r.deposits {
+		unsettled := d.Status == domain.DepositStatusPending || d.Status == domain.DepositStatusTimedOut
+		if d.ID > afterID && unsettled && len(deposits) < limit {
+			copied := *d
+			deposits = append(deposits, &copied)
+		}
+	}
+
+	return deposits, nil
+}
+
+func (r *memoryDepositRepo) MarkSucceeded(_ context.Context, deposit *domain.Deposit, chargeID string) (*domain.Deposit, common.AppError) {
+	d := r.find(deposit.ID)
+	if d.Status != domain.DepositStatusPending && d.Status != domain.DepositStatusTimedOut {
+		return nil, common.NewConflictError("deposit is already " + d.Status)
+	}
+
+	d.Status, d.GatewayChargeID, d.FailureReason = domain.DepositStatusSucceeded, chargeID, ""
+
+	return d, nil
+}
+
+func (r *memoryDepositRepo) MarkFailed(_ context.Context, deposit *domain.Deposit, status, chargeID, reason string) (*domain.Deposit, common.AppError) {
+	d := r.find(deposit.ID)
+	if d.Status != domain.DepositStatusPending {
+		return nil, common.NewConflictError("deposit is no longer pending")
+	}
+
+	d.Status, d.GatewayChargeID, d.FailureReason = status, chargeID, reason
+
+	return d, nil
+}
+
+func (r *memoryDepositRepo) find(id int64) *domain.Deposit {
+	for _, d := range r.deposits {
+		if d.ID == id {
+			return d
+		}
+	}
+
+	return nil
+}
+
+func TestDepositReconcilerReconcileAll(t *testing.T) {
+	ctx := context.Background()
+	cards := testCards{}
+	g := NewFakeGateway(cards)
+	repo := &memoryDepositRepo{}
+
+	// charge charges cardNumber for a new deposit with status, as if recording the outcome had failed
+	charge := func(cardNumber, status string) *domain.Deposit {
+		d := &domain.Deposit{ID: int64(len(repo.deposits) + 1), UUID: uuid.New(), AmountInCents: 5000, Currency: "USD", Status: status}
+		repo.deposits = append(repo.deposits, d)
+
+		_, _ = g.Charge(ctx, ChargeRequest{
+			Reference:     d.UUID.String(),
+			CardToken:     cards.tokenize(t, cardNumber),
+			ExpiryDate:    time.Now().AddDate(1, 0, 0),
+			AmountInCents: d.AmountInCents,
+			Currency:      d.Currency,
+			Capture:       true,
+		})
+
+		return d
+	}
+
+	capturedPending := charge("4111111111111111", domain.DepositStatusPending)
+	capturedTimedOut := charge("5555555555554444", domain.DepositStatusTimedOut)
+	declinedPending := charge(FakeCardDeclined, domain.DepositStatusPending)
+	neverChargedPending := charge(FakeCardTimeout, domain.DepositStatusPending)
+	neverChargedTimedOut := charge(FakeCardTimeout, domain.DepositStatusTimedOut)
+
+	reconciler := NewDepositReconciler(repo, g)
+	require.Equal(t, 2, reconciler.ReconcileAll(ctx))
+
+	assert.Equal(t, domain.DepositStatusSucceeded, capturedPending.Status)
+	assert.Equal(t, domain.DepositStatusSucceeded, capturedTimedOut.Status)
+	assert.Equal(t, domain.DepositStatusDeclined, declinedPending.Status)
+	assert.Equal(t, "card_declined", declinedPending.FailureReason)
+	assert.Equal(t, domain.DepositStatusFailed, neverChargedPending.Status)
+	assert.Equal(t, domain.DepositStatusTimedOut, neverChargedTimedOut.Status, "the gateway may still capture it")
+
+	assert.Zero(t, reconciler.ReconcileAll(ctx), "a credited deposit is never credited again")
+}
@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Headers of every message, so consumers can route and deduplicate events without decoding them.
+const (
+	HeaderEventID      = "event-id"
+	HeaderEventType    = "event-type"
+	HeaderEventVersion = "event-version"
+	HeaderRequestID    = "request-id"
+)
+
+// Publisher publishes domain events to a Kafka topic. Messages are keyed by aggregate ID, so the events
+// of one wallet, card or transfer land on one partition in the order they occurred. Writes wait for all
+// in-sync replicas, an event is only marked published once it can't be lost by the brokers.
+type Publisher struct {
+	writer *kafkago.Writer
+}
+
+// NewPublisher creates a Publisher writing to topic on brokers.
+func NewPublisher(brokers []string, topic string) *Publisher {
+	return &Publisher{
+		writer: &kafkago.Writer{
+			Addr:         kafkago.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafkago.Hash{},
+			RequiredAcks: kafkago.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+// Publish writes the events as one batch of JSON envelopes, it returns once the brokers acknowledged all of them.
+func (p *Publisher) Publish(ctx context.Context, events []domain.Event) error {
+	messages := make([]kafkago.Message, 0, len(events))
+	for i := range events {
+		e := &events[i]
+
+		value, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", e.ID, err)
+		}
+
+		headers := []kafkago.Header{
+			{Key: HeaderEventID, Value: []byte(e.DedupKey())},
+			{Key: HeaderEventType, Value: []byte(e.Type)},
+			{Key: HeaderEventVersion, Value: []byte(strconv.Itoa(e.Version))},
+		}
+
+		if e.RequestID != "" {
+			headers = append(headers, kafkago.Header{Key: HeaderRequestID, Value: []byte(e.RequestID)})
+		}
+
+		messages = append(messages, kafkago.Message{
+			Key:     []byte(e.AggregateID),
+			Value:   value,
+			Headers: headers,
+			Time:    e.OccurredAt,
+		})
+	}
+
+	if err := p.writer.WriteMessages(ctx, messages...); err != nil {
+		return fmt.Errorf("failed to write events to kafka: %w", err)
+	}
+
+	return nil
+}
+
+// Close flushes pending writes and closes the connections to the brokers.
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
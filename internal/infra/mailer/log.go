@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer is a Mailer for local development that doesn't deliver anything. Emails are appended
+// to a file when a path is set, otherwise logged. Tokens in the emails end up in the file or logs,
+// so it must not be used in production.
+type LogMailer struct {
+	path string
+	from string
+
+	mu sync.Mutex
+}
+
+// NewLogMailer creates a new LogMailer, an empty path logs emails instead of writing them to a file.
+func NewLogMailer(path, from string) *LogMailer {
+	return &LogMailer{path: path, from: from}
+}
+
+// Send writes the message to the file or the log.
+func (m *LogMailer) Send(_ context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	if m.path == "" {
+		slog.Info("email not delivered, log mailer", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec // the path comes from config
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+
+	if _, err = f.Write(append(msg.format(m.from, time.Now()), "\r\n\r\n"...)); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("failed to close mail file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidMessage means the message can't be sent as is, e.g. the recipient address is invalid.
+	ErrInvalidMessage = errors.New("invalid email message")
+)
+
+// Mailer sends transactional emails, like password reset and email verification tokens.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// validate rejects invalid recipients, and line breaks in headers which would allow injecting headers.
+func (m Message) validate() error {
+	if _, err := mail.ParseAddress(m.To); err != nil {
+		return fmt.Errorf("%w: invalid recipient: %w", ErrInvalidMessage, err)
+	}
+
+	if strings.ContainsAny(m.To, "\r\n") || strings.ContainsAny(m.Subject, "\r\n") {
+		return fmt.Errorf("%w: headers must not contain line breaks", ErrInvalidMessage)
+	}
+
+	return nil
+}
+
+// format renders the message as RFC 5322 text with CRLF line endings.
+func (m Message) format(from string, date time.Time) []byte {
+	var b strings.Builder
+
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + m.To + "\r\n")
+	b.WriteString("Subject: " + m.Subject + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+
+	body := strings.ReplaceAll(m.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
+
+// parseAddress returns the bare address of an address like "xPay <no-reply@example.com>".
+func parseAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+
+	return parsed.Address, nil
+}
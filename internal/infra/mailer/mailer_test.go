@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     Message
+		wantErr bool
+	}{
+		{"valid", Message{To: "someone@example.com", Subject: "Hello"}, false},
+		{"invalid recipient", Message{To: "not-an-email", Subject: "Hello"}, true},
+		{"header injection in subject", Message{To: "someone@example.com", Subject: "Hi\r\nBcc: victim@example.com"}, true},
+		{"header injection in recipient", Message{To: "someone@example.com\nBcc: victim@example.com", Subject: "Hi"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidMessage)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMessageFormatUsesCRLF(t *testing.T) {
+	msg := Message{To: "someone@example.com", Subject: "Reset", Body: "line one\nline two\r\n"}
+	formatted := string(msg.format("xPay <no-reply@example.com>", time.Date(2024, 9, 20, 10, 0, 0, 0, time.UTC)))
+
+	assert.True(t, strings.HasPrefix(formatted, "From: xPay <no-reply@example.com>\r\nTo: someone@example.com\r\nSubject: Reset\r\n"))
+	assert.True(t, strings.HasSuffix(formatted, "\r\n\r\nline one\r\nline two\r\n"))
+	assert.NotContains(t, strings.ReplaceAll(formatted, "\r\n", ""), "\n")
+}
+
+func TestLogMailerAppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := NewLogMailer(path, "no-reply@example.com")
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "a@example.com", Subject: "First", Body: "one"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "b@example.com", Subject: "Second", Body: "two"}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Subject: First")
+	assert.Contains(t, string(content), "Subject: Second")
+}
@@ -0,0 +1,118 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPMailer sends emails through an SMTP server. It upgrades the connection with STARTTLS
+// when the server supports it, and authenticates with PLAIN auth when a username is set.
+type SMTPMailer struct {
+	host string
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a new SMTPMailer for the server at host:port, sending as from.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{
+		host: host,
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+	}
+
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return m
+}
+
+// Send delivers the message, the whole SMTP conversation is bound to the context deadline.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("failed to set smtp deadline: %w", err)
+		}
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+
+	// Close is a no-op error after a successful Quit, it only matters on the error paths.
+	defer func() { _ = client.Close() }()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: m.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if m.auth != nil {
+		if err = client.Auth(m.auth); err != nil {
+			return fmt.Errorf("failed to authenticate with smtp server: %w", err)
+		}
+	}
+
+	if err = m.deliver(client, msg); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (m *SMTPMailer) deliver(client *smtp.Client, msg Message) error {
+	from, err := parseAddress(m.from)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Mail(from); err != nil {
+		return fmt.Errorf("smtp MAIL command failed: %w", err)
+	}
+
+	to, err := parseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp RCPT command failed: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA command failed: %w", err)
+	}
+
+	if _, err = w.Write(msg.format(m.from, time.Now())); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// renderCSV writes one row per transaction between an opening and a closing balance row.
+// Amounts are signed, debits are negative.
+func renderCSV(s *domain.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := make([][]string, 0, len(s.Transactions)+3)
+	rows = append(rows,
+		[]string{"Date", "Transaction ID", "Type", "Description", "Amount", "Balance", "Currency"},
+		[]string{s.From.Format(time.RFC3339), "", "", "Opening balance", "", amount(s, s.OpeningBalance), s.Currency},
+	)
+
+	for i := range s.Transactions {
+		t := &s.Transactions[i]
+		rows = append(rows, []string{
+			t.CreatedAt.UTC().Format(time.RFC3339),
+			t.UUID.String(),
+			typeLabel(t.Type),
+			escapeFormula(t.Description),
+			signedAmount(s, t),
+			amount(s, t.BalanceAfterInCents),
+			s.Currency,
+		})
+	}
+
+	rows = append(rows, []string{s.To.Format(time.RFC3339), "", "", "Closing balance", "", amount(s, s.ClosingBalance), s.Currency})
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// escapeFormula prefixes text a spreadsheet would evaluate as a formula with a quote, so it is shown as text.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
@@ -0,0 +1,104 @@
+package statement
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// Exporter generates the files of pending statement exports in the background and deletes expired exports.
+// Several instances can run an Exporter, every export is claimed by one of them.
+type Exporter struct {
+	repo domain.StatementRepository
+}
+
+// NewExporter creates an Exporter for the exports of repo.
+func NewExporter(repo domain.StatementRepository) *Exporter {
+	return &Exporter{repo: repo}
+}
+
+// Run exports all pending statements every interval until ctx is done.
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				if !e.ExportNext(ctx) {
+					break
+				}
+			}
+
+			e.deleteExpired(ctx)
+		}
+	}
+}
+
+// ExportNext claims the oldest pending export and generates its file, it reports whether there was one.
+// An export fails for good when its wallet is gone or its last attempt failed, otherwise it is retried
+// once the claim expired.
+func (e *Exporter) ExportNext(ctx context.Context) bool {
+	claimCtx, cancel := context.WithTimeout(ctx, common.Timeouts.Statement.Write)
+	export, appErr := e.repo.ClaimExport(claimCtx)
+	cancel()
+
+	if appErr != nil {
+		slog.Error("failed to claim statement export", "err", appErr.Error())
+		return false
+	}
+
+	if export == nil {
+		return false
+	}
+
+	exportCtx, cancel := context.WithTimeout(ctx, domain.StatementExportTimeout)
+	defer cancel()
+
+	s, appErr := e.repo.Build(exportCtx, export.WalletID, export.From, export.To)
+	if appErr != nil {
+		slog.Error("failed to build statement", "exportUUID", export.UUID, "attempt", export.Attempts, "err", appErr.Error())
+
+		if appErr.Code() == http.StatusNotFound || export.Attempts >= domain.StatementExportMaxAttempts {
+			e.fail(exportCtx, export, appErr.Error())
+		}
+
+		return true
+	}
+
+	file, err := Render(s, export.Format)
+	if err != nil {
+		slog.Error("failed to render statement", "exportUUID", export.UUID, "err", err)
+		e.fail(exportCtx, export, "failed to render the statement")
+
+		return true
+	}
+
+	if appErr := e.repo.CompleteExport(exportCtx, export, file); appErr != nil {
+		slog.Error("failed to store statement export", "exportUUID", export.UUID, "err", appErr.Error())
+	}
+
+	return true
+}
+
+func (e *Exporter) fail(ctx context.Context, export *domain.StatementExport, reason string) {
+	if appErr := e.repo.FailExport(ctx, export, reason); appErr != nil {
+		slog.Error("failed to mark statement export as failed", "exportUUID", export.UUID, "err", appErr.Error())
+	}
+}
+
+func (e *Exporter) deleteExpired(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, common.Timeouts.Statement.Write)
+	defer cancel()
+
+	if _, appErr := e.repo.DeleteExpiredExports(ctx); appErr != nil {
+		slog.Error("failed to delete expired statement exports", "err", appErr.Error())
+	}
+}
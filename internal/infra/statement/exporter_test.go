@@ -0,0 +1,156 @@
+package statement
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStatementRepo hands out its exports in order and builds statements with buildErr, if set.
+type memoryStatementRepo struct {
+	exports  []*domain.StatementExport
+	files    map[int64]*domain.StatementFile
+	buildErr common.AppError
+}
+
+func (r *memoryStatementRepo) Build(_ context.Context, _ int64, _, _ time.Time) (*domain.Statement, common.AppError) {
+	if r.buildErr != nil {
+		return nil, r.buildErr
+	}
+
+	return testStatement(1), nil
+}
+
+func (r *memoryStatementRepo) CreateExport(_ context.Context, e *domain.StatementExport) common.AppError {
+	e.ID = int64(len(r.exports) + 1)
+	r.exports = append(r.exports, e)
+	return nil
+}
+
+func (r *memoryStatementRepo) FindExport(_ context.Context, _ int64, _ string) (*domain.StatementExport, common.AppError) {
+	return nil, common.NewNotFoundError(common.ErrStatementExportNotFound)
+}
+
+func (r *memoryStatementRepo) FindExportFile(_ context.Context, exportID int64) (*domain.StatementFile, common.AppError) {
+	return r.files[exportID], nil
+}
+
+// ClaimExport mirrors the postgres repository: stuck exports are taken over until they run out of attempts, then failed.
+func (r *memoryStatementRepo) ClaimExport(_ context.Context) (*domain.StatementExport, common.AppError) {
+	now := time.Now()
+	stuck := func(e *domain.StatementExport) bool {
+		return e.Status == domain.StatementExportStatusProcessing && e.StartedAt != nil && e.StartedAt.Before(now.Add(-domain.StatementExportLockTTL))
+	}
+
+	for _, e := range r.exports {
+		if stuck(e) && e.Attempts >= domain.StatementExportMaxAttempts {
+			e.Status = domain.StatementExportStatusFailed
+			e.FailureReason = domain.StatementExportAbandonedReason
+		}
+	}
+
+	for _, e := range r.exports {
+		if (e.Status == domain.StatementExportStatusPending || stuck(e)) && e.Attempts < domain.StatementExportMaxAttempts {
+			e.Status = domain.StatementExportStatusProcessing
+			e.StartedAt = &now
+			e.Attempts++
+			return e, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *memoryStatementRepo) CompleteExport(_ context.Context, e *domain.StatementExport, f *domain.StatementFile) common.AppError {
+	if r.files == nil {
+		r.files = make(map[int64]*domain.StatementFile)
+	}
+
+	r.files[e.ID] = f
+	e.Status = domain.StatementExportStatusReady
+	return nil
+}
+
+func (r *memoryStatementRepo) FailExport(_ context.Context, e *domain.StatementExport, reason string) common.AppError {
+	e.Status = domain.StatementExportStatusFailed
+	e.FailureReason = reason
+	return nil
+}
+
+func (r *memoryStatementRepo) DeleteExpiredExports(_ context.Context) (int64, common.AppError) {
+	return 0, nil
+}
+
+func newTestExport(repo *memoryStatementRepo, format string) *domain.StatementExport {
+	s := testStatement(0)
+	e := domain.NewStatementExport(1, s.From, s.To, format, time.Now())
+	_ = repo.CreateExport(context.Background(), e)
+	return e
+}
+
+func TestExporterExportNext(t *testing.T) {
+	repo := &memoryStatementRepo{}
+	exporter := NewExporter(repo)
+	export := newTestExport(repo, domain.StatementFormatCSV)
+
+	assert.True(t, exporter.ExportNext(context.Background()))
+	assert.Equal(t, domain.StatementExportStatusReady, export.Status)
+	require.Contains(t, repo.files, export.ID)
+	assert.Equal(t, "text/csv; charset=utf-8", repo.files[export.ID].ContentType)
+
+	assert.False(t, exporter.ExportNext(context.Background()), "no export left to claim")
+}
+
+func TestExporterRetriesFailedBuilds(t *testing.T) {
+	repo := &memoryStatementRepo{buildErr: common.NewInternalServerError(common.ErrUnexpectedDatabase, nil)}
+	exporter := NewExporter(repo)
+	export := newTestExport(repo, domain.StatementFormatPDF)
+
+	for attempt := 1; attempt < domain.StatementExportMaxAttempts; attempt++ {
+		assert.True(t, exporter.ExportNext(context.Background()))
+		assert.Equal(t, domain.StatementExportStatusProcessing, export.Status, "attempt %d must be retried", attempt)
+
+		// the claim expires
+		export.Status = domain.StatementExportStatusPending
+	}
+
+	assert.True(t, exporter.ExportNext(context.Background()))
+	assert.Equal(t, domain.StatementExportStatusFailed, export.Status, "the last attempt must fail the export")
+}
+
+func TestExporterFailsExportsOfMissingWallets(t *testing.T) {
+	repo := &memoryStatementRepo{buildErr: common.NewNotFoundError("wallet not found")}
+	export := newTestExport(repo, domain.StatementFormatOFX)
+
+	assert.True(t, NewExporter(repo).ExportNext(context.Background()))
+	assert.Equal(t, domain.StatementExportStatusFailed, export.Status)
+	assert.Equal(t, "wallet not found", export.FailureReason)
+}
+
+func TestExporterFailsUnsupportedFormats(t *testing.T) {
+	repo := &memoryStatementRepo{}
+	export := newTestExport(repo, "xlsx")
+
+	assert.True(t, NewExporter(repo).ExportNext(context.Background()))
+	assert.Equal(t, domain.StatementExportStatusFailed, export.Status)
+}
+
+func TestExporterFailsExportsAbandonedOnTheLastAttempt(t *testing.T) {
+	repo := &memoryStatementRepo{}
+	export := newTestExport(repo, domain.StatementFormatCSV)
+
+	// the worker crashed during the last attempt
+	startedAt := time.Now().Add(-2 * domain.StatementExportLockTTL)
+	export.Status = domain.StatementExportStatusProcessing
+	export.StartedAt = &startedAt
+	export.Attempts = domain.StatementExportMaxAttempts
+
+	assert.False(t, NewExporter(repo).ExportNext(context.Background()), "no attempts left to claim")
+	assert.Equal(t, domain.StatementExportStatusFailed, export.Status)
+	assert.Equal(t, domain.StatementExportAbandonedReason, export.FailureReason)
+}
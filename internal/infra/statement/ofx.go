@@ -0,0 +1,137 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+const (
+	// ofxBankID identifies xPay as the financial institution of the statement's account.
+	ofxBankID = "XPAY"
+
+	ofxDateLayout = "20060102150405.000"
+)
+
+// renderOFX writes an OFX 2.2 bank statement response, which accounting software imports as a checking account.
+// The transaction uuids are the FITIDs, so importing overlapping statements doesn't duplicate transactions.
+func renderOFX(s *domain.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	buf.WriteString(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+
+	w := &ofxWriter{buf: &buf}
+
+	w.open("OFX")
+	w.open("SIGNONMSGSRSV1")
+	w.open("SONRS")
+	w.status()
+	w.element("DTSERVER", ofxDate(s.GeneratedAt))
+	w.element("LANGUAGE", "ENG")
+	w.close("SONRS")
+	w.close("SIGNONMSGSRSV1")
+
+	w.open("BANKMSGSRSV1")
+	w.open("STMTTRNRS")
+	w.element("TRNUID", s.WalletUUID.String())
+	w.status()
+	w.open("STMTRS")
+	w.element("CURDEF", s.Currency)
+
+	w.open("BANKACCTFROM")
+	w.element("BANKID", ofxBankID)
+	w.element("ACCTID", s.WalletUUID.String())
+	w.element("ACCTTYPE", "CHECKING")
+	w.close("BANKACCTFROM")
+
+	w.open("BANKTRANLIST")
+	w.element("DTSTART", ofxDate(s.From))
+	w.element("DTEND", ofxDate(s.To))
+
+	for i := range s.Transactions {
+		t := &s.Transactions[i]
+		trnType := "CREDIT"
+		if t.Direction == domain.PostingDirectionDebit {
+			trnType = "DEBIT"
+		}
+
+		w.open("STMTTRN")
+		w.element("TRNTYPE", trnType)
+		w.element("DTPOSTED", ofxDate(t.CreatedAt))
+		w.element("TRNAMT", signedAmount(s, t))
+		w.element("FITID", t.UUID.String())
+		w.element("NAME", typeLabel(t.Type))
+		w.element("MEMO", t.Description)
+		w.close("STMTTRN")
+	}
+
+	w.close("BANKTRANLIST")
+
+	w.open("LEDGERBAL")
+	w.element("BALAMT", amount(s, s.ClosingBalance))
+	w.element("DTASOF", ofxDate(s.To))
+	w.close("LEDGERBAL")
+
+	w.close("STMTRS")
+	w.close("STMTTRNRS")
+	w.close("BANKMSGSRSV1")
+	w.close("OFX")
+
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ofxDate formats a time as an OFX datetime in UTC, e.g. "20240701120000.000[0:GMT]".
+func ofxDate(t time.Time) string {
+	return t.UTC().Format(ofxDateLayout) + "[0:GMT]"
+}
+
+// ofxWriter writes indented OFX elements, it keeps the first error.
+type ofxWriter struct {
+	buf   *bytes.Buffer
+	depth int
+	err   error
+}
+
+func (w *ofxWriter) indent() {
+	for range w.depth {
+		w.buf.WriteString("  ")
+	}
+}
+
+func (w *ofxWriter) open(name string) {
+	w.indent()
+	fmt.Fprintf(w.buf, "<%s>\n", name)
+	w.depth++
+}
+
+func (w *ofxWriter) close(name string) {
+	w.depth--
+	w.indent()
+	fmt.Fprintf(w.buf, "</%s>\n", name)
+}
+
+func (w *ofxWriter) element(name, value string) {
+	w.indent()
+	fmt.Fprintf(w.buf, "<%s>", name)
+
+	if err := xml.EscapeText(w.buf, []byte(value)); err != nil && w.err == nil {
+		w.err = err
+	}
+
+	fmt.Fprintf(w.buf, "</%s>\n", name)
+}
+
+func (w *ofxWriter) status() {
+	w.open("STATUS")
+	w.element("CODE", "0")
+	w.element("SEVERITY", "INFO")
+	w.close("STATUS")
+}
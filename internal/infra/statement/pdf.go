@@ -0,0 +1,166 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// A4 page in points, set in 8pt Courier. Courier is one of the standard PDF fonts, so nothing is embedded,
+// and its fixed width lines up the columns of the transaction table.
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 8
+	pdfLineHeight   = 11
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+
+	pdfDateTimeLayout = "2006-01-02 15:04"
+)
+
+const pdfTableRow = "%-16s  %-13s  %-32s  %14s  %14s"
+
+// renderPDF writes a plain text statement, paginated, with page numbers at the bottom of every page.
+func renderPDF(s *domain.Statement) ([]byte, error) {
+	return writePDF(paginate(pdfLines(s), pdfLinesPerPage)), nil
+}
+
+func pdfLines(s *domain.Statement) []string {
+	lines := make([]string, 0, len(s.Transactions)+16)
+	lines = append(lines,
+		"xPay Account Statement",
+		"",
+		"Wallet:     "+s.WalletUUID.String(),
+		"Currency:   "+s.Currency,
+		fmt.Sprintf("Period:     %s to %s", s.From.Format(dateLayout), lastDay(s).Format(dateLayout)),
+		"Generated:  "+s.GeneratedAt.UTC().Format(pdfDateTimeLayout)+" UTC",
+		"",
+		"Opening balance:  "+amount(s, s.OpeningBalance),
+		"",
+		fmt.Sprintf(pdfTableRow, "Date (UTC)", "Type", "Description", "Amount", "Balance"),
+		strings.Repeat("-", 97),
+	)
+
+	for i := range s.Transactions {
+		t := &s.Transactions[i]
+		lines = append(lines, fmt.Sprintf(pdfTableRow,
+			t.CreatedAt.UTC().Format(pdfDateTimeLayout),
+			typeLabel(t.Type),
+			shorten(t.Description, 32),
+			signedAmount(s, t),
+			amount(s, t.BalanceAfterInCents),
+		))
+	}
+
+	if len(s.Transactions) == 0 {
+		lines = append(lines, "No transactions in this period.")
+	}
+
+	lines = append(lines,
+		"",
+		"Total credits:    "+amount(s, s.TotalCreditsInCents),
+		"Total debits:     "+amount(s, s.TotalDebitsInCents),
+		"Closing balance:  "+amount(s, s.ClosingBalance),
+	)
+
+	return lines
+}
+
+func paginate(lines []string, perPage int) [][]string {
+	pages := make([][]string, 0, len(lines)/perPage+1)
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+
+	return append(pages, lines)
+}
+
+// writePDF writes a PDF 1.4 document with one text page per entry of pages. Objects 1 to 3 are the catalog,
+// the page tree and the font, followed by a page object and its content stream for every page.
+func writePDF(pages [][]string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	writeObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range pages {
+		content := pdfPageContent(lines, i+1, len(pages))
+
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i))
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+
+	return buf.Bytes()
+}
+
+func pdfPageContent(lines []string, page, pageCount int) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+	for _, line := range lines {
+		fmt.Fprintf(&b, "(%s) Tj T*\n", pdfEscape(line))
+	}
+	b.WriteString("ET\n")
+
+	fmt.Fprintf(&b, "BT /F1 %d Tf %d %d Td (Page %d of %d) Tj ET", pdfFontSize, pdfMargin, pdfMargin/2, page, pageCount)
+
+	return b.String()
+}
+
+// pdfEscape escapes the delimiters of a PDF string and replaces characters outside printable ASCII,
+// which the standard fonts can't show without an embedded encoding.
+func pdfEscape(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < ' ' || r > '~':
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// shorten cuts s to at most n characters, marking the cut with "...".
+func shorten(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-3]) + "..."
+}
@@ -0,0 +1,70 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// ErrUnsupportedFormat means the statement can't be rendered in the requested format.
+var ErrUnsupportedFormat = errors.New("unsupported statement format")
+
+const dateLayout = "2006-01-02"
+
+// Render renders the statement as a CSV, OFX or PDF file named after the wallet and the period.
+func Render(s *domain.Statement, format string) (*domain.StatementFile, error) {
+	var content []byte
+	var contentType string
+	var err error
+
+	switch format {
+	case domain.StatementFormatCSV:
+		content, err = renderCSV(s)
+		contentType = "text/csv; charset=utf-8"
+	case domain.StatementFormatOFX:
+		content, err = renderOFX(s)
+		contentType = "application/x-ofx"
+	case domain.StatementFormatPDF:
+		content, err = renderPDF(s)
+		contentType = "application/pdf"
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s statement: %w", format, err)
+	}
+
+	return &domain.StatementFile{
+		FileName:    fmt.Sprintf("statement-%s-%s-%s.%s", s.WalletUUID, s.From.Format(dateLayout), lastDay(s).Format(dateLayout), format),
+		ContentType: contentType,
+		Content:     content,
+	}, nil
+}
+
+// lastDay returns the last day covered by the statement, its period ends before To.
+func lastDay(s *domain.Statement) time.Time {
+	return s.To.Add(-time.Nanosecond)
+}
+
+// amount formats an amount of the statement's currency in major units, e.g. "10.50".
+func amount(s *domain.Statement, minorUnits int64) string {
+	return domain.Money{Amount: minorUnits, Currency: s.Currency}.Decimal()
+}
+
+// signedAmount is the change a transaction made to the balance, negative for debits.
+func signedAmount(s *domain.Statement, t *domain.Transaction) string {
+	if t.Direction == domain.PostingDirectionDebit {
+		return amount(s, -t.AmountInCents)
+	}
+
+	return amount(s, t.AmountInCents)
+}
+
+// typeLabel turns a transaction type into a label, e.g. "fx_conversion" into "fx conversion".
+func typeLabel(transactionType string) string {
+	return strings.ReplaceAll(transactionType, "_", " ")
+}
@@ -0,0 +1,120 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStatement(transactionCount int) *domain.Statement {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	wallet := &domain.Wallet{UUID: uuid.MustParse("5f0b2c43-3f6a-4c1e-9b7e-0d1c2b3a4f5e"), Currency: "USD"}
+
+	balance := int64(10000)
+	transactions := make([]domain.Transaction, 0, transactionCount)
+
+	for i := range transactionCount {
+		t := domain.Transaction{
+			UUID:          uuid.New(),
+			Type:          domain.JournalEntryTypeDeposit,
+			Direction:     domain.PostingDirectionCredit,
+			Status:        domain.TransactionStatusCompleted,
+			AmountInCents: 2550,
+			Currency:      "USD",
+			Description:   "Card deposit",
+			CreatedAt:     from.Add(time.Duration(i+1) * time.Hour),
+		}
+
+		if i%2 == 1 {
+			t.Type = domain.JournalEntryTypeTransfer
+			t.Direction = domain.PostingDirectionDebit
+			t.AmountInCents = 1005
+			t.Description = "=HYPERLINK(\"http://example.com\")"
+			balance -= t.AmountInCents
+		} else {
+			balance += t.AmountInCents
+		}
+
+		t.BalanceAfterInCents = balance
+		transactions = append(transactions, t)
+	}
+
+	return domain.NewStatement(wallet, from, from.AddDate(0, 1, 0), 10000, transactions, from.AddDate(0, 1, 1))
+}
+
+func TestRenderCSV(t *testing.T) {
+	file, err := Render(testStatement(2), domain.StatementFormatCSV)
+	require.NoError(t, err)
+
+	assert.Equal(t, "statement-5f0b2c43-3f6a-4c1e-9b7e-0d1c2b3a4f5e-2026-03-01-2026-03-31.csv", file.FileName)
+	assert.Equal(t, "text/csv; charset=utf-8", file.ContentType)
+
+	rows, err := csv.NewReader(bytes.NewReader(file.Content)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+
+	assert.Equal(t, []string{"2026-03-01T00:00:00Z", "", "", "Opening balance", "", "100.00", "USD"}, rows[1])
+	assert.Equal(t, []string{"deposit", "Card deposit", "25.50", "125.50"}, rows[2][2:6])
+	assert.Equal(t, []string{"transfer", "'=HYPERLINK(\"http://example.com\")", "-10.05", "115.45"}, rows[3][2:6])
+	assert.Equal(t, []string{"2026-04-01T00:00:00Z", "", "", "Closing balance", "", "115.45", "USD"}, rows[4])
+}
+
+func TestRenderOFX(t *testing.T) {
+	s := testStatement(2)
+
+	file, err := Render(s, domain.StatementFormatOFX)
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/x-ofx", file.ContentType)
+
+	content := string(file.Content)
+	assert.True(t, strings.HasPrefix(content, `<?xml version="1.0"`))
+	assert.Contains(t, content, "<CURDEF>USD</CURDEF>")
+	assert.Contains(t, content, "<DTSTART>20260301000000.000[0:GMT]</DTSTART>")
+	assert.Contains(t, content, "<TRNTYPE>DEBIT</TRNTYPE>")
+	assert.Contains(t, content, "<TRNAMT>-10.05</TRNAMT>")
+	assert.Contains(t, content, "<FITID>"+s.Transactions[0].UUID.String()+"</FITID>")
+	assert.Contains(t, content, "<BALAMT>115.45</BALAMT>")
+	assert.Contains(t, content, "&#34;http://example.com&#34;")
+	assert.Equal(t, 2, strings.Count(content, "<STMTTRN>"))
+}
+
+func TestRenderPDF(t *testing.T) {
+	file, err := Render(testStatement(pdfLinesPerPage+10), domain.StatementFormatPDF)
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/pdf", file.ContentType)
+
+	content := string(file.Content)
+	assert.True(t, strings.HasPrefix(content, "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(content, "%%EOF\n"))
+	assert.Contains(t, content, "/Count 2")
+	assert.Contains(t, content, "(Page 2 of 2) Tj")
+
+	// the xref table must point at the start of every object
+	xref := content[strings.Index(content, "xref\n"):]
+	lines := strings.Split(xref, "\n")
+	for i, line := range lines[3 : 3+7] {
+		var offset int
+		_, err := fmt.Sscanf(line, "%010d", &offset)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(content[offset:], fmt.Sprintf("%d 0 obj", i+1)), "object %d", i+1)
+	}
+}
+
+func TestRenderUnsupportedFormat(t *testing.T) {
+	_, err := Render(testStatement(0), "xlsx")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestPDFEscape(t *testing.T) {
+	assert.Equal(t, `Caf? \(50\\50\)`, pdfEscape(`Café (50\50)`))
+}
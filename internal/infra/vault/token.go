@@ -0,0 +1,56 @@
+package vault
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/ashtishad/xpay/internal/cardvalidation"
+)
+
+// TokenPrefix starts every card token. No card network issues numbers starting with 9,
+// so a token is never mistaken for a card number while it has the same format.
+const TokenPrefix = '9'
+
+const (
+	minTokenLength = 13
+	maxTokenLength = 19
+	lastFourLength = 4
+)
+
+var ErrInvalidCardNumber = errors.New("card number must be 13 to 19 digits")
+
+// NewToken returns a random network-style token for cardNumber: as many digits as the card number,
+// TokenPrefix first, the same last four and a valid Luhn check digit, so it passes the checks a card number does.
+// Nothing of the card number but the last four can be derived from the token.
+func NewToken(cardNumber string) (string, error) {
+	if len(cardNumber) < minTokenLength || len(cardNumber) > maxTokenLength || !cardvalidation.IsDigits(cardNumber) {
+		return "", ErrInvalidCardNumber
+	}
+
+	token := make([]byte, len(cardNumber))
+	token[0] = TokenPrefix
+	copy(token[len(token)-lastFourLength:], cardNumber[len(cardNumber)-lastFourLength:])
+
+	// the digit before the last four is the one that makes the Luhn sum work out
+	balance := len(token) - lastFourLength - 1
+	for i := 1; i < balance; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+
+		token[i] = byte('0' + n.Int64())
+	}
+
+	// balance is at an even distance from the right, so it's never doubled and adds itself to the Luhn sum
+	token[balance] = '0'
+	token[balance] = byte('0' + (10-cardvalidation.LuhnSum(string(token))%10)%10)
+
+	return string(token), nil
+}
+
+// IsToken reports whether s has the format of a token issued by NewToken.
+func IsToken(s string) bool {
+	return len(s) >= minTokenLength && len(s) <= maxTokenLength && s[0] == TokenPrefix && cardvalidation.IsDigits(s) && cardvalidation.LuhnSum(s)%10 == 0
+}
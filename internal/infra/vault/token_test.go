@@ -0,0 +1,34 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewToken(t *testing.T) {
+	for _, cardNumber := range []string{"4111111111111111", "378282246310005", "4222222222222", "6011111111111117000"} {
+		t.Run(cardNumber, func(t *testing.T) {
+			token, err := NewToken(cardNumber)
+			require.NoError(t, err)
+
+			assert.Len(t, token, len(cardNumber))
+			assert.Equal(t, byte(TokenPrefix), token[0])
+			assert.Equal(t, cardNumber[len(cardNumber)-4:], token[len(token)-4:])
+			assert.True(t, IsToken(token), "token passes the Luhn check")
+			assert.False(t, IsToken(cardNumber))
+
+			other, err := NewToken(cardNumber)
+			require.NoError(t, err)
+			assert.NotEqual(t, token, other, "tokens are random")
+		})
+	}
+}
+
+func TestNewTokenInvalidCardNumber(t *testing.T) {
+	for _, cardNumber := range []string{"", "411111111111", "41111111111111111111", "4111-1111-1111-1111"} {
+		_, err := NewToken(cardNumber)
+		assert.ErrorIs(t, err, ErrInvalidCardNumber, cardNumber)
+	}
+}
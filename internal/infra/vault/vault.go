@@ -0,0 +1,136 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+)
+
+// tokenAttempts is how often the vault draws a new token for a card added before tokenization when the drawn one is taken.
+const tokenAttempts = 3
+
+// ErrUnknownToken means no active card has the token.
+var ErrUnknownToken = errors.New("unknown card token")
+
+// VaultedCard is a card number as the vault stores it, encrypted, with the token the rest of the system uses for it.
+type VaultedCard struct {
+	Token     string
+	Encrypted *secure.EncryptedCardNumber
+}
+
+// Tokenizer exchanges card numbers for tokens, it never gives a card number back.
+// Handlers and everything else outside the vault work with it.
+type Tokenizer interface {
+	// Tokenize encrypts a new card number and issues its token, the caller stores both with the card.
+	Tokenize(ctx context.Context, cardNumber string) (*VaultedCard, error)
+
+	// TokenFor returns the token of a stored card, cards added before tokenization get theirs issued.
+	TokenFor(ctx context.Context, card *domain.Card) (string, error)
+}
+
+// Detokenizer exchanges a token for the card number. It's the only way out of the vault for a card number,
+// only the payment gateway gets one, and every call is audited with its purpose and reference before it returns.
+type Detokenizer interface {
+	Detokenize(ctx context.Context, token, purpose, reference string) (string, error)
+}
+
+// Vault keeps the card numbers, encrypted with the CardEncryptor in the cards of repo.
+// It implements both Tokenizer and Detokenizer, callers get only the side they need.
+type Vault struct {
+	repo      domain.CardRepository
+	encryptor *secure.CardEncryptor
+}
+
+// NewVault creates a Vault for the cards of repo.
+func NewVault(repo domain.CardRepository, encryptor *secure.CardEncryptor) *Vault {
+	return &Vault{repo: repo, encryptor: encryptor}
+}
+
+// Tokenize encrypts cardNumber and issues a new random token for it.
+func (v *Vault) Tokenize(ctx context.Context, cardNumber string) (*VaultedCard, error) {
+	token, err := NewToken(cardNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := v.encryptor.Encrypt(ctx, cardNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VaultedCard{Token: token, Encrypted: encrypted}, nil
+}
+
+// TokenFor returns the token of card. A card added before tokenization gets one issued and stored,
+// concurrent callers end up with the same token.
+func (v *Vault) TokenFor(ctx context.Context, card *domain.Card) (string, error) {
+	if card.Token != "" {
+		return card.Token, nil
+	}
+
+	cardNumber, err := v.decrypt(ctx, card)
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 1; ; attempt++ {
+		token, err := NewToken(cardNumber)
+		if err != nil {
+			return "", err
+		}
+
+		stored, appErr := v.repo.SetToken(ctx, card.ID, token)
+		if appErr == nil {
+			card.Token = stored
+			return stored, nil
+		}
+
+		if appErr.Code() != http.StatusConflict || attempt == tokenAttempts {
+			return "", appErr
+		}
+	}
+}
+
+// Detokenize returns the card number of an active card's token. The audit record is written first,
+// a card number is never returned without one.
+func (v *Vault) Detokenize(ctx context.Context, token, purpose, reference string) (string, error) {
+	if !IsToken(token) {
+		return "", ErrUnknownToken
+	}
+
+	card, appErr := v.repo.FindBy(ctx, common.DBColumnCardToken, token)
+	if appErr != nil {
+		if appErr.Code() == http.StatusNotFound {
+			slog.Warn("detokenize called with an unknown card token", "purpose", purpose, "reference", reference,
+				"requestID", common.RequestIDFromContext(ctx))
+			return "", ErrUnknownToken
+		}
+
+		return "", appErr
+	}
+
+	if appErr = v.repo.RecordDetokenization(ctx, &domain.CardDetokenization{
+		CardID:    card.ID,
+		Purpose:   purpose,
+		Reference: reference,
+		RequestID: common.RequestIDFromContext(ctx),
+	}); appErr != nil {
+		return "", fmt.Errorf("failed to audit card detokenization: %w", appErr)
+	}
+
+	return v.decrypt(ctx, card)
+}
+
+func (v *Vault) decrypt(ctx context.Context, card *domain.Card) (string, error) {
+	return v.encryptor.Decrypt(ctx, &secure.EncryptedCardNumber{
+		Ciphertext: card.EncryptedCardNumber,
+		DataKey:    card.EncryptedDataKey,
+		KeyID:      card.EncryptionKeyID,
+	})
+}
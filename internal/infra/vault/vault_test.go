@@ -0,0 +1,159 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCardNumber = "4111111111111111"
+
+// memoryCardRepo holds cards and the detokenization audit in memory.
+type memoryCardRepo struct {
+	domain.CardRepository
+	cards     []*domain.Card
+	audit     []domain.CardDetokenization
+	auditDown bool
+}
+
+func (r *memoryCardRepo) FindBy(_ context.Context, column string, value any) (*domain.Card, common.AppError) {
+	for _, c := range r.cards {
+		if column == common.DBColumnCardToken && c.Token == value && c.Status != domain.CardStatusDeleted {
+			copied := *c
+			return &copied, nil
+		}
+	}
+
+	return nil, common.NewNotFoundError("card not found")
+}
+
+func (r *memoryCardRepo) SetToken(_ context.Context, cardID int64, token string) (string, common.AppError) {
+	for _, c := range r.cards {
+		if c.ID == cardID {
+			if c.Token == "" {
+				c.Token = token
+			}
+
+			return c.Token, nil
+		}
+	}
+
+	return "", common.NewNotFoundError("card not found")
+}
+
+func (r *memoryCardRepo) RecordDetokenization(_ context.Context, d *domain.CardDetokenization) common.AppError {
+	if r.auditDown {
+		return common.NewInternalServerError(common.ErrUnexpectedDatabase, errors.New("connection refused"))
+	}
+
+	r.audit = append(r.audit, *d)
+	return nil
+}
+
+func newTestVault(t *testing.T) (*Vault, *memoryCardRepo) {
+	t.Helper()
+
+	keys, err := secure.NewLocalKeyProvider("test", map[string][]byte{"test": []byte("0123456789abcdef0123456789abcdef")})
+	require.NoError(t, err)
+
+	encryptor, err := secure.NewCardEncryptor(keys, "")
+	require.NoError(t, err)
+
+	repo := &memoryCardRepo{}
+	return NewVault(repo, encryptor), repo
+}
+
+// addCard stores a card number like the card handler does, token is left out for cards added before tokenization.
+func addCard(t *testing.T, v *Vault, repo *memoryCardRepo, withToken bool) *domain.Card {
+	t.Helper()
+
+	vaulted, err := v.Tokenize(context.Background(), testCardNumber)
+	require.NoError(t, err)
+
+	card := &domain.Card{
+		ID:                  int64(len(repo.cards) + 1),
+		EncryptedCardNumber: vaulted.Encrypted.Ciphertext,
+		EncryptedDataKey:    vaulted.Encrypted.DataKey,
+		EncryptionKeyID:     vaulted.Encrypted.KeyID,
+		Status:              domain.CardStatusActive,
+	}
+
+	if withToken {
+		card.Token = vaulted.Token
+	}
+
+	repo.cards = append(repo.cards, card)
+	return card
+}
+
+func TestVaultDetokenize(t *testing.T) {
+	v, repo := newTestVault(t)
+	card := addCard(t, v, repo, true)
+	ctx := common.WithRequestID(context.Background(), "req-1")
+
+	cardNumber, err := v.Detokenize(ctx, card.Token, domain.CardDetokenizationPurposeCharge, "dep-1")
+	require.NoError(t, err)
+	assert.Equal(t, testCardNumber, cardNumber)
+
+	require.Len(t, repo.audit, 1)
+	assert.Equal(t, domain.CardDetokenization{
+		CardID:    card.ID,
+		Purpose:   domain.CardDetokenizationPurposeCharge,
+		Reference: "dep-1",
+		RequestID: "req-1",
+	}, repo.audit[0])
+
+	_, err = v.Detokenize(ctx, testCardNumber, domain.CardDetokenizationPurposeCharge, "dep-2")
+	assert.ErrorIs(t, err, ErrUnknownToken, "a card number isn't a token")
+
+	card.Status = domain.CardStatusDeleted
+	_, err = v.Detokenize(ctx, card.Token, domain.CardDetokenizationPurposeCharge, "dep-3")
+	assert.ErrorIs(t, err, ErrUnknownToken, "tokens of deleted cards are retired")
+
+	assert.Len(t, repo.audit, 1)
+}
+
+func TestVaultDetokenizeWithoutAudit(t *testing.T) {
+	v, repo := newTestVault(t)
+	card := addCard(t, v, repo, true)
+	repo.auditDown = true
+
+	cardNumber, err := v.Detokenize(context.Background(), card.Token, domain.CardDetokenizationPurposeCharge, "dep-1")
+	assert.Error(t, err)
+	assert.Empty(t, cardNumber, "no card number leaves the vault unaudited")
+}
+
+func TestVaultTokenFor(t *testing.T) {
+	ctx := context.Background()
+	v, repo := newTestVault(t)
+
+	tokenized := addCard(t, v, repo, true)
+	token, err := v.TokenFor(ctx, tokenized)
+	require.NoError(t, err)
+	assert.Equal(t, tokenized.Token, token)
+
+	legacy := addCard(t, v, repo, false)
+	token, err = v.TokenFor(ctx, &domain.Card{
+		ID:                  legacy.ID,
+		EncryptedCardNumber: legacy.EncryptedCardNumber,
+		EncryptedDataKey:    legacy.EncryptedDataKey,
+		EncryptionKeyID:     legacy.EncryptionKeyID,
+	})
+	require.NoError(t, err)
+	assert.True(t, IsToken(token))
+	assert.Equal(t, token, legacy.Token, "the issued token is stored with the card")
+
+	again, err := v.TokenFor(ctx, legacy)
+	require.NoError(t, err)
+	assert.Equal(t, token, again)
+
+	cardNumber, err := v.Detokenize(ctx, token, domain.CardDetokenizationPurposeCharge, "dep-1")
+	require.NoError(t, err)
+	assert.Equal(t, testCardNumber, cardNumber)
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenAddress is returned for endpoints that resolve to an address webhooks must not be sent to.
+var ErrForbiddenAddress = errors.New("webhook endpoints must resolve to public addresses")
+
+// nonPublicPrefixes are ranges not covered by the netip.Addr predicates: this network, carrier-grade NAT,
+// IETF protocol assignments and benchmarking.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// IsPublicAddress reports whether webhooks may be sent to the address. Loopback, private, link-local,
+// multicast and unspecified addresses are internal to our network, or meaningless as a destination.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateEndpointURL resolves the host of a webhook endpoint URL and checks that every address it resolves to is public.
+// DNS can change after registration, the Dispatcher checks the address again when it connects.
+func ValidateEndpointURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !IsPublicAddress(addr) {
+			return ErrForbiddenAddress
+		}
+
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host %s: %w", host, err)
+	}
+
+	for _, addr := range addrs {
+		if !IsPublicAddress(addr) {
+			return ErrForbiddenAddress
+		}
+	}
+
+	return nil
+}
+
+// dialControl refuses connections to non-public addresses. It runs after DNS resolution for every address dialed,
+// so a host rebound to an internal address after registration is refused too.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid webhook address %s: %w", address, err)
+	}
+
+	if !IsPublicAddress(addrPort.Addr()) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsPublicAddress(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func TestValidateEndpointURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{"Public IP", "https://93.184.216.34/hooks", nil},
+		{"Loopback IP", "https://127.0.0.1/hooks", ErrForbiddenAddress},
+		{"Loopback IPv6", "https://[::1]:8443/hooks", ErrForbiddenAddress},
+		{"Private IP", "https://10.0.0.5/hooks", ErrForbiddenAddress},
+		{"Cloud Metadata", "https://169.254.169.254/latest/meta-data", ErrForbiddenAddress},
+		{"Localhost", "https://localhost/hooks", ErrForbiddenAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEndpointURL(context.Background(), tt.url)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	assert.NoError(t, dialControl("tcp4", "93.184.216.34:443", nil))
+	assert.ErrorIs(t, dialControl("tcp4", "127.0.0.1:443", nil), ErrForbiddenAddress)
+	assert.ErrorIs(t, dialControl("tcp6", "[fe80::1]:443", nil), ErrForbiddenAddress)
+}
@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+)
+
+// Dispatcher sends due webhook deliveries to their endpoints in the background, signed with the endpoint's secret.
+// Several instances can run a Dispatcher, every delivery attempt is claimed by one of them.
+type Dispatcher struct {
+	repo      domain.WebhookRepository
+	encryptor *secure.OwnerBoundEncryptor
+	client    *http.Client
+}
+
+// NewDispatcher creates a Dispatcher for the deliveries of repo. Redirects aren't followed,
+// an endpoint answering with one fails the attempt. Connections to non-public addresses are refused
+// and no proxy is used, so endpoints can't reach the internal network.
+func NewDispatcher(repo domain.WebhookRepository, encryptor *secure.OwnerBoundEncryptor) *Dispatcher {
+	dialer := &net.Dialer{
+		Timeout: domain.WebhookRequestTimeout,
+		Control: dialControl,
+	}
+
+	return &Dispatcher{
+		repo:      repo,
+		encryptor: encryptor,
+		client: &http.Client{
+			Timeout: domain.WebhookRequestTimeout,
+			Transport: &http.Transport{
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				TLSHandshakeTimeout: domain.WebhookRequestTimeout,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Run sends all due deliveries every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				if !d.DeliverNext(ctx) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// DeliverNext claims the most overdue delivery, sends it and records the attempt, it reports whether there was one.
+// A delivery whose attempt couldn't be recorded is retried once its claim expired.
+func (d *Dispatcher) DeliverNext(ctx context.Context) bool {
+	claimCtx, cancel := context.WithTimeout(ctx, common.Timeouts.Webhook.Write)
+	delivery, endpoint, appErr := d.repo.ClaimDelivery(claimCtx)
+	cancel()
+
+	if appErr != nil {
+		slog.Error("failed to claim webhook delivery", "err", appErr.Error())
+		return false
+	}
+
+	if delivery == nil {
+		return false
+	}
+
+	attempt := d.send(ctx, delivery, endpoint)
+
+	recordCtx, cancel := context.WithTimeout(ctx, common.Timeouts.Webhook.Write)
+	defer cancel()
+
+	if appErr := d.repo.RecordAttempt(recordCtx, delivery, endpoint, attempt); appErr != nil {
+		slog.Error("failed to record webhook delivery attempt", "deliveryUUID", delivery.UUID, "err", appErr.Error())
+	}
+
+	return true
+}
+
+// send posts the delivery's payload to the endpoint and returns the attempt, it never returns nil.
+func (d *Dispatcher) send(ctx context.Context, delivery *domain.WebhookDelivery, endpoint *domain.WebhookEndpoint) *domain.WebhookAttempt {
+	attempt := &domain.WebhookAttempt{}
+
+	secret, err := d.encryptor.Decrypt(endpoint.EncryptedSecret, endpoint.UUID.String())
+	if err != nil {
+		attempt.Error = "failed to sign the payload"
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", common.WebhookUserAgent)
+	req.Header.Set(common.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(common.WebhookDeliveryHeader, delivery.UUID.String())
+	req.Header.Set(common.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(common.WebhookSignatureHeader, secure.SignWebhookPayload(string(secret), timestamp, delivery.Payload))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	// Only the status code is recorded, response bodies are never stored nor shown to the endpoint's owner
+	_ = resp.Body.Close()
+
+	attempt.ResponseStatus = resp.StatusCode
+
+	return attempt
+}
@@ -0,0 +1,207 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "whsec_test"
+
+// memoryWebhookRepo hands out its pending deliveries in order, all of them to its single endpoint.
+type memoryWebhookRepo struct {
+	domain.WebhookRepository
+	endpoint   *domain.WebhookEndpoint
+	deliveries []*domain.WebhookDelivery
+	attempts   []*domain.WebhookAttempt
+}
+
+func (r *memoryWebhookRepo) ClaimDelivery(_ context.Context) (*domain.WebhookDelivery, *domain.WebhookEndpoint, common.AppError) {
+	for _, d := range r.deliveries {
+		if d.Status == domain.WebhookDeliveryStatusPending && !d.NextAttemptAt.After(time.Now()) && r.endpoint.IsActive() {
+			d.Attempts++
+			next := time.Now().Add(domain.WebhookDeliveryLockTTL)
+			d.NextAttemptAt = &next
+			return d, r.endpoint, nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+func (r *memoryWebhookRepo) RecordAttempt(_ context.Context, d *domain.WebhookDelivery, e *domain.WebhookEndpoint, a *domain.WebhookAttempt) common.AppError {
+	a.Attempt = d.Attempts
+	a.CreatedAt = time.Now()
+	r.attempts = append(r.attempts, a)
+	d.ApplyAttempt(a, a.CreatedAt)
+
+	if a.Succeeded() {
+		e.ConsecutiveFailures = 0
+	} else {
+		e.ConsecutiveFailures++
+	}
+
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, url string) (*Dispatcher, *memoryWebhookRepo) {
+	t.Helper()
+
+	encryptor, err := secure.NewOwnerBoundEncryptor("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+
+	endpoint := domain.NewWebhookEndpoint(1, url, domain.WebhookEventTypes)
+	endpoint.EncryptedSecret, err = encryptor.Encrypt([]byte(testSecret), endpoint.UUID.String())
+	require.NoError(t, err)
+
+	domainEvent, err := domain.NewEvent(context.Background(), domain.EventCardAdded, domain.AggregateTypeCard, uuid.New(),
+		domain.CardAddedData{LastFour: "4242"}, time.Now())
+	require.NoError(t, err)
+
+	event := domain.NewWebhookEvent(domainEvent)
+	payload, err := event.Payload()
+	require.NoError(t, err)
+
+	now := time.Now()
+	delivery := &domain.WebhookDelivery{
+		ID:            1,
+		UUID:          event.ID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        domain.WebhookDeliveryStatusPending,
+		NextAttemptAt: &now,
+	}
+
+	repo := &memoryWebhookRepo{endpoint: endpoint, deliveries: []*domain.WebhookDelivery{delivery}}
+
+	dispatcher := NewDispatcher(repo, encryptor)
+	// httptest servers listen on loopback, which the dispatcher refuses to dial
+	dispatcher.client.Transport = http.DefaultTransport.(*http.Transport).Clone()
+
+	return dispatcher, repo
+}
+
+func TestDispatcher_DeliverNext(t *testing.T) {
+	var received *http.Request
+	var body []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dispatcher, repo := newTestDispatcher(t, server.URL)
+	delivery := repo.deliveries[0]
+
+	require.True(t, dispatcher.DeliverNext(context.Background()))
+	require.NotNil(t, received)
+
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, domain.WebhookEventCardAdded, received.Header.Get(common.WebhookEventHeader))
+	assert.Equal(t, delivery.UUID.String(), received.Header.Get(common.WebhookDeliveryHeader))
+	assert.JSONEq(t, string(delivery.Payload), string(body))
+
+	timestamp, err := strconv.ParseInt(received.Header.Get(common.WebhookTimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, secure.VerifyWebhookSignature(testSecret, timestamp, body, received.Header.Get(common.WebhookSignatureHeader)))
+
+	assert.Equal(t, domain.WebhookDeliveryStatusSucceeded, delivery.Status)
+	require.Len(t, repo.attempts, 1)
+	assert.Equal(t, http.StatusNoContent, repo.attempts[0].ResponseStatus)
+
+	assert.False(t, dispatcher.DeliverNext(context.Background()), "a delivered event is not sent again")
+}
+
+func TestDispatcher_DeliverNext_Failures(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+	}{
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("boom"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "redirect is not followed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://example.com/elsewhere", http.StatusFound)
+			},
+			wantStatus: http.StatusFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			dispatcher, repo := newTestDispatcher(t, server.URL)
+			delivery := repo.deliveries[0]
+
+			require.True(t, dispatcher.DeliverNext(context.Background()))
+			require.Len(t, repo.attempts, 1)
+
+			attempt := repo.attempts[0]
+			assert.Equal(t, tt.wantStatus, attempt.ResponseStatus)
+
+			assert.Equal(t, domain.WebhookDeliveryStatusPending, delivery.Status)
+			assert.Equal(t, 1, repo.endpoint.ConsecutiveFailures)
+			require.NotNil(t, delivery.NextAttemptAt)
+			assert.WithinDuration(t, attempt.CreatedAt.Add(domain.WebhookRetryBaseDelay), *delivery.NextAttemptAt, time.Second)
+		})
+	}
+}
+
+func TestDispatcher_DeliverNext_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	dispatcher, repo := newTestDispatcher(t, url)
+
+	require.True(t, dispatcher.DeliverNext(context.Background()))
+	require.Len(t, repo.attempts, 1)
+	assert.Zero(t, repo.attempts[0].ResponseStatus)
+	assert.NotEmpty(t, repo.attempts[0].Error)
+	assert.Equal(t, domain.WebhookDeliveryStatusPending, repo.deliveries[0].Status)
+}
+
+func TestDispatcher_DeliverNext_RefusesInternalAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dispatcher, repo := newTestDispatcher(t, server.URL)
+	dispatcher.client = NewDispatcher(repo, nil).client
+
+	require.True(t, dispatcher.DeliverNext(context.Background()))
+	require.Len(t, repo.attempts, 1)
+
+	assert.Zero(t, requests, "the loopback endpoint must not be reached")
+	assert.Zero(t, repo.attempts[0].ResponseStatus)
+	assert.Contains(t, repo.attempts[0].Error, ErrForbiddenAddress.Error())
+	assert.Equal(t, domain.WebhookDeliveryStatusPending, repo.deliveries[0].Status)
+}
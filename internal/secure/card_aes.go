@@ -1,6 +1,7 @@
 package secure
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -8,102 +9,199 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"regexp"
+
+	"github.com/ashtishad/xpay/internal/cardvalidation"
 )
 
-// CardEncryptor provides methods for encrypting and decrypting card numbers using AES-GCM.
+// cardDataKeySize is the size of the AES-256 data key generated for every card number.
+const cardDataKeySize = 32
+
+// EncryptedCardNumber is a card number sealed with its own data key. DataKey is the data key wrapped by the
+// key-encryption key KeyID of the KeyProvider. Card numbers stored before envelope encryption have neither,
+// they are encrypted directly with the legacy card.aes_key.
+type EncryptedCardNumber struct {
+	Ciphertext []byte
+	DataKey    []byte
+	KeyID      string
+}
+
+// IsLegacy reports whether the card number was encrypted directly with the legacy key.
+func (e *EncryptedCardNumber) IsLegacy() bool {
+	return e.KeyID == ""
+}
+
+// CardEncryptor provides methods for encrypting and decrypting card numbers using AES-GCM envelope encryption.
+// Every card number gets a random data key, wrapped by the active key-encryption key of the KeyProvider.
 type CardEncryptor struct {
-	gcm cipher.AEAD
+	keys   KeyProvider
+	legacy cipher.AEAD
 }
 
-// NewCardEncryptor creates a new CardEncryptor instance with the provided AES key.
-// It initializes the AES cipher and GCM mode for encryption and decryption.
-func NewCardEncryptor(key string) (*CardEncryptor, error) {
-	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+// NewCardEncryptor creates a new CardEncryptor instance wrapping data keys with keys.
+// legacyKey decrypts card numbers stored before envelope encryption, it may be empty once none are left.
+func NewCardEncryptor(keys KeyProvider, legacyKey string) (*CardEncryptor, error) {
+	ce := &CardEncryptor{keys: keys}
+	if legacyKey == "" {
+		return ce, nil
+	}
+
+	if len(legacyKey) != 16 && len(legacyKey) != 24 && len(legacyKey) != 32 {
 		err := errors.New("invalid AES key size: must be 16, 24, or 32 bytes")
 		slog.Error("Failed to create CardEncryptor", "error", err)
 		return nil, err
 	}
 
-	block, err := aes.NewCipher([]byte(key))
+	gcm, err := newGCM([]byte(legacyKey))
 	if err != nil {
-		slog.Error("Failed to create AES cipher", "error", err)
 		return nil, err
 	}
 
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		slog.Error("Failed to create GCM", "error", err)
-		return nil, err
-	}
+	ce.legacy = gcm
 
-	return &CardEncryptor{gcm: gcm}, nil
+	return ce, nil
 }
 
-// Encrypt takes a plaintext card number, validates it, and encrypts it using AES-GCM.
-// It returns the encrypted card number as a byte slice, with the nonce prepended.
-func (ce *CardEncryptor) Encrypt(plaintext string) ([]byte, error) {
-	if err := ce.validateCardNumber(plaintext); err != nil {
+// Encrypt takes a plaintext card number, validates it, and encrypts it with a new data key using AES-GCM.
+// The ciphertext has the nonce prepended, the data key is returned wrapped by the active key-encryption key.
+func (ce *CardEncryptor) Encrypt(ctx context.Context, plaintext string) (*EncryptedCardNumber, error) {
+	if _, err := cardvalidation.ValidateNumber(plaintext); err != nil {
 		slog.Error("Invalid card number during encryption", "error", err)
 		return nil, fmt.Errorf("invalid card number: %w", err)
 	}
 
-	nonce := make([]byte, ce.gcm.NonceSize())
+	return ce.seal(ctx, plaintext)
+}
+
+// seal encrypts a card number with a new data key without validating it, for card numbers validated when they were stored.
+func (ce *CardEncryptor) seal(ctx context.Context, plaintext string) (*EncryptedCardNumber, error) {
+	dataKey := make([]byte, cardDataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		slog.Error("Failed to generate data key", "error", err)
+		return nil, err
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		slog.Error("Failed to generate nonce for encryption", "error", err)
 		return nil, err
 	}
 
-	return ce.gcm.Seal(nonce, nonce, []byte(plaintext), nil), nil
+	keyID, wrapped, err := ce.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		slog.Error("Failed to wrap data key", "error", err)
+		return nil, err
+	}
+
+	return &EncryptedCardNumber{
+		Ciphertext: gcm.Seal(nonce, nonce, []byte(plaintext), nil),
+		DataKey:    wrapped,
+		KeyID:      keyID,
+	}, nil
 }
 
-// Decrypt takes an encrypted card number (with prepended nonce), decrypts it using AES-GCM,
-// and validates the decrypted card number. It returns the decrypted card number as a string.
-func (ce *CardEncryptor) Decrypt(ciphertext []byte) (string, error) {
-	if len(ciphertext) < ce.gcm.NonceSize() {
+// Decrypt unwraps the data key of an encrypted card number, or uses the legacy key, decrypts the card number
+// using AES-GCM and checks it is made of digits. It returns the decrypted card number as a string.
+// Card numbers are validated when they are encrypted only: cards stored under older, looser rules,
+// e.g. without a Luhn check, must stay readable.
+func (ce *CardEncryptor) Decrypt(ctx context.Context, e *EncryptedCardNumber) (string, error) {
+	gcm, err := ce.cipherOf(ctx, e)
+	if err != nil {
+		return "", err
+	}
+
+	if len(e.Ciphertext) < gcm.NonceSize() {
 		err := errors.New("ciphertext too short")
 		slog.Error("Decryption failed", "error", err)
 		return "", err
 	}
 
-	nonce, ciphertext := ciphertext[:ce.gcm.NonceSize()], ciphertext[ce.gcm.NonceSize():]
-	plaintext, err := ce.gcm.Open(nil, nonce, ciphertext, nil)
+	nonce, ciphertext := e.Ciphertext[:gcm.NonceSize()], e.Ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
 		slog.Error("Failed to decrypt ciphertext", "error", err)
 		return "", err
 	}
 
 	decryptedNumber := string(plaintext)
-	if err := ce.validateCardNumber(decryptedNumber); err != nil {
-		slog.Error("Decrypted data is not a valid card number", "error", err)
-		return "", fmt.Errorf("decrypted data is not a valid card number: %w", err)
+	if !cardvalidation.IsDigits(decryptedNumber) {
+		err := errors.New("decrypted data is not a card number")
+		slog.Error("Decryption failed", "error", err)
+		return "", err
 	}
 
 	return decryptedNumber, nil
 }
 
-// validateCardNumber checks if the provided card number is valid for Visa, Mastercard, or American Express
-// using regex patterns. It returns an error if the card number is empty or doesn't match any valid pattern.
-func (ce *CardEncryptor) validateCardNumber(cardNumber string) error {
-	if cardNumber == "" {
-		err := errors.New("card number cannot be empty")
-		slog.Error("Card number validation failed", "error", err)
-		return err
+// ActiveKeyID returns the ID of the key-encryption key new data keys are wrapped with.
+func (ce *CardEncryptor) ActiveKeyID() string {
+	return ce.keys.ActiveKeyID()
+}
+
+// Reencrypt moves an encrypted card number to the active key-encryption key. Its data key is rewrapped,
+// the ciphertext stays the same. Legacy card numbers are decrypted and encrypted with a new data key.
+func (ce *CardEncryptor) Reencrypt(ctx context.Context, e *EncryptedCardNumber) (*EncryptedCardNumber, error) {
+	if e.IsLegacy() {
+		cardNumber, err := ce.Decrypt(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+
+		return ce.seal(ctx, cardNumber)
+	}
+
+	dataKey, err := ce.keys.UnwrapKey(ctx, e.KeyID, e.DataKey)
+	if err != nil {
+		slog.Error("Failed to unwrap data key", "keyID", e.KeyID, "error", err)
+		return nil, err
 	}
 
-	patterns := map[string]string{
-		"Visa":             "^4[0-9]{12}(?:[0-9]{3})?$",
-		"Mastercard":       "^5[1-5][0-9]{14}$",
-		"American Express": "^3[47][0-9]{13}$",
+	keyID, wrapped, err := ce.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		slog.Error("Failed to wrap data key", "error", err)
+		return nil, err
 	}
 
-	for _, pattern := range patterns {
-		ok, _ := regexp.MatchString(pattern, cardNumber)
-		if ok {
-			return nil
+	return &EncryptedCardNumber{Ciphertext: e.Ciphertext, DataKey: wrapped, KeyID: keyID}, nil
+}
+
+// cipherOf returns the AES-GCM cipher of the card number's data key, or of the legacy key.
+func (ce *CardEncryptor) cipherOf(ctx context.Context, e *EncryptedCardNumber) (cipher.AEAD, error) {
+	if e.IsLegacy() {
+		if ce.legacy == nil {
+			err := errors.New("card number encrypted with the legacy key, but card.aes_key is not set")
+			slog.Error("Decryption failed", "error", err)
+			return nil, err
 		}
+
+		return ce.legacy, nil
+	}
+
+	dataKey, err := ce.keys.UnwrapKey(ctx, e.KeyID, e.DataKey)
+	if err != nil {
+		slog.Error("Failed to unwrap data key", "keyID", e.KeyID, "error", err)
+		return nil, err
+	}
+
+	return newGCM(dataKey)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		slog.Error("Failed to create AES cipher", "error", err)
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		slog.Error("Failed to create GCM", "error", err)
+		return nil, err
 	}
 
-	err := errors.New("invalid card number format")
-	return err
+	return gcm, nil
 }
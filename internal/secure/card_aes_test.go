@@ -0,0 +1,177 @@
+package secure
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+const testCardNumber = "4111111111111111"
+
+func newTestKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand.Read() error = %v", err)
+	}
+
+	return key
+}
+
+func newTestCardEncryptor(t *testing.T, activeKeyID string, keys map[string][]byte, legacyKey string) *CardEncryptor {
+	t.Helper()
+
+	provider, err := NewLocalKeyProvider(activeKeyID, keys)
+	if err != nil {
+		t.Fatalf("NewLocalKeyProvider() error = %v", err)
+	}
+
+	ce, err := NewCardEncryptor(provider, legacyKey)
+	if err != nil {
+		t.Fatalf("NewCardEncryptor() error = %v", err)
+	}
+
+	return ce
+}
+
+func TestCardEncryptorRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ce := newTestCardEncryptor(t, "2026-01", map[string][]byte{"2026-01": newTestKey(t)}, "")
+
+	a, err := ce.Encrypt(ctx, testCardNumber)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	if a.KeyID != "2026-01" || len(a.DataKey) == 0 {
+		t.Errorf("Encrypt() key = %q, data key length %d, want a data key wrapped by 2026-01", a.KeyID, len(a.DataKey))
+	}
+
+	b, err := ce.Encrypt(ctx, testCardNumber)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	if bytes.Equal(a.DataKey, b.DataKey) || bytes.Equal(a.Ciphertext, b.Ciphertext) {
+		t.Error("Encrypt() reused a data key, want one per card number")
+	}
+
+	got, err := ce.Decrypt(ctx, a)
+	if err != nil || got != testCardNumber {
+		t.Errorf("Decrypt() = %q, %v, want %q", got, err, testCardNumber)
+	}
+
+	if _, err = ce.Encrypt(ctx, "1234"); err == nil {
+		t.Error("Encrypt() accepted an invalid card number")
+	}
+}
+
+func TestCardEncryptorRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := newTestKey(t), newTestKey(t)
+
+	before := newTestCardEncryptor(t, "2025-07", map[string][]byte{"2025-07": oldKey}, "")
+	encrypted, err := before.Encrypt(ctx, testCardNumber)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	after := newTestCardEncryptor(t, "2026-01", map[string][]byte{"2025-07": oldKey, "2026-01": newKey}, "")
+	if got, err := after.Decrypt(ctx, encrypted); err != nil || got != testCardNumber {
+		t.Fatalf("Decrypt() after rotation = %q, %v, want %q", got, err, testCardNumber)
+	}
+
+	reencrypted, err := after.Reencrypt(ctx, encrypted)
+	if err != nil {
+		t.Fatalf("Reencrypt() error = %v", err)
+	}
+
+	if reencrypted.KeyID != "2026-01" || !bytes.Equal(reencrypted.Ciphertext, encrypted.Ciphertext) {
+		t.Errorf("Reencrypt() = key %q, want the data key rewrapped by 2026-01 and the same ciphertext", reencrypted.KeyID)
+	}
+
+	// the old key can be removed from the keyring once every card was re-encrypted
+	retired := newTestCardEncryptor(t, "2026-01", map[string][]byte{"2026-01": newKey}, "")
+	if got, err := retired.Decrypt(ctx, reencrypted); err != nil || got != testCardNumber {
+		t.Errorf("Decrypt() without the old key = %q, %v, want %q", got, err, testCardNumber)
+	}
+
+	if _, err := retired.Decrypt(ctx, encrypted); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt() of a card under a removed key error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestCardEncryptorLegacy(t *testing.T) {
+	ctx := context.Background()
+	legacyKey := string(newTestKey(t))
+
+	gcm, err := newGCM([]byte(legacyKey))
+	if err != nil {
+		t.Fatalf("newGCM() error = %v", err)
+	}
+
+	// card numbers stored before envelope encryption: nonce and ciphertext under the legacy key, no data key
+	nonce := make([]byte, gcm.NonceSize())
+	legacy := &EncryptedCardNumber{Ciphertext: gcm.Seal(nonce, nonce, []byte(testCardNumber), nil)}
+
+	ce := newTestCardEncryptor(t, "2026-01", map[string][]byte{"2026-01": newTestKey(t)}, legacyKey)
+	if got, err := ce.Decrypt(ctx, legacy); err != nil || got != testCardNumber {
+		t.Fatalf("Decrypt() of a legacy card = %q, %v, want %q", got, err, testCardNumber)
+	}
+
+	reencrypted, err := ce.Reencrypt(ctx, legacy)
+	if err != nil {
+		t.Fatalf("Reencrypt() error = %v", err)
+	}
+
+	if reencrypted.IsLegacy() || reencrypted.KeyID != ce.ActiveKeyID() {
+		t.Errorf("Reencrypt() key = %q, want %q", reencrypted.KeyID, ce.ActiveKeyID())
+	}
+
+	withoutLegacyKey := newTestCardEncryptor(t, "2026-01", map[string][]byte{"2026-01": newTestKey(t)}, "")
+	if _, err := withoutLegacyKey.Decrypt(ctx, legacy); err == nil {
+		t.Error("Decrypt() of a legacy card without the legacy key succeeded")
+	}
+}
+
+func TestCardEncryptorKeepsLooselyValidatedCardsReadable(t *testing.T) {
+	ctx := context.Background()
+	legacyKey := string(newTestKey(t))
+
+	gcm, err := newGCM([]byte(legacyKey))
+	if err != nil {
+		t.Fatalf("newGCM() error = %v", err)
+	}
+
+	// accepted by the prefix-only check of earlier releases, fails the Luhn check
+	const looseCardNumber = "4111111111111112"
+
+	nonce := make([]byte, gcm.NonceSize())
+	legacy := &EncryptedCardNumber{Ciphertext: gcm.Seal(nonce, nonce, []byte(looseCardNumber), nil)}
+
+	ce := newTestCardEncryptor(t, "2026-01", map[string][]byte{"2026-01": newTestKey(t)}, legacyKey)
+	if _, err := ce.Encrypt(ctx, looseCardNumber); err == nil {
+		t.Error("Encrypt() of a card number failing the Luhn check succeeded")
+	}
+
+	if got, err := ce.Decrypt(ctx, legacy); err != nil || got != looseCardNumber {
+		t.Fatalf("Decrypt() = %q, %v, want %q", got, err, looseCardNumber)
+	}
+
+	reencrypted, err := ce.Reencrypt(ctx, legacy)
+	if err != nil {
+		t.Fatalf("Reencrypt() error = %v", err)
+	}
+
+	if got, err := ce.Decrypt(ctx, reencrypted); err != nil || got != looseCardNumber {
+		t.Errorf("Decrypt() after Reencrypt() = %q, %v, want %q", got, err, looseCardNumber)
+	}
+
+	garbage := &EncryptedCardNumber{Ciphertext: gcm.Seal(nonce, nonce, []byte("not a card"), nil)}
+	if _, err := ce.Decrypt(ctx, garbage); err == nil {
+		t.Error("Decrypt() of data that isn't a card number succeeded")
+	}
+}
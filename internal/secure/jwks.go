@@ -0,0 +1,52 @@
+package secure
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"fmt"
+)
+
+// p256CoordinateSize is the byte length of an x or y coordinate of a P-256 point.
+const p256CoordinateSize = 32
+
+// JWKS is a JSON Web Key Set as defined in RFC 7517, served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is the public part of an ES256 signing key as defined in RFC 7517 and RFC 7518.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// newJWK encodes a P-256 public key as a JWK, other curves can't sign ES256 tokens.
+func newJWK(keyID string, publicKey *ecdsa.PublicKey) (JWK, error) {
+	if publicKey.Curve != elliptic.P256() {
+		return JWK{}, fmt.Errorf("jwt key %q is not a P-256 key", keyID)
+	}
+
+	ecdhKey, err := publicKey.ECDH()
+	if err != nil {
+		return JWK{}, fmt.Errorf("invalid jwt key %q: %w", keyID, err)
+	}
+
+	// Uncompressed point encoding, 0x04 || x || y
+	point := ecdhKey.Bytes()
+
+	return JWK{
+		KeyType:   "EC",
+		Curve:     "P-256",
+		X:         base64.RawURLEncoding.EncodeToString(point[1 : 1+p256CoordinateSize]),
+		Y:         base64.RawURLEncoding.EncodeToString(point[1+p256CoordinateSize:]),
+		KeyID:     keyID,
+		Use:       "sig",
+		Algorithm: "ES256",
+	}, nil
+}
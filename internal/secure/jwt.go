@@ -11,59 +11,124 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	TokenTypeAccess       = "access"
+	TokenTypeMFAChallenge = "mfa_challenge"
+
+	// MFAChallengeExpiration is how long a user has to enter the second factor after the password was verified.
+	MFAChallengeExpiration = 5 * time.Minute
+)
+
+// JWTManager signs tokens with the active key and verifies them with any key of the key set.
+// Every token carries the id of its signing key in the kid header, so after a rotation tokens
+// signed with a retired key stay valid until they expire, as long as the retired key is configured.
 type JWTManager struct {
-	AccessExpiration time.Duration
+	AccessExpiration  time.Duration
+	RefreshExpiration time.Duration
 
-	publicKey  *ecdsa.PublicKey
-	privateKey *ecdsa.PrivateKey
+	activeKeyID string
+	privateKey  *ecdsa.PrivateKey
+	publicKeys  map[string]*ecdsa.PublicKey
+	jwks        JWKS
 }
 
+// NewJWTManager parses the key set of the config. Only the active key needs a private key,
+// retired keys are kept for verification and listed in the JWKS until they're removed from the config.
 func NewJWTManager(config *common.JWTConfig) (*JWTManager, error) {
-	privateKey, err := jwt.ParseECPrivateKeyFromPEM([]byte(config.PrivateKey))
-	if err != nil {
-		return nil, fmt.Errorf("could not parse private key: %w", err)
+	jm := &JWTManager{
+		AccessExpiration:  config.AccessExpiration,
+		RefreshExpiration: config.RefreshExpiration,
+		activeKeyID:       config.ActiveKeyID,
+		publicKeys:        make(map[string]*ecdsa.PublicKey, len(config.Keys)),
+		jwks:              JWKS{Keys: make([]JWK, 0, len(config.Keys))},
 	}
 
-	publicKey, err := jwt.ParseECPublicKeyFromPEM([]byte(config.PublicKey))
-	if err != nil {
-		return nil, fmt.Errorf("could not parse public key: %w", err)
+	for _, key := range config.Keys {
+		if _, exists := jm.publicKeys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.ID)
+		}
+
+		publicKey, err := jwt.ParseECPublicKeyFromPEM([]byte(key.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse public key %q: %w", key.ID, err)
+		}
+
+		jwk, err := newJWK(key.ID, publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		jm.publicKeys[key.ID] = publicKey
+		jm.jwks.Keys = append(jm.jwks.Keys, jwk)
+
+		if key.ID != config.ActiveKeyID {
+			continue
+		}
+
+		privateKey, err := jwt.ParseECPrivateKeyFromPEM([]byte(key.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse private key %q: %w", key.ID, err)
+		}
+
+		if !privateKey.PublicKey.Equal(publicKey) {
+			return nil, fmt.Errorf("private key %q doesn't match its public key", key.ID)
+		}
+
+		jm.privateKey = privateKey
 	}
 
-	return &JWTManager{
-		privateKey:       privateKey,
-		publicKey:        publicKey,
-		AccessExpiration: config.AccessExpiration,
-	}, nil
+	if jm.privateKey == nil {
+		return nil, fmt.Errorf("active jwt key %q is not in the key set", config.ActiveKeyID)
+	}
+
+	return jm, nil
 }
 
+// JWTClaims are the claims of tokens signed by JWTManager. RegisteredClaims.ID is the jti, a unique
+// token ID used to revoke a single token before it expires. TokenType tells access tokens apart from
+// MFA challenge tokens, which only prove the password step of a login.
 type JWTClaims struct {
-	UserUUID string
-	UserRole string
+	UserUUID  string
+	UserRole  string
+	TokenType string
 	jwt.RegisteredClaims
 }
 
-// GetPublicKey returns the public key used for token verification
-func (jm *JWTManager) GetPublicKey() *ecdsa.PublicKey {
-	return jm.publicKey
+// JWKS returns the public keys of the key set, to be published for other services verifying our tokens.
+func (jm *JWTManager) JWKS() JWKS {
+	return jm.jwks
 }
 
 // GenerateAccessToken returns signed jwt token string
 func (jm *JWTManager) GenerateAccessToken(userUUID string, userRole string) (string, error) {
+	return jm.generateToken(userUUID, userRole, TokenTypeAccess, jm.AccessExpiration)
+}
+
+// GenerateMFAChallengeToken returns a short-lived signed token that proves the user passed the password step,
+// it is exchanged for an access token together with a TOTP or recovery code.
+func (jm *JWTManager) GenerateMFAChallengeToken(userUUID string, userRole string) (string, error) {
+	return jm.generateToken(userUUID, userRole, TokenTypeMFAChallenge, MFAChallengeExpiration)
+}
+
+func (jm *JWTManager) generateToken(userUUID, userRole, tokenType string, expiration time.Duration) (string, error) {
 	if err := uuid.Validate(userUUID); err != nil {
 		return "", errors.New("invalid user uuid")
 	}
 
 	claims := JWTClaims{
-		UserUUID: userUUID,
-		UserRole: userRole,
+		UserUUID:  userUUID,
+		UserRole:  userRole,
+		TokenType: tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(jm.AccessExpiration)),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = jm.activeKeyID
 
 	signedString, err := token.SignedString(jm.privateKey)
 	if err != nil {
@@ -73,7 +138,9 @@ func (jm *JWTManager) GenerateAccessToken(userUUID string, userRole string) (str
 	return signedString, nil
 }
 
-func ValidateToken(tokenString string, jwtPublicKey *ecdsa.PublicKey) (*JWTClaims, error) {
+// ValidateToken verifies the signature of a token with the key named by its kid header, and its expiry.
+// Tokens without a kid, or signed with a key that is no longer in the key set, are rejected.
+func (jm *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
 	if tokenString == "" {
 		return nil, errors.New("token string cannot be empty")
 	}
@@ -83,7 +150,14 @@ func ValidateToken(tokenString string, jwtPublicKey *ecdsa.PublicKey) (*JWTClaim
 			return nil, errors.New("unexpected signing method")
 		}
 
-		return jwtPublicKey, nil
+		kid, _ := token.Header["kid"].(string)
+
+		publicKey, ok := jm.publicKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		return publicKey, nil
 	}
 
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, keyFunc)
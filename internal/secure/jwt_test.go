@@ -0,0 +1,141 @@
+package secure
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/google/uuid"
+)
+
+func newTestJWTKey(t *testing.T, id string) common.JWTKeyConfig {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	privateDER, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+
+	return common.JWTKeyConfig{
+		ID:         id,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateDER})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+	}
+}
+
+func newTestJWTManager(t *testing.T, activeKeyID string, keys ...common.JWTKeyConfig) *JWTManager {
+	t.Helper()
+
+	jm, err := NewJWTManager(&common.JWTConfig{
+		ActiveKeyID:      activeKeyID,
+		Keys:             keys,
+		AccessExpiration: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTManager() error = %v", err)
+	}
+
+	return jm
+}
+
+func TestJWTManagerKeyRotation(t *testing.T) {
+	oldKey, newKey := newTestJWTKey(t, "old"), newTestJWTKey(t, "new")
+	userUUID := uuid.NewString()
+
+	before := newTestJWTManager(t, "old", oldKey)
+	oldToken, err := before.GenerateAccessToken(userUUID, "user")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	retiredKey := oldKey
+	retiredKey.PrivateKey = ""
+	after := newTestJWTManager(t, "new", newKey, retiredKey)
+
+	newToken, err := after.GenerateAccessToken(userUUID, "user")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	for name, token := range map[string]string{"retired key": oldToken, "active key": newToken} {
+		claims, err := after.ValidateToken(token)
+		if err != nil {
+			t.Fatalf("ValidateToken() token signed with %s error = %v", name, err)
+		}
+
+		if claims.UserUUID != userUUID {
+			t.Errorf("ValidateToken() UserUUID = %q, want %q", claims.UserUUID, userUUID)
+		}
+	}
+
+	if _, err := before.ValidateToken(newToken); err == nil {
+		t.Error("ValidateToken() accepted a token signed with a key that isn't in the key set")
+	}
+
+	removed := newTestJWTManager(t, "new", newKey)
+	if _, err := removed.ValidateToken(oldToken); err == nil {
+		t.Error("ValidateToken() accepted a token signed with a removed key")
+	}
+}
+
+func TestNewJWTManagerRejectsInvalidKeySets(t *testing.T) {
+	active, other := newTestJWTKey(t, "active"), newTestJWTKey(t, "other")
+
+	mismatched := active
+	mismatched.PublicKey = other.PublicKey
+
+	tests := []struct {
+		name        string
+		activeKeyID string
+		keys        []common.JWTKeyConfig
+	}{
+		{"active key missing", "missing", []common.JWTKeyConfig{active}},
+		{"duplicate key id", "active", []common.JWTKeyConfig{active, active}},
+		{"private key doesn't match public key", "active", []common.JWTKeyConfig{mismatched}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJWTManager(&common.JWTConfig{ActiveKeyID: tt.activeKeyID, Keys: tt.keys})
+			if err == nil {
+				t.Error("NewJWTManager() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestJWTManagerJWKS(t *testing.T) {
+	jm := newTestJWTManager(t, "active", newTestJWTKey(t, "active"), newTestJWTKey(t, "retired"))
+
+	jwks := jm.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() returned %d keys, want 2", len(jwks.Keys))
+	}
+
+	for i, kid := range []string{"active", "retired"} {
+		key := jwks.Keys[i]
+		if key.KeyID != kid || key.KeyType != "EC" || key.Curve != "P-256" || key.Algorithm != "ES256" {
+			t.Errorf("JWKS() key %d = %+v, want an ES256 key with kid %q", i, key, kid)
+		}
+
+		// 32 byte coordinates, base64url encoded without padding
+		if len(key.X) != 43 || len(key.Y) != 43 {
+			t.Errorf("JWKS() key %d coordinate lengths = %d, %d, want 43", i, len(key.X), len(key.Y))
+		}
+	}
+}
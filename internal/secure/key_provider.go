@@ -0,0 +1,138 @@
+package secure
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	// ErrUnknownKey means a data key was wrapped with a key-encryption key the provider doesn't hold,
+	// e.g. one removed from the keyring before all records were re-encrypted.
+	ErrUnknownKey = errors.New("unknown key-encryption key")
+)
+
+// KeyProvider wraps the data keys that encrypt sensitive records with versioned key-encryption keys (KEKs),
+// like a KMS does. The KEKs never leave the provider, records store their wrapped data key and the KEK's ID,
+// so a KEK can be rotated without making older records unreadable.
+type KeyProvider interface {
+	// ActiveKeyID returns the ID of the KEK new data keys are wrapped with.
+	ActiveKeyID() string
+
+	// WrapKey encrypts dataKey with the active KEK, it returns the KEK's ID and the wrapped key.
+	WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error)
+
+	// UnwrapKey decrypts a data key wrapped with the KEK keyID, the active or an older one.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Keyring is the JSON file of a LocalKeyProvider, keys are base64 encoded AES keys by ID.
+//
+// Example:
+//
+//	{"activeKeyId": "2026-01", "keys": {"2025-07": "CWcK...", "2026-01": "OZlL..."}}
+type Keyring struct {
+	ActiveKeyID string            `json:"activeKeyId"`
+	Keys        map[string]string `json:"keys"`
+}
+
+// LocalKeyProvider is a KeyProvider holding its KEKs in memory, e.g. loaded from a keyring file mounted as a secret.
+// Data keys are wrapped with AES-GCM, bound to the KEK's ID through the associated data.
+type LocalKeyProvider struct {
+	activeKeyID string
+	keks        map[string]cipher.AEAD
+}
+
+// NewLocalKeyProvider creates a LocalKeyProvider with AES keys by ID, activeKeyID must be one of them.
+func NewLocalKeyProvider(activeKeyID string, keys map[string][]byte) (*LocalKeyProvider, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active key-encryption key %q is not in the keyring", activeKeyID)
+	}
+
+	keks := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		if id == "" {
+			return nil, errors.New("key-encryption key ID cannot be empty")
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key-encryption key %q: %w", id, err)
+		}
+
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCM for key-encryption key %q: %w", id, err)
+		}
+
+		keks[id] = gcm
+	}
+
+	return &LocalKeyProvider{activeKeyID: activeKeyID, keks: keks}, nil
+}
+
+// LoadLocalKeyProvider creates a LocalKeyProvider from the Keyring JSON file at path.
+func LoadLocalKeyProvider(path string) (*LocalKeyProvider, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // the path comes from config
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+
+	var keyring Keyring
+	if err = json.Unmarshal(data, &keyring); err != nil {
+		return nil, fmt.Errorf("failed to parse keyring: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(keyring.Keys))
+	for id, encoded := range keyring.Keys {
+		if keys[id], err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return nil, fmt.Errorf("failed to decode key-encryption key %q: %w", id, err)
+		}
+	}
+
+	return NewLocalKeyProvider(keyring.ActiveKeyID, keys)
+}
+
+// ActiveKeyID returns the ID of the KEK new data keys are wrapped with.
+func (p *LocalKeyProvider) ActiveKeyID() string {
+	return p.activeKeyID
+}
+
+// WrapKey encrypts dataKey with the active KEK, the wrapped key has the nonce prepended.
+func (p *LocalKeyProvider) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	kek := p.keks[p.activeKeyID]
+
+	nonce := make([]byte, kek.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return p.activeKeyID, kek.Seal(nonce, nonce, dataKey, []byte(p.activeKeyID)), nil
+}
+
+// UnwrapKey decrypts a data key wrapped by WrapKey with the KEK keyID.
+func (p *LocalKeyProvider) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := p.keks[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+
+	if len(wrapped) < kek.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+
+	nonce, wrapped := wrapped[:kek.NonceSize()], wrapped[kek.NonceSize():]
+	dataKey, err := kek.Open(nil, nonce, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return dataKey, nil
+}
@@ -0,0 +1,44 @@
+package secure
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLocalKeyProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	keyring := `{"activeKeyId": "2026-01", "keys": {"2025-07": "CWcKy/Jl/FOwCevQfkWDSGU5QZt0WMZCh/kC68k1LmM=",
+		"2026-01": "OZlLzXVWy/rTZE/qiNU2SuPrybpxrjSLK5SvoKgQby0="}}`
+
+	if err := os.WriteFile(path, []byte(keyring), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	provider, err := LoadLocalKeyProvider(path)
+	if err != nil {
+		t.Fatalf("LoadLocalKeyProvider() error = %v", err)
+	}
+
+	if provider.ActiveKeyID() != "2026-01" {
+		t.Errorf("ActiveKeyID() = %q, want 2026-01", provider.ActiveKeyID())
+	}
+
+	keyID, wrapped, err := provider.WrapKey(context.Background(), []byte("data key"))
+	if err != nil {
+		t.Fatalf("WrapKey() error = %v", err)
+	}
+
+	if _, err = provider.UnwrapKey(context.Background(), "2025-07", wrapped); err == nil {
+		t.Error("UnwrapKey() with another key succeeded, want the key ID bound to the wrapped key")
+	}
+
+	if got, err := provider.UnwrapKey(context.Background(), keyID, wrapped); err != nil || string(got) != "data key" {
+		t.Errorf("UnwrapKey() = %q, %v, want the data key", got, err)
+	}
+
+	if _, err := NewLocalKeyProvider("missing", map[string][]byte{"2026-01": newTestKey(t)}); err == nil {
+		t.Error("NewLocalKeyProvider() accepted an active key that isn't in the keyring")
+	}
+}
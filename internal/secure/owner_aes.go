@@ -0,0 +1,70 @@
+package secure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log/slog"
+)
+
+// OwnerBoundEncryptor encrypts secrets at rest using AES-GCM, e.g. TOTP secrets or webhook signing secrets,
+// each kind with its own key separate from card data. Ciphertexts are bound to their owner through the
+// associated data, so a secret copied to another owner's row fails to decrypt.
+type OwnerBoundEncryptor struct {
+	gcm cipher.AEAD
+}
+
+// NewOwnerBoundEncryptor creates a new OwnerBoundEncryptor instance with the provided AES key.
+func NewOwnerBoundEncryptor(key string) (*OwnerBoundEncryptor, error) {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		err := errors.New("invalid AES key size: must be 16, 24, or 32 bytes")
+		slog.Error("Failed to create OwnerBoundEncryptor", "error", err)
+		return nil, err
+	}
+
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		slog.Error("Failed to create AES cipher", "error", err)
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		slog.Error("Failed to create GCM", "error", err)
+		return nil, err
+	}
+
+	return &OwnerBoundEncryptor{gcm: gcm}, nil
+}
+
+// Encrypt seals the secret for the given owner, e.g. the user UUID of a TOTP secret or the endpoint UUID of a webhook secret.
+// It returns the ciphertext with the nonce prepended.
+func (oe *OwnerBoundEncryptor) Encrypt(secret []byte, owner string) ([]byte, error) {
+	nonce := make([]byte, oe.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		slog.Error("Failed to generate nonce for encryption", "error", err)
+		return nil, err
+	}
+
+	return oe.gcm.Seal(nonce, nonce, secret, []byte(owner)), nil
+}
+
+// Decrypt opens a ciphertext created by Encrypt for the same owner.
+func (oe *OwnerBoundEncryptor) Decrypt(ciphertext []byte, owner string) ([]byte, error) {
+	if len(ciphertext) < oe.gcm.NonceSize() {
+		err := errors.New("ciphertext too short")
+		slog.Error("Decryption failed", "error", err)
+		return nil, err
+	}
+
+	nonce, ciphertext := ciphertext[:oe.gcm.NonceSize()], ciphertext[oe.gcm.NonceSize():]
+	secret, err := oe.gcm.Open(nil, nonce, ciphertext, []byte(owner))
+	if err != nil {
+		slog.Error("Failed to decrypt ciphertext", "error", err)
+		return nil, err
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,155 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Coverage lists where the registered API routes and the policy disagree.
+type Coverage struct {
+	// Missing are registered routes the policy neither names nor declares public, they return 403 for everyone
+	// e.g., "POST /api/v1/users/:user_uuid/wallets"
+	Missing []string
+
+	// Orphaned are policy routes that aren't registered, and grants or scopes of actions the policy doesn't name
+	// e.g., "GET /api/v1/users/:user_uuid/wallet (GetWallet)"
+	Orphaned []string
+
+	// UnknownRoles are roles of the policy that no user can have, or that have scopes but no grants
+	UnknownRoles []string
+}
+
+// CheckCoverage compares the registered routes under basePath with the routes of the policy.
+func (p *Policy) CheckCoverage(routes gin.RoutesInfo, basePath string) *Coverage {
+	coverage := &Coverage{}
+	registered := make(map[string]bool)
+
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, basePath) {
+			continue
+		}
+
+		registered[routeKey(route.Method, route.Path)] = true
+
+		if _, named := p.actionName(route.Method, route.Path); !named && !p.isPublic(route.Method, route.Path) {
+			coverage.Missing = append(coverage.Missing, routeKey(route.Method, route.Path))
+		}
+	}
+
+	actions := make(map[string]string)
+	for _, action := range p.Actions() {
+		actions[action.Name] = action.Method
+
+		if !registered[routeKey(action.Method, action.Route)] {
+			coverage.Orphaned = append(coverage.Orphaned, fmt.Sprintf("%s (%s)", routeKey(action.Method, action.Route), action.Name))
+		}
+	}
+
+	for route, methods := range p.Public {
+		for _, method := range methods {
+			if !registered[routeKey(method, route)] {
+				coverage.Orphaned = append(coverage.Orphaned, fmt.Sprintf("%s (public)", routeKey(method, route)))
+			}
+		}
+	}
+
+	for role, grants := range p.Roles {
+		for action, methods := range grants {
+			for _, method := range methods {
+				if actions[action] != method {
+					coverage.Orphaned = append(coverage.Orphaned, fmt.Sprintf("%s %s granted to %s", method, action, role))
+				}
+			}
+		}
+	}
+
+	for role, scopes := range p.Scopes {
+		for action := range scopes {
+			if _, granted := p.Roles[role][action]; !granted {
+				coverage.Orphaned = append(coverage.Orphaned, fmt.Sprintf("scope of %s for %s, which isn't granted", role, action))
+			}
+		}
+	}
+
+	coverage.UnknownRoles = p.unknownRoles()
+
+	sort.Strings(coverage.Missing)
+	sort.Strings(coverage.Orphaned)
+
+	return coverage
+}
+
+// Err returns an error listing every difference, or nil if the policy covers the routes exactly.
+func (c *Coverage) Err() error {
+	var errs []error
+
+	if len(c.Missing) > 0 {
+		errs = append(errs, fmt.Errorf("routes missing from the policy: %s", strings.Join(c.Missing, ", ")))
+	}
+
+	if len(c.Orphaned) > 0 {
+		errs = append(errs, fmt.Errorf("orphaned policy entries: %s", strings.Join(c.Orphaned, ", ")))
+	}
+
+	if len(c.UnknownRoles) > 0 {
+		errs = append(errs, fmt.Errorf("unknown policy roles: %s", strings.Join(c.UnknownRoles, ", ")))
+	}
+
+	return errors.Join(errs...)
+}
+
+// actionName returns the policy action name of the route.
+func (p *Policy) actionName(method, path string) (string, bool) {
+	for _, routes := range p.Routes {
+		if name, ok := routes[path][method]; ok {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *Policy) isPublic(method, path string) bool {
+	for _, m := range p.Public[path] {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unknownRoles returns the roles of the policy grants and scopes that aren't user roles, sorted.
+func (p *Policy) unknownRoles() []string {
+	unknown := make(map[string]bool)
+
+	for role := range p.Roles {
+		if !domain.IsValidUserRole(role) {
+			unknown[role] = true
+		}
+	}
+
+	for role := range p.Scopes {
+		if _, ok := p.Roles[role]; !ok || !domain.IsValidUserRole(role) {
+			unknown[role] = true
+		}
+	}
+
+	roles := make([]string, 0, len(unknown))
+	for role := range unknown {
+		roles = append(roles, role)
+	}
+
+	sort.Strings(roles)
+
+	return roles
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
@@ -0,0 +1,67 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheckCoverage(t *testing.T) {
+	policy := &Policy{
+		Routes: map[string]map[string]map[string]string{
+			"wallets": {
+				"/api/v1/users/:user_uuid/wallets":              {"POST": "CreateWallet"},
+				"/api/v1/users/:user_uuid/wallets/:wallet_uuid": {"GET": "GetWallet"},
+			},
+		},
+		Public: map[string][]string{
+			"/api/v1/login":    {"POST"},
+			"/api/v1/register": {"POST"},
+		},
+		Roles: map[string]map[string][]string{
+			"user":    {"CreateWallet": {"POST"}, "DeleteWallet": {"DELETE"}},
+			"auditor": {"GetWallet": {"GET"}},
+		},
+		Scopes: map[string]map[string]string{
+			"admin": {"GetWallet": "any"},
+		},
+	}
+
+	routes := gin.RoutesInfo{
+		{Method: "POST", Path: "/api/v1/users/:user_uuid/wallets"},
+		{Method: "PATCH", Path: "/api/v1/users/:user_uuid/wallets/:wallet_uuid/status"},
+		{Method: "POST", Path: "/api/v1/login"},
+		{Method: "GET", Path: "/swagger/*any"},
+	}
+
+	coverage := policy.CheckCoverage(routes, "/api/v1")
+
+	assert.Equal(t, []string{"PATCH /api/v1/users/:user_uuid/wallets/:wallet_uuid/status"}, coverage.Missing)
+	assert.Equal(t, []string{
+		"DELETE DeleteWallet granted to user",
+		"GET /api/v1/users/:user_uuid/wallets/:wallet_uuid (GetWallet)",
+		"POST /api/v1/register (public)",
+		"scope of admin for GetWallet, which isn't granted",
+	}, coverage.Orphaned)
+	assert.Equal(t, []string{"admin", "auditor"}, coverage.UnknownRoles)
+	assert.Error(t, coverage.Err())
+}
+
+func TestPolicyCheckCoverageMatches(t *testing.T) {
+	policy, err := LoadPolicy()
+	assert.NoError(t, err)
+
+	var routes gin.RoutesInfo
+	for _, action := range policy.Actions() {
+		routes = append(routes, gin.RouteInfo{Method: action.Method, Path: action.Route})
+	}
+
+	for route, methods := range policy.Public {
+		for _, method := range methods {
+			routes = append(routes, gin.RouteInfo{Method: method, Path: route})
+		}
+	}
+
+	assert.NoError(t, policy.CheckCoverage(routes, "/api/v1").Err())
+}
@@ -0,0 +1,106 @@
+package rbac
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Explanation describes how Authorize decides a request, to debug access denials.
+type Explanation struct {
+	Role    string `json:"role"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Allowed bool   `json:"allowed"`
+
+	// Action and Template are the resolved route name and the policy route it belongs to,
+	// both are empty when no policy route matches the path and method
+	Action   string `json:"action,omitempty"`
+	Template string `json:"template,omitempty"`
+
+	// Scope is the access scope of the permission, set when the request is allowed
+	Scope string `json:"scope,omitempty"`
+
+	// Matches are the policy routes matching the path in precedence order, more than one means the path is ambiguous
+	Matches []TemplateMatch `json:"matches"`
+
+	// Grants are the role's grants of the resolved action, by method
+	Grants []ExplainedGrant `json:"grants"`
+
+	Reason string `json:"reason"`
+}
+
+// TemplateMatch is a policy route matching the explained path.
+type TemplateMatch struct {
+	Template string `json:"template"`
+
+	// Action is the route name of the explained method on this route, empty if the route doesn't have the method
+	Action string `json:"action,omitempty"`
+
+	// Selected is true for the route that decided the request, the first one in precedence order with the method
+	Selected bool `json:"selected"`
+}
+
+// ExplainedGrant is a method of the resolved action the role is granted, with its access scope.
+type ExplainedGrant struct {
+	Method string `json:"method"`
+	Scope  string `json:"scope"`
+}
+
+// Explain resolves a request like Authorize, and reports every step of the decision.
+// The path can be a route template, as gin's c.FullPath() returns it, or a concrete request path.
+func (r *RBAC) Explain(role, path, method string) *Explanation {
+	explanation := &Explanation{
+		Role:    role,
+		Method:  method,
+		Path:    path,
+		Matches: []TemplateMatch{},
+		Grants:  []ExplainedGrant{},
+	}
+
+	matches := r.matchTemplates(path)
+	selected, found := resolveTemplate(matches, method)
+
+	for _, t := range matches {
+		explanation.Matches = append(explanation.Matches, TemplateMatch{
+			Template: t.template,
+			Action:   t.actions[method],
+			Selected: found && t.template == selected.template,
+		})
+	}
+
+	switch {
+	case len(matches) == 0:
+		explanation.Reason = "no policy route matches the path"
+		return explanation
+	case !found:
+		explanation.Reason = fmt.Sprintf("no policy route matching the path has the %s method", method)
+		return explanation
+	}
+
+	explanation.Action = selected.actions[method]
+	explanation.Template = selected.template
+
+	r.mu.RLock()
+	methods := r.permissions[role][explanation.Action]
+	for m, scope := range methods {
+		explanation.Grants = append(explanation.Grants, ExplainedGrant{Method: m, Scope: scope})
+	}
+
+	scope, granted := methods[method]
+	r.mu.RUnlock()
+
+	sort.Slice(explanation.Grants, func(i, j int) bool { return explanation.Grants[i].Method < explanation.Grants[j].Method })
+
+	switch {
+	case granted:
+		explanation.Allowed = true
+		explanation.Scope = scope
+		explanation.Reason = fmt.Sprintf("role %s is granted %s with scope %s", role, explanation.Action, scope)
+	case len(methods) > 0:
+		explanation.Reason = fmt.Sprintf("role %s is granted %s, but not for the %s method", role, explanation.Action, method)
+	default:
+		explanation.Reason = fmt.Sprintf("role %s isn't granted %s", role, explanation.Action)
+	}
+
+	return explanation
+}
@@ -0,0 +1,96 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRBAC_Explain(t *testing.T) {
+	policy, _ := LoadPolicy()
+	rbac := New(policy)
+
+	cardRoute := "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid"
+
+	t.Run("Allowed", func(t *testing.T) {
+		explanation := rbac.Explain("agent", cardRoute, "GET")
+
+		assert.True(t, explanation.Allowed)
+		assert.Equal(t, "GetCard", explanation.Action)
+		assert.Equal(t, cardRoute, explanation.Template)
+		assert.Equal(t, "onboarded", explanation.Scope)
+		assert.Equal(t, []TemplateMatch{{Template: cardRoute, Action: "GetCard", Selected: true}}, explanation.Matches)
+		assert.Equal(t, []ExplainedGrant{{Method: "GET", Scope: "onboarded"}}, explanation.Grants)
+	})
+
+	t.Run("Concrete Path", func(t *testing.T) {
+		explanation := rbac.Explain("user", "/api/v1/users/4b1c/wallets/9f2e/cards/77a0", "DELETE")
+
+		assert.True(t, explanation.Allowed)
+		assert.Equal(t, "DeleteCard", explanation.Action)
+		assert.Equal(t, cardRoute, explanation.Template)
+	})
+
+	t.Run("Not Granted", func(t *testing.T) {
+		explanation := rbac.Explain("agent", cardRoute, "DELETE")
+
+		assert.False(t, explanation.Allowed)
+		assert.Equal(t, "DeleteCard", explanation.Action)
+		assert.Empty(t, explanation.Grants)
+		assert.Equal(t, "role agent isn't granted DeleteCard", explanation.Reason)
+	})
+
+	t.Run("Unknown Method", func(t *testing.T) {
+		explanation := rbac.Explain("admin", cardRoute, "PUT")
+
+		assert.False(t, explanation.Allowed)
+		assert.Empty(t, explanation.Action)
+		assert.Len(t, explanation.Matches, 1)
+		assert.False(t, explanation.Matches[0].Selected)
+		assert.Equal(t, "no policy route matching the path has the PUT method", explanation.Reason)
+	})
+
+	t.Run("Unknown Path", func(t *testing.T) {
+		explanation := rbac.Explain("admin", "/api/v1/unknown", "GET")
+
+		assert.False(t, explanation.Allowed)
+		assert.Empty(t, explanation.Matches)
+		assert.Equal(t, "no policy route matches the path", explanation.Reason)
+	})
+}
+
+func TestRBAC_ExplainAmbiguousPath(t *testing.T) {
+	policy := &Policy{
+		Routes: map[string]map[string]map[string]string{
+			"users": {
+				"/api/v1/users/:user_uuid": {"GET": "GetUser", "DELETE": "DeleteUser"},
+			},
+			"profile": {
+				"/api/v1/users/me": {"GET": "GetProfile"},
+			},
+		},
+		Roles: map[string]map[string][]string{
+			"user": {"GetProfile": {"GET"}, "DeleteUser": {"DELETE"}},
+		},
+	}
+
+	rbac := New(policy)
+
+	// The static segment wins, no matter in which order the maps are iterated
+	for range 20 {
+		explanation := rbac.Explain("user", "/api/v1/users/me", "GET")
+
+		assert.True(t, explanation.Allowed)
+		assert.Equal(t, "GetProfile", explanation.Action)
+		assert.Equal(t, []TemplateMatch{
+			{Template: "/api/v1/users/me", Action: "GetProfile", Selected: true},
+			{Template: "/api/v1/users/:user_uuid", Action: "GetUser"},
+		}, explanation.Matches)
+	}
+
+	// A more specific route without the method falls through to the next match
+	explanation := rbac.Explain("user", "/api/v1/users/me", "DELETE")
+	assert.True(t, explanation.Allowed)
+	assert.Equal(t, "DeleteUser", explanation.Action)
+	assert.Equal(t, "/api/v1/users/:user_uuid", explanation.Template)
+}
@@ -4,6 +4,9 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"sort"
+
+	"github.com/ashtishad/xpay/internal/domain"
 )
 
 //go:embed policy.json
@@ -15,9 +18,17 @@ type Policy struct {
 	// e.g., "users" -> "/api/v1/users" -> "POST" -> "CreateUserWithRole"
 	Routes map[string]map[string]map[string]string `json:"routes"`
 
+	// Public maps the URL patterns of API routes that don't require authentication to their methods
+	// e.g., "/api/v1/login" -> ["POST"]
+	Public map[string][]string `json:"public"`
+
 	// Roles maps role names to their allowed actions and HTTP methods
 	// e.g., "admin" -> "CreateUserWithRole" -> ["POST"]
 	Roles map[string]map[string][]string `json:"roles"`
+
+	// Scopes maps role names to the access scope of their actions, actions without one are limited to self
+	// e.g., "agent" -> "GetWalletBalance" -> "onboarded"
+	Scopes map[string]map[string]string `json:"scopes"`
 }
 
 // LoadPolicy reads and parses the RBAC policy from the embedded policy.json file
@@ -32,5 +43,72 @@ func LoadPolicy() (*Policy, error) {
 		return nil, fmt.Errorf("failed to unmarshal policy data: %w", err)
 	}
 
+	for role, scopes := range policy.Scopes {
+		for action, scope := range scopes {
+			if !domain.IsValidPolicyScope(scope) {
+				return nil, fmt.Errorf("invalid scope %q of role %q for action %q", scope, role, action)
+			}
+		}
+	}
+
 	return &policy, nil
 }
+
+// RoleNames returns the role names of the policy, sorted.
+func (p *Policy) RoleNames() []string {
+	roles := make([]string, 0, len(p.Roles))
+	for role := range p.Roles {
+		roles = append(roles, role)
+	}
+
+	sort.Strings(roles)
+
+	return roles
+}
+
+// Actions returns every named route of the policy, sorted by name.
+func (p *Policy) Actions() []domain.PolicyAction {
+	var actions []domain.PolicyAction
+	for _, routes := range p.Routes {
+		for route, methods := range routes {
+			for method, name := range methods {
+				actions = append(actions, domain.PolicyAction{Name: name, Method: method, Route: route})
+			}
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Name < actions[j].Name })
+
+	return actions
+}
+
+// Scope returns the access scope the policy grants the role for the action.
+func (p *Policy) Scope(role, action string) string {
+	if scope, ok := p.Scopes[role][action]; ok {
+		return scope
+	}
+
+	return domain.PolicyScopeSelf
+}
+
+// DefaultGrants returns the grants of the policy sorted by role and action, the store is seeded with them.
+func (p *Policy) DefaultGrants() []domain.PolicyGrant {
+	var grants []domain.PolicyGrant
+	for _, role := range p.RoleNames() {
+		for action, methods := range p.Roles[role] {
+			for _, method := range methods {
+				grants = append(grants, domain.PolicyGrant{Role: role, Action: action, Scope: p.Scope(role, action), Method: method})
+			}
+		}
+	}
+
+	sort.Slice(grants, func(i, j int) bool {
+		if grants[i].Role != grants[j].Role {
+			return grants[i].Role < grants[j].Role
+		}
+
+		return grants[i].Action < grants[j].Action
+	})
+
+	return grants
+}
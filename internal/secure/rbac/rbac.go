@@ -1,76 +1,249 @@
 package rbac
 
 import (
+	"context"
+	"log/slog"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/ashtishad/xpay/internal/common"
 	"github.com/ashtishad/xpay/internal/domain"
 )
 
-// RBAC handles role-based access control permissions
+// policyCategoryRBAC is the policy routes category of the policy management api.
+const policyCategoryRBAC = "rbac"
+
+// RBAC handles role-based access control permissions. The routes are defined by the embedded policy,
+// the grants come from the policy itself or, with NewFromStore, from the database where admins manage them.
 type RBAC struct {
 	// routes stores the policy routes for quick lookup
 	// e.g., "users" -> "/api/v1/users" -> "POST" -> "CreateUserWithRole"
 	routes map[string]map[string]map[string]string
 
-	// permissions stores preprocessed role permissions for efficient checking
-	// e.g., "admin" -> "CreateUserWithRole" -> "POST" -> true
-	permissions map[string]map[string]map[string]bool
+	// templates are the policy routes in match precedence order, see sortTemplates
+	templates []routeTemplate
+
+	// store is nil when the grants come from the embedded policy
+	store domain.RBACPolicyRepository
+
+	mu sync.RWMutex
+
+	// permissions stores preprocessed role permissions with their access scope for efficient checking
+	// e.g., "admin" -> "GetWalletBalance" -> "GET" -> "any"
+	permissions map[string]map[string]map[string]string
+
+	// version is the store's policy version the permissions were loaded at
+	version int64
 }
 
 // New creates a new RBAC instance from a Policy
 func New(policy *Policy) *RBAC {
-	rbac := &RBAC{
+	return &RBAC{
 		routes:      policy.Routes,
-		permissions: make(map[string]map[string]map[string]bool),
+		templates:   sortTemplates(policy.Routes),
+		permissions: buildPermissions(policy.DefaultGrants()),
 	}
+}
 
-	for role, actions := range policy.Roles {
-		rbac.permissions[role] = make(map[string]map[string]bool)
-		for actionName, methods := range actions {
-			rbac.permissions[role][actionName] = make(map[string]bool)
-			for _, method := range methods {
-				rbac.permissions[role][actionName][method] = true
-			}
+// NewFromStore seeds the store with the policy and creates an RBAC instance with the stored grants.
+// Call Sync, or Run in the background, to pick up grants changed by admins on other instances.
+func NewFromStore(ctx context.Context, policy *Policy, store domain.RBACPolicyRepository) (*RBAC, common.AppError) {
+	if appErr := store.Seed(ctx, policy.RoleNames(), policy.Actions(), policy.DefaultGrants()); appErr != nil {
+		return nil, appErr
+	}
+
+	rbac := &RBAC{
+		routes:    policy.Routes,
+		templates: sortTemplates(policy.Routes),
+		store:     store,
+	}
+
+	if appErr := rbac.Sync(ctx); appErr != nil {
+		return nil, appErr
+	}
+
+	return rbac, nil
+}
+
+// Sync reloads the grants from the store if the policy changed since the last load.
+func (r *RBAC) Sync(ctx context.Context) common.AppError {
+	if r.store == nil {
+		return nil
+	}
+
+	version, appErr := r.store.Version(ctx)
+	if appErr != nil {
+		return appErr
+	}
+
+	r.mu.RLock()
+	upToDate := r.permissions != nil && r.version == version
+	r.mu.RUnlock()
+
+	if upToDate {
+		return nil
+	}
+
+	grants, appErr := r.store.ListGrants(ctx)
+	if appErr != nil {
+		return appErr
+	}
+
+	permissions := buildPermissions(grants)
+
+	r.mu.Lock()
+	r.permissions = permissions
+	r.version = version
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Run syncs the grants from the store every interval until ctx is done.
+func (r *RBAC) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.syncWithTimeout(ctx)
 		}
 	}
+}
+
+func (r *RBAC) syncWithTimeout(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, common.Timeouts.RBAC.Read)
+	defer cancel()
 
-	return rbac
+	if appErr := r.Sync(ctx); appErr != nil {
+		slog.Error("failed to sync rbac policy", "err", appErr.Error())
+	}
 }
 
 // HasPermission checks if a role has permission for a given path and method
-// Used in the Auth Middleware
 func (r *RBAC) HasPermission(role, path, method string) bool {
+	_, ok := r.Authorize(role, path, method)
+	return ok
+}
+
+// Authorize checks if a role has permission for a given path and method, and returns the access scope
+// of the permission, it limits whose resources the role can access on routes with a user_uuid.
+// Used in the Auth Middleware
+func (r *RBAC) Authorize(role, path, method string) (string, bool) {
 	routeName := getRouteName(r, path, method)
 	if routeName == "" {
-		return false
+		return "", false
 	}
 
-	rolePerm, ok := r.permissions[role]
-	if !ok {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scope, ok := r.permissions[role][routeName][method]
+
+	return scope, ok
+}
+
+// IsProtectedGrant reports whether revoking the grant could lock every admin out of policy management,
+// admins always keep the actions of the rbac routes.
+func (r *RBAC) IsProtectedGrant(role, action string) bool {
+	if role != domain.UserRoleAdmin {
 		return false
 	}
 
-	routePerm, ok := rolePerm[routeName]
-	if !ok {
-		return false
+	for _, methods := range r.routes[policyCategoryRBAC] {
+		for _, name := range methods {
+			if name == action {
+				return true
+			}
+		}
 	}
 
-	return routePerm[method]
+	return false
+}
+
+func buildPermissions(grants []domain.PolicyGrant) map[string]map[string]map[string]string {
+	permissions := make(map[string]map[string]map[string]string)
+	for _, g := range grants {
+		if permissions[g.Role] == nil {
+			permissions[g.Role] = make(map[string]map[string]string)
+		}
+
+		if permissions[g.Role][g.Action] == nil {
+			permissions[g.Role][g.Action] = make(map[string]string)
+		}
+
+		permissions[g.Role][g.Action][g.Method] = g.Scope
+	}
+
+	return permissions
+}
+
+// routeTemplate is a policy route with its actions by method.
+type routeTemplate struct {
+	template string
+	actions  map[string]string
 }
 
-// getRouteName resolves the route name from a path and method
+// sortTemplates orders the policy routes by match precedence, like gin's router: comparing segment by segment,
+// a static segment wins over a param, and the template that sorts first wins the remaining ties.
+// e.g., "/api/v1/users/logout" wins over "/api/v1/users/:user_uuid" for the path "/api/v1/users/logout"
+func sortTemplates(routes map[string]map[string]map[string]string) []routeTemplate {
+	var templates []routeTemplate
+	for _, categoryRoutes := range routes {
+		for template, actions := range categoryRoutes {
+			templates = append(templates, routeTemplate{template: template, actions: actions})
+		}
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		a, b := strings.Split(templates[i].template, "/"), strings.Split(templates[j].template, "/")
+		for k := 0; k < len(a) && k < len(b); k++ {
+			aParam, bParam := strings.HasPrefix(a[k], ":"), strings.HasPrefix(b[k], ":")
+			if aParam != bParam {
+				return !aParam
+			}
+		}
+
+		return templates[i].template < templates[j].template
+	})
+
+	return templates
+}
+
+// matchTemplates returns the policy routes matching the path, in precedence order.
+func (r *RBAC) matchTemplates(path string) []routeTemplate {
+	var matches []routeTemplate
+	for _, t := range r.templates {
+		if matchRoute(path, t.template) {
+			matches = append(matches, t)
+		}
+	}
+
+	return matches
+}
+
+// getRouteName resolves the route name from a path and method, the first matching template
+// in precedence order with the method wins.
 // Tailored for policy.json and gin's c.FullPath()
 func getRouteName(rbac *RBAC, path, method string) string {
-	for _, routes := range rbac.routes {
-		for routeTemplate, actions := range routes {
-			if matchRoute(path, routeTemplate) {
-				if _, ok := actions[method]; ok {
-					return actions[method]
-				}
-			}
+	template, _ := resolveTemplate(rbac.matchTemplates(path), method)
+	return template.actions[method]
+}
+
+// resolveTemplate returns the first of the matching templates that has an action for the method.
+func resolveTemplate(matches []routeTemplate, method string) (routeTemplate, bool) {
+	for _, t := range matches {
+		if _, ok := t.actions[method]; ok {
+			return t, true
 		}
 	}
-	return ""
+
+	return routeTemplate{}, false
 }
 
 func matchRoute(path, template string) bool {
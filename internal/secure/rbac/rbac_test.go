@@ -1,12 +1,118 @@
 package rbac
 
 import (
+	"context"
+	"slices"
+	"sync"
 	"testing"
 
+	"github.com/ashtishad/xpay/internal/common"
 	"github.com/ashtishad/xpay/internal/domain"
 	"github.com/stretchr/testify/assert"
 )
 
+// memoryPolicyStore mirrors the seeding and versioning of the postgres policy store.
+type memoryPolicyStore struct {
+	mu      sync.Mutex
+	actions map[string]domain.PolicyAction
+	grants  map[string]map[string]string
+	version int64
+}
+
+func newMemoryPolicyStore() *memoryPolicyStore {
+	return &memoryPolicyStore{
+		actions: make(map[string]domain.PolicyAction),
+		grants:  make(map[string]map[string]string),
+	}
+}
+
+func (s *memoryPolicyStore) Seed(_ context.Context, _ []string, actions []domain.PolicyAction, grants []domain.PolicyGrant) common.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	newActions := make(map[string]bool)
+	for _, action := range actions {
+		if _, known := s.actions[action.Name]; !known {
+			s.actions[action.Name] = action
+			newActions[action.Name] = true
+		}
+	}
+
+	for _, g := range grants {
+		if newActions[g.Action] {
+			s.setGrant(g.Role, g.Action, g.Scope)
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryPolicyStore) Version(_ context.Context) (int64, common.AppError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.version, nil
+}
+
+func (s *memoryPolicyStore) ListActions(_ context.Context) ([]domain.PolicyAction, common.AppError) {
+	return nil, nil
+}
+
+func (s *memoryPolicyStore) ListGrants(_ context.Context) ([]domain.PolicyGrant, common.AppError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var grants []domain.PolicyGrant
+	for role, actions := range s.grants {
+		for action, scope := range actions {
+			grants = append(grants, domain.PolicyGrant{Role: role, Action: action, Scope: scope, Method: s.actions[action].Method})
+		}
+	}
+
+	return grants, nil
+}
+
+func (s *memoryPolicyStore) Grant(_ context.Context, role, action, scope string, _ int64) common.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setGrant(role, action, scope)
+
+	return nil
+}
+
+func (s *memoryPolicyStore) Revoke(_ context.Context, role, action string, _ int64) common.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setGrant(role, action, "")
+
+	return nil
+}
+
+func (s *memoryPolicyStore) ListChanges(_ context.Context, _ int) ([]domain.PolicyChange, common.AppError) {
+	return nil, nil
+}
+
+// setGrant grants the action within scope, or revokes it when scope is empty.
+func (s *memoryPolicyStore) setGrant(role, action, scope string) {
+	if s.grants[role] == nil {
+		s.grants[role] = make(map[string]string)
+	}
+
+	if s.grants[role][action] == scope {
+		return
+	}
+
+	if scope == "" {
+		delete(s.grants[role], action)
+	} else {
+		s.grants[role][action] = scope
+	}
+
+	s.version++
+}
+
 func TestNew(t *testing.T) {
 	policy, err := LoadPolicy()
 	assert.NoError(t, err)
@@ -33,16 +139,47 @@ func TestRBAC_HasPermission(t *testing.T) {
 		{"Admin Create User", "admin", "/api/v1/users", "POST", true},
 		{"Admin Create Wallet", "admin", "/api/v1/users/:user_uuid/wallets", "POST", true},
 		{"Admin Get Wallet Balance", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/balance", "GET", true},
+		{"Admin List Transactions", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transactions", "GET", true},
+		{"Admin Get Statement", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements", "GET", true},
+		{"Admin Get Statement Export", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid", "GET", true},
+		{"Admin Download Statement Export", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid/download", "GET", true},
 		{"Admin Update Wallet Status", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/status", "PATCH", true},
 		{"Admin Add Card", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards", "POST", true},
 		{"Admin Get Card", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", "GET", true},
 		{"Admin Update Card", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", "PATCH", true},
 		{"Admin Delete Card", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", "DELETE", true},
 		{"Admin List Cards", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards", "GET", true},
+		{"Admin Check Ledger Consistency", "admin", "/api/v1/ledger/consistency", "GET", true},
+		{"Admin Create Transfer", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transfers", "POST", true},
+		{"Admin Create FX Quote", "admin", "/api/v1/users/:user_uuid/fx/quotes", "POST", true},
+		{"Admin Convert Funds", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/conversions", "POST", true},
+		{"Admin Get Payment Intent", "admin", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid", "GET", true},
+		{"Admin Void Payment Intent", "admin", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/void", "POST", true},
+		{"Admin Create Payment Intent (Denied)", "admin", "/api/v1/users/:user_uuid/payment-intents", "POST", false},
+		{"Admin List Refunds", "admin", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/refunds", "GET", true},
+		{"Admin Create Refund (Denied)", "admin", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/refunds", "POST", false},
+		{"Admin List Webhook Endpoints", "admin", "/api/v1/users/:user_uuid/webhooks", "GET", true},
+		{"Admin Get Webhook Delivery", "admin", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid/deliveries/:delivery_uuid", "GET", true},
+		{"Admin Create Deposit", "admin", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/deposits", "POST", true},
+		{"Admin Logout", "admin", "/api/v1/logout", "POST", true},
+		{"Admin Enroll TOTP", "admin", "/api/v1/users/:user_uuid/mfa/totp", "POST", true},
+		{"Admin Confirm TOTP", "admin", "/api/v1/users/:user_uuid/mfa/totp/confirm", "POST", true},
+		{"Admin Get Login History", "admin", "/api/v1/users/:user_uuid/login-history", "GET", true},
+		{"Admin List Policy Actions", "admin", "/api/v1/rbac/actions", "GET", true},
+		{"Admin List Policy Grants", "admin", "/api/v1/rbac/grants", "GET", true},
+		{"Admin Grant Policy Action", "admin", "/api/v1/rbac/roles/:role/actions/:action", "PUT", true},
+		{"Admin Revoke Policy Action", "admin", "/api/v1/rbac/roles/:role/actions/:action", "DELETE", true},
+		{"Admin List Policy Changes", "admin", "/api/v1/rbac/changes", "GET", true},
+		{"Admin Explain Permission", "admin", "/api/v1/rbac/explain", "GET", true},
+		{"Admin Revoke User Sessions", "admin", "/api/v1/users/:user_uuid/sessions", "DELETE", true},
 
 		// User permissions
 		{"User Create Wallet", "user", "/api/v1/users/:user_uuid/wallets", "POST", true},
 		{"User Get Wallet Balance", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/balance", "GET", true},
+		{"User List Transactions", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transactions", "GET", true},
+		{"User Get Statement", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements", "GET", true},
+		{"User Get Statement Export", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid", "GET", true},
+		{"User Download Statement Export", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid/download", "GET", true},
 		{"User Update Wallet Status", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/status", "PATCH", true},
 		{"User Add Card", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards", "POST", true},
 		{"User Get Card", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", "GET", true},
@@ -50,19 +187,63 @@ func TestRBAC_HasPermission(t *testing.T) {
 		{"User Delete Card", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", "DELETE", true},
 		{"User List Cards", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards", "GET", true},
 		{"User Create User (Denied)", "user", "/api/v1/users", "POST", false},
+		{"User Check Ledger Consistency (Denied)", "user", "/api/v1/ledger/consistency", "GET", false},
+		{"User Create Transfer", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transfers", "POST", true},
+		{"User Create FX Quote", "user", "/api/v1/users/:user_uuid/fx/quotes", "POST", true},
+		{"User Convert Funds", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/conversions", "POST", true},
+		{"User Confirm Payment Intent", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/payment-intents/:payment_intent_uuid/confirm", "POST", true},
+		{"User Create Payment Intent (Denied)", "user", "/api/v1/users/:user_uuid/payment-intents", "POST", false},
+		{"User Capture Payment Intent (Denied)", "user", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/capture", "POST", false},
+		{"User Create Refund (Denied)", "user", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/refunds", "POST", false},
+		{"User Create Webhook Endpoint", "user", "/api/v1/users/:user_uuid/webhooks", "POST", true},
+		{"User Delete Webhook Endpoint", "user", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid", "DELETE", true},
+		{"User Redeliver Webhook Delivery", "user", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid/deliveries/:delivery_uuid/redeliver", "POST", true},
+		{"User Create Deposit", "user", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/deposits", "POST", true},
+		{"User Logout", "user", "/api/v1/logout", "POST", true},
+		{"User Enroll TOTP", "user", "/api/v1/users/:user_uuid/mfa/totp", "POST", true},
+		{"User Confirm TOTP", "user", "/api/v1/users/:user_uuid/mfa/totp/confirm", "POST", true},
+		{"User Get Login History", "user", "/api/v1/users/:user_uuid/login-history", "GET", true},
+		{"User List Policy Grants (Denied)", "user", "/api/v1/rbac/grants", "GET", false},
+		{"User Grant Policy Action (Denied)", "user", "/api/v1/rbac/roles/:role/actions/:action", "PUT", false},
+		{"User Explain Permission (Denied)", "user", "/api/v1/rbac/explain", "GET", false},
+		{"User Revoke User Sessions (Denied)", "user", "/api/v1/users/:user_uuid/sessions", "DELETE", false},
 
 		// Agent permissions
 		{"Agent Create User", "agent", "/api/v1/users", "POST", true},
 		{"Agent Get Wallet Balance", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/balance", "GET", true},
+		{"Agent List Transactions", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transactions", "GET", true},
+		{"Agent Get Statement", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements", "GET", true},
+		{"Agent Get Statement Export", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid", "GET", true},
+		{"Agent Download Statement Export", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid/download", "GET", true},
 		{"Agent Update Wallet Status", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/status", "PATCH", true},
 		{"Agent Get Card", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", "GET", true},
 		{"Agent List Cards", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards", "GET", true},
 		{"Agent Create Wallet (Denied)", "agent", "/api/v1/users/:user_uuid/wallets", "POST", false},
 		{"Agent Add Card (Denied)", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards", "POST", false},
+		{"Agent Create Transfer (Denied)", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transfers", "POST", false},
+		{"Agent Create FX Quote (Denied)", "agent", "/api/v1/users/:user_uuid/fx/quotes", "POST", false},
+		{"Agent Convert Funds (Denied)", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/conversions", "POST", false},
+		{"Agent Confirm Payment Intent (Denied)", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/payment-intents/:payment_intent_uuid/confirm", "POST", false},
+		{"Agent List Refunds (Denied)", "agent", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/refunds", "GET", false},
+		{"Agent Create Webhook Endpoint (Denied)", "agent", "/api/v1/users/:user_uuid/webhooks", "POST", false},
+		{"Agent List Webhook Deliveries (Denied)", "agent", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid/deliveries", "GET", false},
+		{"Agent Create Deposit (Denied)", "agent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/deposits", "POST", false},
+		{"Agent Logout", "agent", "/api/v1/logout", "POST", true},
+		{"Agent Enroll TOTP", "agent", "/api/v1/users/:user_uuid/mfa/totp", "POST", true},
+		{"Agent Confirm TOTP", "agent", "/api/v1/users/:user_uuid/mfa/totp/confirm", "POST", true},
+		{"Agent Get Login History", "agent", "/api/v1/users/:user_uuid/login-history", "GET", true},
+		{"Agent List Policy Grants (Denied)", "agent", "/api/v1/rbac/grants", "GET", false},
+		{"Agent Grant Policy Action (Denied)", "agent", "/api/v1/rbac/roles/:role/actions/:action", "PUT", false},
+		{"Agent Explain Permission (Denied)", "agent", "/api/v1/rbac/explain", "GET", false},
+		{"Agent Revoke User Sessions (Denied)", "agent", "/api/v1/users/:user_uuid/sessions", "DELETE", false},
 
 		// Merchant permissions
 		{"Merchant Create Wallet", "merchant", "/api/v1/users/:user_uuid/wallets", "POST", true},
 		{"Merchant Get Wallet Balance", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/balance", "GET", true},
+		{"Merchant List Transactions", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transactions", "GET", true},
+		{"Merchant Get Statement", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements", "GET", true},
+		{"Merchant Get Statement Export", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid", "GET", true},
+		{"Merchant Download Statement Export", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid/download", "GET", true},
 		{"Merchant Update Wallet Status", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/status", "PATCH", true},
 		{"Merchant Add Card", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards", "POST", true},
 		{"Merchant Get Card", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", "GET", true},
@@ -70,6 +251,27 @@ func TestRBAC_HasPermission(t *testing.T) {
 		{"Merchant Delete Card", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", "DELETE", true},
 		{"Merchant List Cards", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards", "GET", true},
 		{"Merchant Create User (Denied)", "merchant", "/api/v1/users", "POST", false},
+		{"Merchant Create Transfer", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transfers", "POST", true},
+		{"Merchant Create FX Quote", "merchant", "/api/v1/users/:user_uuid/fx/quotes", "POST", true},
+		{"Merchant Convert Funds", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/conversions", "POST", true},
+		{"Merchant Create Payment Intent", "merchant", "/api/v1/users/:user_uuid/payment-intents", "POST", true},
+		{"Merchant Get Payment Intent", "merchant", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid", "GET", true},
+		{"Merchant Capture Payment Intent", "merchant", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/capture", "POST", true},
+		{"Merchant Void Payment Intent", "merchant", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/void", "POST", true},
+		{"Merchant Confirm Payment Intent", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/payment-intents/:payment_intent_uuid/confirm", "POST", true},
+		{"Merchant Create Refund", "merchant", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/refunds", "POST", true},
+		{"Merchant List Refunds", "merchant", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/refunds", "GET", true},
+		{"Merchant Update Webhook Endpoint Status", "merchant", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid", "PATCH", true},
+		{"Merchant List Webhook Deliveries", "merchant", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid/deliveries", "GET", true},
+		{"Merchant Create Deposit", "merchant", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/deposits", "POST", true},
+		{"Merchant Logout", "merchant", "/api/v1/logout", "POST", true},
+		{"Merchant Enroll TOTP", "merchant", "/api/v1/users/:user_uuid/mfa/totp", "POST", true},
+		{"Merchant Confirm TOTP", "merchant", "/api/v1/users/:user_uuid/mfa/totp/confirm", "POST", true},
+		{"Merchant Get Login History", "merchant", "/api/v1/users/:user_uuid/login-history", "GET", true},
+		{"Merchant List Policy Grants (Denied)", "merchant", "/api/v1/rbac/grants", "GET", false},
+		{"Merchant Grant Policy Action (Denied)", "merchant", "/api/v1/rbac/roles/:role/actions/:action", "PUT", false},
+		{"Merchant Explain Permission (Denied)", "merchant", "/api/v1/rbac/explain", "GET", false},
+		{"Merchant Revoke User Sessions (Denied)", "merchant", "/api/v1/users/:user_uuid/sessions", "DELETE", false},
 
 		// Invalid routes (all denied)
 		{"Invalid User Route", "admin", "/api/v1/users/:user_uuid", "GET", false},
@@ -106,6 +308,10 @@ func TestGetRouteName(t *testing.T) {
 		// Wallet Management
 		{"Create Wallet", "/api/v1/users/:user_uuid/wallets", "POST", "CreateWallet"},
 		{"Get Wallet Balance", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/balance", "GET", "GetWalletBalance"},
+		{"List Transactions", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transactions", "GET", "ListTransactions"},
+		{"Get Statement", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements", "GET", "GetStatement"},
+		{"Get Statement Export", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid", "GET", "GetStatementExport"},
+		{"Download Statement Export", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid/download", "GET", "DownloadStatementExport"},
 		{"Update Wallet Status", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/status", "PATCH", "UpdateWalletStatus"},
 
 		// Card Management
@@ -115,6 +321,52 @@ func TestGetRouteName(t *testing.T) {
 		{"Delete Card", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", "DELETE", "DeleteCard"},
 		{"List Cards", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/cards", "GET", "ListCards"},
 
+		// Ledger
+		{"Check Ledger Consistency", "/api/v1/ledger/consistency", "GET", "CheckLedgerConsistency"},
+
+		// Transfers
+		{"Create Transfer", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transfers", "POST", "CreateTransfer"},
+
+		// Deposits
+		{"Create Deposit", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/deposits", "POST", "CreateDeposit"},
+
+		// FX
+		{"Create FX Quote", "/api/v1/users/:user_uuid/fx/quotes", "POST", "CreateFXQuote"},
+		{"Convert Funds", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/conversions", "POST", "ConvertFunds"},
+
+		// Payment Intents
+		{"Create Payment Intent", "/api/v1/users/:user_uuid/payment-intents", "POST", "CreatePaymentIntent"},
+		{"Get Payment Intent", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid", "GET", "GetPaymentIntent"},
+		{"Capture Payment Intent", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/capture", "POST", "CapturePaymentIntent"},
+		{"Void Payment Intent", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/void", "POST", "VoidPaymentIntent"},
+		{"Confirm Payment Intent", "/api/v1/users/:user_uuid/wallets/:wallet_uuid/payment-intents/:payment_intent_uuid/confirm", "POST", "ConfirmPaymentIntent"},
+		{"Create Refund", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/refunds", "POST", "CreateRefund"},
+		{"List Refunds", "/api/v1/users/:user_uuid/payment-intents/:payment_intent_uuid/refunds", "GET", "ListRefunds"},
+
+		// Webhooks
+		{"Create Webhook Endpoint", "/api/v1/users/:user_uuid/webhooks", "POST", "CreateWebhookEndpoint"},
+		{"List Webhook Endpoints", "/api/v1/users/:user_uuid/webhooks", "GET", "ListWebhookEndpoints"},
+		{"Update Webhook Endpoint Status", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid", "PATCH", "UpdateWebhookEndpointStatus"},
+		{"Delete Webhook Endpoint", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid", "DELETE", "DeleteWebhookEndpoint"},
+		{"List Webhook Deliveries", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid/deliveries", "GET", "ListWebhookDeliveries"},
+		{"Get Webhook Delivery", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid/deliveries/:delivery_uuid", "GET", "GetWebhookDelivery"},
+		{"Redeliver Webhook Delivery", "/api/v1/users/:user_uuid/webhooks/:webhook_uuid/deliveries/:delivery_uuid/redeliver", "POST", "RedeliverWebhookDelivery"},
+
+		// Sessions
+		{"Logout", "/api/v1/logout", "POST", "Logout"},
+		{"Revoke User Sessions", "/api/v1/users/:user_uuid/sessions", "DELETE", "RevokeUserSessions"},
+		{"Enroll TOTP", "/api/v1/users/:user_uuid/mfa/totp", "POST", "EnrollTOTP"},
+		{"Confirm TOTP", "/api/v1/users/:user_uuid/mfa/totp/confirm", "POST", "ConfirmTOTP"},
+		{"Get Login History", "/api/v1/users/:user_uuid/login-history", "GET", "GetLoginHistory"},
+
+		// RBAC Policy
+		{"List Policy Actions", "/api/v1/rbac/actions", "GET", "ListPolicyActions"},
+		{"List Policy Grants", "/api/v1/rbac/grants", "GET", "ListPolicyGrants"},
+		{"Grant Policy Action", "/api/v1/rbac/roles/:role/actions/:action", "PUT", "GrantPolicyAction"},
+		{"Revoke Policy Action", "/api/v1/rbac/roles/:role/actions/:action", "DELETE", "RevokePolicyAction"},
+		{"List Policy Changes", "/api/v1/rbac/changes", "GET", "ListPolicyChanges"},
+		{"Explain Permission", "/api/v1/rbac/explain", "GET", "ExplainPermission"},
+
 		// Invalid Routes
 		{"Invalid User Route", "/api/v1/users/:user_uuid", "GET", ""},
 		{"Invalid Wallet Route", "/api/v1/users/:user_uuid/wallets/:wallet_uuid", "GET", ""},
@@ -137,6 +389,114 @@ func TestGetRouteName(t *testing.T) {
 	}
 }
 
+func TestNewFromStore(t *testing.T) {
+	policy, err := LoadPolicy()
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	store := newMemoryPolicyStore()
+	static := New(policy)
+
+	rbac, appErr := NewFromStore(ctx, policy, store)
+	assert.Nil(t, appErr)
+
+	for _, action := range policy.Actions() {
+		for _, role := range policy.RoleNames() {
+			assert.Equal(t, static.HasPermission(role, action.Route, action.Method), rbac.HasPermission(role, action.Route, action.Method),
+				"Role: %s, Action: %s", role, action.Name)
+		}
+	}
+
+	statusRoute := "/api/v1/users/:user_uuid/wallets/:wallet_uuid/status"
+
+	assert.Nil(t, store.Revoke(ctx, domain.UserRoleUser, "UpdateWalletStatus", 1))
+	assert.True(t, rbac.HasPermission(domain.UserRoleUser, statusRoute, "PATCH"), "grants changed before Sync")
+
+	assert.Nil(t, rbac.Sync(ctx))
+	assert.False(t, rbac.HasPermission(domain.UserRoleUser, statusRoute, "PATCH"))
+	assert.True(t, rbac.HasPermission(domain.UserRoleMerchant, statusRoute, "PATCH"))
+
+	assert.Nil(t, store.Grant(ctx, domain.UserRoleAgent, "UpdateWalletStatus", domain.PolicyScopeAny, 1))
+	assert.Nil(t, rbac.Sync(ctx))
+
+	scope, ok := rbac.Authorize(domain.UserRoleAgent, statusRoute, "PATCH")
+	assert.True(t, ok)
+	assert.Equal(t, domain.PolicyScopeAny, scope)
+
+	// A restart seeds again, admin changes to known actions must survive it
+	restarted, appErr := NewFromStore(ctx, policy, store)
+	assert.Nil(t, appErr)
+	assert.False(t, restarted.HasPermission(domain.UserRoleUser, statusRoute, "PATCH"))
+
+	scope, _ = restarted.Authorize(domain.UserRoleAgent, statusRoute, "PATCH")
+	assert.Equal(t, domain.PolicyScopeAny, scope)
+}
+
+func TestRBAC_Authorize(t *testing.T) {
+	policy, _ := LoadPolicy()
+	rbac := New(policy)
+
+	balanceRoute := "/api/v1/users/:user_uuid/wallets/:wallet_uuid/balance"
+	transferRoute := "/api/v1/users/:user_uuid/wallets/:wallet_uuid/transfers"
+
+	tests := []struct {
+		name   string
+		role   string
+		path   string
+		method string
+		scope  string
+		ok     bool
+	}{
+		{"Admin Get Any Wallet Balance", "admin", balanceRoute, "GET", domain.PolicyScopeAny, true},
+		{"Agent Get Onboarded Wallet Balance", "agent", balanceRoute, "GET", domain.PolicyScopeOnboarded, true},
+		{"User Get Own Wallet Balance", "user", balanceRoute, "GET", domain.PolicyScopeSelf, true},
+		{"Admin Create Transfer From Own Wallet", "admin", transferRoute, "POST", domain.PolicyScopeSelf, true},
+		{"Agent Create Transfer (Denied)", "agent", transferRoute, "POST", "", false},
+		{"Agent Get Onboarded Login History", "agent", "/api/v1/users/:user_uuid/login-history", "GET", domain.PolicyScopeOnboarded, true},
+		{"Admin Revoke Any User Sessions", "admin", "/api/v1/users/:user_uuid/sessions", "DELETE", domain.PolicyScopeAny, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, ok := rbac.Authorize(tt.role, tt.path, tt.method)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.scope, scope)
+		})
+	}
+}
+
+func TestPolicyDefaultGrants(t *testing.T) {
+	policy, err := LoadPolicy()
+	assert.NoError(t, err)
+
+	rolesByAction := make(map[string][]string)
+	for _, g := range policy.DefaultGrants() {
+		assert.True(t, domain.IsValidPolicyScope(g.Scope), "Role: %s, Action: %s", g.Role, g.Action)
+		rolesByAction[g.Action] = append(rolesByAction[g.Action], g.Role)
+	}
+
+	assert.Equal(t, []string{domain.UserRoleAdmin}, rolesByAction["RevokeUserSessions"])
+	assert.True(t, slices.Equal(policy.RoleNames(), rolesByAction["Logout"]), "Logout should be granted to every role")
+
+	for _, action := range policy.Actions() {
+		assert.NotEmpty(t, action.Route, "Action: %s", action.Name)
+		assert.NotEmpty(t, rolesByAction[action.Name], "Action %s isn't granted to any role", action.Name)
+	}
+
+	assert.Equal(t, domain.PolicyScopeOnboarded, policy.Scope(domain.UserRoleAgent, "ListCards"))
+	assert.Equal(t, domain.PolicyScopeSelf, policy.Scope(domain.UserRoleMerchant, "ListCards"))
+}
+
+func TestIsProtectedGrant(t *testing.T) {
+	policy, _ := LoadPolicy()
+	rbac := New(policy)
+
+	assert.True(t, rbac.IsProtectedGrant(domain.UserRoleAdmin, "GrantPolicyAction"))
+	assert.True(t, rbac.IsProtectedGrant(domain.UserRoleAdmin, "ListPolicyChanges"))
+	assert.False(t, rbac.IsProtectedGrant(domain.UserRoleAgent, "GrantPolicyAction"))
+	assert.False(t, rbac.IsProtectedGrant(domain.UserRoleAdmin, "UpdateWalletStatus"))
+}
+
 func TestCanCreateUser(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -176,7 +536,7 @@ func TestLoadPolicy(t *testing.T) {
 	}
 
 	// Check if all expected route categories are present
-	expectedCategories := []string{"users", "wallets", "cards"}
+	expectedCategories := []string{"users", "wallets", "cards", "ledger"}
 	for _, category := range expectedCategories {
 		assert.Contains(t, policy.Routes, category)
 	}
@@ -0,0 +1,54 @@
+package secure
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+)
+
+const (
+	// RecoveryCodeCount is the number of single use recovery codes issued when MFA is enabled.
+	RecoveryCodeCount = 10
+
+	recoveryCodeBytes = 5
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateRecoveryCodes returns RecoveryCodeCount random codes formatted as xxxx-xxxx
+// and their bcrypt hashes, the codes are shown to the user once and only the hashes are stored.
+func GenerateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+
+	for range RecoveryCodeCount {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		code := encoded[:4] + "-" + encoded[4:]
+
+		hash, err := GeneratePasswordHash(code)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, hash)
+	}
+
+	return codes, hashes, nil
+}
+
+// NormalizeRecoveryCode accepts codes typed in upper case, with spaces or without the dash.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+	if len(code) != recoveryCodeBytes*8/5 {
+		return code
+	}
+
+	return code[:4] + "-" + code[4:]
+}
@@ -0,0 +1,57 @@
+package secure
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// opaqueTokenBytes is the entropy of refresh and account tokens, 256 bits.
+const opaqueTokenBytes = 32
+
+// GenerateRefreshToken returns an opaque random refresh token for the client and its hash for storage.
+// Only the hash is stored, so a database leak doesn't expose usable refresh tokens.
+func GenerateRefreshToken() (string, []byte, error) {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the SHA-256 hash used to look up a refresh token.
+// A fast hash is enough, refresh tokens are high entropy random values, not passwords.
+func HashRefreshToken(token string) []byte {
+	return hashOpaqueToken(token)
+}
+
+// GenerateAccountToken returns a single use token for password reset and email verification, and its hash for storage.
+func GenerateAccountToken() (string, []byte, error) {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate account token: %w", err)
+	}
+
+	return token, HashAccountToken(token), nil
+}
+
+// HashAccountToken returns the SHA-256 hash used to look up a password reset or email verification token.
+func HashAccountToken(token string) []byte {
+	return hashOpaqueToken(token)
+}
+
+func generateOpaqueToken() (string, error) {
+	b := make([]byte, opaqueTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashOpaqueToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
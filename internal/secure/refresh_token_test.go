@@ -0,0 +1,45 @@
+package secure
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token, hash, err := GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() error = %v", err)
+	}
+
+	if len(token) != 43 {
+		t.Errorf("GenerateRefreshToken() token length = %d, want 43", len(token))
+	}
+
+	if !bytes.Equal(hash, HashRefreshToken(token)) {
+		t.Error("GenerateRefreshToken() hash doesn't match HashRefreshToken(token)")
+	}
+
+	other, _, err := GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() error = %v", err)
+	}
+
+	if token == other {
+		t.Error("GenerateRefreshToken() returned the same token twice")
+	}
+}
+
+func TestGenerateAccountToken(t *testing.T) {
+	token, hash, err := GenerateAccountToken()
+	if err != nil {
+		t.Fatalf("GenerateAccountToken() error = %v", err)
+	}
+
+	if !bytes.Equal(hash, HashAccountToken(token)) {
+		t.Error("GenerateAccountToken() hash doesn't match HashAccountToken(token)")
+	}
+
+	if bytes.Equal(HashAccountToken(token), HashAccountToken(token+"x")) {
+		t.Error("HashAccountToken() returned the same hash for different tokens")
+	}
+}
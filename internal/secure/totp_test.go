@@ -0,0 +1,88 @@
+package secure
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 test key from RFC 6238 Appendix B.
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestGenerateTOTPCode(t *testing.T) {
+	// RFC 6238 Appendix B SHA1 vectors, truncated to the last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		if got := GenerateTOTPCode(rfc6238Secret, time.Unix(tt.unix, 0)); got != tt.want {
+			t.Errorf("GenerateTOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code := GenerateTOTPCode(rfc6238Secret, now)
+
+	tests := []struct {
+		name   string
+		code   string
+		at     time.Time
+		wantOk bool
+	}{
+		{"Current step", code, now, true},
+		{"One step of clock drift", code, now.Add(totpPeriod), true},
+		{"Two steps of clock drift", code, now.Add(2 * totpPeriod), false},
+		{"Wrong code", "000000", now, false},
+		{"Wrong length", "12345", now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTPCode(rfc6238Secret, tt.code, tt.at)
+			if ok != tt.wantOk {
+				t.Fatalf("ValidateTOTPCode() ok = %v, want %v", ok, tt.wantOk)
+			}
+
+			if ok && step != totpStep(now) {
+				t.Errorf("ValidateTOTPCode() step = %d, want %d", step, totpStep(now))
+			}
+		})
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI(rfc6238Secret, "xPay", "someone@example.com")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/xPay:someone@example.com?") {
+		t.Errorf("TOTPProvisioningURI() = %s, unexpected label", uri)
+	}
+
+	if !strings.Contains(uri, "secret="+EncodeTOTPSecret(rfc6238Secret)) {
+		t.Errorf("TOTPProvisioningURI() = %s, missing secret", uri)
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("GenerateRecoveryCodes() returned %d codes and %d hashes, want %d", len(codes), len(hashes), RecoveryCodeCount)
+	}
+
+	if err := VerifyPassword(hashes[0], NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", "")))); err != nil {
+		t.Errorf("normalized recovery code doesn't match its hash: %v", err)
+	}
+}
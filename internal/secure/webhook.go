@@ -0,0 +1,48 @@
+package secure
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// WebhookSecretPrefix marks webhook signing secrets, so they are recognized when leaked.
+	WebhookSecretPrefix = "whsec_"
+
+	// WebhookSignatureVersion prefixes the signature, a new scheme gets a new version.
+	WebhookSignatureVersion = "v1"
+
+	webhookSecretBytes = 32
+)
+
+// GenerateWebhookSecret returns a random signing secret for a webhook endpoint.
+// It is shown to the owner once, only its encryption is stored.
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return WebhookSecretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// SignWebhookPayload returns the signature of a delivery, the hex encoded HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the endpoint's secret. Signing the timestamp lets receivers reject replayed deliveries.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return WebhookSignatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature is the signature of the body at timestamp, in constant time.
+func VerifyWebhookSignature(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhookPayload(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,62 @@
+package secure
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"id":"evt"}`)
+
+	// expected value computed independently with Python's hmac module
+	want := "v1=a94cea056df1fbb92eadafcf2c5cd541dbe0c6ef736e4748202dd53f86694a3e"
+	if got := SignWebhookPayload("whsec_test", 1700000000, body); got != want {
+		t.Errorf("SignWebhookPayload() = %s, want %s", got, want)
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"evt"}`)
+	signature := SignWebhookPayload("whsec_test", 1700000000, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		want      bool
+	}{
+		{"valid", "whsec_test", 1700000000, body, true},
+		{"wrong secret", "whsec_other", 1700000000, body, false},
+		{"replayed with another timestamp", "whsec_test", 1700000001, body, false},
+		{"tampered body", "whsec_test", 1700000000, []byte(`{"id":"evx"}`), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyWebhookSignature(tt.secret, tt.timestamp, tt.body, signature); got != tt.want {
+				t.Errorf("VerifyWebhookSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateWebhookSecret(t *testing.T) {
+	a, err := GenerateWebhookSecret()
+	if err != nil {
+		t.Fatalf("GenerateWebhookSecret() error = %v", err)
+	}
+
+	b, err := GenerateWebhookSecret()
+	if err != nil {
+		t.Fatalf("GenerateWebhookSecret() error = %v", err)
+	}
+
+	if !strings.HasPrefix(a, WebhookSecretPrefix) || len(a) != len(WebhookSecretPrefix)+43 {
+		t.Errorf("GenerateWebhookSecret() = %s, want %s followed by 43 characters", a, WebhookSecretPrefix)
+	}
+
+	if a == b {
+		t.Error("GenerateWebhookSecret() returned the same secret twice")
+	}
+}
@@ -0,0 +1,24 @@
+package dto
+
+// ForgotPasswordRequest holds the email of the account to reset the password for.
+// @Description ForgotPasswordRequest validates input for requesting a password reset token.
+// @Description Email must be a valid email address.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest holds a password reset token and the new password.
+// @Description ResetPasswordRequest validates input for resetting a password.
+// @Description Token is the token from the password reset email.
+// @Description NewPassword must be at least 8 and at max 64 characters long.
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required,max=128"`
+	NewPassword string `json:"newPassword" binding:"required,min=8,max=64"`
+}
+
+// VerifyEmailRequest holds an email verification token.
+// @Description VerifyEmailRequest validates input for verifying an email address.
+// @Description Token is the token from the verification email.
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required,max=128"`
+}
@@ -53,3 +53,15 @@ type LoginRequest struct {
 type LoginResponse struct {
 	User domain.User `json:"user"`
 }
+
+// RefreshTokenRequest carries the refresh token for clients that don't send it as a cookie.
+// @Description RefreshTokenRequest is optional, the refreshToken cookie is used when the body is empty.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"omitempty,max=128"`
+}
+
+// RefreshTokenResponse contains the user data returned after the tokens were rotated.
+// @Description RefreshTokenResponse includes the authenticated user's details.
+type RefreshTokenResponse struct {
+	User domain.User `json:"user"`
+}
@@ -4,28 +4,41 @@ import (
 	"errors"
 	"time"
 
+	"github.com/ashtishad/xpay/internal/cardvalidation"
 	"github.com/ashtishad/xpay/internal/common"
 	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/vault"
 	"github.com/google/uuid"
 )
 
 // AddCardRequest represents the request body for adding a new card.
 // @Description AddCardRequest validates input for adding a new card.
-// @Description CardNumber must be a valid credit card number between 13 and 19 digits.
-// @Description Provider must be one of: visa, mastercard, or amex.
+// @Description CardNumber must be a card number of a supported network, with its length and a valid check digit.
+// @Description Provider is optional, it's detected from the card number and must match it when given.
 // @Description Type must be either credit or debit.
 // @Description ExpiryDate must be a future date and "MM/YY" format.
-// @Description CVV must be minimum 3 and max 4 four digits.
+// @Description The CVV is not accepted, it's never stored with the card. Deposits send it to the gateway.
 type AddCardRequest struct {
-	CardNumber string `json:"cardNumber" binding:"required,credit_card,min=13,max=19"`
-	Provider   string `json:"provider" binding:"required,oneof=visa mastercard amex"`
+	CardNumber string `json:"cardNumber" binding:"required"`
+	Provider   string `json:"provider,omitempty" binding:"omitempty,oneof=visa mastercard amex discover jcb unionpay"`
 	Type       string `json:"type" binding:"required,oneof=credit debit"`
 	ExpiryDate string `json:"expiryDate" binding:"required,len=5"`
-	CVV        string `json:"cvv" binding:"required,min=3,max=4"`
 }
 
-// ToCard converts AddCardRequest to domain.Card
-func (r *AddCardRequest) ToCard(userID, walletID int64, encryptedCardNumber []byte) (*domain.Card, error) {
+// Validate checks the card number and sets Provider to the one detected from it.
+// It returns the errors of the invalid fields.
+func (r *AddCardRequest) Validate() cardvalidation.Errors {
+	scheme, errs := cardvalidation.Validate(r.CardNumber, r.Provider)
+	if errs != nil {
+		return errs
+	}
+
+	r.Provider = scheme.Provider
+	return nil
+}
+
+// ToCard converts AddCardRequest to domain.Card, with the card number as the vault stores it
+func (r *AddCardRequest) ToCard(userID, walletID int64, vaulted *vault.VaultedCard) (*domain.Card, error) {
 	expiryDate, err := parseExpiryDate(r.ExpiryDate)
 	if err != nil {
 		return nil, err
@@ -35,7 +48,10 @@ func (r *AddCardRequest) ToCard(userID, walletID int64, encryptedCardNumber []by
 		UUID:                uuid.New(),
 		UserID:              userID,
 		WalletID:            walletID,
-		EncryptedCardNumber: encryptedCardNumber,
+		EncryptedCardNumber: vaulted.Encrypted.Ciphertext,
+		EncryptedDataKey:    vaulted.Encrypted.DataKey,
+		EncryptionKeyID:     vaulted.Encrypted.KeyID,
+		Token:               vaulted.Token,
 		Provider:            r.Provider,
 		Type:                r.Type,
 		LastFour:            r.CardNumber[len(r.CardNumber)-4:],
@@ -44,6 +60,16 @@ func (r *AddCardRequest) ToCard(userID, walletID int64, encryptedCardNumber []by
 	}, nil
 }
 
+// NewCardValidationErrorResponse creates an ErrorResponse listing the invalid fields of a card.
+func NewCardValidationErrorResponse(errs cardvalidation.Errors) ErrorResponse {
+	fields := make([]FieldError, len(errs))
+	for i, e := range errs {
+		fields[i] = FieldError{Field: e.Field, Message: e.Message}
+	}
+
+	return ErrorResponse{Error: errs.Error(), Fields: fields}
+}
+
 // AddCardResponse contains the card data returned after successfully adding a card.
 // @Description AddCardResponse includes the created card's details.
 type AddCardResponse struct {
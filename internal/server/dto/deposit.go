@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/google/uuid"
+)
+
+// CreateDepositRequest represents the request body for topping up a wallet from a saved card.
+// @Description CreateDepositRequest validates input for a card deposit.
+// @Description CardUUID must reference an active card saved to the same wallet.
+// @Description AmountInCents must be greater than zero.
+// @Description CVV is optional, 4 digits for amex and 3 for other cards. It's sent to the gateway to verify the card
+// @Description and never stored.
+type CreateDepositRequest struct {
+	CardUUID      string `json:"cardUuid" binding:"required,uuid"`
+	AmountInCents int64  `json:"amountInCents" binding:"required,gt=0"`
+	CVV           string `json:"cvv,omitempty"`
+}
+
+// ToDeposit converts CreateDepositRequest to a pending *domain.Deposit
+func (r *CreateDepositRequest) ToDeposit(wallet *domain.Wallet, card *domain.Card, userID int64) *domain.Deposit {
+	return &domain.Deposit{
+		UUID:          uuid.New(),
+		UserID:        userID,
+		WalletID:      wallet.ID,
+		WalletUUID:    wallet.UUID,
+		CardID:        card.ID,
+		CardUUID:      card.UUID,
+		AmountInCents: r.AmountInCents,
+		Currency:      wallet.Currency,
+		Status:        domain.DepositStatusPending,
+	}
+}
+
+// CreateDepositResponse contains the deposit record, for successful and unsuccessful charges alike.
+// @Description CreateDepositResponse includes the deposit's details and final status.
+type CreateDepositResponse struct {
+	Deposit domain.Deposit `json:"deposit"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"errors"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// CreateFXQuoteRequest represents the request body for an fx quote.
+// @Description CreateFXQuoteRequest validates input for pricing a currency conversion.
+// @Description FromCurrency and ToCurrency must be different supported wallet currencies.
+// @Description AmountInCents is in the minor unit of FromCurrency and must be greater than zero.
+type CreateFXQuoteRequest struct {
+	FromCurrency  string `json:"fromCurrency" binding:"required,oneof=USD EUR GBP BDT INR SGD AED JPY KWD"`
+	ToCurrency    string `json:"toCurrency" binding:"required,oneof=USD EUR GBP BDT INR SGD AED JPY KWD"`
+	AmountInCents int64  `json:"amountInCents" binding:"required,gt=0"`
+}
+
+// Validate checks the rules that can't be expressed with binding tags.
+func (r *CreateFXQuoteRequest) Validate() error {
+	if r.FromCurrency == r.ToCurrency {
+		return errors.New("fromCurrency and toCurrency must differ")
+	}
+
+	return nil
+}
+
+// FXQuoteResponse contains a quote with the locked rate and the amount the conversion will pay out.
+// @Description FXQuoteResponse includes the quote, which can be used for one conversion until expiresAt.
+type FXQuoteResponse struct {
+	Quote domain.FXQuote `json:"quote"`
+}
+
+// ConvertFundsRequest represents the request body for converting funds at a quoted rate.
+// @Description ConvertFundsRequest references the quote to convert at, the wallet must hold the quote's fromCurrency.
+type ConvertFundsRequest struct {
+	QuoteUUID string `json:"quoteUuid" binding:"required,uuid"`
+}
+
+// FXConversionResponse contains the conversion record returned after a successful conversion.
+// @Description FXConversionResponse includes the completed conversion's details.
+type FXConversionResponse struct {
+	Conversion domain.FXConversion `json:"conversion"`
+}
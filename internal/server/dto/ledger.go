@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/google/uuid"
+)
+
+// LedgerConsistencyResponse reports whether cached wallet balances match the ledger postings.
+// @Description LedgerConsistencyResponse lists wallets whose balance differs from their postings
+// @Description and journal entries whose debits and credits don't balance.
+type LedgerConsistencyResponse struct {
+	Consistent          bool                       `json:"consistent"`
+	CheckedAt           time.Time                  `json:"checkedAt"`
+	WalletDiscrepancies []domain.LedgerDiscrepancy `json:"walletDiscrepancies"`
+	UnbalancedEntries   []uuid.UUID                `json:"unbalancedEntries"`
+}
+
+// NewLedgerConsistencyResponse creates a LedgerConsistencyResponse from a domain.LedgerConsistencyReport
+func NewLedgerConsistencyResponse(report *domain.LedgerConsistencyReport) LedgerConsistencyResponse {
+	return LedgerConsistencyResponse{
+		Consistent:          report.IsConsistent(),
+		CheckedAt:           report.CheckedAt,
+		WalletDiscrepancies: report.WalletDiscrepancies,
+		UnbalancedEntries:   report.UnbalancedEntries,
+	}
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "github.com/ashtishad/xpay/internal/domain"
+
+// EnrollTOTPResponse contains the secret of a pending TOTP enrollment.
+// @Description EnrollTOTPResponse includes the base32 secret for manual entry and the otpauth:// URI for a QR code.
+// @Description The enrollment is pending until it is confirmed with a code from the authenticator app.
+type EnrollTOTPResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// ConfirmTOTPRequest holds the code that proves the authenticator app was set up.
+// @Description ConfirmTOTPRequest validates input for confirming a TOTP enrollment.
+// @Description Code must be the 6 digit code currently shown by the authenticator app.
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// ConfirmTOTPResponse contains the recovery codes issued when MFA is enabled.
+// @Description ConfirmTOTPResponse includes single use recovery codes, they are shown only once.
+type ConfirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// MFAChallengeResponse is returned by login instead of the session when the user has MFA enabled.
+// @Description MFAChallengeResponse includes a short-lived token to exchange for a session at /login/mfa.
+// @Description ExpiresIn is the token lifetime in seconds.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
+
+// LoginMFARequest holds the second factor of a login.
+// @Description LoginMFARequest validates input for the second login step.
+// @Description MFAToken is the token returned by login.
+// @Description Exactly one of Code, the 6 digit TOTP code, or RecoveryCode is required.
+type LoginMFARequest struct {
+	MFAToken     string `json:"mfaToken" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode,excluded_with=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recoveryCode" binding:"omitempty,max=32"`
+}
+
+// LoginMFAResponse contains the user data returned after the second factor was verified.
+// @Description LoginMFAResponse includes the authenticated user's details.
+type LoginMFAResponse struct {
+	User domain.User `json:"user"`
+}
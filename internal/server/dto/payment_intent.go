@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// CreatePaymentIntentRequest represents the request body for a merchant's payment intent.
+// @Description CreatePaymentIntentRequest validates input for requesting a payment from a customer.
+// @Description The amount is paid into the merchant's wallet in Currency, in its minor unit, and must be greater than zero.
+// @Description Description is optional, at max 255 characters long, and shown to the customer.
+type CreatePaymentIntentRequest struct {
+	AmountInCents int64  `json:"amountInCents" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,oneof=USD EUR GBP BDT INR SGD AED JPY KWD"`
+	Description   string `json:"description" binding:"max=255"`
+}
+
+// CapturePaymentIntentRequest represents the optional request body for capturing a payment intent.
+// @Description CapturePaymentIntentRequest sets the amount to capture, at most the authorized amount.
+// @Description Without a body or an amount, the full authorized amount is captured.
+type CapturePaymentIntentRequest struct {
+	AmountInCents *int64 `json:"amountInCents" binding:"omitempty,gt=0"`
+}
+
+// PaymentIntentResponse contains a payment intent.
+// @Description PaymentIntentResponse includes the intent's status, amounts and the wallets it is paid between.
+type PaymentIntentResponse struct {
+	PaymentIntent domain.PaymentIntent `json:"paymentIntent"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure/rbac"
+)
+
+// PolicyActionsResponse contains every action that can be granted to a role.
+type PolicyActionsResponse struct {
+	Actions []domain.PolicyAction `json:"actions"`
+}
+
+// PolicyGrantsResponse contains the grants of every role.
+type PolicyGrantsResponse struct {
+	Grants []domain.PolicyGrant `json:"grants"`
+}
+
+// GrantPolicyActionRequest sets whose resources the role can access with the action on routes with a user_uuid:
+// self for the caller's own, onboarded adds the users the caller created, any for every user.
+type GrantPolicyActionRequest struct {
+	Scope string `json:"scope" binding:"required,oneof=self onboarded any"`
+}
+
+// ExplainPermissionRequest is the request to explain, path is a route template, e.g. /api/v1/users/:user_uuid/wallets,
+// or a request path.
+type ExplainPermissionRequest struct {
+	Role   string `form:"role" binding:"required"`
+	Method string `form:"method" binding:"required,oneof=GET POST PUT PATCH DELETE"`
+	Path   string `form:"path" binding:"required,startswith=/"`
+}
+
+// PermissionExplanationResponse contains how the policy decides the explained request.
+type PermissionExplanationResponse struct {
+	Explanation *rbac.Explanation `json:"explanation"`
+}
+
+// PolicyChangesResponse contains the most recent policy changes, newest first.
+// @Description PolicyChangesResponse includes who made each change, actorUuid is null for grants seeded from the embedded policy.
+type PolicyChangesResponse struct {
+	Changes []domain.PolicyChange `json:"changes"`
+}
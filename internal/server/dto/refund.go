@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// CreateRefundRequest represents the optional request body for refunding a captured payment.
+// @Description CreateRefundRequest sets the amount to refund, at most the captured amount that wasn't refunded yet.
+// @Description Without a body or an amount, everything left to refund is refunded.
+// @Description Reason is optional, at max 255 characters long.
+type CreateRefundRequest struct {
+	AmountInCents *int64 `json:"amountInCents" binding:"omitempty,gt=0"`
+	Reason        string `json:"reason" binding:"max=255"`
+}
+
+// RefundResponse contains a refund.
+// @Description RefundResponse includes the refund's status, a failed refund has the reason it failed.
+type RefundResponse struct {
+	Refund domain.Refund `json:"refund"`
+}
+
+// ListRefundsResponse contains the refunds of a payment intent, oldest first.
+type ListRefundsResponse struct {
+	Refunds []domain.Refund `json:"refunds"`
+}
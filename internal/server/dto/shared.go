@@ -2,8 +2,16 @@ package dto
 
 // ErrorResponse represents a standardized error message structure.
 // @Description ErrorResponse provides a consistent error format.
+// @Description Fields lists the invalid fields of validation errors that report them.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError is the validation error of one request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type SuccessResponse struct {
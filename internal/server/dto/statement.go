@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// GetStatementRequest represents the query params of a wallet statement.
+// @Description GetStatementRequest selects the statement period by day in UTC, from and to are both inclusive.
+// @Description Periods longer than 31 days are exported in the background, a period covers at most 366 days.
+type GetStatementRequest struct {
+	From   time.Time `form:"from" binding:"required" time_format:"2006-01-02" time_utc:"1"`
+	To     time.Time `form:"to" binding:"required" time_format:"2006-01-02" time_utc:"1"`
+	Format string    `form:"format" binding:"required,oneof=csv ofx pdf"`
+}
+
+// Validate checks the rules that can't be expressed with binding tags.
+func (r *GetStatementRequest) Validate() error {
+	if r.To.Before(r.From) {
+		return errors.New("to must not be before from")
+	}
+
+	if _, to := r.Period(); to.Sub(r.From) > domain.StatementMaxDays*24*time.Hour {
+		return fmt.Errorf("a statement covers at most %d days", domain.StatementMaxDays)
+	}
+
+	return nil
+}
+
+// Period returns the statement period, from the start of the from day until the end of the to day.
+func (r *GetStatementRequest) Period() (time.Time, time.Time) {
+	return r.From, r.To.AddDate(0, 0, 1)
+}
+
+// StatementExportResponse contains a statement export generated in the background.
+// @Description StatementExportResponse includes the export's status, poll it until the status is ready or failed.
+// @Description DownloadURL is set once the export is ready, the file can be downloaded until expiresAt.
+type StatementExportResponse struct {
+	Export      domain.StatementExport `json:"export"`
+	DownloadURL string                 `json:"downloadUrl,omitempty"`
+}
+
+// NewStatementExportResponse creates the response for an export of the user's wallet.
+func NewStatementExportResponse(userUUID, walletUUID string, export *domain.StatementExport) StatementExportResponse {
+	res := StatementExportResponse{Export: *export}
+
+	if export.IsReady() {
+		res.DownloadURL = StatementExportURL(userUUID, walletUUID, export) + "/download"
+	}
+
+	return res
+}
+
+// StatementExportURL returns the URL of an export of the user's wallet.
+func StatementExportURL(userUUID, walletUUID string, export *domain.StatementExport) string {
+	return fmt.Sprintf("%s/users/%s/wallets/%s/statements/%s", common.APIBasePath, userUUID, walletUUID, export.UUID)
+}
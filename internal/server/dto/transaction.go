@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// ListTransactionsRequest represents the query params of a wallet history page.
+// @Description ListTransactionsRequest filters the history, from is inclusive and to is exclusive, both in RFC 3339.
+// @Description Amounts are in the minor unit of the wallet currency. Cursor is the nextCursor of the previous page.
+// Keep the type binding in sync with the journal_entry_type enum.
+type ListTransactionsRequest struct {
+	Type             string     `form:"type" binding:"omitempty,oneof=opening_balance transfer deposit fx_conversion payment refund"`
+	Status           string     `form:"status" binding:"omitempty,oneof=completed failed"`
+	From             *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To               *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinAmountInCents *int64     `form:"minAmountInCents" binding:"omitempty,gt=0"`
+	MaxAmountInCents *int64     `form:"maxAmountInCents" binding:"omitempty,gt=0"`
+	Cursor           string     `form:"cursor"`
+}
+
+// Validate checks the rules that can't be expressed with binding tags.
+func (r *ListTransactionsRequest) Validate() error {
+	if r.From != nil && r.To != nil && !r.To.After(*r.From) {
+		return errors.New("to must be after from")
+	}
+
+	if r.MinAmountInCents != nil && r.MaxAmountInCents != nil && *r.MaxAmountInCents < *r.MinAmountInCents {
+		return errors.New("maxAmountInCents must not be less than minAmountInCents")
+	}
+
+	return nil
+}
+
+// ToFilters converts ListTransactionsRequest to domain.TransactionFilters without the wallet,
+// it fails on a malformed cursor.
+func (r *ListTransactionsRequest) ToFilters(limit int) (domain.TransactionFilters, error) {
+	filters := domain.TransactionFilters{
+		From:      r.From,
+		To:        r.To,
+		MinAmount: r.MinAmountInCents,
+		MaxAmount: r.MaxAmountInCents,
+		Limit:     limit,
+	}
+
+	if r.Type != "" {
+		filters.Type = &r.Type
+	}
+
+	if r.Status != "" {
+		filters.Status = &r.Status
+	}
+
+	if r.Cursor != "" {
+		cursor, err := domain.DecodeTransactionCursor(r.Cursor)
+		if err != nil {
+			return domain.TransactionFilters{}, err
+		}
+
+		filters.After = cursor
+	}
+
+	return filters, nil
+}
+
+// TransactionListResponse contains a page of a wallet's history, newest first.
+// @Description TransactionListResponse includes the balance after each transaction.
+// @Description NextCursor is omitted on the last page.
+type TransactionListResponse struct {
+	Transactions []domain.Transaction `json:"transactions"`
+	NextCursor   string               `json:"nextCursor,omitempty"`
+}
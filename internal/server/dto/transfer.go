@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/google/uuid"
+)
+
+// CreateTransferRequest represents the request body for a wallet to wallet transfer.
+// @Description CreateTransferRequest validates input for a peer-to-peer transfer.
+// @Description Exactly one of RecipientWalletUUID or RecipientEmail must be provided.
+// @Description With RecipientEmail, funds go to the recipient's wallet in the sender wallet's currency.
+// @Description AmountInCents must be greater than zero.
+// @Description Note is optional, at max 255 characters long.
+type CreateTransferRequest struct {
+	RecipientWalletUUID string `json:"recipientWalletUuid" binding:"omitempty,uuid"`
+	RecipientEmail      string `json:"recipientEmail" binding:"omitempty,email"`
+	AmountInCents       int64  `json:"amountInCents" binding:"required,gt=0"`
+	Note                string `json:"note" binding:"max=255"`
+}
+
+// Validate checks the rules that can't be expressed with binding tags.
+func (r *CreateTransferRequest) Validate() error {
+	if (r.RecipientWalletUUID == "") == (r.RecipientEmail == "") {
+		return errors.New("exactly one of recipientWalletUuid or recipientEmail is required")
+	}
+
+	return nil
+}
+
+// ToTransfer converts CreateTransferRequest to *domain.Transfer
+func (r *CreateTransferRequest) ToTransfer(sender, recipient *domain.Wallet, initiatedBy int64) *domain.Transfer {
+	return &domain.Transfer{
+		UUID:                uuid.New(),
+		SenderWalletID:      sender.ID,
+		SenderWalletUUID:    sender.UUID,
+		RecipientWalletID:   recipient.ID,
+		RecipientWalletUUID: recipient.UUID,
+		InitiatedBy:         initiatedBy,
+		AmountInCents:       r.AmountInCents,
+		Currency:            sender.Currency,
+		Status:              domain.TransferStatusCompleted,
+		Note:                strings.TrimSpace(r.Note),
+	}
+}
+
+// CreateTransferResponse contains the transfer record returned after a successful transfer.
+// @Description CreateTransferResponse includes the completed transfer's details.
+type CreateTransferResponse struct {
+	Transfer domain.Transfer `json:"transfer"`
+}
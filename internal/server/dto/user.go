@@ -31,3 +31,9 @@ func (r *CreateUserRequest) ToUser(passwordHash string) *domain.User {
 type CreateUserResponse struct {
 	User domain.User `json:"user"`
 }
+
+// LoginHistoryResponse contains a user's recent login attempts, newest first.
+// @Description LoginHistoryResponse includes the ip address, user agent and outcome of each attempt.
+type LoginHistoryResponse struct {
+	Attempts []domain.LoginAttempt `json:"attempts"`
+}
@@ -9,7 +9,7 @@ import (
 )
 
 type CreateWalletRequest struct {
-	Currency string `json:"currency" binding:"required,oneof=USD"`
+	Currency string `json:"currency" binding:"required,oneof=USD EUR GBP BDT INR SGD AED JPY KWD"`
 }
 
 // ToNewWallet converts CreateWalletRequest to *domain.Wallet
@@ -30,9 +30,14 @@ type CreateWalletResponse struct {
 	Wallet domain.Wallet `json:"wallet"`
 }
 
+// GetWalletBalanceResponse contains the balance of a wallet.
+// @Description GetWalletBalanceResponse includes the funds on hold for authorized payment intents,
+// @Description availableInCents is the balance without them, the amount that can be spent.
 type GetWalletBalanceResponse struct {
-	BalanceInCents int64  `json:"balanceInCents"`
-	Currency       string `json:"currency"`
+	BalanceInCents   int64  `json:"balanceInCents"`
+	HeldInCents      int64  `json:"heldInCents"`
+	AvailableInCents int64  `json:"availableInCents"`
+	Currency         string `json:"currency"`
 }
 
 type UpdateWalletStatusRequest struct {
@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+)
+
+// CreateWebhookEndpointRequest represents the request body for registering a webhook endpoint.
+// @Description CreateWebhookEndpointRequest validates input for receiving events, the URL must use https and be at max 2048 characters long.
+// @Description Its host must resolve to public IP addresses, the handler checks this.
+// @Description Events lists at least one of wallet.status_changed, card.added and transfer.completed.
+// Keep the events binding in sync with domain.WebhookEventTypes.
+type CreateWebhookEndpointRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048,startswith=https://"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=wallet.status_changed card.added transfer.completed"`
+}
+
+// UpdateWebhookEndpointStatusRequest represents the request body for enabling or disabling a webhook endpoint.
+// @Description UpdateWebhookEndpointStatusRequest sets the status, enabling an endpoint clears its failures.
+type UpdateWebhookEndpointStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active disabled"`
+}
+
+// ListWebhookDeliveriesRequest represents the query params of the deliveries of a webhook endpoint.
+// @Description ListWebhookDeliveriesRequest filters the deliveries by status, dead deliveries ran out of attempts.
+type ListWebhookDeliveriesRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded dead"`
+}
+
+// CreateWebhookEndpointResponse contains a new webhook endpoint with its signing secret.
+// @Description CreateWebhookEndpointResponse includes the signing secret, it is only shown once.
+type CreateWebhookEndpointResponse struct {
+	Endpoint domain.WebhookEndpoint `json:"endpoint"`
+	Secret   string                 `json:"secret"`
+}
+
+// WebhookEndpointResponse contains a webhook endpoint.
+// @Description WebhookEndpointResponse includes the endpoint's status and failures, a disabled endpoint has the reason it was disabled.
+type WebhookEndpointResponse struct {
+	Endpoint domain.WebhookEndpoint `json:"endpoint"`
+}
+
+// ListWebhookEndpointsResponse contains the webhook endpoints of a user, oldest first.
+type ListWebhookEndpointsResponse struct {
+	Endpoints []domain.WebhookEndpoint `json:"endpoints"`
+}
+
+// WebhookDeliveryResponse contains a webhook delivery.
+// @Description WebhookDeliveryResponse includes the delivered payload and every attempt with the endpoint's response status.
+type WebhookDeliveryResponse struct {
+	Delivery domain.WebhookDelivery `json:"delivery"`
+}
+
+// ListWebhookDeliveriesResponse contains the latest deliveries of a webhook endpoint, newest first.
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []domain.WebhookDelivery `json:"deliveries"`
+}
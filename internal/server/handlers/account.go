@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/mailer"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountTokenSender creates single use password reset and email verification tokens and emails them to users.
+type AccountTokenSender struct {
+	accountTokenRepo domain.AccountTokenRepository
+	mailer           mailer.Mailer
+}
+
+func NewAccountTokenSender(accountTokenRepo domain.AccountTokenRepository, m mailer.Mailer) *AccountTokenSender {
+	return &AccountTokenSender{
+		accountTokenRepo: accountTokenRepo,
+		mailer:           m,
+	}
+}
+
+// Send issues a token of the purpose for the user, replacing earlier unused ones, and emails it.
+// It doesn't use the request context, so a client that disconnects can't cancel a half sent email.
+func (s *AccountTokenSender) Send(c *gin.Context, user *domain.User, purpose string) common.AppError {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), common.Timeouts.Mailer.Write)
+	defer cancel()
+
+	token, tokenHash, err := secure.GenerateAccountToken()
+	if err != nil {
+		return common.NewInternalServerError("failed to generate account token", err)
+	}
+
+	msg, ttl := accountTokenMessage(user, purpose, token)
+
+	appErr := s.accountTokenRepo.Create(ctx, &domain.AccountToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+
+	if appErr != nil {
+		return appErr
+	}
+
+	if err = s.mailer.Send(ctx, msg); err != nil {
+		return common.NewInternalServerError("failed to send email", err)
+	}
+
+	return nil
+}
+
+// sendEmailVerification emails a verification token to a new user. Failures are only logged, the account
+// exists already. A password reset verifies the email too, so the user isn't stuck without the email.
+func (s *AccountTokenSender) sendEmailVerification(c *gin.Context, user *domain.User) {
+	if appErr := s.Send(c, user, domain.AccountTokenPurposeEmailVerification); appErr != nil {
+		slog.Error("failed to send email verification", "requestID", c.GetString(common.ContextKeyRequestID), "error", appErr.DetailedError())
+	}
+}
+
+func accountTokenMessage(user *domain.User, purpose, token string) (mailer.Message, time.Duration) {
+	if purpose == domain.AccountTokenPurposePasswordReset {
+		return mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your xPay password",
+			Body: fmt.Sprintf("Hi %s,\n\nUse this token to reset your password, it expires in %s:\n\n%s\n\n"+
+				"If you didn't ask for a password reset, you can ignore this email.\n",
+				user.FullName, domain.PasswordResetTokenTTL, token),
+		}, domain.PasswordResetTokenTTL
+	}
+
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your xPay email address",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this token to verify your email address, it expires in %s:\n\n%s\n",
+			user.FullName, domain.EmailVerificationTokenTTL, token),
+	}, domain.EmailVerificationTokenTTL
+}
+
+type AccountHandler struct {
+	userRepo         domain.UserRepository
+	accountTokenRepo domain.AccountTokenRepository
+	tokenSender      *AccountTokenSender
+}
+
+func NewAccountHandler(userRepo domain.UserRepository, accountTokenRepo domain.AccountTokenRepository, tokenSender *AccountTokenSender) *AccountHandler {
+	return &AccountHandler{
+		userRepo:         userRepo,
+		accountTokenRepo: accountTokenRepo,
+		tokenSender:      tokenSender,
+	}
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset token
+// @Description Emails a single use password reset token, valid for 30 minutes, if an active account exists for the email.
+// @Description Always responds 202, so the endpoint can't be used to find out which emails have accounts.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param input body dto.ForgotPasswordRequest true "Account email"
+// @Success 202 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /password/forgot [post]
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Account.Read)
+	defer cancel()
+
+	accepted := dto.SuccessResponse{Message: "If an account exists for this email, a password reset token was sent to it"}
+
+	user, appErr := h.userRepo.FindBy(ctx, common.DBColumnEmail, req.Email)
+	if appErr != nil {
+		if appErr.Code() != http.StatusNotFound {
+			slog.Error("failed to find user", "requestID", requestID, "error", appErr.Error())
+			c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
+	if user.Status != domain.UserStatusActive {
+		slog.Warn("password reset requested for non active user", "requestID", requestID, "userUUID", user.UUID, "status", user.Status)
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
+	// A failure is only logged, an error response would tell that the account exists
+	if appErr := h.tokenSender.Send(c, user, domain.AccountTokenPurposePasswordReset); appErr != nil {
+		slog.Error("failed to send password reset token", "requestID", requestID, "error", appErr.DetailedError())
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// ResetPassword godoc
+// @Summary Reset a forgotten password
+// @Description Sets a new password with a token from ForgotPassword, the token can be used once.
+// @Description All sessions of the user are revoked, every access and refresh token issued so far stops working.
+// @Description The token proves access to the inbox, so an unverified email address becomes verified.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param input body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /password/reset [post]
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	passwordHash, err := secure.GeneratePasswordHash(req.NewPassword)
+	if err != nil {
+		slog.Error("failed to generate password hash", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Account.Write)
+	defer cancel()
+
+	if appErr := h.accountTokenRepo.ResetPassword(ctx, secure.HashAccountToken(req.Token), passwordHash); appErr != nil {
+		slog.Error("failed to reset password", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Marks the email address as verified with the token emailed after registration, the token can be used once.
+// @Description Users need a verified email address to create wallets.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param input body dto.VerifyEmailRequest true "Verification token"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /email/verify [post]
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Account.Write)
+	defer cancel()
+
+	if appErr := h.accountTokenRepo.VerifyEmail(ctx, secure.HashAccountToken(req.Token)); appErr != nil {
+		slog.Error("failed to verify email", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
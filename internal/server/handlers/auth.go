@@ -3,32 +3,51 @@ package handlers
 import (
 	"context"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/ashtishad/xpay/internal/common"
 	"github.com/ashtishad/xpay/internal/domain"
 	"github.com/ashtishad/xpay/internal/secure"
 	"github.com/ashtishad/xpay/internal/server/dto"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
-	userRepo   domain.UserRepository
-	jwtManager *secure.JWTManager
+	userRepo         domain.UserRepository
+	refreshTokenRepo domain.RefreshTokenRepository
+	mfaRepo          domain.MFARepository
+	loginAttemptRepo domain.LoginAttemptRepository
+	tokenSender      *AccountTokenSender
+	jwtManager       *secure.JWTManager
+	mfaEncryptor     *secure.OwnerBoundEncryptor
+	denylist         *domain.TokenDenylist
 }
 
-func NewAuthHandler(userRepo domain.UserRepository, jm *secure.JWTManager) *AuthHandler {
+func NewAuthHandler(userRepo domain.UserRepository, refreshTokenRepo domain.RefreshTokenRepository, mfaRepo domain.MFARepository,
+	loginAttemptRepo domain.LoginAttemptRepository, tokenSender *AccountTokenSender, jm *secure.JWTManager, mfaEncryptor *secure.OwnerBoundEncryptor,
+	denylist *domain.TokenDenylist) *AuthHandler {
 	return &AuthHandler{
-		userRepo:   userRepo,
-		jwtManager: jm,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		mfaRepo:          mfaRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		tokenSender:      tokenSender,
+		jwtManager:       jm,
+		mfaEncryptor:     mfaEncryptor,
+		denylist:         denylist,
 	}
 }
 
 // Register godoc
 // @Summary Register a new user
 // @Description Hashes password using bcrypt before storage.
-// @Description Generates JWT access token using ECDSA encryption.
-// @Description Sets HTTP-only cookie with access token and X-Request-Id header.
+// @Description Generates JWT access token using ECDSA encryption and starts a new refresh token family.
+// @Description Sets HTTP-only cookies with access and refresh tokens and X-Request-Id header.
+// @Description Emails a token to verify the email address, which is required to create wallets.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -64,14 +83,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	accessToken, err := h.jwtManager.GenerateAccessToken(createdUser.UUID.String(), createdUser.Role)
-	if err != nil {
-		slog.Error("failed to generate access token", "requestID", requestID, "error", err.Error())
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+	if appErr := h.startSession(ctx, c, createdUser); appErr != nil {
+		slog.Error("failed to start session", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
-	c.SetCookie("accessToken", accessToken, int(h.jwtManager.AccessExpiration.Seconds()), "/", "", true, true)
+	h.tokenSender.sendEmailVerification(c, createdUser)
 
 	c.JSON(http.StatusCreated, dto.RegisterUserResponse{
 		User: *createdUser,
@@ -81,16 +99,22 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // Login godoc
 // @Summary Authenticate a user and provide access tokens
 // @Description Verifies password using bcrypt comparison.
-// @Description Generates new JWT access token using ECDSA encryption.
-// @Description Sets HTTP-only cookie with new access token and and X-Request-Id header.
+// @Description Generates new JWT access token using ECDSA encryption and starts a new refresh token family.
+// @Description Sets HTTP-only cookies with new access and refresh tokens and X-Request-Id header.
+// @Description If the user has two-factor authentication enabled, no session is started. Responds 202 with a
+// @Description short-lived MFA token instead, which is exchanged for a session at /login/mfa.
+// @Description Consecutive failed logins lock the account with exponential backoff, a locked account
+// @Description responds 423 with a Retry-After header even for the correct password. Every attempt is recorded.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body dto.LoginRequest true "User login credentials"
 // @Success 200 {object} dto.LoginResponse
+// @Success 202 {object} dto.MFAChallengeResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 423 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -102,19 +126,241 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Auth.Read)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Auth.Write)
 	defer cancel()
 
 	user, appErr := h.userRepo.FindBy(ctx, common.DBColumnEmail, req.Email)
 	if appErr != nil {
 		slog.Error("failed to find user", "requestID", requestID, "error", appErr.Error())
+		if appErr.Code() == http.StatusNotFound {
+			h.recordLoginAttempt(ctx, c, req.Email, nil, domain.LoginOutcomeUnknownEmail)
+		}
+
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
+	if user.IsLoginLocked(time.Now()) {
+		h.rejectLockedLogin(ctx, c, user, *user.LoginLockedUntil)
+		return
+	}
+
 	if err := secure.VerifyPassword(user.PasswordHash, req.Password); err != nil {
 		slog.Error("invalid credentials", "requestID", requestID, "error", err.Error())
-		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Invalid credentials"})
+		h.failLogin(ctx, c, user, domain.LoginOutcomeInvalidPass, common.NewUnauthorizedError("Invalid credentials"))
+		return
+	}
+
+	mfaEnabled, appErr := h.mfaRepo.IsEnabled(ctx, user.ID)
+	if appErr != nil {
+		slog.Error("failed to check mfa enrollment", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if mfaEnabled {
+		// concurrent failed logins may have locked the account while the password was verified
+		lockedUntil, appErr := h.userRepo.FindLoginLock(ctx, user.ID)
+		if appErr != nil {
+			slog.Error("failed to check login lock", "requestID", requestID, "error", appErr.Error())
+			c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+			return
+		}
+
+		if lockedUntil != nil {
+			h.rejectLockedLogin(ctx, c, user, *lockedUntil)
+			return
+		}
+
+		mfaToken, err := h.jwtManager.GenerateMFAChallengeToken(user.UUID.String(), user.Role)
+		if err != nil {
+			slog.Error("failed to generate mfa challenge token", "requestID", requestID, "error", err.Error())
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+			return
+		}
+
+		h.recordLoginAttempt(ctx, c, user.Email, &user.ID, domain.LoginOutcomeMFARequired)
+
+		c.JSON(http.StatusAccepted, dto.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int(secure.MFAChallengeExpiration.Seconds()),
+		})
+
+		return
+	}
+
+	lockedUntil, appErr := h.completeLogin(ctx, c, user)
+	if appErr != nil {
+		slog.Error("failed to start session", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if lockedUntil != nil {
+		h.rejectLockedLogin(ctx, c, user, *lockedUntil)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.LoginResponse{
+		User: *user,
+	})
+}
+
+// LoginMFA godoc
+// @Summary Complete a login with a second factor
+// @Description Exchanges the MFA token returned by login and a TOTP code, or a single use recovery code, for a session.
+// @Description A TOTP code is accepted once, the MFA token is revoked after a successful exchange.
+// @Description Invalid codes count as failed logins and lock the account like invalid passwords do.
+// @Description Sets HTTP-only cookies with new access and refresh tokens and X-Request-Id header.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body dto.LoginMFARequest true "MFA token and second factor"
+// @Success 200 {object} dto.LoginMFAResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 423 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /login/mfa [post]
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	var req dto.LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	claims, jti, appErr := h.validateMFAChallenge(req.MFAToken)
+	if appErr != nil {
+		slog.Warn("invalid mfa challenge token", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.MFA.Write)
+	defer cancel()
+
+	user, appErr := h.userRepo.FindBy(ctx, common.DBColumnUUID, claims.UserUUID)
+	if appErr != nil {
+		slog.Error("failed to find user", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if user.Status != domain.UserStatusActive ||
+		(user.SessionsRevokedAt != nil && (claims.IssuedAt == nil || user.IsSessionRevoked(claims.IssuedAt.Time))) {
+		slog.Warn("mfa login denied", "requestID", requestID, "userUUID", user.UUID, "status", user.Status)
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: common.ErrInvalidMFAChallenge})
+		return
+	}
+
+	if user.IsLoginLocked(time.Now()) {
+		h.rejectLockedLogin(ctx, c, user, *user.LoginLockedUntil)
+		return
+	}
+
+	if appErr := h.verifySecondFactor(ctx, user, req); appErr != nil {
+		slog.Warn("failed to verify second factor", "requestID", requestID, "userUUID", user.UUID, "error", appErr.Error())
+		if appErr.Code() == http.StatusUnauthorized {
+			h.failLogin(ctx, c, user, domain.LoginOutcomeInvalidMFACode, appErr)
+			return
+		}
+
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if appErr := h.denylist.Revoke(ctx, jti, user.ID, claims.ExpiresAt.Time); appErr != nil {
+		slog.Error("failed to revoke mfa challenge token", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	lockedUntil, appErr := h.completeLogin(ctx, c, user)
+	if appErr != nil {
+		slog.Error("failed to start session", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if lockedUntil != nil {
+		h.rejectLockedLogin(ctx, c, user, *lockedUntil)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.LoginMFAResponse{
+		User: *user,
+	})
+}
+
+// RefreshToken godoc
+// @Summary Exchange a refresh token for new access and refresh tokens
+// @Description Reads the refresh token from the request body or the HTTP-only refreshToken cookie.
+// @Description Refresh tokens are single use, every call rotates it into a new refresh token of the same family.
+// @Description Presenting an already used refresh token revokes the whole family, the user has to log in again.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body dto.RefreshTokenRequest false "Refresh token, if not sent as cookie"
+// @Success 200 {object} dto.RefreshTokenResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /token/refresh [post]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	var req dto.RefreshTokenRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+			return
+		}
+	}
+
+	presentedToken := req.RefreshToken
+	if presentedToken == "" {
+		presentedToken, _ = c.Cookie(common.RefreshTokenCookieName)
+	}
+
+	if presentedToken == "" {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Missing refresh token"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Auth.Write)
+	defer cancel()
+
+	refreshToken, tokenHash, err := secure.GenerateRefreshToken()
+	if err != nil {
+		slog.Error("failed to generate refresh token", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+		return
+	}
+
+	next := &domain.RefreshToken{
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(h.jwtManager.RefreshExpiration),
+	}
+
+	if appErr := h.refreshTokenRepo.Rotate(ctx, secure.HashRefreshToken(presentedToken), next); appErr != nil {
+		slog.Error("failed to rotate refresh token", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	user, appErr := h.userRepo.FindBy(ctx, common.DBColumnID, next.UserID)
+	if appErr != nil {
+		slog.Error("failed to find user", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if user.Status != domain.UserStatusActive {
+		slog.Warn("refresh denied for non active user", "requestID", requestID, "userUUID", user.UUID, "status", user.Status)
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: common.ErrInvalidRefreshToken})
 		return
 	}
 
@@ -125,9 +371,244 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	c.SetCookie("accessToken", accessToken, int(h.jwtManager.AccessExpiration.Seconds()), "/", "", true, true)
+	h.setSessionCookies(c, accessToken, refreshToken)
 
-	c.JSON(http.StatusOK, dto.LoginResponse{
+	c.JSON(http.StatusOK, dto.RefreshTokenResponse{
 		User: *user,
 	})
 }
+
+// Logout godoc
+// @Summary Log out the current session
+// @Description Revokes the access token used for this request, it is rejected from now on even though it hasn't expired.
+// @Description If a refresh token is sent in the body or the refreshToken cookie, its whole token family is revoked too.
+// @Description Clears the access and refresh token cookies.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.RefreshTokenRequest false "Refresh token, if not sent as cookie"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	user, claims, appErr := authorizedSession(c)
+	if appErr != nil {
+		slog.Error("failed to get authorized session", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.RefreshTokenRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Auth.Write)
+	defer cancel()
+
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		slog.Error("invalid token id", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Invalid or expired token"})
+		return
+	}
+
+	if appErr := h.denylist.Revoke(ctx, jti, user.ID, claims.ExpiresAt.Time); appErr != nil {
+		slog.Error("failed to revoke access token", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	refreshToken := req.RefreshToken
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie(common.RefreshTokenCookieName)
+	}
+
+	if refreshToken != "" {
+		if appErr := h.refreshTokenRepo.RevokeFamily(ctx, secure.HashRefreshToken(refreshToken), user.ID); appErr != nil {
+			slog.Error("failed to revoke refresh token family", "requestID", requestID, "error", appErr.Error())
+			c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+			return
+		}
+	}
+
+	c.SetCookie(common.AccessTokenCookieName, "", -1, "/", "", true, true)
+	c.SetCookie(common.RefreshTokenCookieName, "", -1, common.RefreshTokenCookiePath, "", true, true)
+
+	c.Status(http.StatusNoContent)
+}
+
+// validateMFAChallenge verifies an MFA challenge token and returns its claims and jti.
+// Access tokens are rejected, as are challenge tokens that were already exchanged for a session.
+func (h *AuthHandler) validateMFAChallenge(mfaToken string) (*secure.JWTClaims, uuid.UUID, common.AppError) {
+	claims, err := h.jwtManager.ValidateToken(mfaToken)
+	if err != nil || claims.TokenType != secure.TokenTypeMFAChallenge || claims.IssuedAt == nil {
+		return nil, uuid.Nil, common.NewUnauthorizedError(common.ErrInvalidMFAChallenge)
+	}
+
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil || h.denylist.IsRevoked(jti) {
+		return nil, uuid.Nil, common.NewUnauthorizedError(common.ErrInvalidMFAChallenge)
+	}
+
+	return claims, jti, nil
+}
+
+// verifySecondFactor checks the TOTP code or recovery code of the request and marks it as used.
+func (h *AuthHandler) verifySecondFactor(ctx context.Context, user *domain.User, req dto.LoginMFARequest) common.AppError {
+	if req.Code == "" {
+		return h.useRecoveryCode(ctx, user, req.RecoveryCode)
+	}
+
+	enrollment, appErr := h.mfaRepo.FindByUserID(ctx, user.ID)
+	if appErr != nil {
+		return appErr
+	}
+
+	if !enrollment.IsEnabled() {
+		return common.NewUnauthorizedError(common.ErrMFANotSetUp)
+	}
+
+	secret, err := h.mfaEncryptor.Decrypt(enrollment.EncryptedSecret, user.UUID.String())
+	if err != nil {
+		return common.NewInternalServerError("failed to decrypt totp secret", err)
+	}
+
+	step, ok := secure.ValidateTOTPCode(secret, req.Code, time.Now())
+	if !ok {
+		return common.NewUnauthorizedError(common.ErrInvalidMFACode)
+	}
+
+	return h.mfaRepo.RecordTOTPUse(ctx, user.ID, step)
+}
+
+// useRecoveryCode compares the code with each unused recovery code hash and marks the matching one as used.
+func (h *AuthHandler) useRecoveryCode(ctx context.Context, user *domain.User, recoveryCode string) common.AppError {
+	codes, appErr := h.mfaRepo.ListUnusedRecoveryCodes(ctx, user.ID)
+	if appErr != nil {
+		return appErr
+	}
+
+	normalized := secure.NormalizeRecoveryCode(recoveryCode)
+	for _, code := range codes {
+		if secure.VerifyPassword(code.CodeHash, normalized) == nil {
+			return h.mfaRepo.UseRecoveryCode(ctx, code.ID)
+		}
+	}
+
+	return common.NewUnauthorizedError(common.ErrInvalidMFACode)
+}
+
+// completeLogin resets the failed login counter, starts a session and records the successful login.
+// If concurrent failed logins locked the account while the credentials were verified, it starts no session
+// and returns the time the account is locked until instead.
+func (h *AuthHandler) completeLogin(ctx context.Context, c *gin.Context, user *domain.User) (*time.Time, common.AppError) {
+	lockedUntil, appErr := h.userRepo.ResetLoginFailures(ctx, user.ID)
+	if appErr != nil || lockedUntil != nil {
+		return lockedUntil, appErr
+	}
+
+	if appErr := h.startSession(ctx, c, user); appErr != nil {
+		return nil, appErr
+	}
+
+	h.recordLoginAttempt(ctx, c, user.Email, &user.ID, domain.LoginOutcomeSucceeded)
+
+	return nil, nil
+}
+
+// rejectLockedLogin records the attempt on a locked account and responds with 423.
+func (h *AuthHandler) rejectLockedLogin(ctx context.Context, c *gin.Context, user *domain.User, lockedUntil time.Time) {
+	slog.Warn("login to locked account", "requestID", c.GetString(common.ContextKeyRequestID), "userUUID", user.UUID)
+	h.recordLoginAttempt(ctx, c, user.Email, &user.ID, domain.LoginOutcomeLocked)
+	respondLoginLocked(c, lockedUntil)
+}
+
+// failLogin counts a failed login and responds with failure, or with 423 if the account is locked,
+// by this failure or by concurrent ones.
+func (h *AuthHandler) failLogin(ctx context.Context, c *gin.Context, user *domain.User, outcome string, failure common.AppError) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+
+	lockedUntil, appErr := h.userRepo.RecordLoginFailure(ctx, user.ID)
+	if appErr != nil {
+		slog.Error("failed to record login failure", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	h.recordLoginAttempt(ctx, c, user.Email, &user.ID, outcome)
+
+	if lockedUntil != nil {
+		slog.Warn("account locked after failed logins", "requestID", requestID, "userUUID", user.UUID, "lockedUntil", *lockedUntil)
+		respondLoginLocked(c, *lockedUntil)
+		return
+	}
+
+	c.JSON(failure.Code(), dto.ErrorResponse{Error: failure.Error()})
+}
+
+// recordLoginAttempt adds the attempt to the login history. The history is best effort,
+// failing to record it doesn't fail the login.
+func (h *AuthHandler) recordLoginAttempt(ctx context.Context, c *gin.Context, email string, userID *int64, outcome string) {
+	attempt := &domain.LoginAttempt{
+		UserID:    userID,
+		Email:     email,
+		IPAddress: c.ClientIP(),
+		UserAgent: truncate(c.Request.UserAgent(), common.UserAgentMaxLength),
+		Outcome:   outcome,
+	}
+
+	if appErr := h.loginAttemptRepo.Create(ctx, attempt); appErr != nil {
+		slog.Error("failed to record login attempt", "requestID", c.GetString(common.ContextKeyRequestID), "error", appErr.Error())
+	}
+}
+
+// respondLoginLocked rejects a login to a locked account, Retry-After tells the client when to try again.
+func respondLoginLocked(c *gin.Context, lockedUntil time.Time) {
+	retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+	c.Header(common.RetryAfterHeader, strconv.Itoa(max(retryAfter, 1)))
+
+	appErr := common.NewLockedError(common.ErrAccountLocked)
+	c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+}
+
+// startSession issues an access token and the first refresh token of a new family, and sets both as cookies.
+func (h *AuthHandler) startSession(ctx context.Context, c *gin.Context, user *domain.User) common.AppError {
+	accessToken, err := h.jwtManager.GenerateAccessToken(user.UUID.String(), user.Role)
+	if err != nil {
+		return common.NewInternalServerError("failed to generate access token", err)
+	}
+
+	refreshToken, tokenHash, err := secure.GenerateRefreshToken()
+	if err != nil {
+		return common.NewInternalServerError("failed to generate refresh token", err)
+	}
+
+	appErr := h.refreshTokenRepo.Create(ctx, &domain.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  uuid.New(),
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(h.jwtManager.RefreshExpiration),
+	})
+
+	if appErr != nil {
+		return appErr
+	}
+
+	h.setSessionCookies(c, accessToken, refreshToken)
+
+	return nil
+}
+
+func (h *AuthHandler) setSessionCookies(c *gin.Context, accessToken, refreshToken string) {
+	c.SetCookie(common.AccessTokenCookieName, accessToken, int(h.jwtManager.AccessExpiration.Seconds()), "/", "", true, true)
+	c.SetCookie(common.RefreshTokenCookieName, refreshToken, int(h.jwtManager.RefreshExpiration.Seconds()), common.RefreshTokenCookiePath, "", true, true)
+}
@@ -7,28 +7,28 @@ import (
 
 	"github.com/ashtishad/xpay/internal/common"
 	"github.com/ashtishad/xpay/internal/domain"
-	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/ashtishad/xpay/internal/infra/vault"
 	"github.com/ashtishad/xpay/internal/server/dto"
 	"github.com/gin-gonic/gin"
 )
 
 type CardHandler struct {
-	cardRepo      domain.CardRepository
-	walletRepo    domain.WalletRepository
-	cardEncryptor *secure.CardEncryptor
+	cardRepo   domain.CardRepository
+	walletRepo domain.WalletRepository
+	cardVault  vault.Tokenizer
 }
 
-func NewCardHandler(cardRepo domain.CardRepository, walletRepo domain.WalletRepository, cardEncryptor *secure.CardEncryptor) *CardHandler {
+func NewCardHandler(cardRepo domain.CardRepository, walletRepo domain.WalletRepository, cardVault vault.Tokenizer) *CardHandler {
 	return &CardHandler{
-		cardRepo:      cardRepo,
-		walletRepo:    walletRepo,
-		cardEncryptor: cardEncryptor,
+		cardRepo:   cardRepo,
+		walletRepo: walletRepo,
+		cardVault:  cardVault,
 	}
 }
 
 // AddCardToWallet godoc
 // @Summary Add a new card to a wallet
-// @Description Adds a new card to the specified wallet, encrypting sensitive data
+// @Description Adds a new card to the specified wallet, the card number is encrypted and tokenized by the vault
 // @Tags card
 // @Accept json
 // @Produce json
@@ -56,9 +56,9 @@ func (h *CardHandler) AddCardToWallet(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Card.Write)
 	defer cancel()
 
-	walletID, appErr := h.getWalletID(ctx, c.Param("wallet_uuid"))
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), authorizedUser)
 	if appErr != nil {
-		slog.Error("failed to get wallet ID", "requestID", requestID, "error", appErr.Error())
+		slog.Error("failed to find wallet", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
@@ -70,14 +70,20 @@ func (h *CardHandler) AddCardToWallet(c *gin.Context) {
 		return
 	}
 
-	encryptedCardNumber, err := h.cardEncryptor.Encrypt(req.CardNumber)
+	if errs := req.Validate(); errs != nil {
+		slog.Error("invalid card", "requestID", requestID, "error", errs.Error())
+		c.JSON(http.StatusBadRequest, dto.NewCardValidationErrorResponse(errs))
+		return
+	}
+
+	vaulted, err := h.cardVault.Tokenize(ctx, req.CardNumber)
 	if err != nil {
-		slog.Error("failed to encrypt card number", "requestID", requestID, "error", err.Error())
+		slog.Error("failed to tokenize card number", "requestID", requestID, "error", err.Error())
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to process card data"})
 		return
 	}
 
-	card, err := req.ToCard(authorizedUser.ID, walletID, encryptedCardNumber)
+	card, err := req.ToCard(authorizedUser.ID, wallet.ID, vaulted)
 	if err != nil {
 		slog.Error("failed to create card object", "requestID", requestID, "error", err.Error())
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
@@ -111,25 +117,17 @@ func (h *CardHandler) AddCardToWallet(c *gin.Context) {
 // @Router /users/{user_uuid}/wallets/{wallet_uuid}/cards/{card_uuid} [get]
 func (h *CardHandler) GetCard(c *gin.Context) {
 	requestID := c.GetString(common.ContextKeyRequestID)
-	_, appErr := validateUserAccess(c)
+	owner, appErr := validateUserAccess(c)
 	if appErr != nil {
 		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
-	cardUUID := c.Param("card_uuid")
-	if cardUUID == "" {
-		appErr := common.NewBadRequestError("Card UUID is required")
-		slog.Error("missing card UUID", "requestID", requestID)
-		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
-		return
-	}
-
 	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Card.Read)
 	defer cancel()
 
-	card, appErr := h.cardRepo.FindBy(ctx, common.DBColumnUUID, cardUUID)
+	card, appErr := h.findWalletCard(ctx, c.Param("wallet_uuid"), c.Param("card_uuid"), owner)
 	if appErr != nil {
 		slog.Error("failed to find card", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
@@ -159,21 +157,13 @@ func (h *CardHandler) GetCard(c *gin.Context) {
 // @Router /users/{user_uuid}/wallets/{wallet_uuid}/cards/{card_uuid} [patch]
 func (h *CardHandler) UpdateCard(c *gin.Context) {
 	requestID := c.GetString(common.ContextKeyRequestID)
-	_, appErr := validateUserAccess(c)
+	owner, appErr := validateUserAccess(c)
 	if appErr != nil {
 		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
-	cardUUID := c.Param("card_uuid")
-	if cardUUID == "" {
-		appErr := common.NewBadRequestError("Card UUID is required")
-		slog.Error("missing card UUID", "requestID", requestID)
-		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
-		return
-	}
-
 	var req dto.UpdateCardRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
@@ -184,7 +174,7 @@ func (h *CardHandler) UpdateCard(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Card.Write)
 	defer cancel()
 
-	card, appErr := h.cardRepo.FindBy(ctx, common.DBColumnUUID, cardUUID)
+	card, appErr := h.findWalletCard(ctx, c.Param("wallet_uuid"), c.Param("card_uuid"), owner)
 	if appErr != nil {
 		slog.Error("failed to find card", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
@@ -225,25 +215,24 @@ func (h *CardHandler) UpdateCard(c *gin.Context) {
 // @Router /users/{user_uuid}/wallets/{wallet_uuid}/cards/{card_uuid} [delete]
 func (h *CardHandler) DeleteCard(c *gin.Context) {
 	requestID := c.GetString(common.ContextKeyRequestID)
-	_, appErr := validateUserAccess(c)
+	owner, appErr := validateUserAccess(c)
 	if appErr != nil {
 		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
-	cardUUID := c.Param("card_uuid")
-	if cardUUID == "" {
-		appErr := common.NewBadRequestError("Card UUID is required")
-		slog.Error("missing card UUID", "requestID", requestID)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Card.Write)
+	defer cancel()
+
+	card, appErr := h.findWalletCard(ctx, c.Param("wallet_uuid"), c.Param("card_uuid"), owner)
+	if appErr != nil {
+		slog.Error("failed to find card", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Card.Write)
-	defer cancel()
-
-	appErr = h.cardRepo.Delete(ctx, cardUUID)
+	appErr = h.cardRepo.Delete(ctx, card.UUID.String())
 	if appErr != nil {
 		slog.Error("failed to delete card", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
@@ -266,6 +255,7 @@ func (h *CardHandler) DeleteCard(c *gin.Context) {
 // @Success 200 {object} dto.CardListResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /users/{user_uuid}/wallets/{wallet_uuid}/cards [get]
 func (h *CardHandler) ListCards(c *gin.Context) {
@@ -280,16 +270,16 @@ func (h *CardHandler) ListCards(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Card.Read)
 	defer cancel()
 
-	walletID, appErr := h.getWalletID(ctx, c.Param("wallet_uuid"))
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), authorizedUser)
 	if appErr != nil {
-		slog.Error("failed to get wallet ID", "requestID", requestID, "error", appErr.Error())
+		slog.Error("failed to find wallet", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
 	filters := domain.CardFilters{
 		UserID:   &authorizedUser.ID,
-		WalletID: &walletID,
+		WalletID: &wallet.ID,
 	}
 
 	if provider := c.Query("provider"); provider != "" && domain.IsValidCardProvider(provider) {
@@ -310,11 +300,26 @@ func (h *CardHandler) ListCards(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.NewCardListResponse(cards))
 }
 
-// Helper function to get wallet ID from UUID
-func (h *CardHandler) getWalletID(ctx context.Context, walletUUID string) (int64, common.AppError) {
-	if walletUUID == "" {
-		return 0, common.NewBadRequestError("Wallet UUID is required")
+// findWalletCard returns a card of the owner's wallet. A card UUID of another wallet is not found,
+// so the card of one user can't be reached through the wallet UUID of another.
+func (h *CardHandler) findWalletCard(ctx context.Context, walletUUID, cardUUID string, owner *domain.User) (*domain.Card, common.AppError) {
+	if cardUUID == "" {
+		return nil, common.NewBadRequestError("Card UUID is required")
+	}
+
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, walletUUID, owner)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	card, appErr := h.cardRepo.FindBy(ctx, common.DBColumnUUID, cardUUID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if card.WalletID != wallet.ID {
+		return nil, common.NewNotFoundError("card not found in this wallet")
 	}
 
-	return h.walletRepo.FindIDFromUUID(ctx, walletUUID)
+	return card, nil
 }
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCardRequiresTheOwnersWallet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	owner := &domain.User{ID: 7, UUID: uuid.New()}
+	ownWallet := &domain.Wallet{ID: 3, UUID: uuid.New(), UserID: owner.ID}
+	otherWallet := &domain.Wallet{ID: 4, UUID: uuid.New(), UserID: owner.ID + 1}
+
+	tests := []struct {
+		name       string
+		wallet     *domain.Wallet
+		cardWallet int64
+		wantStatus int
+	}{
+		{"Card Of Own Wallet", ownWallet, ownWallet.ID, http.StatusOK},
+		{"Card Of Another Wallet", ownWallet, otherWallet.ID, http.StatusNotFound},
+		{"Wallet Of Another User", otherWallet, otherWallet.ID, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := &domain.Card{ID: 5, UUID: uuid.New(), WalletID: tt.cardWallet, Status: domain.CardStatusActive}
+			h := NewCardHandler(&stubCardRepo{card: card}, &stubWalletRepo{wallet: tt.wallet}, stubTokenizer{})
+
+			router := gin.New()
+			router.GET("/users/:user_uuid/wallets/:wallet_uuid/cards/:card_uuid", func(c *gin.Context) {
+				c.Set(common.ContextKeyResourceOwner, owner)
+				c.Next()
+			}, h.GetCard)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+				"/users/"+owner.UUID.String()+"/wallets/"+tt.wallet.UUID.String()+"/cards/"+card.UUID.String(), nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
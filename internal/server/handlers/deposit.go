@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/cardvalidation"
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/gateway"
+	"github.com/ashtishad/xpay/internal/infra/vault"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type DepositHandler struct {
+	depositRepo    domain.DepositRepository
+	walletRepo     domain.WalletRepository
+	cardRepo       domain.CardRepository
+	cardVault      vault.Tokenizer
+	paymentGateway gateway.PaymentGateway
+}
+
+func NewDepositHandler(depositRepo domain.DepositRepository, walletRepo domain.WalletRepository, cardRepo domain.CardRepository,
+	cardVault vault.Tokenizer, paymentGateway gateway.PaymentGateway) *DepositHandler {
+	return &DepositHandler{
+		depositRepo:    depositRepo,
+		walletRepo:     walletRepo,
+		cardRepo:       cardRepo,
+		cardVault:      cardVault,
+		paymentGateway: paymentGateway,
+	}
+}
+
+// CreateDeposit godoc
+// @Summary Top up a wallet from a saved card
+// @Description Charges a card saved to the wallet through the payment gateway and credits the wallet when the charge succeeds.
+// @Description A deposit record is persisted for every attempt. Declined and failed charges are returned
+// @Description with their status and leave the wallet balance unchanged. When the gateway timed out, or the outcome of
+// @Description the charge couldn't be recorded, 202 is returned with the deposit. A background job settles it with the
+// @Description gateway later, and credits the wallet if the card was charged. Retry with the same Idempotency-Key
+// @Description to get the same deposit, not a new charge.
+// @Tags deposit
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param wallet_uuid path string true "Wallet UUID"
+// @Param input body dto.CreateDepositRequest true "Deposit details"
+// @Success 201 {object} dto.CreateDepositResponse
+// @Success 202 {object} dto.CreateDepositResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 402 {object} dto.CreateDepositResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 502 {object} dto.CreateDepositResponse
+// @Router /users/{user_uuid}/wallets/{wallet_uuid}/deposits [post]
+func (h *DepositHandler) CreateDeposit(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	authorizedUser, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.CreateDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	// Once the card is charged the outcome must be recorded, even if the client goes away.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), common.Timeouts.Deposit.Write)
+	defer cancel()
+
+	wallet, card, appErr := h.findDepositSource(ctx, c.Param("wallet_uuid"), req.CardUUID, authorizedUser)
+	if appErr != nil {
+		slog.Error("failed to find deposit source", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if req.CVV != "" {
+		if errs := cardvalidation.ValidateCVV(card.Provider, req.CVV); errs != nil {
+			slog.Error("invalid card verification code", "requestID", requestID, "error", errs.Error())
+			c.JSON(http.StatusBadRequest, dto.NewCardValidationErrorResponse(errs))
+			return
+		}
+	}
+
+	cardToken, err := h.cardVault.TokenFor(ctx, card)
+	if err != nil {
+		slog.Error("failed to get card token", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to process card data"})
+		return
+	}
+
+	deposit, appErr := h.depositRepo.Create(ctx, req.ToDeposit(wallet, card, authorizedUser.ID))
+	if appErr != nil {
+		slog.Error("failed to create deposit", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	gatewayCtx, gatewayCancel := context.WithTimeout(ctx, common.Timeouts.Gateway.Write)
+	defer gatewayCancel()
+
+	charge, err := h.paymentGateway.Charge(gatewayCtx, gateway.ChargeRequest{
+		Reference:     deposit.UUID.String(),
+		CardToken:     cardToken,
+		CVV:           req.CVV,
+		ExpiryDate:    card.ExpiryDate,
+		AmountInCents: deposit.AmountInCents,
+		Currency:      deposit.Currency,
+		Capture:       true,
+	})
+
+	deposit, statusCode, appErr := h.recordChargeOutcome(ctx, deposit, charge, err)
+	if appErr != nil {
+		// The card may be charged, an error status would release the Idempotency-Key and a retry charge it again.
+		// The deposit stays pending until the reconciler settles it with the gateway.
+		slog.Error("failed to record deposit outcome", "requestID", requestID, "depositUUID", deposit.UUID, "error", appErr.Error())
+		c.JSON(http.StatusAccepted, dto.CreateDepositResponse{Deposit: *deposit})
+		return
+	}
+
+	if deposit.Status != domain.DepositStatusSucceeded {
+		slog.Warn("deposit was not successful", "requestID", requestID, "depositUUID", deposit.UUID,
+			"status", deposit.Status, "reason", deposit.FailureReason)
+	}
+
+	c.JSON(statusCode, dto.CreateDepositResponse{Deposit: *deposit})
+}
+
+// findDepositSource returns the caller's wallet and the card to charge, both of which must be active.
+func (h *DepositHandler) findDepositSource(ctx context.Context, walletUUID, cardUUID string, owner *domain.User) (*domain.Wallet, *domain.Card, common.AppError) {
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, walletUUID, owner)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	if wallet.Status != domain.WalletStatusActive {
+		return nil, nil, common.NewUnprocessableEntityError(fmt.Sprintf("wallet is %s", wallet.Status))
+	}
+
+	card, appErr := h.cardRepo.FindBy(ctx, common.DBColumnUUID, cardUUID)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	if card.WalletID != wallet.ID {
+		return nil, nil, common.NewNotFoundError("card not found in this wallet")
+	}
+
+	if card.Status != domain.CardStatusActive {
+		return nil, nil, common.NewUnprocessableEntityError(fmt.Sprintf("card is %s", card.Status))
+	}
+
+	return wallet, card, nil
+}
+
+// recordChargeOutcome persists the gateway result on the deposit and picks the response status code.
+// Only a captured charge credits the wallet, every other outcome is stored with its failure reason.
+// A timeout is answered with 202, the charge may still be captured and the reconciler credits it then.
+func (h *DepositHandler) recordChargeOutcome(ctx context.Context, deposit *domain.Deposit, charge *gateway.Charge, chargeErr error) (*domain.Deposit, int, common.AppError) {
+	switch {
+	case errors.Is(chargeErr, gateway.ErrTimeout) || errors.Is(chargeErr, context.DeadlineExceeded):
+		d, appErr := h.depositRepo.MarkFailed(ctx, deposit, domain.DepositStatusTimedOut, "", "payment gateway timed out")
+		return orDeposit(d, deposit), http.StatusAccepted, appErr
+
+	case chargeErr != nil:
+		d, appErr := h.depositRepo.MarkFailed(ctx, deposit, domain.DepositStatusFailed, "", chargeErr.Error())
+		return orDeposit(d, deposit), http.StatusBadGateway, appErr
+
+	case charge.Status == gateway.ChargeStatusDeclined:
+		d, appErr := h.depositRepo.MarkFailed(ctx, deposit, domain.DepositStatusDeclined, charge.ID, charge.DeclineCode)
+		return orDeposit(d, deposit), http.StatusPaymentRequired, appErr
+
+	case charge.Status != gateway.ChargeStatusCaptured:
+		reason := fmt.Sprintf("unexpected charge status %q", charge.Status)
+		d, appErr := h.depositRepo.MarkFailed(ctx, deposit, domain.DepositStatusFailed, charge.ID, reason)
+		return orDeposit(d, deposit), http.StatusBadGateway, appErr
+	}
+
+	d, appErr := h.markSucceeded(ctx, deposit, charge.ID)
+	return orDeposit(d, deposit), http.StatusCreated, appErr
+}
+
+// markSucceeded credits the wallet for a captured charge. The serializable transaction conflicts with concurrent
+// activity on the wallet, the card is charged already, so it's retried here instead of asking the client to retry.
+func (h *DepositHandler) markSucceeded(ctx context.Context, deposit *domain.Deposit, chargeID string) (*domain.Deposit, common.AppError) {
+	for attempt := 1; ; attempt++ {
+		d, appErr := h.depositRepo.MarkSucceeded(ctx, deposit, chargeID)
+		if appErr == nil || !isConcurrentUpdate(appErr) || attempt == domain.DepositSucceedMaxAttempts {
+			return d, appErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, appErr
+		case <-time.After(time.Duration(attempt) * domain.DepositSucceedRetryBackoff):
+		}
+	}
+}
+
+// orDeposit keeps the original deposit for logging when a repository call failed.
+func orDeposit(updated, original *domain.Deposit) *domain.Deposit {
+	if updated != nil {
+		return updated
+	}
+
+	return original
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/gateway"
+	"github.com/ashtishad/xpay/internal/infra/vault"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDepositToken = "9111111111111111"
+
+type stubWalletRepo struct {
+	domain.WalletRepository
+	wallet *domain.Wallet
+}
+
+func (r *stubWalletRepo) FindBy(_ context.Context, _ string, _ any) (*domain.Wallet, common.AppError) {
+	return r.wallet, nil
+}
+
+type stubCardRepo struct {
+	domain.CardRepository
+	card *domain.Card
+}
+
+func (r *stubCardRepo) FindBy(_ context.Context, _ string, _ any) (*domain.Card, common.AppError) {
+	return r.card, nil
+}
+
+type stubTokenizer struct{}
+
+func (stubTokenizer) Tokenize(_ context.Context, _ string) (*vault.VaultedCard, error) {
+	return nil, nil
+}
+
+func (stubTokenizer) TokenFor(_ context.Context, _ *domain.Card) (string, error) {
+	return testDepositToken, nil
+}
+
+type stubDetokenizer map[string]string
+
+func (d stubDetokenizer) Detokenize(_ context.Context, token, _, _ string) (string, error) {
+	return d[token], nil
+}
+
+// conflictingDepositRepo fails MarkSucceeded with a serialization conflict for the first conflicts calls.
+type conflictingDepositRepo struct {
+	domain.DepositRepository
+	conflicts        int
+	succeedCalls     int
+	succeededCharges []string
+}
+
+func (r *conflictingDepositRepo) Create(_ context.Context, d *domain.Deposit) (*domain.Deposit, common.AppError) {
+	d.ID = 1
+	return d, nil
+}
+
+func (r *conflictingDepositRepo) MarkSucceeded(_ context.Context, d *domain.Deposit, chargeID string) (*domain.Deposit, common.AppError) {
+	r.succeedCalls++
+	if r.succeedCalls <= r.conflicts {
+		return nil, common.NewConflictError(common.ErrConcurrentUpdate)
+	}
+
+	r.succeededCharges = append(r.succeededCharges, chargeID)
+	d.Status = domain.DepositStatusSucceeded
+	d.GatewayChargeID = chargeID
+
+	return d, nil
+}
+
+func newDepositRouter(t *testing.T, repo domain.DepositRepository) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	owner := &domain.User{ID: 7, UUID: uuid.New()}
+	wallet := &domain.Wallet{ID: 3, UUID: uuid.New(), UserID: owner.ID, Currency: "USD", Status: domain.WalletStatusActive}
+	card := &domain.Card{ID: 5, UUID: uuid.New(), WalletID: wallet.ID, Provider: "visa",
+		ExpiryDate: time.Now().AddDate(1, 0, 0), Status: domain.CardStatusActive}
+
+	paymentGateway := gateway.NewFakeGateway(stubDetokenizer{testDepositToken: "4111111111111111"})
+	h := NewDepositHandler(repo, &stubWalletRepo{wallet: wallet}, &stubCardRepo{card: card}, stubTokenizer{}, paymentGateway)
+
+	router := gin.New()
+	router.POST("/users/:user_uuid/wallets/:wallet_uuid/deposits", func(c *gin.Context) {
+		c.Set(common.ContextKeyResourceOwner, owner)
+		c.Next()
+	}, h.CreateDeposit)
+
+	return router
+}
+
+func postDeposit(t *testing.T, router *gin.Engine) (*httptest.ResponseRecorder, dto.CreateDepositResponse) {
+	t.Helper()
+
+	body, err := json.Marshal(dto.CreateDepositRequest{CardUUID: uuid.NewString(), AmountInCents: 5000})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/users/"+uuid.NewString()+"/wallets/"+uuid.NewString()+"/deposits", bytes.NewReader(body))
+	router.ServeHTTP(w, req)
+
+	var resp dto.CreateDepositResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return w, resp
+}
+
+func TestCreateDepositRetriesConcurrentUpdates(t *testing.T) {
+	repo := &conflictingDepositRepo{conflicts: domain.DepositSucceedMaxAttempts - 1}
+
+	w, resp := postDeposit(t, newDepositRouter(t, repo))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, domain.DepositSucceedMaxAttempts, repo.succeedCalls)
+	assert.Len(t, repo.succeededCharges, 1)
+	assert.Equal(t, domain.DepositStatusSucceeded, resp.Deposit.Status)
+}
+
+func TestCreateDepositAcceptsCapturedChargeItCouldNotCredit(t *testing.T) {
+	repo := &conflictingDepositRepo{conflicts: domain.DepositSucceedMaxAttempts}
+
+	w, resp := postDeposit(t, newDepositRouter(t, repo))
+
+	// 202 is stored for the Idempotency-Key, a retry gets the same deposit and doesn't charge the card again
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, domain.DepositSucceedMaxAttempts, repo.succeedCalls)
+	assert.Empty(t, repo.succeededCharges)
+	assert.Equal(t, domain.DepositStatusPending, resp.Deposit.Status)
+	assert.NotEqual(t, uuid.Nil, resp.Deposit.UUID)
+}
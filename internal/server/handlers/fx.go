@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/fx"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type FXHandler struct {
+	fxRepo     domain.FXRepository
+	walletRepo domain.WalletRepository
+	rates      fx.RatesProvider
+}
+
+func NewFXHandler(fxRepo domain.FXRepository, walletRepo domain.WalletRepository, rates fx.RatesProvider) *FXHandler {
+	return &FXHandler{
+		fxRepo:     fxRepo,
+		walletRepo: walletRepo,
+		rates:      rates,
+	}
+}
+
+// CreateFXQuote godoc
+// @Summary Quote a currency conversion
+// @Description Prices the conversion of an amount between two currencies and locks the rate for 30 seconds.
+// @Description The converted amount is rounded down to the minor unit of the target currency.
+// @Description The quote can be used for one conversion from any of the user's wallets in the source currency.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param input body dto.CreateFXQuoteRequest true "Conversion to quote"
+// @Success 201 {object} dto.FXQuoteResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/fx/quotes [post]
+func (h *FXHandler) CreateFXQuote(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.CreateFXQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.FX.Write)
+	defer cancel()
+
+	quote, appErr := h.priceQuote(ctx, owner.ID, &req)
+	if appErr != nil {
+		slog.Error("failed to price fx quote", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if appErr := h.fxRepo.CreateQuote(ctx, quote); appErr != nil {
+		slog.Error("failed to create fx quote", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.FXQuoteResponse{Quote: *quote})
+}
+
+// priceQuote fetches the current rate and prices the requested conversion at it.
+func (h *FXHandler) priceQuote(ctx context.Context, userID int64, req *dto.CreateFXQuoteRequest) (*domain.FXQuote, common.AppError) {
+	rate, err := h.rates.Rate(ctx, req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		if errors.Is(err, fx.ErrRateUnavailable) {
+			return nil, common.NewUnprocessableEntityError(err.Error())
+		}
+
+		return nil, common.NewInternalServerError(common.ErrUnexpectedServer, err)
+	}
+
+	source, err := domain.NewMoney(req.AmountInCents, req.FromCurrency)
+	if err != nil {
+		return nil, common.NewBadRequestError(err.Error())
+	}
+
+	quote, err := domain.NewFXQuote(userID, source, req.ToCurrency, rate, time.Now())
+	if err != nil {
+		if errors.Is(err, domain.ErrAmountTooSmall) {
+			return nil, common.NewUnprocessableEntityError(err.Error())
+		}
+
+		return nil, common.NewInternalServerError(common.ErrUnexpectedServer, err)
+	}
+
+	return quote, nil
+}
+
+// ConvertFunds godoc
+// @Summary Convert funds at a quoted rate
+// @Description Debits the quote's source amount from the wallet and credits the quote's target amount to the user's
+// @Description wallet in the target currency, in one serializable transaction backed by a single journal entry.
+// @Description The quote must belong to the user, be unused and not expired. Both wallets must be active.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param wallet_uuid path string true "Source Wallet UUID"
+// @Param input body dto.ConvertFundsRequest true "Quote to convert at"
+// @Success 201 {object} dto.FXConversionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/wallets/{wallet_uuid}/conversions [post]
+func (h *FXHandler) ConvertFunds(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.ConvertFundsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.FX.Write)
+	defer cancel()
+
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), owner)
+	if appErr != nil {
+		slog.Error("failed to find source wallet", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	conversion, appErr := h.fxRepo.Convert(ctx, owner.ID, req.QuoteUUID, wallet.ID)
+	if appErr != nil {
+		slog.Error("failed to convert funds", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.FXConversionResponse{Conversion: *conversion})
+}
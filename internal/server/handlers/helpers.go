@@ -1,45 +1,113 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/ashtishad/xpay/internal/common"
 	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
-// validateUserAccess checks if the authenticated user has permission to access the requested resource.
-// It verifies:
-// 1. The presence of the user_uuid route parameter
-// 2. The existence of an authenticated user in the context
-// 3. The authenticated user's UUID matches the requested user_uuid
-// Returns the authorized user on success, or an appropriate AppError on failure.
+// validateUserAccess returns the owner of the requested resources, the user of the user_uuid route param.
+// AuthMiddleware resolves the owner and checks that the access scope the policy grants the authenticated
+// user's role covers them, e.g. their own resources, the users they onboarded or any user's.
+// Returns the owner on success, or an appropriate AppError on failure.
 func validateUserAccess(c *gin.Context) (*domain.User, common.AppError) {
-	userUUID := c.Param("user_uuid")
-	if userUUID == "" {
+	if c.Param("user_uuid") == "" {
 		return nil, common.NewBadRequestError("User UUID route param is required")
 	}
 
-	authUser, exists := c.Get(common.ContextKeyAuthorizedUser)
+	resourceOwner, exists := c.Get(common.ContextKeyResourceOwner)
 	if !exists {
 		return nil, common.NewUnauthorizedError("User not authenticated")
 	}
 
-	authorizedUser, ok := authUser.(*domain.User)
+	owner, ok := resourceOwner.(*domain.User)
 	if !ok {
-		slog.Error("failed to cast authorized user")
+		slog.Error("failed to cast resource owner")
 		return nil, common.NewInternalServerError("Unexpected server error", nil)
 	}
 
-	if authorizedUser.UUID.String() != userUUID {
-		return nil, common.NewForbiddenError("You can only access your own resources")
+	return owner, nil
+}
+
+// queryLimit parses the optional limit query param, it must be between 1 and maxLimit.
+func queryLimit(c *gin.Context, defaultLimit, maxLimit int) (int, common.AppError) {
+	limitParam := c.Query("limit")
+	if limitParam == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 1 || limit > maxLimit {
+		return 0, common.NewBadRequestError(fmt.Sprintf("limit must be a number between 1 and %d", maxLimit))
+	}
+
+	return limit, nil
+}
+
+// authorizedSession returns the authenticated user and the claims of the access token used for the request.
+func authorizedSession(c *gin.Context) (*domain.User, *secure.JWTClaims, common.AppError) {
+	authUser, userExists := c.Get(common.ContextKeyAuthorizedUser)
+	tokenClaims, claimsExist := c.Get(common.ContextKeyTokenClaims)
+	if !userExists || !claimsExist {
+		return nil, nil, common.NewUnauthorizedError("User not authenticated")
+	}
+
+	user, userOk := authUser.(*domain.User)
+	claims, claimsOk := tokenClaims.(*secure.JWTClaims)
+	if !userOk || !claimsOk {
+		slog.Error("failed to cast authorized session")
+		return nil, nil, common.NewInternalServerError("Unexpected server error", nil)
+	}
+
+	return user, claims, nil
+}
+
+// findOwnedWallet retrieves a wallet by UUID and verifies it belongs to the given user.
+// Use it before moving money out of a wallet, the route's user_uuid alone doesn't prove wallet ownership.
+func findOwnedWallet(ctx context.Context, walletRepo domain.WalletRepository, walletUUID string, owner *domain.User) (*domain.Wallet, common.AppError) {
+	if walletUUID == "" {
+		return nil, common.NewBadRequestError("Wallet UUID is required")
+	}
+
+	wallet, appErr := walletRepo.FindBy(ctx, common.DBColumnUUID, walletUUID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if wallet.UserID != owner.ID {
+		return nil, common.NewForbiddenError("You can only access your own wallets")
+	}
+
+	return wallet, nil
+}
+
+// isConcurrentUpdate reports whether a serializable transaction lost a race and can be run again.
+func isConcurrentUpdate(appErr common.AppError) bool {
+	return appErr.Code() == http.StatusConflict && appErr.Error() == common.ErrConcurrentUpdate
+}
+
+// truncate shortens s to at most maxLen bytes without splitting a UTF-8 character.
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+
+	for maxLen > 0 && !utf8.RuneStart(s[maxLen]) {
+		maxLen--
 	}
 
-	return authorizedUser, nil
+	return s[:maxLen]
 }
 
 // formatValidationError formats validation errors into a single string
@@ -70,6 +138,20 @@ func formatValidationError(err error) string {
 			messages = append(messages, fmt.Sprintf("%s must be a valid date and time", e.Field()))
 		case "credit_card":
 			messages = append(messages, fmt.Sprintf("%s must be a valid credit card number", e.Field()))
+		case "gt":
+			messages = append(messages, fmt.Sprintf("%s must be greater than %s", e.Field(), e.Param()))
+		case "len":
+			messages = append(messages, fmt.Sprintf("%s must be exactly %s characters long", e.Field(), e.Param()))
+		case "numeric":
+			messages = append(messages, fmt.Sprintf("%s must contain only digits", e.Field()))
+		case "required_without":
+			messages = append(messages, fmt.Sprintf("%s is required when %s is not provided", e.Field(), e.Param()))
+		case "excluded_with":
+			messages = append(messages, fmt.Sprintf("%s must not be provided together with %s", e.Field(), e.Param()))
+		case "uuid":
+			messages = append(messages, fmt.Sprintf("%s must be a valid UUID", e.Field()))
+		case "startswith":
+			messages = append(messages, fmt.Sprintf("%s must start with %s", e.Field(), e.Param()))
 		default:
 			messages = append(messages, fmt.Sprintf("%s failed validation on tag %s", e.Field(), e.Tag()))
 		}
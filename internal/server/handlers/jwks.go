@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	jwtManager *secure.JWTManager
+}
+
+func NewJWKSHandler(jm *secure.JWTManager) *JWKSHandler {
+	return &JWKSHandler{jwtManager: jm}
+}
+
+// GetJWKS godoc
+// @Summary Get the token signing keys
+// @Description Returns the public keys of the active and retired token signing keys as a JSON Web Key Set (RFC 7517),
+// @Description so other services can verify access tokens. Tokens name their signing key in the kid header.
+// @Description Served at the root, outside of the /api/v1 base path.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} secure.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header(common.CacheControlHeader, common.JWKSCacheControl)
+	c.JSON(http.StatusOK, h.jwtManager.JWKS())
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	ledgerRepo domain.LedgerRepository
+}
+
+func NewLedgerHandler(ledgerRepo domain.LedgerRepository) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerRepo: ledgerRepo,
+	}
+}
+
+// CheckLedgerConsistency godoc
+// @Summary Reconcile wallet balances with the ledger
+// @Description Proves that every cached wallet balance equals the sum of its postings
+// @Description and that every journal entry is balanced. Only admins can perform this action.
+// @Tags ledger
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.LedgerConsistencyResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /ledger/consistency [get]
+func (h *LedgerHandler) CheckLedgerConsistency(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Ledger.Read)
+	defer cancel()
+
+	report, appErr := h.ledgerRepo.CheckConsistency(ctx)
+	if appErr != nil {
+		slog.Error("failed to check ledger consistency", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if !report.IsConsistent() {
+		slog.Error("ledger is inconsistent", "requestID", requestID,
+			"walletDiscrepancies", len(report.WalletDiscrepancies), "unbalancedEntries", len(report.UnbalancedEntries))
+	}
+
+	c.JSON(http.StatusOK, dto.NewLedgerConsistencyResponse(report))
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaRepo      domain.MFARepository
+	mfaEncryptor *secure.OwnerBoundEncryptor
+}
+
+func NewMFAHandler(mfaRepo domain.MFARepository, mfaEncryptor *secure.OwnerBoundEncryptor) *MFAHandler {
+	return &MFAHandler{
+		mfaRepo:      mfaRepo,
+		mfaEncryptor: mfaEncryptor,
+	}
+}
+
+// EnrollTOTP godoc
+// @Summary Start a TOTP two-factor authentication enrollment
+// @Description Generates a new TOTP secret, stored AES-GCM encrypted, and returns it with an otpauth:// provisioning URI.
+// @Description The enrollment stays pending until it is confirmed, calling this again replaces a pending secret.
+// @Tags mfa
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Success 201 {object} dto.EnrollTOTPResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/mfa/totp [post]
+func (h *MFAHandler) EnrollTOTP(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	user, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	secret, err := secure.GenerateTOTPSecret()
+	if err != nil {
+		slog.Error("failed to generate totp secret", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+		return
+	}
+
+	encryptedSecret, err := h.mfaEncryptor.Encrypt(secret, user.UUID.String())
+	if err != nil {
+		slog.Error("failed to encrypt totp secret", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.MFA.Write)
+	defer cancel()
+
+	if appErr := h.mfaRepo.SavePending(ctx, user.ID, encryptedSecret); appErr != nil {
+		slog.Error("failed to save totp enrollment", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	// The secret must not be cached, nor stored for idempotent replays
+	c.Header(common.CacheControlHeader, "no-store")
+	c.JSON(http.StatusCreated, dto.EnrollTOTPResponse{
+		Secret:          secure.EncodeTOTPSecret(secret),
+		ProvisioningURI: secure.TOTPProvisioningURI(secret, common.TOTPIssuer, user.Email),
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm a TOTP enrollment and enable two-factor authentication
+// @Description Verifies a code from the authenticator app against the pending secret and enables MFA.
+// @Description Returns single use recovery codes, they are stored bcrypt hashed and shown only once.
+// @Description From now on login returns an MFA challenge token instead of starting a session.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param input body dto.ConfirmTOTPRequest true "TOTP code"
+// @Success 200 {object} dto.ConfirmTOTPResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/mfa/totp/confirm [post]
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	user, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.ConfirmTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.MFA.Write)
+	defer cancel()
+
+	enrollment, appErr := h.mfaRepo.FindByUserID(ctx, user.ID)
+	if appErr != nil {
+		slog.Error("failed to find totp enrollment", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if enrollment.IsEnabled() {
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: common.ErrMFAAlreadyEnabled})
+		return
+	}
+
+	secret, err := h.mfaEncryptor.Decrypt(enrollment.EncryptedSecret, user.UUID.String())
+	if err != nil {
+		slog.Error("failed to decrypt totp secret", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+		return
+	}
+
+	step, ok := secure.ValidateTOTPCode(secret, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{Error: common.ErrInvalidMFACode})
+		return
+	}
+
+	recoveryCodes, recoveryCodeHashes, err := secure.GenerateRecoveryCodes()
+	if err != nil {
+		slog.Error("failed to generate recovery codes", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+		return
+	}
+
+	if appErr := h.mfaRepo.Enable(ctx, user.ID, step, recoveryCodeHashes); appErr != nil {
+		slog.Error("failed to enable mfa", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	slog.Info("enabled totp two-factor authentication", "requestID", requestID, "userUUID", user.UUID)
+
+	// The recovery codes must not be cached, nor stored for idempotent replays
+	c.Header(common.CacheControlHeader, "no-store")
+	c.JSON(http.StatusOK, dto.ConfirmTOTPResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
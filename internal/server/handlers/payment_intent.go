@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PaymentIntentHandler struct {
+	paymentIntentRepo domain.PaymentIntentRepository
+	walletRepo        domain.WalletRepository
+}
+
+func NewPaymentIntentHandler(paymentIntentRepo domain.PaymentIntentRepository, walletRepo domain.WalletRepository) *PaymentIntentHandler {
+	return &PaymentIntentHandler{
+		paymentIntentRepo: paymentIntentRepo,
+		walletRepo:        walletRepo,
+	}
+}
+
+// CreatePaymentIntent godoc
+// @Summary Create a payment intent
+// @Description Creates a merchant's request to be paid an amount into their wallet in the currency.
+// @Description The customer confirms it from their wallet within 24 hours, which places a hold on the funds.
+// @Tags payment-intents
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "Merchant User UUID"
+// @Param input body dto.CreatePaymentIntentRequest true "Payment intent details"
+// @Success 201 {object} dto.PaymentIntentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/payment-intents [post]
+func (h *PaymentIntentHandler) CreatePaymentIntent(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.CreatePaymentIntentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.PaymentIntent.Write)
+	defer cancel()
+
+	merchantWallet, appErr := h.walletRepo.FindByUserAndCurrency(ctx, owner.ID, req.Currency)
+	if appErr != nil {
+		slog.Error("failed to find merchant wallet", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if merchantWallet.Status != domain.WalletStatusActive {
+		c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{Error: "merchant wallet is not active"})
+		return
+	}
+
+	intent := domain.NewPaymentIntent(merchantWallet, req.AmountInCents, strings.TrimSpace(req.Description), time.Now().UTC())
+	if appErr := h.paymentIntentRepo.Create(ctx, intent); appErr != nil {
+		slog.Error("failed to create payment intent", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.PaymentIntentResponse{PaymentIntent: *intent})
+}
+
+// GetPaymentIntent godoc
+// @Summary Get a payment intent
+// @Description Retrieves one of the merchant's payment intents
+// @Tags payment-intents
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "Merchant User UUID"
+// @Param payment_intent_uuid path string true "Payment Intent UUID"
+// @Success 200 {object} dto.PaymentIntentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/payment-intents/{payment_intent_uuid} [get]
+func (h *PaymentIntentHandler) GetPaymentIntent(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, intentUUID, appErr := merchantPaymentIntentParams(c)
+	if appErr != nil {
+		slog.Error("invalid payment intent request", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.PaymentIntent.Read)
+	defer cancel()
+
+	intent, appErr := h.paymentIntentRepo.FindByMerchant(ctx, owner.ID, intentUUID)
+	if appErr != nil {
+		slog.Error("failed to find payment intent", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PaymentIntentResponse{PaymentIntent: *intent})
+}
+
+// ConfirmPaymentIntent godoc
+// @Summary Confirm a payment intent
+// @Description Pays a merchant's payment intent from one of the customer's wallets by placing a hold on the amount.
+// @Description The held funds stay in the balance but can't be spent, until the merchant captures or voids the intent
+// @Description or the hold expires after 7 days. The wallet must be active and hold the intent's currency.
+// @Tags payment-intents
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "Customer User UUID"
+// @Param wallet_uuid path string true "Wallet UUID"
+// @Param payment_intent_uuid path string true "Payment Intent UUID"
+// @Success 200 {object} dto.PaymentIntentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/wallets/{wallet_uuid}/payment-intents/{payment_intent_uuid}/confirm [post]
+func (h *PaymentIntentHandler) ConfirmPaymentIntent(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	intentUUID, appErr := paymentIntentUUIDParam(c)
+	if appErr != nil {
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.PaymentIntent.Write)
+	defer cancel()
+
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), owner)
+	if appErr != nil {
+		slog.Error("failed to find customer wallet", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	intent, appErr := h.paymentIntentRepo.Confirm(ctx, intentUUID, wallet.ID)
+	if appErr != nil {
+		slog.Error("failed to confirm payment intent", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PaymentIntentResponse{PaymentIntent: *intent})
+}
+
+// CapturePaymentIntent godoc
+// @Summary Capture a payment intent
+// @Description Moves the held funds of an authorized payment intent from the customer's wallet into the merchant's wallet,
+// @Description in one serializable transaction backed by a single journal entry. A partial capture releases the rest
+// @Description of the hold to the customer. An intent is captured once.
+// @Tags payment-intents
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "Merchant User UUID"
+// @Param payment_intent_uuid path string true "Payment Intent UUID"
+// @Param input body dto.CapturePaymentIntentRequest false "Amount to capture, the full amount by default"
+// @Success 200 {object} dto.PaymentIntentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/payment-intents/{payment_intent_uuid}/capture [post]
+func (h *PaymentIntentHandler) CapturePaymentIntent(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, intentUUID, appErr := merchantPaymentIntentParams(c)
+	if appErr != nil {
+		slog.Error("invalid payment intent request", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.CapturePaymentIntentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.PaymentIntent.Write)
+	defer cancel()
+
+	intent, appErr := h.paymentIntentRepo.Capture(ctx, owner.ID, intentUUID, req.AmountInCents)
+	if appErr != nil {
+		slog.Error("failed to capture payment intent", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PaymentIntentResponse{PaymentIntent: *intent})
+}
+
+// VoidPaymentIntent godoc
+// @Summary Void a payment intent
+// @Description Cancels a payment intent that wasn't captured, the hold of a confirmed intent is released to the customer.
+// @Tags payment-intents
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "Merchant User UUID"
+// @Param payment_intent_uuid path string true "Payment Intent UUID"
+// @Success 200 {object} dto.PaymentIntentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/payment-intents/{payment_intent_uuid}/void [post]
+func (h *PaymentIntentHandler) VoidPaymentIntent(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, intentUUID, appErr := merchantPaymentIntentParams(c)
+	if appErr != nil {
+		slog.Error("invalid payment intent request", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.PaymentIntent.Write)
+	defer cancel()
+
+	intent, appErr := h.paymentIntentRepo.Void(ctx, owner.ID, intentUUID)
+	if appErr != nil {
+		slog.Error("failed to void payment intent", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PaymentIntentResponse{PaymentIntent: *intent})
+}
+
+// merchantPaymentIntentParams returns the merchant of the route and the payment_intent_uuid route param.
+func merchantPaymentIntentParams(c *gin.Context) (*domain.User, string, common.AppError) {
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		return nil, "", appErr
+	}
+
+	intentUUID, appErr := paymentIntentUUIDParam(c)
+	if appErr != nil {
+		return nil, "", appErr
+	}
+
+	return owner, intentUUID, nil
+}
+
+func paymentIntentUUIDParam(c *gin.Context) (string, common.AppError) {
+	intentUUID := c.Param("payment_intent_uuid")
+	if err := uuid.Validate(intentUUID); err != nil {
+		return "", common.NewBadRequestError("Invalid payment intent UUID")
+	}
+
+	return intentUUID, nil
+}
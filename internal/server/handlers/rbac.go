@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure/rbac"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type RBACHandler struct {
+	policyRepo domain.RBACPolicyRepository
+	rbac       *rbac.RBAC
+}
+
+func NewRBACHandler(policyRepo domain.RBACPolicyRepository, rbac *rbac.RBAC) *RBACHandler {
+	return &RBACHandler{
+		policyRepo: policyRepo,
+		rbac:       rbac,
+	}
+}
+
+// ListPolicyActions godoc
+// @Summary List the actions that can be granted
+// @Description Returns every named API route that can be granted to a role, with its method and route. Only admins can perform this action.
+// @Tags rbac
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.PolicyActionsResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /rbac/actions [get]
+func (h *RBACHandler) ListPolicyActions(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.RBAC.Read)
+	defer cancel()
+
+	actions, appErr := h.policyRepo.ListActions(ctx)
+	if appErr != nil {
+		slog.Error("failed to list rbac actions", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PolicyActionsResponse{
+		Actions: actions,
+	})
+}
+
+// ListPolicyGrants godoc
+// @Summary List the grants of every role
+// @Description Returns which role can call which action. Only admins can perform this action.
+// @Tags rbac
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.PolicyGrantsResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /rbac/grants [get]
+func (h *RBACHandler) ListPolicyGrants(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.RBAC.Read)
+	defer cancel()
+
+	grants, appErr := h.policyRepo.ListGrants(ctx)
+	if appErr != nil {
+		slog.Error("failed to list rbac grants", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PolicyGrantsResponse{
+		Grants: grants,
+	})
+}
+
+// GrantPolicyAction godoc
+// @Summary Grant an action to a role
+// @Description Allows every user with the role to call the action on the resources of the users in the scope, or changes the scope
+// @Description of an existing grant. Takes effect on this instance right away and on the others within the policy sync interval,
+// @Description no restart needed. Granting an existing grant with the same scope does nothing. Only admins can perform this action.
+// @Tags rbac
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param role path string true "Role" Enums(admin, user, agent, merchant)
+// @Param action path string true "Action name, e.g. UpdateWalletStatus"
+// @Param input body dto.GrantPolicyActionRequest true "Access scope of the grant"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /rbac/roles/{role}/actions/{action} [put]
+func (h *RBACHandler) GrantPolicyAction(c *gin.Context) {
+	var req dto.GrantPolicyActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", c.GetString(common.ContextKeyRequestID), "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	h.changeGrant(c, domain.PolicyChangeGrant, req.Scope)
+}
+
+// RevokePolicyAction godoc
+// @Summary Revoke an action from a role
+// @Description Stops users with the role from calling the action. Takes effect on this instance right away and on the others
+// @Description within the policy sync interval, no restart needed. Revoking a missing grant does nothing.
+// @Description The policy management actions can't be revoked from admins. Only admins can perform this action.
+// @Tags rbac
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param role path string true "Role" Enums(admin, user, agent, merchant)
+// @Param action path string true "Action name, e.g. UpdateWalletStatus"
+// @Success 204
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /rbac/roles/{role}/actions/{action} [delete]
+func (h *RBACHandler) RevokePolicyAction(c *gin.Context) {
+	h.changeGrant(c, domain.PolicyChangeRevoke, "")
+}
+
+// ListPolicyChanges godoc
+// @Summary List the recent policy changes
+// @Description Returns the most recent grants and revocations with the admin who made them, newest first.
+// @Description Grants seeded from the embedded policy have no actor. Only admins can perform this action.
+// @Tags rbac
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param limit query int false "Number of changes to return, 1 to 200" default(50)
+// @Success 200 {object} dto.PolicyChangesResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /rbac/changes [get]
+func (h *RBACHandler) ListPolicyChanges(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+
+	limit, appErr := queryLimit(c, domain.PolicyChangesDefaultLimit, domain.PolicyChangesMaxLimit)
+	if appErr != nil {
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.RBAC.Read)
+	defer cancel()
+
+	changes, appErr := h.policyRepo.ListChanges(ctx, limit)
+	if appErr != nil {
+		slog.Error("failed to list rbac policy changes", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PolicyChangesResponse{
+		Changes: changes,
+	})
+}
+
+// ExplainPermission godoc
+// @Summary Explain an access decision
+// @Description Shows how the policy decides a request of a role: the policy routes matching the path in precedence order,
+// @Description the resolved action and route, the role's grants of the action and the reason for the decision.
+// @Description Use it to debug an "Access denied" response. Only admins can perform this action.
+// @Tags rbac
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param role query string true "Role" Enums(admin, user, agent, merchant)
+// @Param method query string true "HTTP method" Enums(GET, POST, PUT, PATCH, DELETE)
+// @Param path query string true "Route template or request path, e.g. /api/v1/users/:user_uuid/wallets"
+// @Success 200 {object} dto.PermissionExplanationResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /rbac/explain [get]
+func (h *RBACHandler) ExplainPermission(c *gin.Context) {
+	var req dto.ExplainPermissionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		slog.Error("invalid query params", "requestID", c.GetString(common.ContextKeyRequestID), "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PermissionExplanationResponse{
+		Explanation: h.rbac.Explain(req.Role, req.Path, req.Method),
+	})
+}
+
+// changeGrant grants, within scope, or revokes the action of the route params, records the admin who did it
+// and reloads the grants of this instance, the other instances pick the change up on their next sync.
+func (h *RBACHandler) changeGrant(c *gin.Context, changeType, scope string) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	role, action := c.Param("role"), c.Param("action")
+
+	admin, _, appErr := authorizedSession(c)
+	if appErr != nil {
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if changeType == domain.PolicyChangeRevoke && h.rbac.IsProtectedGrant(role, action) {
+		c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{Error: common.ErrPolicyGrantProtected})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.RBAC.Write)
+	defer cancel()
+
+	if changeType == domain.PolicyChangeGrant {
+		appErr = h.policyRepo.Grant(ctx, role, action, scope, admin.ID)
+	} else {
+		appErr = h.policyRepo.Revoke(ctx, role, action, admin.ID)
+	}
+
+	if appErr != nil {
+		slog.Error("failed to change rbac grant", "requestID", requestID, "change", changeType, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	slog.Info("rbac policy changed", "requestID", requestID, "change", changeType, "role", role, "action", action, "scope", scope, "adminUUID", admin.UUID)
+
+	if appErr := h.rbac.Sync(ctx); appErr != nil {
+		slog.Error("failed to reload rbac policy", "requestID", requestID, "error", appErr.Error())
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	refundRepo domain.RefundRepository
+}
+
+func NewRefundHandler(refundRepo domain.RefundRepository) *RefundHandler {
+	return &RefundHandler{
+		refundRepo: refundRepo,
+	}
+}
+
+// CreateRefund godoc
+// @Summary Refund a captured payment
+// @Description Moves all or part of a captured payment from the merchant's wallet back to the wallet the customer paid from,
+// @Description in one serializable transaction backed by a single journal entry. The refunds of a payment never add up
+// @Description to more than its captured amount. When the merchant's available balance can't cover the refund or a wallet
+// @Description isn't active, the refund is stored as failed and 422 is returned with it. Send an Idempotency-Key to retry safely.
+// @Tags payment-intents
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "Merchant User UUID"
+// @Param payment_intent_uuid path string true "Payment Intent UUID"
+// @Param input body dto.CreateRefundRequest false "Amount to refund, everything left to refund by default"
+// @Success 201 {object} dto.RefundResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.RefundResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/payment-intents/{payment_intent_uuid}/refunds [post]
+func (h *RefundHandler) CreateRefund(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, intentUUID, appErr := merchantPaymentIntentParams(c)
+	if appErr != nil {
+		slog.Error("invalid refund request", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.PaymentIntent.Write)
+	defer cancel()
+
+	refund, appErr := h.refundRepo.Create(ctx, owner.ID, intentUUID, req.AmountInCents, strings.TrimSpace(req.Reason))
+	if appErr != nil {
+		slog.Error("failed to create refund", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if refund.Status != domain.RefundStatusSucceeded {
+		slog.Warn("refund failed", "requestID", requestID, "refundUUID", refund.UUID, "reason", refund.FailureReason)
+		c.JSON(http.StatusUnprocessableEntity, dto.RefundResponse{Refund: *refund})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.RefundResponse{Refund: *refund})
+}
+
+// ListRefunds godoc
+// @Summary List the refunds of a payment
+// @Description Retrieves the refunds of one of the merchant's payment intents, oldest first, failed refunds included
+// @Tags payment-intents
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "Merchant User UUID"
+// @Param payment_intent_uuid path string true "Payment Intent UUID"
+// @Success 200 {object} dto.ListRefundsResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/payment-intents/{payment_intent_uuid}/refunds [get]
+func (h *RefundHandler) ListRefunds(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, intentUUID, appErr := merchantPaymentIntentParams(c)
+	if appErr != nil {
+		slog.Error("invalid refund request", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.PaymentIntent.Read)
+	defer cancel()
+
+	refunds, appErr := h.refundRepo.ListByPaymentIntent(ctx, owner.ID, intentUUID)
+	if appErr != nil {
+		slog.Error("failed to list refunds", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ListRefundsResponse{Refunds: refunds})
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/statement"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StatementHandler struct {
+	statementRepo domain.StatementRepository
+	walletRepo    domain.WalletRepository
+}
+
+func NewStatementHandler(statementRepo domain.StatementRepository, walletRepo domain.WalletRepository) *StatementHandler {
+	return &StatementHandler{
+		statementRepo: statementRepo,
+		walletRepo:    walletRepo,
+	}
+}
+
+// GetStatement godoc
+// @Summary Get a wallet statement
+// @Description Returns the wallet's statement for the period as a CSV, OFX or PDF file: the opening balance,
+// @Description the completed transactions with the running balance and the closing balance.
+// @Description Periods longer than 31 days are exported in the background, the response is then 202 with the export,
+// @Description poll the Location until it is ready and download the file from its downloadUrl.
+// @Tags wallet
+// @Produce text/csv,application/x-ofx,application/pdf,json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param wallet_uuid path string true "Wallet UUID"
+// @Param from query string true "First day of the period, YYYY-MM-DD in UTC"
+// @Param to query string true "Last day of the period, YYYY-MM-DD in UTC"
+// @Param format query string true "File format" Enums(csv, ofx, pdf)
+// @Success 200 {file} file "Statement file"
+// @Success 202 {object} dto.StatementExportResponse
+// @Header 202 {string} Location "URL of the export"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/wallets/{wallet_uuid}/statements [get]
+func (h *StatementHandler) GetStatement(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.GetStatementRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		slog.Error("invalid query params", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Statement.Read)
+	defer cancel()
+
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), owner)
+	if appErr != nil {
+		slog.Error("failed to find wallet", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	from, to := req.Period()
+
+	if !domain.IsSyncStatementPeriod(from, to) {
+		export := domain.NewStatementExport(wallet.ID, from, to, req.Format, time.Now().UTC())
+		if appErr := h.statementRepo.CreateExport(ctx, export); appErr != nil {
+			slog.Error("failed to create statement export", "requestID", requestID, "error", appErr.Error())
+			c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+			return
+		}
+
+		c.Header(common.LocationHeader, dto.StatementExportURL(owner.UUID.String(), wallet.UUID.String(), export))
+		c.JSON(http.StatusAccepted, dto.NewStatementExportResponse(owner.UUID.String(), wallet.UUID.String(), export))
+		return
+	}
+
+	s, appErr := h.statementRepo.Build(ctx, wallet.ID, from, to)
+	if appErr != nil {
+		slog.Error("failed to build statement", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	file, err := statement.Render(s, req.Format)
+	if err != nil {
+		slog.Error("failed to render statement", "requestID", requestID, "error", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+		return
+	}
+
+	sendStatementFile(c, file)
+}
+
+// GetStatementExport godoc
+// @Summary Get a statement export
+// @Description Returns a statement export generated in the background, with its downloadUrl once it is ready.
+// @Description Exports expire 7 days after they were requested.
+// @Tags wallet
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param wallet_uuid path string true "Wallet UUID"
+// @Param statement_uuid path string true "Statement export UUID"
+// @Success 200 {object} dto.StatementExportResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/wallets/{wallet_uuid}/statements/{statement_uuid} [get]
+func (h *StatementHandler) GetStatementExport(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Statement.Read)
+	defer cancel()
+
+	owner, wallet, export, appErr := h.findStatementExport(ctx, c)
+	if appErr != nil {
+		slog.Error("failed to find statement export", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewStatementExportResponse(owner.UUID.String(), wallet.UUID.String(), export))
+}
+
+// DownloadStatementExport godoc
+// @Summary Download a statement export
+// @Description Returns the file of a ready statement export.
+// @Tags wallet
+// @Produce text/csv,application/x-ofx,application/pdf,json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param wallet_uuid path string true "Wallet UUID"
+// @Param statement_uuid path string true "Statement export UUID"
+// @Success 200 {file} file "Statement file"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse "Export is not ready"
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/wallets/{wallet_uuid}/statements/{statement_uuid}/download [get]
+func (h *StatementHandler) DownloadStatementExport(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Statement.Read)
+	defer cancel()
+
+	_, _, export, appErr := h.findStatementExport(ctx, c)
+	if appErr != nil {
+		slog.Error("failed to find statement export", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	file, appErr := h.statementRepo.FindExportFile(ctx, export.ID)
+	if appErr != nil {
+		slog.Error("failed to read statement export file", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	sendStatementFile(c, file)
+}
+
+// findStatementExport finds the export of the statement_uuid route param, it must belong to a wallet of the user.
+func (h *StatementHandler) findStatementExport(ctx context.Context, c *gin.Context) (*domain.User, *domain.Wallet, *domain.StatementExport, common.AppError) {
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		return nil, nil, nil, appErr
+	}
+
+	exportUUID := c.Param("statement_uuid")
+	if err := uuid.Validate(exportUUID); err != nil {
+		return nil, nil, nil, common.NewBadRequestError("Invalid statement export UUID")
+	}
+
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), owner)
+	if appErr != nil {
+		return nil, nil, nil, appErr
+	}
+
+	export, appErr := h.statementRepo.FindExport(ctx, wallet.ID, exportUUID)
+	if appErr != nil {
+		return nil, nil, nil, appErr
+	}
+
+	return owner, wallet, export, nil
+}
+
+// sendStatementFile writes the statement as an attachment, statements are never cached.
+func sendStatementFile(c *gin.Context, file *domain.StatementFile) {
+	c.Header(common.CacheControlHeader, "no-store")
+	c.Header(common.ContentDispositionHeader, mime.FormatMediaType("attachment", map[string]string{"filename": file.FileName}))
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
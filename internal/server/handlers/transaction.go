@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type TransactionHandler struct {
+	transactionRepo domain.TransactionRepository
+	walletRepo      domain.WalletRepository
+}
+
+func NewTransactionHandler(transactionRepo domain.TransactionRepository, walletRepo domain.WalletRepository) *TransactionHandler {
+	return &TransactionHandler{
+		transactionRepo: transactionRepo,
+		walletRepo:      walletRepo,
+	}
+}
+
+// ListTransactions godoc
+// @Summary List wallet transactions
+// @Description Lists a page of the wallet's transactions, newest first, with the wallet balance after each of them.
+// @Description Pass the nextCursor of a page as cursor to get the next page, with the same filters.
+// @Tags wallet
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param wallet_uuid path string true "Wallet UUID"
+// @Param type query string false "Filter by type" Enums(opening_balance, transfer, deposit, fx_conversion, payment, refund)
+// @Param status query string false "Filter by status" Enums(completed, failed)
+// @Param from query string false "Only transactions at or after this time, RFC 3339"
+// @Param to query string false "Only transactions before this time, RFC 3339"
+// @Param minAmountInCents query int false "Minimum amount"
+// @Param maxAmountInCents query int false "Maximum amount"
+// @Param cursor query string false "Cursor of the next page"
+// @Param limit query int false "Number of transactions to return, 1 to 100" default(20)
+// @Success 200 {object} dto.TransactionListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/wallets/{wallet_uuid}/transactions [get]
+func (h *TransactionHandler) ListTransactions(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.ListTransactionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		slog.Error("invalid query params", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	limit, appErr := queryLimit(c, domain.TransactionHistoryDefaultLimit, domain.TransactionHistoryMaxLimit)
+	if appErr != nil {
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	filters, err := req.ToFilters(limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Wallet.Read)
+	defer cancel()
+
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), owner)
+	if appErr != nil {
+		slog.Error("failed to find wallet", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	filters.WalletID = wallet.ID
+
+	page, appErr := h.transactionRepo.List(ctx, filters)
+	if appErr != nil {
+		slog.Error("failed to list transactions", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TransactionListResponse{
+		Transactions: page.Transactions,
+		NextCursor:   page.NextCursor,
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+type TransferHandler struct {
+	transferRepo domain.TransferRepository
+	walletRepo   domain.WalletRepository
+	userRepo     domain.UserRepository
+}
+
+func NewTransferHandler(transferRepo domain.TransferRepository, walletRepo domain.WalletRepository, userRepo domain.UserRepository) *TransferHandler {
+	return &TransferHandler{
+		transferRepo: transferRepo,
+		walletRepo:   walletRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// CreateTransfer godoc
+// @Summary Transfer funds to another wallet
+// @Description Atomically debits the sender wallet and credits the recipient wallet in one serializable transaction.
+// @Description The recipient is looked up by wallet UUID or by email, in which case the recipient's wallet
+// @Description in the sender wallet's currency is used. Both wallets must be active.
+// @Tags transfer
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param wallet_uuid path string true "Sender Wallet UUID"
+// @Param input body dto.CreateTransferRequest true "Transfer details"
+// @Success 201 {object} dto.CreateTransferResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/wallets/{wallet_uuid}/transfers [post]
+func (h *TransferHandler) CreateTransfer(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	authorizedUser, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Transfer.Write)
+	defer cancel()
+
+	senderWallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), authorizedUser)
+	if appErr != nil {
+		slog.Error("failed to find sender wallet", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	recipientWallet, appErr := h.findRecipientWallet(ctx, &req, senderWallet.Currency)
+	if appErr != nil {
+		slog.Error("failed to find recipient wallet", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	transfer, appErr := h.transferRepo.Create(ctx, req.ToTransfer(senderWallet, recipientWallet, authorizedUser.ID))
+	if appErr != nil {
+		slog.Error("failed to create transfer", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.CreateTransferResponse{Transfer: *transfer})
+}
+
+// findRecipientWallet resolves the recipient wallet by UUID, or by the recipient's email and the transfer currency.
+func (h *TransferHandler) findRecipientWallet(ctx context.Context, req *dto.CreateTransferRequest, currency string) (*domain.Wallet, common.AppError) {
+	if req.RecipientWalletUUID != "" {
+		return h.walletRepo.FindBy(ctx, common.DBColumnUUID, req.RecipientWalletUUID)
+	}
+
+	recipient, appErr := h.userRepo.FindBy(ctx, common.DBColumnEmail, req.RecipientEmail)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return h.walletRepo.FindByUserAndCurrency(ctx, recipient.ID, currency)
+}
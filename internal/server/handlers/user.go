@@ -11,21 +11,27 @@ import (
 	"github.com/ashtishad/xpay/internal/secure/rbac"
 	"github.com/ashtishad/xpay/internal/server/dto"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type UserHandler struct {
-	userRepo domain.UserRepository
+	userRepo         domain.UserRepository
+	loginAttemptRepo domain.LoginAttemptRepository
+	tokenSender      *AccountTokenSender
 }
 
-func NewUserHandler(userRepo domain.UserRepository) *UserHandler {
+func NewUserHandler(userRepo domain.UserRepository, loginAttemptRepo domain.LoginAttemptRepository, tokenSender *AccountTokenSender) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		tokenSender:      tokenSender,
 	}
 }
 
 // CreateUserWithRole godoc
 // @Summary Create a new user with a specific role
 // @Description Creates a new user with admin, user, agent, or merchant role. Only admins can perform this action.
+// @Description Emails the new user a token to verify the email address.
 // @Tags user
 // @Accept json
 // @Produce json
@@ -76,6 +82,7 @@ func (h *UserHandler) CreateUserWithRole(c *gin.Context) {
 	}
 
 	newUser := req.ToUser(passwordHash)
+	newUser.CreatedBy = &user.ID
 	createdUser, appErr := h.userRepo.Create(ctx, newUser)
 	if appErr != nil {
 		slog.Error("failed to create user", "requestID", requestID, "error", appErr.Error())
@@ -83,7 +90,105 @@ func (h *UserHandler) CreateUserWithRole(c *gin.Context) {
 		return
 	}
 
+	h.tokenSender.sendEmailVerification(c, createdUser)
+
 	c.JSON(http.StatusCreated, dto.CreateUserResponse{
 		User: *createdUser,
 	})
 }
+
+// RevokeUserSessions godoc
+// @Summary Revoke all sessions of a user
+// @Description Logs a user out everywhere, e.g. after an account compromise. Every access token issued to the user
+// @Description so far is rejected and all of the user's refresh tokens are revoked. Only admins can perform this action.
+// @Tags user
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/sessions [delete]
+func (h *UserHandler) RevokeUserSessions(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	userUUID := c.Param("user_uuid")
+	if err := uuid.Validate(userUUID); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid user UUID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.User.Write)
+	defer cancel()
+
+	userID, appErr := h.userRepo.FindIDFromUUID(ctx, userUUID)
+	if appErr != nil {
+		slog.Error("failed to find user", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if appErr := h.userRepo.RevokeAllSessions(ctx, userID); appErr != nil {
+		slog.Error("failed to revoke user sessions", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	slog.Info("revoked all user sessions", "requestID", requestID, "userUUID", userUUID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetLoginHistory godoc
+// @Summary Get the recent login attempts of a user
+// @Description Lists login attempts with ip address, user agent and outcome, newest first.
+// @Description Users can see their own history, admins can see the history of any user.
+// @Tags user
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param limit query int false "Number of attempts to return, 1 to 100" default(20)
+// @Success 200 {object} dto.LoginHistoryResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/login-history [get]
+func (h *UserHandler) GetLoginHistory(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	if _, appErr := validateUserAccess(c); appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	limit, appErr := queryLimit(c, domain.LoginHistoryDefaultLimit, domain.LoginHistoryMaxLimit)
+	if appErr != nil {
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.User.Read)
+	defer cancel()
+
+	userID, appErr := h.userRepo.FindIDFromUUID(ctx, c.Param("user_uuid"))
+	if appErr != nil {
+		slog.Error("failed to find user", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	attempts, appErr := h.loginAttemptRepo.ListByUserID(ctx, userID, limit)
+	if appErr != nil {
+		slog.Error("failed to list login attempts", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.LoginHistoryResponse{
+		Attempts: attempts,
+	})
+}
@@ -25,7 +25,7 @@ func NewWalletHandler(walletRepo domain.WalletRepository, userRepo domain.UserRe
 
 // CreateWallet godoc
 // @Summary Create a new wallet for a user
-// @Description Creates a new wallet for the specified user
+// @Description Creates a new wallet for the specified user, the user must have verified their email address
 // @Tags wallet
 // @Accept json
 // @Produce json
@@ -48,6 +48,11 @@ func (h *WalletHandler) CreateWallet(c *gin.Context) {
 		return
 	}
 
+	if !authorizedUser.IsEmailVerified() {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: common.ErrEmailNotVerified})
+		return
+	}
+
 	var req dto.CreateWalletRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
@@ -72,7 +77,7 @@ func (h *WalletHandler) CreateWallet(c *gin.Context) {
 
 // GetWalletBalance godoc
 // @Summary Get wallet balance
-// @Description Retrieves the balance of a specific wallet for a user
+// @Description Retrieves the balance and currency of one of the user's wallets, with the funds on hold for payment intents
 // @Tags wallet
 // @Produce json
 // @Param Authorization header string true "Bearer token"
@@ -86,25 +91,17 @@ func (h *WalletHandler) CreateWallet(c *gin.Context) {
 // @Router /users/{user_uuid}/wallets/{wallet_uuid}/balance [get]
 func (h *WalletHandler) GetWalletBalance(c *gin.Context) {
 	requestID := c.GetString(common.ContextKeyRequestID)
-	_, appErr := validateUserAccess(c)
+	owner, appErr := validateUserAccess(c)
 	if appErr != nil {
 		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
-	walletUUID := c.Param("wallet_uuid")
-	if walletUUID == "" {
-		appErr := common.NewBadRequestError("Wallet UUID is required")
-		slog.Error("missing wallet UUID", "requestID", requestID)
-		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
-		return
-	}
-
 	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Wallet.Read)
 	defer cancel()
 
-	balance, appErr := h.walletRepo.GetBalance(ctx, walletUUID)
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), owner)
 	if appErr != nil {
 		slog.Error("failed to get wallet balance", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
@@ -112,8 +109,10 @@ func (h *WalletHandler) GetWalletBalance(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, dto.GetWalletBalanceResponse{
-		BalanceInCents: balance,
-		Currency:       domain.WalletCurrencyUSD,
+		BalanceInCents:   wallet.BalanceInCents,
+		HeldInCents:      wallet.HeldInCents,
+		AvailableInCents: wallet.AvailableInCents(),
+		Currency:         wallet.Currency,
 	})
 }
 
@@ -136,21 +135,13 @@ func (h *WalletHandler) GetWalletBalance(c *gin.Context) {
 // @Router /users/{user_uuid}/wallets/{wallet_uuid}/status [patch]
 func (h *WalletHandler) UpdateWalletStatus(c *gin.Context) {
 	requestID := c.GetString(common.ContextKeyRequestID)
-	_, appErr := validateUserAccess(c)
+	owner, appErr := validateUserAccess(c)
 	if appErr != nil {
 		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
 		return
 	}
 
-	walletUUID := c.Param("wallet_uuid")
-	if walletUUID == "" {
-		appErr := common.NewBadRequestError("Wallet UUID is required")
-		slog.Error("missing wallet UUID", "requestID", requestID)
-		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
-		return
-	}
-
 	var req dto.UpdateWalletStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
@@ -161,7 +152,14 @@ func (h *WalletHandler) UpdateWalletStatus(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Wallet.Write)
 	defer cancel()
 
-	appErr = h.walletRepo.UpdateStatus(ctx, walletUUID, req.Status)
+	wallet, appErr := findOwnedWallet(ctx, h.walletRepo, c.Param("wallet_uuid"), owner)
+	if appErr != nil {
+		slog.Error("failed to find wallet", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	appErr = h.walletRepo.UpdateStatus(ctx, wallet.UUID.String(), req.Status)
 	if appErr != nil {
 		slog.Error("failed to update wallet status", "requestID", requestID, "error", appErr.Error())
 		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
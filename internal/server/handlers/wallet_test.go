@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// statusWalletRepo records the wallets whose status was updated.
+type statusWalletRepo struct {
+	stubWalletRepo
+	updated []string
+}
+
+func (r *statusWalletRepo) UpdateStatus(_ context.Context, walletUUID string, _ string) common.AppError {
+	r.updated = append(r.updated, walletUUID)
+	return nil
+}
+
+func TestUpdateWalletStatusRequiresTheOwnersWallet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	owner := &domain.User{ID: 7, UUID: uuid.New()}
+
+	tests := []struct {
+		name       string
+		wallet     *domain.Wallet
+		wantStatus int
+	}{
+		{"Own Wallet", &domain.Wallet{ID: 3, UUID: uuid.New(), UserID: owner.ID}, http.StatusOK},
+		{"Wallet Of Another User", &domain.Wallet{ID: 4, UUID: uuid.New(), UserID: owner.ID + 1}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &statusWalletRepo{stubWalletRepo: stubWalletRepo{wallet: tt.wallet}}
+			h := NewWalletHandler(repo, nil)
+
+			router := gin.New()
+			router.PATCH("/users/:user_uuid/wallets/:wallet_uuid/status", func(c *gin.Context) {
+				c.Set(common.ContextKeyResourceOwner, owner)
+				c.Next()
+			}, h.UpdateWalletStatus)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch,
+				"/users/"+owner.UUID.String()+"/wallets/"+tt.wallet.UUID.String()+"/status", strings.NewReader(`{"status":"blocked"}`)))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, []string{tt.wallet.UUID.String()}, repo.updated)
+			} else {
+				assert.Empty(t, repo.updated)
+			}
+		})
+	}
+}
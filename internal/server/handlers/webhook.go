@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/webhook"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	webhookRepo      domain.WebhookRepository
+	webhookEncryptor *secure.OwnerBoundEncryptor
+}
+
+func NewWebhookHandler(webhookRepo domain.WebhookRepository, webhookEncryptor *secure.OwnerBoundEncryptor) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo:      webhookRepo,
+		webhookEncryptor: webhookEncryptor,
+	}
+}
+
+// CreateWebhookEndpoint godoc
+// @Summary Register a webhook endpoint
+// @Description Registers an https URL that receives the subscribed events as signed POST requests.
+// @Description The URL's host must resolve to public IP addresses only, loopback, private and link-local addresses are rejected.
+// @Description The signing secret is returned once, verify the X-Xpay-Signature header of every delivery with it.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param input body dto.CreateWebhookEndpointRequest true "Endpoint URL and events"
+// @Success 201 {object} dto.CreateWebhookEndpointResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/webhooks [post]
+func (h *WebhookHandler) CreateWebhookEndpoint(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.CreateWebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Webhook.Write)
+	defer cancel()
+
+	if err := webhook.ValidateEndpointURL(ctx, req.URL); err != nil {
+		slog.Warn("rejected webhook endpoint URL", "requestID", requestID, "error", err.Error())
+		if errors.Is(err, webhook.ErrForbiddenAddress) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: common.ErrWebhookURLNotPublic})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: common.ErrWebhookURLUnresolvable})
+		return
+	}
+
+	secret, err := secure.GenerateWebhookSecret()
+	if err != nil {
+		slog.Error("failed to generate webhook secret", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+		return
+	}
+
+	endpoint := domain.NewWebhookEndpoint(owner.ID, req.URL, req.Events)
+
+	endpoint.EncryptedSecret, err = h.webhookEncryptor.Encrypt([]byte(secret), endpoint.UUID.String())
+	if err != nil {
+		slog.Error("failed to encrypt webhook secret", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: common.ErrUnexpectedServer})
+		return
+	}
+
+	if appErr := h.webhookRepo.CreateEndpoint(ctx, endpoint); appErr != nil {
+		slog.Error("failed to create webhook endpoint", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	// The signing secret is only returned here, it must not be cached nor stored for idempotent replays
+	c.Header(common.CacheControlHeader, "no-store")
+	c.JSON(http.StatusCreated, dto.CreateWebhookEndpointResponse{Endpoint: *endpoint, Secret: secret})
+}
+
+// ListWebhookEndpoints godoc
+// @Summary List webhook endpoints
+// @Description Retrieves the user's webhook endpoints, oldest first, disabled endpoints included
+// @Tags webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Success 200 {object} dto.ListWebhookEndpointsResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/webhooks [get]
+func (h *WebhookHandler) ListWebhookEndpoints(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Webhook.Read)
+	defer cancel()
+
+	endpoints, appErr := h.webhookRepo.ListEndpoints(ctx, owner.ID)
+	if appErr != nil {
+		slog.Error("failed to list webhook endpoints", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ListWebhookEndpointsResponse{Endpoints: endpoints})
+}
+
+// UpdateWebhookEndpointStatus godoc
+// @Summary Enable or disable a webhook endpoint
+// @Description Disabling an endpoint pauses its deliveries. Enabling it clears its failures and resumes its pending deliveries,
+// @Description deliveries dead-lettered while it kept failing can be redelivered.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param webhook_uuid path string true "Webhook Endpoint UUID"
+// @Param input body dto.UpdateWebhookEndpointStatusRequest true "New status"
+// @Success 200 {object} dto.WebhookEndpointResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/webhooks/{webhook_uuid} [patch]
+func (h *WebhookHandler) UpdateWebhookEndpointStatus(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.UpdateWebhookEndpointStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Error("invalid request body", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Webhook.Write)
+	defer cancel()
+
+	endpoint, appErr := h.findOwnedEndpoint(ctx, c, owner)
+	if appErr != nil {
+		slog.Error("failed to find webhook endpoint", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if appErr := h.webhookRepo.UpdateEndpointStatus(ctx, endpoint, req.Status); appErr != nil {
+		slog.Error("failed to update webhook endpoint status", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.WebhookEndpointResponse{Endpoint: *endpoint})
+}
+
+// DeleteWebhookEndpoint godoc
+// @Summary Delete a webhook endpoint
+// @Description Deletes a webhook endpoint with its deliveries, pending deliveries are not sent
+// @Tags webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param webhook_uuid path string true "Webhook Endpoint UUID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/webhooks/{webhook_uuid} [delete]
+func (h *WebhookHandler) DeleteWebhookEndpoint(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Webhook.Write)
+	defer cancel()
+
+	endpoint, appErr := h.findOwnedEndpoint(ctx, c, owner)
+	if appErr != nil {
+		slog.Error("failed to find webhook endpoint", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if appErr := h.webhookRepo.DeleteEndpoint(ctx, endpoint.ID); appErr != nil {
+		slog.Error("failed to delete webhook endpoint", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary List the deliveries of a webhook endpoint
+// @Description Retrieves the latest deliveries of one of the user's webhook endpoints, newest first.
+// @Description Filter by status dead to find the deliveries that ran out of attempts.
+// @Tags webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param webhook_uuid path string true "Webhook Endpoint UUID"
+// @Param status query string false "Filter by status" Enums(pending, succeeded, dead)
+// @Param limit query int false "Number of deliveries to return, 1 to 100" default(20)
+// @Success 200 {object} dto.ListWebhookDeliveriesResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/webhooks/{webhook_uuid}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var req dto.ListWebhookDeliveriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		slog.Error("invalid query params", "requestID", requestID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: formatValidationError(err)})
+		return
+	}
+
+	limit, appErr := queryLimit(c, domain.WebhookDeliveriesDefaultLimit, domain.WebhookDeliveriesMaxLimit)
+	if appErr != nil {
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Webhook.Read)
+	defer cancel()
+
+	endpoint, appErr := h.findOwnedEndpoint(ctx, c, owner)
+	if appErr != nil {
+		slog.Error("failed to find webhook endpoint", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	var status *string
+	if req.Status != "" {
+		status = &req.Status
+	}
+
+	deliveries, appErr := h.webhookRepo.ListDeliveries(ctx, endpoint.ID, status, limit)
+	if appErr != nil {
+		slog.Error("failed to list webhook deliveries", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ListWebhookDeliveriesResponse{Deliveries: deliveries})
+}
+
+// GetWebhookDelivery godoc
+// @Summary Get a webhook delivery
+// @Description Retrieves a delivery of one of the user's webhook endpoints with its payload and attempt log
+// @Tags webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param webhook_uuid path string true "Webhook Endpoint UUID"
+// @Param delivery_uuid path string true "Delivery UUID"
+// @Success 200 {object} dto.WebhookDeliveryResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/webhooks/{webhook_uuid}/deliveries/{delivery_uuid} [get]
+func (h *WebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	deliveryUUID, appErr := webhookDeliveryUUIDParam(c)
+	if appErr != nil {
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Webhook.Read)
+	defer cancel()
+
+	endpoint, appErr := h.findOwnedEndpoint(ctx, c, owner)
+	if appErr != nil {
+		slog.Error("failed to find webhook endpoint", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	delivery, appErr := h.webhookRepo.FindDelivery(ctx, endpoint.ID, deliveryUUID)
+	if appErr != nil {
+		slog.Error("failed to find webhook delivery", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.WebhookDeliveryResponse{Delivery: *delivery})
+}
+
+// RedeliverWebhookDelivery godoc
+// @Summary Redeliver a webhook delivery
+// @Description Schedules one more attempt of a delivery right away, e.g. a dead-lettered one after fixing the endpoint.
+// @Description The endpoint must be active, the payload and delivery ID are unchanged so receivers can deduplicate.
+// @Tags webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_uuid path string true "User UUID"
+// @Param webhook_uuid path string true "Webhook Endpoint UUID"
+// @Param delivery_uuid path string true "Delivery UUID"
+// @Success 202 {object} dto.WebhookDeliveryResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /users/{user_uuid}/webhooks/{webhook_uuid}/deliveries/{delivery_uuid}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhookDelivery(c *gin.Context) {
+	requestID := c.GetString(common.ContextKeyRequestID)
+	owner, appErr := validateUserAccess(c)
+	if appErr != nil {
+		slog.Error("failed to validate user access", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	deliveryUUID, appErr := webhookDeliveryUUIDParam(c)
+	if appErr != nil {
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Webhook.Write)
+	defer cancel()
+
+	endpoint, appErr := h.findOwnedEndpoint(ctx, c, owner)
+	if appErr != nil {
+		slog.Error("failed to find webhook endpoint", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	if !endpoint.IsActive() {
+		c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{Error: common.ErrWebhookEndpointDisabled})
+		return
+	}
+
+	delivery, appErr := h.webhookRepo.Redeliver(ctx, endpoint.ID, deliveryUUID)
+	if appErr != nil {
+		slog.Error("failed to redeliver webhook delivery", "requestID", requestID, "error", appErr.Error())
+		c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.WebhookDeliveryResponse{Delivery: *delivery})
+}
+
+// findOwnedEndpoint finds the endpoint of the webhook_uuid path param, it must belong to owner.
+func (h *WebhookHandler) findOwnedEndpoint(ctx context.Context, c *gin.Context, owner *domain.User) (*domain.WebhookEndpoint, common.AppError) {
+	endpointUUID := c.Param("webhook_uuid")
+	if err := uuid.Validate(endpointUUID); err != nil {
+		return nil, common.NewBadRequestError("Invalid webhook endpoint UUID")
+	}
+
+	return h.webhookRepo.FindEndpoint(ctx, owner.ID, endpointUUID)
+}
+
+func webhookDeliveryUUIDParam(c *gin.Context) (string, common.AppError) {
+	deliveryUUID := c.Param("delivery_uuid")
+	if err := uuid.Validate(deliveryUUID); err != nil {
+		return "", common.NewBadRequestError("Invalid webhook delivery UUID")
+	}
+
+	return deliveryUUID, nil
+}
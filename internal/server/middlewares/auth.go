@@ -1,7 +1,7 @@
 package middlewares
 
 import (
-	"crypto/ecdsa"
+	"context"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -12,11 +12,18 @@ import (
 	"github.com/ashtishad/xpay/internal/secure/rbac"
 	"github.com/ashtishad/xpay/internal/server/dto"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// AuthMiddleware validates JWT tokens, authenticates users, enforces RBAC policies,
-// and sets the authenticated user in the request context for subsequent handlers.
-func AuthMiddleware(userRepo domain.UserRepository, jwtPublicKey *ecdsa.PublicKey, rbac *rbac.RBAC) gin.HandlerFunc {
+// AuthMiddleware validates JWT tokens, rejects revoked tokens, authenticates users, enforces RBAC policies,
+// and sets the authenticated user and token claims in the request context for subsequent handlers.
+// A token is revoked when its jti is in the denylist, e.g. after logout, or when it was issued
+// before the user's sessions were revoked by an admin.
+//
+// On routes with a user_uuid it also resolves the owner of the requested resources and checks the access scope
+// the policy grants the user's role, e.g. agents can manage the users they onboarded. The owner is set in the
+// request context, handlers get it with validateUserAccess.
+func AuthMiddleware(userRepo domain.UserRepository, jm *secure.JWTManager, rbac *rbac.RBAC, denylist *domain.TokenDenylist) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(common.AuthorizationHeaderKey)
 		if authHeader == "" {
@@ -33,7 +40,7 @@ func AuthMiddleware(userRepo domain.UserRepository, jwtPublicKey *ecdsa.PublicKe
 		}
 
 		tokenString := bearerToken[1]
-		claims, err := secure.ValidateToken(tokenString, jwtPublicKey)
+		claims, err := jm.ValidateToken(tokenString)
 		if err != nil {
 			slog.Error("failed to validate token", "err", err)
 			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Invalid or expired token"})
@@ -41,6 +48,28 @@ func AuthMiddleware(userRepo domain.UserRepository, jwtPublicKey *ecdsa.PublicKe
 			return
 		}
 
+		if claims.TokenType != secure.TokenTypeAccess {
+			slog.Warn("non access token used for authentication", "userUUID", claims.UserUUID, "tokenType", claims.TokenType)
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+
+		jti, err := uuid.Parse(claims.ID)
+		if err != nil {
+			slog.Warn("token without a valid jti", "userUUID", claims.UserUUID)
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+
+		if denylist.IsRevoked(jti) {
+			slog.Warn("revoked token used", "userUUID", claims.UserUUID, "jti", jti)
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: common.ErrTokenRevoked})
+			c.Abort()
+			return
+		}
+
 		user, appErr := userRepo.FindBy(c.Request.Context(), common.DBColumnUUID, claims.UserUUID)
 		if appErr != nil {
 			slog.Error("failed to find user from jwt claims user uuid", "err", err)
@@ -49,14 +78,68 @@ func AuthMiddleware(userRepo domain.UserRepository, jwtPublicKey *ecdsa.PublicKe
 			return
 		}
 
-		if !rbac.HasPermission(user.Role, c.FullPath(), c.Request.Method) {
-			slog.Warn("access denied", "role", user.Role, "method", c.Request.Method, "path", c.FullPath())
+		if user.SessionsRevokedAt != nil && (claims.IssuedAt == nil || user.IsSessionRevoked(claims.IssuedAt.Time)) {
+			slog.Warn("token issued before sessions were revoked", "userUUID", claims.UserUUID, "jti", jti)
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: common.ErrTokenRevoked})
+			c.Abort()
+			return
+		}
+
+		scope, ok := rbac.Authorize(user.Role, c.FullPath(), c.Request.Method)
+		if !ok {
+			slog.Warn("access denied", "role", user.Role, "method", c.Request.Method, "path", c.FullPath(),
+				"reason", rbac.Explain(user.Role, c.FullPath(), c.Request.Method).Reason)
 			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "Access denied"})
 			c.Abort()
 			return
 		}
 
+		if ownerUUID := c.Param("user_uuid"); ownerUUID != "" {
+			owner, appErr := resolveResourceOwner(c.Request.Context(), userRepo, user, ownerUUID, scope)
+			if appErr != nil {
+				slog.Warn("resource access denied", "userUUID", user.UUID, "ownerUUID", ownerUUID, "scope", scope, "err", appErr.Error())
+				c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+				c.Abort()
+				return
+			}
+
+			c.Set(common.ContextKeyResourceOwner, owner)
+		}
+
 		c.Set(common.ContextKeyAuthorizedUser, user)
+		c.Set(common.ContextKeyTokenClaims, claims)
 		c.Next()
 	}
 }
+
+// resolveResourceOwner returns the user whose resources are requested, if the scope lets the caller access them.
+// Other users are only looked up for broader scopes than self, and callers that can't access any user
+// get 403 for unknown users too, so they can't probe which users exist.
+func resolveResourceOwner(ctx context.Context, userRepo domain.UserRepository, caller *domain.User, ownerUUID, scope string) (*domain.User, common.AppError) {
+	if ownerUUID == caller.UUID.String() {
+		return caller, nil
+	}
+
+	if scope == domain.PolicyScopeSelf {
+		return nil, common.NewForbiddenError(common.ErrResourceAccessDenied)
+	}
+
+	if err := uuid.Validate(ownerUUID); err != nil {
+		return nil, common.NewBadRequestError("Invalid user UUID")
+	}
+
+	owner, appErr := userRepo.FindBy(ctx, common.DBColumnUUID, ownerUUID)
+	if appErr != nil {
+		if appErr.Code() == http.StatusNotFound && scope != domain.PolicyScopeAny {
+			return nil, common.NewForbiddenError(common.ErrResourceAccessDenied)
+		}
+
+		return nil, appErr
+	}
+
+	if !domain.CanAccessUser(scope, caller, owner) {
+		return nil, common.NewForbiddenError(common.ErrResourceAccessDenied)
+	}
+
+	return owner, nil
+}
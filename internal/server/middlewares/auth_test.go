@@ -0,0 +1,201 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/ashtishad/xpay/internal/secure/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUserRepo finds users by uuid, the other methods of UserRepository aren't used by these tests.
+type memoryUserRepo struct {
+	domain.UserRepository
+	users map[string]*domain.User
+}
+
+func (r *memoryUserRepo) FindBy(_ context.Context, _ string, value any) (*domain.User, common.AppError) {
+	user, ok := r.users[value.(string)]
+	if !ok {
+		return nil, common.NewNotFoundError("user not found")
+	}
+
+	return user, nil
+}
+
+func TestResolveResourceOwner(t *testing.T) {
+	agent := &domain.User{ID: 1, UUID: uuid.New(), Role: domain.UserRoleAgent}
+	onboarded := &domain.User{ID: 2, UUID: uuid.New(), Role: domain.UserRoleUser, CreatedBy: &agent.ID}
+	other := &domain.User{ID: 3, UUID: uuid.New(), Role: domain.UserRoleUser}
+
+	repo := &memoryUserRepo{users: map[string]*domain.User{
+		agent.UUID.String():     agent,
+		onboarded.UUID.String(): onboarded,
+		other.UUID.String():     other,
+	}}
+
+	tests := []struct {
+		name      string
+		ownerUUID string
+		scope     string
+		wantOwner *domain.User
+		wantCode  int
+	}{
+		{"Own Resources", agent.UUID.String(), domain.PolicyScopeSelf, agent, 0},
+		{"Other User With Self Scope", onboarded.UUID.String(), domain.PolicyScopeSelf, nil, http.StatusForbidden},
+		{"Onboarded User", onboarded.UUID.String(), domain.PolicyScopeOnboarded, onboarded, 0},
+		{"Not Onboarded User", other.UUID.String(), domain.PolicyScopeOnboarded, nil, http.StatusForbidden},
+		{"Unknown User With Onboarded Scope", uuid.NewString(), domain.PolicyScopeOnboarded, nil, http.StatusForbidden},
+		{"Any User", other.UUID.String(), domain.PolicyScopeAny, other, 0},
+		{"Unknown User With Any Scope", uuid.NewString(), domain.PolicyScopeAny, nil, http.StatusNotFound},
+		{"Invalid UUID", "not-a-uuid", domain.PolicyScopeAny, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, appErr := resolveResourceOwner(context.Background(), repo, agent, tt.ownerUUID, tt.scope)
+			if tt.wantCode != 0 {
+				assert.NotNil(t, appErr)
+				assert.Equal(t, tt.wantCode, appErr.Code())
+				return
+			}
+
+			assert.Nil(t, appErr)
+			assert.Equal(t, tt.wantOwner, owner)
+		})
+	}
+}
+
+func newTestJWTManager(t *testing.T) *secure.JWTManager {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+
+	jm, err := secure.NewJWTManager(&common.JWTConfig{
+		ActiveKeyID: "test",
+		Keys: []common.JWTKeyConfig{{
+			ID:         "test",
+			PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateDER})),
+			PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		}},
+		AccessExpiration: time.Minute,
+	})
+	require.NoError(t, err)
+
+	return jm
+}
+
+// newAuthRouter serves the route behind the auth middleware with the default policy, the handler
+// responds with the uuid of the resource owner the middleware resolved.
+func newAuthRouter(t *testing.T, repo domain.UserRepository, jm *secure.JWTManager, method, route string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	policy, err := rbac.LoadPolicy()
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Handle(method, route, AuthMiddleware(repo, jm, rbac.New(policy), domain.NewTokenDenylist(nil)), func(c *gin.Context) {
+		owner, _ := c.Get(common.ContextKeyResourceOwner)
+		c.String(http.StatusOK, owner.(*domain.User).UUID.String())
+	})
+
+	return router
+}
+
+func TestAuthMiddlewareAdminRevokesUserSessions(t *testing.T) {
+	admin := &domain.User{ID: 1, UUID: uuid.New(), Role: domain.UserRoleAdmin}
+	agent := &domain.User{ID: 2, UUID: uuid.New(), Role: domain.UserRoleAgent}
+	user := &domain.User{ID: 3, UUID: uuid.New(), Role: domain.UserRoleUser}
+
+	repo := &memoryUserRepo{users: map[string]*domain.User{
+		admin.UUID.String(): admin,
+		agent.UUID.String(): agent,
+		user.UUID.String():  user,
+	}}
+
+	jm := newTestJWTManager(t)
+	router := newAuthRouter(t, repo, jm, http.MethodDelete, "/api/v1/users/:user_uuid/sessions")
+
+	tests := []struct {
+		name     string
+		caller   *domain.User
+		target   *domain.User
+		wantCode int
+	}{
+		{"Admin Revokes Other User Sessions", admin, user, http.StatusOK},
+		{"Admin Revokes Agent Sessions", admin, agent, http.StatusOK},
+		{"Agent Revokes User Sessions (Denied)", agent, user, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jm.GenerateAccessToken(tt.caller.UUID.String(), tt.caller.Role)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+tt.target.UUID.String()+"/sessions", nil)
+			req.Header.Set(common.AuthorizationHeaderKey, "Bearer "+token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, tt.target.UUID.String(), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareSessionsRevokedAt(t *testing.T) {
+	jm := newTestJWTManager(t)
+
+	tests := []struct {
+		name      string
+		revokedAt time.Time
+		wantCode  int
+	}{
+		// iat is truncated to the second, a token issued right after the revocation has an iat before it
+		{"Token Issued In The Second Of The Revocation", time.Now(), http.StatusOK},
+		{"Token Issued After The Revocation", time.Now().Add(-2 * time.Second), http.StatusOK},
+		{"Token Issued Before The Revocation", time.Now().Add(2 * time.Second), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &domain.User{ID: 1, UUID: uuid.New(), Role: domain.UserRoleUser, SessionsRevokedAt: &tt.revokedAt}
+			repo := &memoryUserRepo{users: map[string]*domain.User{user.UUID.String(): user}}
+			router := newAuthRouter(t, repo, jm, http.MethodGet, "/api/v1/users/:user_uuid/login-history")
+
+			token, err := jm.GenerateAccessToken(user.UUID.String(), user.Role)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+user.UUID.String()+"/login-history", nil)
+			req.Header.Set(common.AuthorizationHeaderKey, "Bearer "+token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+		})
+	}
+}
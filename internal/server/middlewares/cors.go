@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"github.com/ashtishad/xpay/internal/common"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -12,7 +13,8 @@ func CorsMiddleware() gin.HandlerFunc {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:8080", "http://localhost:3000"}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", common.IdempotencyKeyHeader}
+	config.ExposeHeaders = []string{common.RequestIDHeader, common.IdempotentReplayedHeader}
 
 	// if s.Config.Server.AppEnv == common.AppEnvProduction {
 	// 	// For production, I will set more restrictive origins
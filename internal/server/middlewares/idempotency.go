@@ -0,0 +1,196 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/dto"
+	"github.com/gin-gonic/gin"
+)
+
+// Idempotency makes POST, PUT, PATCH and DELETE requests safe to retry when the client sends an Idempotency-Key header.
+// Keys are scoped per authorized user, so it must run after AuthMiddleware. The first request with a key
+// executes and its response is stored; a retry with the same key and body gets the stored response replayed
+// with an Idempotent-Replayed header, a retry with a different body gets 422 and a duplicate that arrives
+// while the first request is still in flight gets 409. Requests without the header are not affected.
+//
+// Responses with 409, 429 or 5xx status codes are not stored, the key is released so the client can retry.
+// Responses sent with Cache-Control: no-store, e.g. ones carrying secrets, are withheld: only their status code
+// is kept and a retry gets 409, the secrets are never written to the database nor replayed.
+func Idempotency(idempotencyRepo domain.IdempotencyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keyValue := c.GetHeader(common.IdempotencyKeyHeader)
+		if keyValue == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		requestID := c.GetString(common.ContextKeyRequestID)
+
+		if len(keyValue) > common.IdempotencyKeyMaxLength {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Idempotency-Key must be at most 255 characters long"})
+			c.Abort()
+			return
+		}
+
+		user, ok := c.Get(common.ContextKeyAuthorizedUser)
+		authorizedUser, isUser := user.(*domain.User)
+		if !ok || !isUser {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			slog.Error("failed to read request body", "requestID", requestID, "error", err.Error())
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Failed to read request body"})
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		candidate := &domain.IdempotencyKey{
+			UserID:      authorizedUser.ID,
+			Key:         keyValue,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: requestFingerprint(body),
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), common.Timeouts.Idempotency.Write)
+		key, acquired, appErr := idempotencyRepo.Acquire(ctx, candidate)
+		cancel()
+
+		if appErr != nil {
+			slog.Error("failed to acquire idempotency key", "requestID", requestID, "error", appErr.Error())
+			c.JSON(appErr.Code(), dto.ErrorResponse{Error: appErr.Error()})
+			c.Abort()
+			return
+		}
+
+		if !acquired {
+			handleExistingKey(c, key, candidate)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		defer finishIdempotentRequest(c, idempotencyRepo, key, recorder, requestID)
+
+		c.Next()
+	}
+}
+
+// handleExistingKey replays the stored response of a completed key, or rejects the request.
+func handleExistingKey(c *gin.Context, key, candidate *domain.IdempotencyKey) {
+	if !key.Matches(candidate.Method, candidate.Path, candidate.RequestHash) {
+		c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{Error: common.ErrIdempotencyKeyReused})
+		c.Abort()
+		return
+	}
+
+	if !key.IsCompleted() {
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: common.ErrIdempotencyKeyInFlight})
+		c.Abort()
+		return
+	}
+
+	if key.ResponseWithheld {
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: common.ErrIdempotentResponseWithheld})
+		c.Abort()
+		return
+	}
+
+	c.Header(common.IdempotentReplayedHeader, "true")
+	c.Data(*key.StatusCode, key.ResponseContentType, key.ResponseBody)
+	c.Abort()
+}
+
+// finishIdempotentRequest stores the recorded response, or releases the key for retryable outcomes.
+// It runs even if the client went away, otherwise the key stays locked until IdempotencyLockTTL.
+func finishIdempotentRequest(c *gin.Context, idempotencyRepo domain.IdempotencyRepository, key *domain.IdempotencyKey, recorder *responseRecorder, requestID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), common.Timeouts.Idempotency.Write)
+	defer cancel()
+
+	statusCode := recorder.Status()
+	if !recorder.Written() || isRetryableStatus(statusCode) {
+		if appErr := idempotencyRepo.Release(ctx, key); appErr != nil {
+			slog.Error("failed to release idempotency key", "requestID", requestID, "error", appErr.Error())
+		}
+
+		return
+	}
+
+	key.StatusCode = &statusCode
+	if isNoStore(recorder.Header().Get(common.CacheControlHeader)) {
+		key.ResponseWithheld = true
+	} else {
+		key.ResponseContentType = recorder.Header().Get("Content-Type")
+		key.ResponseBody = recorder.body.Bytes()
+	}
+
+	if appErr := idempotencyRepo.Complete(ctx, key); appErr != nil {
+		slog.Error("failed to store idempotent response", "requestID", requestID, "statusCode", statusCode, "error", appErr.Error())
+	}
+}
+
+// requestFingerprint hashes the request body to recognize retries. The cvv of a JSON body is left out,
+// card verification codes are never stored, not even hashed, so a retry with another CVV is a replay.
+func requestFingerprint(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err == nil {
+		if _, ok := fields["cvv"]; ok {
+			delete(fields, "cvv")
+			body, _ = json.Marshal(fields)
+		}
+	}
+
+	hash := sha256.Sum256(body)
+	return hash[:]
+}
+
+func isMutatingMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// isNoStore reports whether the Cache-Control header forbids storing the response.
+func isNoStore(cacheControl string) bool {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusConflict || statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// responseRecorder copies everything written to the response, so it can be stored for replays.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,225 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryIdempotencyRepo mirrors the upsert semantics of the postgres repository, without expiry.
+type memoryIdempotencyRepo struct {
+	mu   sync.Mutex
+	keys map[string]*domain.IdempotencyKey
+}
+
+func (r *memoryIdempotencyRepo) Acquire(_ context.Context, key *domain.IdempotencyKey) (*domain.IdempotencyKey, bool, common.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.keys[key.Key]; ok {
+		stored := *existing
+		return &stored, false, nil
+	}
+
+	stored := *key
+	r.keys[key.Key] = &stored
+
+	return key, true, nil
+}
+
+func (r *memoryIdempotencyRepo) Complete(_ context.Context, key *domain.IdempotencyKey) common.AppError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *key
+	r.keys[key.Key] = &stored
+
+	return nil
+}
+
+func (r *memoryIdempotencyRepo) Release(_ context.Context, key *domain.IdempotencyKey) common.AppError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.keys, key.Key)
+
+	return nil
+}
+
+func (r *memoryIdempotencyRepo) DeleteExpired(_ context.Context) (int64, common.AppError) {
+	return 0, nil
+}
+
+func newIdempotencyTestRouter(repo domain.IdempotencyRepository, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(common.ContextKeyAuthorizedUser, &domain.User{ID: 1})
+	}, Idempotency(repo))
+	router.POST("/wallets", handler)
+
+	return router
+}
+
+func sendIdempotent(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/wallets", bytes.NewBufferString(body))
+	req.Header.Set(common.IdempotencyKeyHeader, key)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	repo := &memoryIdempotencyRepo{keys: make(map[string]*domain.IdempotencyKey)}
+	calls := 0
+
+	router := newIdempotencyTestRouter(repo, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	first := sendIdempotent(router, "key-1", `{"currency":"USD"}`)
+	second := sendIdempotent(router, "key-1", `{"currency":"USD"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(common.IdempotentReplayedHeader))
+	assert.Empty(t, first.Header().Get(common.IdempotentReplayedHeader))
+}
+
+func TestIdempotencyCoversPut(t *testing.T) {
+	repo := &memoryIdempotencyRepo{keys: make(map[string]*domain.IdempotencyKey)}
+	calls := 0
+
+	handler := func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"call": calls})
+	}
+
+	router := newIdempotencyTestRouter(repo, handler)
+	router.PUT("/rbac/roles/agent/actions/GetWallet", handler)
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPut, "/rbac/roles/agent/actions/GetWallet", bytes.NewBufferString(`{"scope":"own"}`))
+		req.Header.Set(common.IdempotencyKeyHeader, "key-1")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyRejectsReusedKeyWithDifferentBody(t *testing.T) {
+	repo := &memoryIdempotencyRepo{keys: make(map[string]*domain.IdempotencyKey)}
+	router := newIdempotencyTestRouter(repo, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	sendIdempotent(router, "key-1", `{"amountInCents":100}`)
+	w := sendIdempotent(router, "key-1", `{"amountInCents":200}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestIdempotencyFingerprintLeavesOutCVV(t *testing.T) {
+	repo := &memoryIdempotencyRepo{keys: make(map[string]*domain.IdempotencyKey)}
+	calls := 0
+
+	router := newIdempotencyTestRouter(repo, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	sendIdempotent(router, "key-1", `{"amountInCents":100,"cvv":"123"}`)
+	w := sendIdempotent(router, "key-1", `{"amountInCents":100,"cvv":"456"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "true", w.Header().Get(common.IdempotentReplayedHeader))
+	assert.Equal(t, requestFingerprint([]byte(`{"amountInCents":100}`)), repo.keys["key-1"].RequestHash,
+		"the stored hash doesn't depend on the cvv")
+}
+
+func TestIdempotencyRejectsInFlightDuplicate(t *testing.T) {
+	repo := &memoryIdempotencyRepo{keys: make(map[string]*domain.IdempotencyKey)}
+	started, release := make(chan struct{}), make(chan struct{})
+
+	router := newIdempotencyTestRouter(repo, func(c *gin.Context) {
+		close(started)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- sendIdempotent(router, "key-1", `{}`) }()
+
+	<-started
+	duplicate := sendIdempotent(router, "key-1", `{}`)
+	close(release)
+
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	repo := &memoryIdempotencyRepo{keys: make(map[string]*domain.IdempotencyKey)}
+	calls := 0
+
+	router := newIdempotencyTestRouter(repo, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, sendIdempotent(router, "key-1", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, sendIdempotent(router, "key-1", `{}`).Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyWithholdsNoStoreResponse(t *testing.T) {
+	repo := &memoryIdempotencyRepo{keys: make(map[string]*domain.IdempotencyKey)}
+	calls := 0
+
+	router := newIdempotencyTestRouter(repo, func(c *gin.Context) {
+		calls++
+		c.Header(common.CacheControlHeader, "no-store")
+		c.JSON(http.StatusCreated, gin.H{"secret": "JBSWY3DPEHPK3PXP"})
+	})
+
+	first := sendIdempotent(router, "key-1", `{}`)
+	retry := sendIdempotent(router, "key-1", `{}`)
+
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Contains(t, first.Body.String(), "JBSWY3DPEHPK3PXP")
+
+	stored := repo.keys["key-1"]
+	assert.True(t, stored.ResponseWithheld)
+	assert.Empty(t, stored.ResponseBody, "secrets must not be stored")
+	assert.Equal(t, http.StatusCreated, *stored.StatusCode)
+
+	assert.Equal(t, 1, calls, "a withheld response isn't executed again")
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.NotContains(t, retry.Body.String(), "JBSWY3DPEHPK3PXP")
+	assert.Empty(t, retry.Header().Get(common.IdempotentReplayedHeader))
+}
+
+func TestIsNoStore(t *testing.T) {
+	assert.True(t, isNoStore("no-store"))
+	assert.True(t, isNoStore("private, No-Store"))
+	assert.False(t, isNoStore("no-cache"))
+	assert.False(t, isNoStore(""))
+}
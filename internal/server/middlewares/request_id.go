@@ -8,15 +8,17 @@ import (
 
 // RequestID middleware generates or propagates a unique ID for each request.
 // If a request ID is provided in the header, it uses that; otherwise, it generates a new UUID.
-// The ID is set in both the request context and response header for tracing purposes.
+// The ID is set in both the gin and the request context and the response header for tracing purposes,
+// the request context carries it to the events recorded by the repositories.
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.GetHeader(common.RequestIDHeader)
-		if requestID == "" {
+		if requestID == "" || len(requestID) > common.RequestIDMaxLength {
 			requestID = uuid.New().String()
 		}
 
 		c.Set(common.ContextKeyRequestID, requestID)
+		c.Request = c.Request.WithContext(common.WithRequestID(c.Request.Context(), requestID))
 		c.Header(common.RequestIDHeader, requestID)
 		c.Next()
 	}
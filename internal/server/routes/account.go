@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerAccountRoutes(rg *gin.RouterGroup, userRepo domain.UserRepository, accountTokenRepo domain.AccountTokenRepository, tokenSender *handlers.AccountTokenSender) {
+	accountHandler := handlers.NewAccountHandler(userRepo, accountTokenRepo, tokenSender)
+
+	rg.POST("/password/forgot", accountHandler.ForgotPassword)
+	rg.POST("/password/reset", accountHandler.ResetPassword)
+	rg.POST("/email/verify", accountHandler.VerifyEmail)
+}
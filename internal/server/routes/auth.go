@@ -7,9 +7,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func registerAuthRoutes(rg *gin.RouterGroup, userRepo domain.UserRepository, jm *secure.JWTManager) {
-	authHandler := handlers.NewAuthHandler(userRepo, jm)
+func registerAuthRoutes(rg *gin.RouterGroup, userRepo domain.UserRepository, refreshTokenRepo domain.RefreshTokenRepository, mfaRepo domain.MFARepository,
+	loginAttemptRepo domain.LoginAttemptRepository, tokenSender *handlers.AccountTokenSender, jm *secure.JWTManager, mfaEncryptor *secure.OwnerBoundEncryptor, denylist *domain.TokenDenylist, authMiddleware gin.HandlerFunc) {
+	authHandler := handlers.NewAuthHandler(userRepo, refreshTokenRepo, mfaRepo, loginAttemptRepo, tokenSender, jm, mfaEncryptor, denylist)
 
 	rg.POST("/register", authHandler.Register)
 	rg.POST("/login", authHandler.Login)
+	rg.POST("/login/mfa", authHandler.LoginMFA)
+	rg.POST("/token/refresh", authHandler.RefreshToken)
+	rg.POST("/logout", authMiddleware, authHandler.Logout)
 }
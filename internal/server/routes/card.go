@@ -2,13 +2,13 @@ package routes
 
 import (
 	"github.com/ashtishad/xpay/internal/domain"
-	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/ashtishad/xpay/internal/infra/vault"
 	"github.com/ashtishad/xpay/internal/server/handlers"
 	"github.com/gin-gonic/gin"
 )
 
-func registerCardRoutes(rg *gin.RouterGroup, cardRepo domain.CardRepository, walletRepo domain.WalletRepository, cardEncryptor *secure.CardEncryptor) {
-	cardHandler := handlers.NewCardHandler(cardRepo, walletRepo, cardEncryptor)
+func registerCardRoutes(rg *gin.RouterGroup, cardRepo domain.CardRepository, walletRepo domain.WalletRepository, cardVault vault.Tokenizer) {
+	cardHandler := handlers.NewCardHandler(cardRepo, walletRepo, cardVault)
 
 	cards := rg.Group("/:user_uuid/wallets/:wallet_uuid/cards")
 	{
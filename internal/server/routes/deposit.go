@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/gateway"
+	"github.com/ashtishad/xpay/internal/infra/vault"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerDepositRoutes(rg *gin.RouterGroup, depositRepo domain.DepositRepository, walletRepo domain.WalletRepository, cardRepo domain.CardRepository, cardVault vault.Tokenizer, paymentGateway gateway.PaymentGateway) {
+	depositHandler := handlers.NewDepositHandler(depositRepo, walletRepo, cardRepo, cardVault, paymentGateway)
+
+	rg.POST("/:user_uuid/wallets/:wallet_uuid/deposits", depositHandler.CreateDeposit)
+}
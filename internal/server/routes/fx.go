@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/fx"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerFXRoutes(rg *gin.RouterGroup, fxRepo domain.FXRepository, walletRepo domain.WalletRepository, rates fx.RatesProvider) {
+	fxHandler := handlers.NewFXHandler(fxRepo, walletRepo, rates)
+
+	rg.POST("/:user_uuid/fx/quotes", fxHandler.CreateFXQuote)
+	rg.POST("/:user_uuid/wallets/:wallet_uuid/conversions", fxHandler.ConvertFunds)
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerLedgerRoutes(rg *gin.RouterGroup, ledgerRepo domain.LedgerRepository) {
+	ledgerHandler := handlers.NewLedgerHandler(ledgerRepo)
+
+	rg.GET("/consistency", ledgerHandler.CheckLedgerConsistency)
+}
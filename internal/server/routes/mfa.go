@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerMFARoutes(rg *gin.RouterGroup, mfaRepo domain.MFARepository, mfaEncryptor *secure.OwnerBoundEncryptor) {
+	mfaHandler := handlers.NewMFAHandler(mfaRepo, mfaEncryptor)
+
+	rg.POST("/:user_uuid/mfa/totp", mfaHandler.EnrollTOTP)
+	rg.POST("/:user_uuid/mfa/totp/confirm", mfaHandler.ConfirmTOTP)
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerPaymentIntentRoutes(rg *gin.RouterGroup, paymentIntentRepo domain.PaymentIntentRepository, walletRepo domain.WalletRepository) {
+	paymentIntentHandler := handlers.NewPaymentIntentHandler(paymentIntentRepo, walletRepo)
+
+	rg.POST("/:user_uuid/payment-intents", paymentIntentHandler.CreatePaymentIntent)
+	rg.GET("/:user_uuid/payment-intents/:payment_intent_uuid", paymentIntentHandler.GetPaymentIntent)
+	rg.POST("/:user_uuid/payment-intents/:payment_intent_uuid/capture", paymentIntentHandler.CapturePaymentIntent)
+	rg.POST("/:user_uuid/payment-intents/:payment_intent_uuid/void", paymentIntentHandler.VoidPaymentIntent)
+	rg.POST("/:user_uuid/wallets/:wallet_uuid/payment-intents/:payment_intent_uuid/confirm", paymentIntentHandler.ConfirmPaymentIntent)
+}
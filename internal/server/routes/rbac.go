@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure/rbac"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerRBACRoutes(rg *gin.RouterGroup, policyRepo domain.RBACPolicyRepository, rbac *rbac.RBAC) {
+	rbacHandler := handlers.NewRBACHandler(policyRepo, rbac)
+
+	rg.GET("/actions", rbacHandler.ListPolicyActions)
+	rg.GET("/grants", rbacHandler.ListPolicyGrants)
+	rg.PUT("/roles/:role/actions/:action", rbacHandler.GrantPolicyAction)
+	rg.DELETE("/roles/:role/actions/:action", rbacHandler.RevokePolicyAction)
+	rg.GET("/changes", rbacHandler.ListPolicyChanges)
+	rg.GET("/explain", rbacHandler.ExplainPermission)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerRefundRoutes(rg *gin.RouterGroup, refundRepo domain.RefundRepository) {
+	refundHandler := handlers.NewRefundHandler(refundRepo)
+
+	rg.POST("/:user_uuid/payment-intents/:payment_intent_uuid/refunds", refundHandler.CreateRefund)
+	rg.GET("/:user_uuid/payment-intents/:payment_intent_uuid/refunds", refundHandler.ListRefunds)
+}
@@ -5,26 +5,72 @@ import (
 
 	"github.com/ashtishad/xpay/internal/common"
 	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/fx"
+	"github.com/ashtishad/xpay/internal/infra/gateway"
+	"github.com/ashtishad/xpay/internal/infra/mailer"
+	"github.com/ashtishad/xpay/internal/infra/vault"
 	"github.com/ashtishad/xpay/internal/secure"
 	"github.com/ashtishad/xpay/internal/secure/rbac"
+	"github.com/ashtishad/xpay/internal/server/handlers"
 	"github.com/ashtishad/xpay/internal/server/middlewares"
 	"github.com/gin-gonic/gin"
 )
 
-func InitRoutes(rg *gin.RouterGroup, db *sql.DB, config *common.AppConfig, jm *secure.JWTManager, cardEncryptor *secure.CardEncryptor, rbac *rbac.RBAC) {
+func InitRoutes(rg *gin.RouterGroup, db *sql.DB, config *common.AppConfig, jm *secure.JWTManager, cardVault vault.Tokenizer, mfaEncryptor *secure.OwnerBoundEncryptor,
+	webhookEncryptor *secure.OwnerBoundEncryptor, rbac *rbac.RBAC, paymentGateway gateway.PaymentGateway, denylist *domain.TokenDenylist, m mailer.Mailer, rates fx.RatesProvider) {
 	userRepo := domain.NewUserRepository(db)
 	walletRepo := domain.NewWalletRepository(db)
 	cardRepo := domain.NewCardRepository(db)
+	ledgerRepo := domain.NewLedgerRepository(db)
+	transferRepo := domain.NewTransferRepository(db)
+	depositRepo := domain.NewDepositRepository(db)
+	idempotencyRepo := domain.NewIdempotencyRepository(db)
+	refreshTokenRepo := domain.NewRefreshTokenRepository(db)
+	mfaRepo := domain.NewMFARepository(db)
+	loginAttemptRepo := domain.NewLoginAttemptRepository(db)
+	accountTokenRepo := domain.NewAccountTokenRepository(db)
+	policyRepo := domain.NewRBACPolicyRepository(db)
+	fxRepo := domain.NewFXRepository(db)
+	transactionRepo := domain.NewTransactionRepository(db)
+	statementRepo := domain.NewStatementRepository(db)
+	paymentIntentRepo := domain.NewPaymentIntentRepository(db)
+	refundRepo := domain.NewRefundRepository(db)
+	webhookRepo := domain.NewWebhookRepository(db)
 
-	// Register public routes
-	registerAuthRoutes(rg, userRepo, jm)
+	tokenSender := handlers.NewAccountTokenSender(accountTokenRepo, m)
+
+	authMiddleware := middlewares.AuthMiddleware(userRepo, jm, rbac, denylist)
+
+	// Register public routes, and logout which needs the access token to revoke
+	registerAuthRoutes(rg, userRepo, refreshTokenRepo, mfaRepo, loginAttemptRepo, tokenSender, jm, mfaEncryptor, denylist, authMiddleware)
+	registerAccountRoutes(rg, userRepo, accountTokenRepo, tokenSender)
 
 	// Create authenticated user gin router group
 	authGroup := rg.Group("/users")
-	authGroup.Use(middlewares.AuthMiddleware(userRepo, jm.GetPublicKey(), rbac))
+	authGroup.Use(authMiddleware, middlewares.Idempotency(idempotencyRepo))
 
 	// Register authenticated routes
-	registerUserManagementRoutes(authGroup, userRepo)
-	registerWalletRoutes(authGroup, walletRepo, userRepo)
-	registerCardRoutes(authGroup, cardRepo, walletRepo, cardEncryptor)
+	registerUserManagementRoutes(authGroup, userRepo, loginAttemptRepo, tokenSender)
+	registerWalletRoutes(authGroup, walletRepo, userRepo, transactionRepo)
+	registerCardRoutes(authGroup, cardRepo, walletRepo, cardVault)
+	registerTransferRoutes(authGroup, transferRepo, walletRepo, userRepo)
+	registerDepositRoutes(authGroup, depositRepo, walletRepo, cardRepo, cardVault, paymentGateway)
+	registerMFARoutes(authGroup, mfaRepo, mfaEncryptor)
+	registerFXRoutes(authGroup, fxRepo, walletRepo, rates)
+	registerStatementRoutes(authGroup, statementRepo, walletRepo)
+	registerPaymentIntentRoutes(authGroup, paymentIntentRepo, walletRepo)
+	registerRefundRoutes(authGroup, refundRepo)
+	registerWebhookRoutes(authGroup, webhookRepo, webhookEncryptor)
+
+	// Create authenticated ledger gin router group
+	ledgerGroup := rg.Group("/ledger")
+	ledgerGroup.Use(authMiddleware, middlewares.Idempotency(idempotencyRepo))
+
+	registerLedgerRoutes(ledgerGroup, ledgerRepo)
+
+	// Create authenticated rbac policy gin router group
+	rbacGroup := rg.Group("/rbac")
+	rbacGroup.Use(authMiddleware, middlewares.Idempotency(idempotencyRepo))
+
+	registerRBACRoutes(rbacGroup, policyRepo, rbac)
 }
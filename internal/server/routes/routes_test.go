@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/secure/rbac"
+	"github.com/gin-gonic/gin"
+)
+
+// requirePolicyCoverage fails the test unless the rbac policy covers exactly the routes registered on router,
+// it's the check the server runs on startup.
+func requirePolicyCoverage(t *testing.T, router *gin.Engine) {
+	t.Helper()
+
+	policy, err := rbac.LoadPolicy()
+	if err != nil {
+		t.Fatalf("failed to load rbac policy: %v", err)
+	}
+
+	if err := policy.CheckCoverage(router.Routes(), common.APIBasePath).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPolicyCoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	// Registering routes doesn't touch the dependencies, the handlers are never called
+	InitRoutes(router.Group(common.APIBasePath), nil, &common.AppConfig{}, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	requirePolicyCoverage(t, router)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerStatementRoutes(rg *gin.RouterGroup, statementRepo domain.StatementRepository, walletRepo domain.WalletRepository) {
+	statementHandler := handlers.NewStatementHandler(statementRepo, walletRepo)
+
+	rg.GET("/:user_uuid/wallets/:wallet_uuid/statements", statementHandler.GetStatement)
+	rg.GET("/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid", statementHandler.GetStatementExport)
+	rg.GET("/:user_uuid/wallets/:wallet_uuid/statements/:statement_uuid/download", statementHandler.DownloadStatementExport)
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerTransferRoutes(rg *gin.RouterGroup, transferRepo domain.TransferRepository, walletRepo domain.WalletRepository, userRepo domain.UserRepository) {
+	transferHandler := handlers.NewTransferHandler(transferRepo, walletRepo, userRepo)
+
+	rg.POST("/:user_uuid/wallets/:wallet_uuid/transfers", transferHandler.CreateTransfer)
+}
@@ -6,7 +6,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func registerUserManagementRoutes(rg *gin.RouterGroup, userRepo domain.UserRepository) {
-	userHandler := handlers.NewUserHandler(userRepo)
+func registerUserManagementRoutes(rg *gin.RouterGroup, userRepo domain.UserRepository, loginAttemptRepo domain.LoginAttemptRepository,
+	tokenSender *handlers.AccountTokenSender) {
+	userHandler := handlers.NewUserHandler(userRepo, loginAttemptRepo, tokenSender)
 	rg.POST("", userHandler.CreateUserWithRole)
+	rg.DELETE("/:user_uuid/sessions", userHandler.RevokeUserSessions)
+	rg.GET("/:user_uuid/login-history", userHandler.GetLoginHistory)
 }
@@ -6,10 +6,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func registerWalletRoutes(rg *gin.RouterGroup, walletRepo domain.WalletRepository, userRepo domain.UserRepository) {
+func registerWalletRoutes(rg *gin.RouterGroup, walletRepo domain.WalletRepository, userRepo domain.UserRepository,
+	transactionRepo domain.TransactionRepository) {
 	walletHandler := handlers.NewWalletHandler(walletRepo, userRepo)
+	transactionHandler := handlers.NewTransactionHandler(transactionRepo, walletRepo)
 
 	rg.POST("/:user_uuid/wallets", walletHandler.CreateWallet)
 	rg.GET("/:user_uuid/wallets/:wallet_uuid/balance", walletHandler.GetWalletBalance)
 	rg.PATCH("/:user_uuid/wallets/:wallet_uuid/status", walletHandler.UpdateWalletStatus)
+	rg.GET("/:user_uuid/wallets/:wallet_uuid/transactions", transactionHandler.ListTransactions)
 }
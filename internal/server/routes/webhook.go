@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func registerWebhookRoutes(rg *gin.RouterGroup, webhookRepo domain.WebhookRepository, webhookEncryptor *secure.OwnerBoundEncryptor) {
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo, webhookEncryptor)
+
+	webhooks := rg.Group("/:user_uuid/webhooks")
+	{
+		webhooks.POST("", webhookHandler.CreateWebhookEndpoint)
+		webhooks.GET("", webhookHandler.ListWebhookEndpoints)
+		webhooks.PATCH("/:webhook_uuid", webhookHandler.UpdateWebhookEndpointStatus)
+		webhooks.DELETE("/:webhook_uuid", webhookHandler.DeleteWebhookEndpoint)
+		webhooks.GET("/:webhook_uuid/deliveries", webhookHandler.ListWebhookDeliveries)
+		webhooks.GET("/:webhook_uuid/deliveries/:delivery_uuid", webhookHandler.GetWebhookDelivery)
+		webhooks.POST("/:webhook_uuid/deliveries/:delivery_uuid/redeliver", webhookHandler.RedeliverWebhookDelivery)
+	}
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/ashtishad/xpay/internal/secure"
+	"github.com/ashtishad/xpay/internal/server/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// InitWellKnownRoutes registers the /.well-known routes, they live at the root and not under the api version.
+func InitWellKnownRoutes(router *gin.Engine, jm *secure.JWTManager) {
+	jwksHandler := handlers.NewJWKSHandler(jm)
+
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+}
@@ -7,10 +7,21 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"sync"
 
 	"github.com/ashtishad/xpay/docs"
 	"github.com/ashtishad/xpay/internal/common"
+	"github.com/ashtishad/xpay/internal/domain"
+	"github.com/ashtishad/xpay/internal/infra/cardkeys"
+	"github.com/ashtishad/xpay/internal/infra/events"
+	"github.com/ashtishad/xpay/internal/infra/fx"
+	"github.com/ashtishad/xpay/internal/infra/gateway"
+	"github.com/ashtishad/xpay/internal/infra/kafka"
+	"github.com/ashtishad/xpay/internal/infra/mailer"
 	"github.com/ashtishad/xpay/internal/infra/postgres"
+	"github.com/ashtishad/xpay/internal/infra/statement"
+	"github.com/ashtishad/xpay/internal/infra/vault"
+	"github.com/ashtishad/xpay/internal/infra/webhook"
 	"github.com/ashtishad/xpay/internal/secure"
 	"github.com/ashtishad/xpay/internal/secure/rbac"
 	"github.com/ashtishad/xpay/internal/server/middlewares"
@@ -26,6 +37,13 @@ type Server struct {
 	httpServer *http.Server
 	DB         *sql.DB
 	Config     *common.AppConfig
+
+	// Background workers run until Shutdown cancels them, Shutdown waits for them before closing the database.
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
+
+	// eventPublisher is closed once the outbox relay stopped.
+	eventPublisher events.EventPublisher
 }
 
 // NewServer initializes and returns a new Server instance.
@@ -50,22 +68,54 @@ func NewServer(ctx context.Context) (*Server, error) {
 		return nil, fmt.Errorf("failed to create JWT manager: %w", err)
 	}
 
-	cardEncryptor, err := secure.NewCardEncryptor(cfg.Card.AESKey)
+	cardEncryptor, err := setupCardEncryptor(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create card encryptor: %w", err)
 	}
 
+	mfaEncryptor, err := secure.NewOwnerBoundEncryptor(cfg.MFA.AESKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mfa encryptor: %w", err)
+	}
+
+	webhookEncryptor, err := secure.NewOwnerBoundEncryptor(cfg.Webhook.AESKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook encryptor: %w", err)
+	}
+
 	policy, err := rbac.LoadPolicy()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load rbac policy: %w", err)
 	}
 
-	rbac := rbac.New(policy)
+	rbacCtx, cancel := context.WithTimeout(ctx, common.Timeouts.RBAC.Startup)
+	defer cancel()
+
+	rbac, appErr := rbac.NewFromStore(rbacCtx, policy, domain.NewRBACPolicyRepository(db))
+	if appErr != nil {
+		return nil, fmt.Errorf("failed to load rbac policy from the database: %w", appErr)
+	}
+
+	// only the gateway can detokenize cards, handlers get the vault as a vault.Tokenizer
+	cardVault := vault.NewVault(domain.NewCardRepository(db), cardEncryptor)
+	paymentGateway := gateway.NewFakeGateway(cardVault)
+	mailSender := setupMailer(cfg)
+
+	rates, err := setupRatesProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	denylist := domain.NewTokenDenylist(domain.NewRevokedTokenRepository(db))
+	if appErr := denylist.Sync(ctx); appErr != nil {
+		return nil, fmt.Errorf("failed to load token denylist: %w", appErr)
+	}
 
 	s := &Server{
-		Router: router,
-		DB:     db,
-		Config: cfg,
+		Router:         router,
+		DB:             db,
+		Config:         cfg,
+		eventPublisher: setupEventPublisher(cfg),
 		httpServer: &http.Server{
 			Addr:         cfg.App.ServerAddress,
 			Handler:      router,
@@ -76,7 +126,19 @@ func NewServer(ctx context.Context) (*Server, error) {
 	}
 
 	s.setupMiddlewares()
-	s.setupRoutes(jwtManager, cardEncryptor, rbac)
+	s.setupRoutes(jwtManager, cardVault, mfaEncryptor, webhookEncryptor, rbac, paymentGateway, denylist, mailSender, rates)
+
+	if err := s.checkPolicyCoverage(policy); err != nil {
+		return nil, err
+	}
+
+	s.startWorkers(denylist, rbac, statement.NewExporter(domain.NewStatementRepository(db)),
+		domain.NewPaymentIntentExpirer(domain.NewPaymentIntentRepository(db)),
+		domain.NewIdempotencyKeyPurger(domain.NewIdempotencyRepository(db)),
+		gateway.NewDepositReconciler(domain.NewDepositRepository(db), paymentGateway),
+		webhook.NewDispatcher(domain.NewWebhookRepository(db), webhookEncryptor),
+		events.NewRelay(domain.NewOutboxRepository(db), s.eventPublisher),
+		cardkeys.NewReencryptor(domain.NewCardRepository(db), cardEncryptor))
 
 	setSwaggerInfo(s.httpServer.Addr)
 
@@ -128,17 +190,134 @@ func setupRouter(appSettings common.AppSettings) *gin.Engine {
 	return router
 }
 
+// setupCardEncryptor creates the CardEncryptor wrapping card data keys with the keyring of card.keyring_file,
+// or with card.aes_key as the only key-encryption key when none is set. card.aes_key also decrypts card numbers
+// stored before envelope encryption.
+func setupCardEncryptor(cfg *common.AppConfig) (*secure.CardEncryptor, error) {
+	var keys secure.KeyProvider
+
+	if cfg.Card.KeyringFile != "" {
+		keyring, err := secure.LoadLocalKeyProvider(cfg.Card.KeyringFile)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = keyring
+	} else {
+		if cfg.App.Env == common.AppEnvProduction {
+			slog.Warn("card.keyring_file is not set, card.aes_key wraps the card data keys and can't be rotated")
+		}
+
+		keyring, err := secure.NewLocalKeyProvider(common.CardDefaultKeyID, map[string][]byte{common.CardDefaultKeyID: []byte(cfg.Card.AESKey)})
+		if err != nil {
+			return nil, err
+		}
+
+		keys = keyring
+	}
+
+	return secure.NewCardEncryptor(keys, cfg.Card.AESKey)
+}
+
+// setupMailer creates the Mailer selected by mailer.driver, config validation guarantees a known driver.
+func setupMailer(cfg *common.AppConfig) mailer.Mailer {
+	if cfg.Mailer.Driver == common.MailerDriverSMTP {
+		smtp := cfg.Mailer.SMTP
+		return mailer.NewSMTPMailer(smtp.Host, smtp.Port, smtp.Username, smtp.Password, cfg.Mailer.From)
+	}
+
+	if cfg.App.Env == common.AppEnvProduction {
+		slog.Warn("log mailer is used in production, emails are not delivered")
+	}
+
+	return mailer.NewLogMailer(cfg.Mailer.FilePath, cfg.Mailer.From)
+}
+
+// setupEventPublisher creates the EventPublisher selected by events.driver, config validation guarantees a known driver.
+// The log publisher writes events to stdout for local runs without a broker.
+func setupEventPublisher(cfg *common.AppConfig) events.EventPublisher {
+	if cfg.Events.Driver == common.EventsDriverKafka {
+		return kafka.NewPublisher(cfg.Events.Kafka.Brokers, cfg.Events.Kafka.Topic)
+	}
+
+	if cfg.App.Env == common.AppEnvProduction {
+		slog.Warn("log event publisher is used in production, events are not streamed")
+	}
+
+	return events.NewLogPublisher(os.Stdout)
+}
+
+// setupRatesProvider loads the exchange rates from fx.rates_file, or the sample rates embedded in the binary.
+func setupRatesProvider(cfg *common.AppConfig) (fx.RatesProvider, error) {
+	if cfg.FX.RatesFile == "" && cfg.App.Env == common.AppEnvProduction {
+		slog.Warn("sample fx rates are used in production, set fx.rates_file")
+	}
+
+	rates, err := fx.LoadStaticRates(cfg.FX.RatesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fx rates: %w", err)
+	}
+
+	return rates, nil
+}
+
 // setupMiddlewares adds all necessary middlewares to the Gin router.
 func (s *Server) setupMiddlewares() {
 	s.Router.Use(middlewares.InitMiddlewares()...)
 }
 
 // setupRoutes initializes all API routes for the server.
-func (s *Server) setupRoutes(jm *secure.JWTManager, cardEncryptor *secure.CardEncryptor, rbac *rbac.RBAC) {
+func (s *Server) setupRoutes(jm *secure.JWTManager, cardVault vault.Tokenizer, mfaEncryptor *secure.OwnerBoundEncryptor,
+	webhookEncryptor *secure.OwnerBoundEncryptor, rbac *rbac.RBAC, paymentGateway gateway.PaymentGateway, denylist *domain.TokenDenylist, m mailer.Mailer, rates fx.RatesProvider) {
 	s.Router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	routes.InitWellKnownRoutes(s.Router, jm)
+
+	apiGroup := s.Router.Group(common.APIBasePath)
+	routes.InitRoutes(apiGroup, s.DB, s.Config, jm, cardVault, mfaEncryptor, webhookEncryptor, rbac, paymentGateway, denylist, m, rates)
+}
+
+// checkPolicyCoverage verifies that the rbac policy names or declares public every registered API route,
+// and that every policy entry belongs to a registered route. A mismatch fails startup, except in dev
+// where it's logged, so a route can be tried before its policy entry is written.
+func (s *Server) checkPolicyCoverage(policy *rbac.Policy) error {
+	err := policy.CheckCoverage(s.Router.Routes(), common.APIBasePath).Err()
+	if err == nil {
+		return nil
+	}
+
+	if s.Config.App.Env == common.AppEnvDev {
+		slog.Warn("rbac policy doesn't match the registered routes", "err", err)
+		return nil
+	}
 
-	apiGroup := s.Router.Group("/api/v1")
-	routes.InitRoutes(apiGroup, s.DB, s.Config, jm, cardEncryptor, rbac)
+	return fmt.Errorf("rbac policy doesn't match the registered routes: %w", err)
+}
+
+// startWorkers launches the background jobs, they stop when Shutdown is called.
+func (s *Server) startWorkers(denylist *domain.TokenDenylist, rbac *rbac.RBAC, exporter *statement.Exporter,
+	expirer *domain.PaymentIntentExpirer, purger *domain.IdempotencyKeyPurger, reconciler *gateway.DepositReconciler, dispatcher *webhook.Dispatcher, relay *events.Relay, reencryptor *cardkeys.Reencryptor) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopWorkers = cancel
+
+	s.runWorker(func() { denylist.Run(ctx, common.DenylistSyncInterval) })
+	s.runWorker(func() { rbac.Run(ctx, common.PolicySyncInterval) })
+	s.runWorker(func() { exporter.Run(ctx, common.StatementExportInterval) })
+	s.runWorker(func() { expirer.Run(ctx, common.PaymentIntentExpiryInterval) })
+	s.runWorker(func() { purger.Run(ctx, common.IdempotencyPurgeInterval) })
+	s.runWorker(func() { reconciler.Run(ctx, common.DepositReconciliationInterval) })
+	s.runWorker(func() { dispatcher.Run(ctx, common.WebhookDispatchInterval) })
+	s.runWorker(func() { relay.Run(ctx, common.OutboxRelayInterval) })
+	s.runWorker(func() { reencryptor.Run(ctx, common.CardReencryptionInterval) })
+}
+
+// runWorker runs fn in a goroutine tracked by the server's WaitGroup.
+func (s *Server) runWorker(fn func()) {
+	s.workers.Add(1)
+
+	go func() {
+		defer s.workers.Done()
+		fn()
+	}()
 }
 
 // Start begins listening for HTTP requests on the configured address.
@@ -149,6 +328,13 @@ func (s *Server) Start() error {
 // Shutdown gracefully stops the server, closing the database connection and stopping the HTTP server.
 // It uses the provided context for timeout control.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopWorkers()
+	s.workers.Wait()
+
+	if err := s.eventPublisher.Close(); err != nil {
+		slog.Error("failed to close event publisher", "error", err)
+	}
+
 	if err := s.DB.Close(); err != nil {
 		slog.Error("failed to close database connection", "error", err)
 	}
@@ -165,7 +351,7 @@ func setSwaggerInfo(addr string) {
 	docs.SwaggerInfo.Title = "xPay Digital Wallet API"
 	docs.SwaggerInfo.Version = "1.0"
 	docs.SwaggerInfo.Schemes = []string{"http", "https"}
-	docs.SwaggerInfo.BasePath = "/api/v1"
+	docs.SwaggerInfo.BasePath = common.APIBasePath
 	docs.SwaggerInfo.Host = addr
 
 	slog.Info(fmt.Sprintf("Swagger Specs available at http://%s/swagger/index.html", docs.SwaggerInfo.Host))
@@ -0,0 +1,88 @@
+CREATE TYPE posting_direction AS ENUM ('debit', 'credit');
+CREATE TYPE journal_entry_type AS ENUM ('opening_balance');
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL DEFAULT gen_random_uuid(),
+    type journal_entry_type NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A posting belongs to exactly one account: either a wallet or a named system account
+-- (e.g. the clearing account money enters from). Wallets are liabilities, so a credit increases
+-- the wallet balance and a debit decreases it.
+CREATE TABLE IF NOT EXISTS postings (
+    id BIGSERIAL PRIMARY KEY,
+    journal_entry_id BIGINT NOT NULL REFERENCES journal_entries(id) ON DELETE RESTRICT,
+    wallet_id BIGINT REFERENCES wallets(id) ON DELETE RESTRICT,
+    system_account VARCHAR(50),
+    direction posting_direction NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency wallet_currency NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT check_posting_single_account CHECK ((wallet_id IS NULL) <> (system_account IS NULL))
+);
+
+CREATE INDEX idx_postings_journal_entry_id ON postings(journal_entry_id);
+CREATE INDEX idx_postings_wallet_id ON postings(wallet_id) WHERE wallet_id IS NOT NULL;
+
+-- Every journal entry must balance (debits = credits) per currency. The check is deferred to
+-- commit time so all postings of an entry can be inserted before it runs.
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM postings
+        WHERE journal_entry_id = NEW.journal_entry_id
+        GROUP BY currency
+        HAVING SUM(CASE WHEN direction = 'debit' THEN amount ELSE -amount END) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER trigger_postings_check_balanced
+AFTER INSERT ON postings
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- The ledger is append-only, corrections are made with new compensating entries.
+CREATE OR REPLACE FUNCTION prevent_ledger_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_journal_entries_append_only
+BEFORE UPDATE OR DELETE ON journal_entries
+FOR EACH ROW
+EXECUTE FUNCTION prevent_ledger_mutation();
+
+CREATE TRIGGER trigger_postings_append_only
+BEFORE UPDATE OR DELETE ON postings
+FOR EACH ROW
+EXECUTE FUNCTION prevent_ledger_mutation();
+
+-- Carry existing balances over, so every wallet balance is explained by postings from day one.
+DO $$
+DECLARE
+    w RECORD;
+    entry_id BIGINT;
+BEGIN
+    FOR w IN SELECT id, balance, currency FROM wallets WHERE balance > 0 ORDER BY id LOOP
+        INSERT INTO journal_entries (type, description)
+        VALUES ('opening_balance', 'Balance carried over into the ledger')
+        RETURNING id INTO entry_id;
+
+        INSERT INTO postings (journal_entry_id, system_account, direction, amount, currency)
+        VALUES (entry_id, 'opening_balance_equity', 'debit', w.balance, w.currency);
+
+        INSERT INTO postings (journal_entry_id, wallet_id, direction, amount, currency)
+        VALUES (entry_id, w.id, 'credit', w.balance, w.currency);
+    END LOOP;
+END $$;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_transfers_recipient_wallet_id;
+DROP INDEX IF EXISTS idx_transfers_sender_wallet_id;
+
+DROP TABLE IF EXISTS transfers;
+
+DROP TYPE IF EXISTS transfer_status;
+
+-- Postgres can't drop a single enum value, 'transfer' stays in journal_entry_type.
@@ -0,0 +1,21 @@
+ALTER TYPE journal_entry_type ADD VALUE IF NOT EXISTS 'transfer';
+
+CREATE TYPE transfer_status AS ENUM ('completed');
+
+CREATE TABLE IF NOT EXISTS transfers (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL DEFAULT gen_random_uuid(),
+    sender_wallet_id BIGINT NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    recipient_wallet_id BIGINT NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    initiated_by BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    journal_entry_id BIGINT UNIQUE NOT NULL REFERENCES journal_entries(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency wallet_currency NOT NULL,
+    status transfer_status NOT NULL DEFAULT 'completed',
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT check_transfer_distinct_wallets CHECK (sender_wallet_id <> recipient_wallet_id)
+);
+
+CREATE INDEX idx_transfers_sender_wallet_id ON transfers(sender_wallet_id, created_at DESC);
+CREATE INDEX idx_transfers_recipient_wallet_id ON transfers(recipient_wallet_id, created_at DESC);
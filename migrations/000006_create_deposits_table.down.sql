@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS update_deposit_updated_at_trigger ON deposits;
+
+DROP INDEX IF EXISTS idx_deposits_card_id;
+DROP INDEX IF EXISTS idx_deposits_wallet_id;
+
+DROP TABLE IF EXISTS deposits;
+
+DROP TYPE IF EXISTS deposit_status;
+
+-- Postgres can't drop a single enum value, 'deposit' stays in journal_entry_type.
@@ -0,0 +1,29 @@
+ALTER TYPE journal_entry_type ADD VALUE IF NOT EXISTS 'deposit';
+
+CREATE TYPE deposit_status AS ENUM ('pending', 'succeeded', 'declined', 'failed', 'timed_out');
+
+CREATE TABLE IF NOT EXISTS deposits (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    wallet_id BIGINT NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    card_id BIGINT NOT NULL REFERENCES cards(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency wallet_currency NOT NULL,
+    status deposit_status NOT NULL DEFAULT 'pending',
+    gateway_charge_id VARCHAR(255),
+    failure_reason VARCHAR(255) NOT NULL DEFAULT '',
+    journal_entry_id BIGINT UNIQUE REFERENCES journal_entries(id) ON DELETE RESTRICT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- A wallet is credited if and only if the deposit succeeded
+    CONSTRAINT check_deposit_succeeded_has_journal_entry CHECK ((status = 'succeeded') = (journal_entry_id IS NOT NULL))
+);
+
+CREATE INDEX idx_deposits_wallet_id ON deposits(wallet_id, created_at DESC);
+CREATE INDEX idx_deposits_card_id ON deposits(card_id);
+
+CREATE TRIGGER update_deposit_updated_at_trigger
+BEFORE UPDATE ON deposits
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at();
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    request_hash BYTEA NOT NULL,
+    -- status_code and response_body stay NULL while the first request is in flight
+    status_code INT,
+    response_content_type VARCHAR(255),
+    response_body BYTEA,
+    locked_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    CONSTRAINT unique_user_idempotency_key UNIQUE (user_id, idempotency_key),
+    CONSTRAINT check_idempotency_key_completed CHECK ((status_code IS NULL) = (completed_at IS NULL))
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
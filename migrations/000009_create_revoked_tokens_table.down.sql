@@ -0,0 +1,6 @@
+ALTER TABLE users DROP COLUMN IF EXISTS sessions_revoked_at;
+
+DROP INDEX IF EXISTS idx_revoked_tokens_expires_at;
+DROP INDEX IF EXISTS idx_revoked_tokens_revoked_at;
+
+DROP TABLE IF EXISTS revoked_tokens;
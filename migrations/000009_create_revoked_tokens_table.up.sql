@@ -0,0 +1,13 @@
+-- Access tokens revoked before they expire, e.g. on logout. Rows are useless after expires_at and get purged.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti UUID PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_revoked_tokens_revoked_at ON revoked_tokens(revoked_at);
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Access tokens issued at or before this time are rejected, set by the admin revoke all sessions endpoint
+ALTER TABLE users ADD COLUMN sessions_revoked_at TIMESTAMPTZ;
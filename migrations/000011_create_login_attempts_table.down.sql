@@ -0,0 +1,7 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS login_locked_until,
+    DROP COLUMN IF EXISTS failed_login_attempts;
+
+DROP INDEX IF EXISTS idx_login_attempts_user_id_created_at;
+DROP TABLE IF EXISTS login_attempts;
+DROP TYPE IF EXISTS login_attempt_outcome;
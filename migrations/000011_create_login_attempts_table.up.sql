@@ -0,0 +1,19 @@
+CREATE TYPE login_attempt_outcome AS ENUM ('succeeded', 'mfa_required', 'invalid_password', 'invalid_mfa_code', 'locked', 'unknown_email');
+
+-- Every login attempt, user_id is NULL when the email doesn't belong to an account
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(100) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    outcome login_attempt_outcome NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_login_attempts_user_id_created_at ON login_attempts(user_id, created_at DESC);
+
+-- Consecutive failed logins, reset by a successful login. Logins are rejected until login_locked_until.
+ALTER TABLE users
+    ADD COLUMN failed_login_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN login_locked_until TIMESTAMPTZ;
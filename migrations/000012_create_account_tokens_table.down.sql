@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+
+DROP INDEX IF EXISTS idx_account_tokens_user_id_purpose;
+DROP TABLE IF EXISTS account_tokens;
+DROP TYPE IF EXISTS account_token_purpose;
@@ -0,0 +1,19 @@
+CREATE TYPE account_token_purpose AS ENUM ('password_reset', 'email_verification');
+
+-- Single use password reset and email verification tokens, stored as SHA-256 hashes
+CREATE TABLE IF NOT EXISTS account_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose account_token_purpose NOT NULL,
+    token_hash BYTEA UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_account_tokens_user_id_purpose ON account_tokens(user_id, purpose);
+
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+-- Accounts created before verification existed keep access to wallets
+UPDATE users SET email_verified_at = created_at;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS rbac_policy_changes;
+DROP TYPE IF EXISTS rbac_policy_change_type;
+DROP TABLE IF EXISTS rbac_role_actions;
+DROP TABLE IF EXISTS rbac_actions;
+DROP TABLE IF EXISTS rbac_roles;
@@ -0,0 +1,33 @@
+-- Roles and actions are seeded from the embedded policy.json on boot, actions are the named routes of the API
+CREATE TABLE IF NOT EXISTS rbac_roles (
+    name VARCHAR(50) PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS rbac_actions (
+    name VARCHAR(100) PRIMARY KEY,
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Grants of an action are seeded once, when the action is first seen, and managed by admins afterwards
+CREATE TABLE IF NOT EXISTS rbac_role_actions (
+    role VARCHAR(50) NOT NULL REFERENCES rbac_roles(name) ON DELETE CASCADE,
+    action VARCHAR(100) NOT NULL REFERENCES rbac_actions(name) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (role, action)
+);
+
+CREATE TYPE rbac_policy_change_type AS ENUM ('grant', 'revoke');
+
+-- Audit log of the policy, actor_user_id is NULL for grants seeded from policy.json.
+-- The newest id is the policy version, instances reload their grants when it changes.
+CREATE TABLE IF NOT EXISTS rbac_policy_changes (
+    id BIGSERIAL PRIMARY KEY,
+    actor_user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    change_type rbac_policy_change_type NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+ALTER TABLE rbac_policy_changes DROP COLUMN IF EXISTS scope;
+ALTER TABLE rbac_role_actions DROP COLUMN IF EXISTS scope;
+DROP TYPE IF EXISTS rbac_access_scope;
+
+DROP INDEX IF EXISTS idx_users_created_by;
+ALTER TABLE users DROP COLUMN IF EXISTS created_by;
@@ -0,0 +1,18 @@
+-- Who onboarded a user, agents can manage the users they created
+ALTER TABLE users ADD COLUMN created_by BIGINT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_users_created_by ON users(created_by);
+
+-- Whose resources a grant covers on routes with a user_uuid: the caller's own, the users they onboarded or any user's
+CREATE TYPE rbac_access_scope AS ENUM ('self', 'onboarded', 'any');
+
+ALTER TABLE rbac_role_actions ADD COLUMN scope rbac_access_scope NOT NULL DEFAULT 'self';
+ALTER TABLE rbac_policy_changes ADD COLUMN scope rbac_access_scope;
+
+-- Grants seeded before scopes existed get the scopes of policy.json, admins could already read any login history
+-- and revoke any user's sessions
+UPDATE rbac_role_actions SET scope = 'any'
+WHERE role = 'admin' AND action IN ('GetWalletBalance', 'UpdateWalletStatus', 'GetCard', 'ListCards', 'GetLoginHistory', 'RevokeUserSessions');
+
+UPDATE rbac_role_actions SET scope = 'onboarded'
+WHERE role = 'agent' AND action IN ('GetWalletBalance', 'UpdateWalletStatus', 'GetCard', 'ListCards', 'GetLoginHistory');
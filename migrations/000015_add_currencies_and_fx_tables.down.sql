@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_fx_conversions_target_wallet_id;
+DROP INDEX IF EXISTS idx_fx_conversions_source_wallet_id;
+
+DROP TABLE IF EXISTS fx_conversions;
+
+DROP INDEX IF EXISTS idx_fx_quotes_user_id;
+
+DROP TABLE IF EXISTS fx_quotes;
+
+-- Postgres can't drop a single enum value, the added currencies stay in wallet_currency
+-- and 'fx_conversion' stays in journal_entry_type.
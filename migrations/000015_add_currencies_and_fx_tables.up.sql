@@ -0,0 +1,49 @@
+-- Keep in sync with the currencies of domain.CurrencyExponent.
+ALTER TYPE wallet_currency ADD VALUE IF NOT EXISTS 'EUR';
+ALTER TYPE wallet_currency ADD VALUE IF NOT EXISTS 'GBP';
+ALTER TYPE wallet_currency ADD VALUE IF NOT EXISTS 'BDT';
+ALTER TYPE wallet_currency ADD VALUE IF NOT EXISTS 'INR';
+ALTER TYPE wallet_currency ADD VALUE IF NOT EXISTS 'SGD';
+ALTER TYPE wallet_currency ADD VALUE IF NOT EXISTS 'AED';
+ALTER TYPE wallet_currency ADD VALUE IF NOT EXISTS 'JPY';
+ALTER TYPE wallet_currency ADD VALUE IF NOT EXISTS 'KWD';
+
+ALTER TYPE journal_entry_type ADD VALUE IF NOT EXISTS 'fx_conversion';
+
+-- A quote locks the rate of a conversion until it expires, and can be used once.
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_currency wallet_currency NOT NULL,
+    to_currency wallet_currency NOT NULL,
+    rate NUMERIC(30, 10) NOT NULL CHECK (rate > 0),
+    source_amount BIGINT NOT NULL CHECK (source_amount > 0),
+    target_amount BIGINT NOT NULL CHECK (target_amount > 0),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT check_fx_quote_distinct_currencies CHECK (from_currency <> to_currency)
+);
+
+CREATE INDEX idx_fx_quotes_user_id ON fx_quotes(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS fx_conversions (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL DEFAULT gen_random_uuid(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    quote_id BIGINT UNIQUE NOT NULL REFERENCES fx_quotes(id) ON DELETE RESTRICT,
+    source_wallet_id BIGINT NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    target_wallet_id BIGINT NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    journal_entry_id BIGINT UNIQUE NOT NULL REFERENCES journal_entries(id) ON DELETE RESTRICT,
+    source_amount BIGINT NOT NULL CHECK (source_amount > 0),
+    source_currency wallet_currency NOT NULL,
+    target_amount BIGINT NOT NULL CHECK (target_amount > 0),
+    target_currency wallet_currency NOT NULL,
+    rate NUMERIC(30, 10) NOT NULL CHECK (rate > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT check_fx_conversion_distinct_wallets CHECK (source_wallet_id <> target_wallet_id)
+);
+
+CREATE INDEX idx_fx_conversions_source_wallet_id ON fx_conversions(source_wallet_id, created_at DESC);
+CREATE INDEX idx_fx_conversions_target_wallet_id ON fx_conversions(target_wallet_id, created_at DESC);
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS trigger_wallet_transactions_append_only ON wallet_transactions;
+
+DROP INDEX IF EXISTS idx_wallet_transactions_failed;
+DROP INDEX IF EXISTS idx_wallet_transactions_type;
+DROP INDEX IF EXISTS idx_wallet_transactions_history;
+
+DROP TABLE IF EXISTS wallet_transactions;
+
+DROP TYPE IF EXISTS transaction_status;
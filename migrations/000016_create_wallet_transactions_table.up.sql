@@ -0,0 +1,47 @@
+CREATE TYPE transaction_status AS ENUM ('completed', 'failed');
+
+-- The history of a wallet, one row per wallet posting plus attempts that didn't move funds (status 'failed').
+-- balance_after is the wallet balance right after the transaction, so history pages don't need to sum postings.
+-- created_at defaults to the clock time of the insert, which is made while the wallet row is locked.
+CREATE TABLE IF NOT EXISTS wallet_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL DEFAULT gen_random_uuid(),
+    wallet_id BIGINT NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    journal_entry_id BIGINT REFERENCES journal_entries(id) ON DELETE RESTRICT,
+    posting_id BIGINT UNIQUE REFERENCES postings(id) ON DELETE RESTRICT,
+    type journal_entry_type NOT NULL,
+    direction posting_direction NOT NULL,
+    status transaction_status NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency wallet_currency NOT NULL,
+    balance_after BIGINT NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    CONSTRAINT check_completed_transaction_posting CHECK ((status = 'completed') = (posting_id IS NOT NULL))
+);
+
+-- History pages are read newest first with keyset pagination on (created_at, id).
+-- Type and failed status filters get their own indexes, failed transactions are rare so that one is partial.
+-- Date ranges narrow the created_at range of these indexes, amount ranges are checked while walking them.
+CREATE INDEX idx_wallet_transactions_history ON wallet_transactions(wallet_id, created_at DESC, id DESC);
+CREATE INDEX idx_wallet_transactions_type ON wallet_transactions(wallet_id, type, created_at DESC, id DESC);
+CREATE INDEX idx_wallet_transactions_failed ON wallet_transactions(wallet_id, created_at DESC, id DESC) WHERE status = 'failed';
+
+CREATE TRIGGER trigger_wallet_transactions_append_only
+BEFORE UPDATE OR DELETE ON wallet_transactions
+FOR EACH ROW
+EXECUTE FUNCTION prevent_ledger_mutation();
+
+-- Backfill the history from the ledger, with the running balance of every wallet.
+-- Deposits that failed before this migration aren't backfilled, they never had postings.
+INSERT INTO wallet_transactions (wallet_id, journal_entry_id, posting_id, type, direction, status,
+    amount, currency, balance_after, description, created_at)
+SELECT p.wallet_id, p.journal_entry_id, p.id, je.type, p.direction, 'completed',
+    p.amount, p.currency,
+    SUM(CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END)
+        OVER (PARTITION BY p.wallet_id ORDER BY p.created_at, p.id),
+    je.description, p.created_at
+FROM postings p
+JOIN journal_entries je ON je.id = p.journal_entry_id
+WHERE p.wallet_id IS NOT NULL
+ORDER BY p.created_at, p.id;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_statement_exports_expires_at;
+DROP INDEX IF EXISTS idx_statement_exports_queue;
+DROP INDEX IF EXISTS idx_statement_exports_wallet_id;
+
+DROP TABLE IF EXISTS statement_exports;
+
+DROP TYPE IF EXISTS statement_export_status;
+DROP TYPE IF EXISTS statement_format;
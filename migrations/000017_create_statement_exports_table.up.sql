@@ -0,0 +1,30 @@
+CREATE TYPE statement_format AS ENUM ('csv', 'ofx', 'pdf');
+CREATE TYPE statement_export_status AS ENUM ('pending', 'processing', 'ready', 'failed');
+
+-- Statements of long periods are generated in the background by a worker, which claims pending exports
+-- with FOR UPDATE SKIP LOCKED. The file is kept until expires_at, then the row is deleted.
+-- attempts counts the claims, an export stuck in processing is taken over until it runs out of attempts.
+CREATE TABLE IF NOT EXISTS statement_exports (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL,
+    wallet_id BIGINT NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    format statement_format NOT NULL,
+    status statement_export_status NOT NULL DEFAULT 'pending',
+    file_name VARCHAR(255),
+    content_type VARCHAR(100),
+    content BYTEA,
+    failure_reason VARCHAR(255),
+    attempts INT NOT NULL DEFAULT 0,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT check_statement_export_period CHECK (period_end > period_start),
+    CONSTRAINT check_ready_statement_export_content CHECK ((status = 'ready') = (content IS NOT NULL))
+);
+
+CREATE INDEX idx_statement_exports_wallet_id ON statement_exports(wallet_id);
+CREATE INDEX idx_statement_exports_queue ON statement_exports(created_at) WHERE status IN ('pending', 'processing');
+CREATE INDEX idx_statement_exports_expires_at ON statement_exports(expires_at);
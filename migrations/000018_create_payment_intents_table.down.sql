@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS update_payment_intent_updated_at_trigger ON payment_intents;
+
+DROP INDEX IF EXISTS idx_payment_intents_expiry;
+DROP INDEX IF EXISTS idx_payment_intents_customer_wallet_id;
+DROP INDEX IF EXISTS idx_payment_intents_merchant_id;
+
+DROP TABLE IF EXISTS payment_intents;
+
+DROP TYPE IF EXISTS payment_intent_status;
+
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS check_wallet_held_balance;
+ALTER TABLE wallets DROP COLUMN IF EXISTS held_balance;
+
+-- Postgres can't drop a single enum value, 'payment' stays in journal_entry_type.
@@ -0,0 +1,45 @@
+ALTER TYPE journal_entry_type ADD VALUE IF NOT EXISTS 'payment';
+
+-- Funds on hold for authorized payment intents stay in the balance until they are captured or released.
+-- held_balance <= balance keeps held funds from being spent, so transfers, conversions and new holds
+-- only use the available balance.
+ALTER TABLE wallets ADD COLUMN held_balance BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE wallets ADD CONSTRAINT check_wallet_held_balance CHECK (held_balance >= 0 AND held_balance <= balance);
+
+CREATE TYPE payment_intent_status AS ENUM ('requires_confirmation', 'authorized', 'captured', 'voided', 'expired');
+
+-- A merchant's request to be paid from a customer's wallet. Confirming it holds the amount on the customer's
+-- wallet until the merchant captures or voids it, or the hold expires at expires_at.
+CREATE TABLE IF NOT EXISTS payment_intents (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL,
+    merchant_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    merchant_wallet_id BIGINT NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    customer_wallet_id BIGINT REFERENCES wallets(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL DEFAULT 0 CHECK (captured_amount >= 0 AND captured_amount <= amount),
+    currency wallet_currency NOT NULL,
+    status payment_intent_status NOT NULL DEFAULT 'requires_confirmation',
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    journal_entry_id BIGINT UNIQUE REFERENCES journal_entries(id) ON DELETE RESTRICT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    authorized_at TIMESTAMPTZ,
+    captured_at TIMESTAMPTZ,
+    canceled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- The customer's wallet is known once the intent is confirmed, voided and expired intents may not have one
+    CONSTRAINT check_payment_intent_customer_wallet CHECK (status IN ('voided', 'expired')
+        OR (status = 'requires_confirmation') = (customer_wallet_id IS NULL)),
+    -- Funds move if and only if the intent was captured
+    CONSTRAINT check_captured_payment_intent_has_journal_entry CHECK ((status = 'captured') = (journal_entry_id IS NOT NULL))
+);
+
+CREATE INDEX idx_payment_intents_merchant_id ON payment_intents(merchant_id, created_at DESC);
+CREATE INDEX idx_payment_intents_customer_wallet_id ON payment_intents(customer_wallet_id) WHERE customer_wallet_id IS NOT NULL;
+CREATE INDEX idx_payment_intents_expiry ON payment_intents(expires_at) WHERE status IN ('requires_confirmation', 'authorized');
+
+CREATE TRIGGER update_payment_intent_updated_at_trigger
+BEFORE UPDATE ON payment_intents
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at();
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_refunds_payment_intent_id;
+
+DROP TABLE IF EXISTS refunds;
+
+DROP TYPE IF EXISTS refund_status;
+
+ALTER TABLE payment_intents DROP CONSTRAINT IF EXISTS check_payment_intent_refunded_amount;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS refunded_amount;
+
+-- Postgres can't drop a single enum value, 'refund' stays in journal_entry_type.
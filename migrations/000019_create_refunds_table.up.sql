@@ -0,0 +1,27 @@
+ALTER TYPE journal_entry_type ADD VALUE IF NOT EXISTS 'refund';
+
+-- The refunds of a captured payment intent never add up to more than its captured amount.
+ALTER TABLE payment_intents ADD COLUMN refunded_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payment_intents ADD CONSTRAINT check_payment_intent_refunded_amount
+    CHECK (refunded_amount >= 0 AND refunded_amount <= captured_amount);
+
+CREATE TYPE refund_status AS ENUM ('succeeded', 'failed');
+
+-- A full or partial refund of a captured payment intent, paid from the merchant's wallet back to the
+-- wallet the customer paid from. Failed refunds are kept with their reason, they moved no funds.
+CREATE TABLE IF NOT EXISTS refunds (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL,
+    payment_intent_id BIGINT NOT NULL REFERENCES payment_intents(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency wallet_currency NOT NULL,
+    status refund_status NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    failure_reason TEXT,
+    journal_entry_id BIGINT UNIQUE REFERENCES journal_entries(id) ON DELETE RESTRICT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Funds move if and only if the refund succeeded
+    CONSTRAINT check_succeeded_refund_has_journal_entry CHECK ((status = 'succeeded') = (journal_entry_id IS NOT NULL))
+);
+
+CREATE INDEX idx_refunds_payment_intent_id ON refunds(payment_intent_id, created_at);
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_endpoint_id;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TRIGGER IF EXISTS update_webhook_endpoint_updated_at_trigger ON webhook_endpoints;
+
+DROP INDEX IF EXISTS idx_webhook_endpoints_user_id;
+
+DROP TABLE IF EXISTS webhook_endpoints;
+
+DROP TYPE IF EXISTS webhook_delivery_status;
+DROP TYPE IF EXISTS webhook_endpoint_status;
@@ -0,0 +1,64 @@
+CREATE TYPE webhook_endpoint_status AS ENUM ('active', 'disabled');
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'succeeded', 'dead');
+
+-- A URL of a user that receives signed events. The signing secret is encrypted with the webhook key,
+-- bound to the endpoint uuid. consecutive_failures and failing_since track failed attempts since the last
+-- successful one, an endpoint that keeps failing is disabled.
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url VARCHAR(2048) NOT NULL,
+    encrypted_secret BYTEA NOT NULL,
+    event_types TEXT[] NOT NULL CHECK (cardinality(event_types) > 0),
+    status webhook_endpoint_status NOT NULL DEFAULT 'active',
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    failing_since TIMESTAMPTZ,
+    disabled_reason VARCHAR(255),
+    disabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_endpoints_user_id ON webhook_endpoints(user_id);
+
+CREATE TRIGGER update_webhook_endpoint_updated_at_trigger
+BEFORE UPDATE ON webhook_endpoints
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at();
+
+-- The delivery of one event to one endpoint. Workers claim due pending deliveries with FOR UPDATE SKIP LOCKED
+-- and push next_attempt_at past the request, so a delivery whose worker crashed is retried. Deliveries that
+-- run out of attempts are dead-lettered, they are only sent again when redelivered manually.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID UNIQUE NOT NULL DEFAULT gen_random_uuid(),
+    endpoint_id BIGINT NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    last_attempt_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_webhook_delivery_event UNIQUE (endpoint_id, event_id),
+    CONSTRAINT check_pending_webhook_delivery_scheduled CHECK ((status = 'pending') = (next_attempt_at IS NOT NULL))
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_endpoint_id ON webhook_deliveries(endpoint_id, created_at DESC, id DESC);
+
+-- Every request sent for a delivery, with the endpoint's response or why there was none.
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt INT NOT NULL,
+    response_status INT,
+    response_body TEXT,
+    error TEXT,
+    duration_ms BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_webhook_delivery_attempt UNIQUE (delivery_id, attempt)
+);
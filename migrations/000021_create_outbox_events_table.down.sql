@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_events_published_at;
+DROP INDEX IF EXISTS idx_outbox_events_due;
+
+DROP TABLE IF EXISTS outbox_events;
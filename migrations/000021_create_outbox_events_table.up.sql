@@ -0,0 +1,22 @@
+-- Domain events recorded in the transaction of the state change they are about, so an event is stored
+-- exactly when its change is committed. Relays claim due unpublished events with FOR UPDATE SKIP LOCKED,
+-- push next_attempt_at past the publish and set published_at once the broker acknowledged them.
+-- An event whose relay crashed in between is published again, consumers drop duplicates by event_id.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID UNIQUE NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    version INT NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    request_id VARCHAR(128),
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_events_due ON outbox_events(next_attempt_at, id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published_at ON outbox_events(published_at) WHERE published_at IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Card numbers encrypted with a data key can't be read without these columns,
+-- re-encrypt them with the legacy key before rolling back.
+ALTER TABLE cards
+    DROP CONSTRAINT IF EXISTS check_card_data_key,
+    DROP COLUMN IF EXISTS encryption_key_id,
+    DROP COLUMN IF EXISTS encrypted_data_key;
@@ -0,0 +1,7 @@
+-- Card numbers are encrypted with a random data key per card, stored wrapped by a key-encryption key (KEK)
+-- of the key provider, encryption_key_id names the KEK. Cards added before have neither, their numbers are
+-- encrypted directly with card.aes_key until the background re-encryption job moves them to the active KEK.
+ALTER TABLE cards
+    ADD COLUMN encrypted_data_key BYTEA,
+    ADD COLUMN encryption_key_id VARCHAR(64),
+    ADD CONSTRAINT check_card_data_key CHECK ((encrypted_data_key IS NULL) = (encryption_key_id IS NULL));
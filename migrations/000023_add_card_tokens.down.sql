@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS card_detokenizations;
+
+DROP INDEX IF EXISTS idx_cards_card_token;
+
+ALTER TABLE cards DROP COLUMN IF EXISTS card_token;
@@ -0,0 +1,17 @@
+-- Card tokens stand in for the card numbers outside the vault, the gateway gets the token of the card to charge.
+-- Cards added before have none until the vault issues theirs on first use.
+ALTER TABLE cards ADD COLUMN card_token VARCHAR(19);
+
+CREATE UNIQUE INDEX idx_cards_card_token ON cards(card_token);
+
+-- Every exchange of a token for the card number, written before the number leaves the vault.
+CREATE TABLE IF NOT EXISTS card_detokenizations (
+    id BIGSERIAL PRIMARY KEY,
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    purpose VARCHAR(32) NOT NULL,
+    reference VARCHAR(100) NOT NULL,
+    request_id VARCHAR(128),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_card_detokenizations_card_id ON card_detokenizations(card_id, created_at);
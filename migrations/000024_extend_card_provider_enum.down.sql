@@ -0,0 +1,6 @@
+-- Postgres can't drop enum values, the type is recreated without them.
+-- This fails while cards of these providers exist, delete them first.
+ALTER TYPE card_provider RENAME TO card_provider_old;
+CREATE TYPE card_provider AS ENUM ('visa', 'mastercard', 'amex');
+ALTER TABLE cards ALTER COLUMN provider TYPE card_provider USING provider::text::card_provider;
+DROP TYPE card_provider_old;
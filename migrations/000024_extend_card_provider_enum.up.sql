@@ -0,0 +1,4 @@
+-- The card provider is detected from the card number, these schemes are supported next to visa, mastercard and amex.
+ALTER TYPE card_provider ADD VALUE IF NOT EXISTS 'discover';
+ALTER TYPE card_provider ADD VALUE IF NOT EXISTS 'jcb';
+ALTER TYPE card_provider ADD VALUE IF NOT EXISTS 'unionpay';
@@ -0,0 +1,3 @@
+-- Responses carrying secrets, e.g. TOTP secrets or recovery codes, are never stored: only their status code is kept
+-- and retries with the key are rejected instead of replayed.
+ALTER TABLE idempotency_keys ADD COLUMN response_withheld BOOLEAN NOT NULL DEFAULT FALSE;
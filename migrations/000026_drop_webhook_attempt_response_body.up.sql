@@ -0,0 +1,3 @@
+-- Response bodies of webhook endpoints are no longer stored, an endpoint pointed at an internal service
+-- could otherwise show its owner what that service answered. Only the status code is kept.
+ALTER TABLE webhook_delivery_attempts DROP COLUMN IF EXISTS response_body;
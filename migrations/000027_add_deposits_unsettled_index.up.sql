@@ -0,0 +1,2 @@
+-- The deposit reconciler lists pending and timed out deposits in id order, settled ones are the vast majority.
+CREATE INDEX IF NOT EXISTS idx_deposits_unsettled ON deposits(id) WHERE status IN ('pending', 'timed_out');